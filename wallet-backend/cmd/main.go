@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"wallet-backend/internal/config"
 	"wallet-backend/internal/database"
+	"wallet-backend/internal/keystore"
 	"wallet-backend/internal/routes"
 	"wallet-backend/internal/services"
 
@@ -34,16 +36,95 @@ func main() {
 
 	// 初始化服务
 	hdWalletService := services.NewHDWalletService(cfg)
+
+	// 从加密密钥库解锁主助记词
+	var ks *keystore.Keystore
+	if cfg.Wallet.Keystore.Dir != "" {
+		if cfg.Wallet.HDWallet.Mnemonic != "" {
+			log.Println("Warning: keystore is configured, ignoring plaintext mnemonic in config")
+		}
+		ks, err = keystore.Open(cfg.Wallet.Keystore)
+		if err != nil {
+			log.Fatalf("Failed to unlock keystore: %v", err)
+		}
+		mnemonic, err := ks.Mnemonic()
+		if err != nil {
+			log.Fatalf("Failed to read mnemonic from keystore: %v", err)
+		}
+		if err := hdWalletService.SetMnemonic(mnemonic); err != nil {
+			log.Fatalf("Failed to load mnemonic from keystore: %v", err)
+		}
+		log.Printf("Keystore unlocked: %s", ks.Dir())
+	}
+
+	// 地址库内存索引，扫描器按地址匹配交易
+	addressIndex := services.NewAddressIndex(cfg)
+	if err := addressIndex.Load(); err != nil {
+		log.Printf("Warning: failed to load address index: %v", err)
+	}
+	addressService := services.NewAddressService(cfg, hdWalletService, addressIndex)
+
+	// 创建交易签名器
+	signer, err := services.NewSigner(cfg, hdWalletService, ks)
+	if err != nil {
+		log.Printf("Warning: signer unavailable, collection and withdrawals are disabled: %v", err)
+	}
 	wsService := services.NewWebSocketService()
 
-	blockScannerService, _ := services.NewBlockScannerService(cfg)
-	collectionService, _ := services.NewCollectionService(cfg)
+	// 根据币种配置建立各链的节点连接
+	chainRegistry := services.NewChainRegistry(cfg)
+	if err := chainRegistry.Reload(); err != nil {
+		log.Printf("Warning: failed to load chain registry: %v", err)
+	}
+	defer chainRegistry.Close()
+
+	// 定期采样各EVM链手续费
+	gasOracleService, _ := services.NewGasOracleService(cfg, chainRegistry)
+
+	// 与节点同步各热钱包地址的nonce
+	nonceManager := services.NewNonceManager(chainRegistry)
+	if err := nonceManager.ResyncAll(context.Background()); err != nil {
+		log.Printf("Warning: failed to resync nonces: %v", err)
+	}
+
+	blockScannerService, _ := services.NewBlockScannerService(cfg, chainRegistry, wsService, addressIndex)
+	var solanaScannerService *services.SolanaScannerService
+	if cfg.Solana.RPCURL != "" {
+		solanaScannerService, _ = services.NewSolanaScannerService(cfg)
+	}
+	var tronScannerService *services.TronScannerService
+	if cfg.Tron.RPCURL != "" {
+		tronScannerService, _ = services.NewTronScannerService(cfg)
+	}
+	var bitcoinScannerService *services.BitcoinScannerService
+	if cfg.Bitcoin.RPCURL != "" {
+		if bitcoinScannerService, err = services.NewBitcoinScannerService(cfg); err != nil {
+			log.Printf("Warning: bitcoin scanner unavailable: %v", err)
+		}
+	}
+	var bitcoinWalletService *services.BitcoinWalletService
+	if cfg.Bitcoin.RPCURL != "" && signer != nil {
+		if bitcoinWalletService, err = services.NewBitcoinWalletService(cfg, hdWalletService, signer); err != nil {
+			log.Printf("Warning: bitcoin withdrawals unavailable: %v", err)
+		}
+	}
+	var ethereumWalletService *services.EthereumWalletService
+	if signer != nil {
+		ethereumWalletService, _ = services.NewEthereumWalletService(cfg, chainRegistry, signer, gasOracleService, nonceManager)
+	}
+	collectionService, _ := services.NewCollectionService(cfg, chainRegistry, signer, gasOracleService, nonceManager)
+	recoveryService, err := services.NewRecoveryService(cfg, hdWalletService)
+	if err != nil {
+		log.Printf("Warning: address recovery unavailable: %v", err)
+	}
+	txMonitorService, _ := services.NewTxMonitorService(cfg, chainRegistry, signer, gasOracleService, nonceManager)
+	confirmationService, _ := services.NewConfirmationService(cfg, chainRegistry, wsService)
 	
 	// 创建定时任务服务
-	schedulerService := services.NewSchedulerService(cfg, blockScannerService, collectionService)
+	schedulerService := services.NewSchedulerService(cfg, blockScannerService, collectionService, addressService, confirmationService)
 
 	// 暂时注释掉有问题的服务
-	// transactionService, err := services.NewTransactionService(cfg)
+	// transactionService, err := services.NewTransactionService(cfg, chainRegistry, nonceManager)
 	// if err != nil {
 	// 	log.Fatalf("Failed to create transaction service: %v", err)
 	// }
@@ -55,6 +136,39 @@ func main() {
 	// 启动定时任务服务
 	go schedulerService.Start()
 
+	// 启动Gas预言机
+	if err := gasOracleService.Start(); err != nil {
+		log.Printf("Failed to start gas oracle: %v", err)
+	}
+
+	// 启动卡住交易监控
+	if cfg.TxMonitor.Enabled {
+		if err := txMonitorService.Start(); err != nil {
+			log.Printf("Failed to start transaction monitor: %v", err)
+		}
+	}
+
+	// 启动Solana扫描
+	if solanaScannerService != nil {
+		if err := solanaScannerService.StartScanning(); err != nil {
+			log.Printf("Failed to start Solana scanner: %v", err)
+		}
+	}
+
+	// 启动TRON扫描
+	if tronScannerService != nil {
+		if err := tronScannerService.StartScanning(); err != nil {
+			log.Printf("Failed to start TRON scanner: %v", err)
+		}
+	}
+
+	// 启动比特币扫描
+	if bitcoinScannerService != nil {
+		if err := bitcoinScannerService.StartScanning(); err != nil {
+			log.Printf("Failed to start Bitcoin scanner: %v", err)
+		}
+	}
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
@@ -65,9 +179,21 @@ func main() {
 	serviceConfig := &services.Config{
 		AppConfig:          cfg,
 		HDWalletService:    hdWalletService,
+		AddressService:     addressService,
+		Signer:             signer,
+		ChainRegistry:      chainRegistry,
+		NonceManager:       nonceManager,
+		GasOracleService:   gasOracleService,
 		WSService:          wsService,
 		BlockScannerService: blockScannerService,
+		SolanaScannerService: solanaScannerService,
+		TronScannerService: tronScannerService,
+		BitcoinScannerService: bitcoinScannerService,
+		BitcoinWalletService: bitcoinWalletService,
+		EthereumWalletService: ethereumWalletService,
 		CollectionService:  collectionService,
+		RecoveryService:    recoveryService,
+		TxMonitorService:   txMonitorService,
 	}
 
 	// 设置路由
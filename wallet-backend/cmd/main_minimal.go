@@ -6,6 +6,8 @@ import (
 	"wallet-backend/internal/database"
 	"wallet-backend/internal/handlers"
 	"wallet-backend/internal/middleware"
+	"wallet-backend/internal/services"
+	"wallet-backend/pkg/validation"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -33,6 +35,14 @@ func main() {
 		log.Fatalf("Failed to create default data: %v", err)
 	}
 
+	// 初始化服务
+	addressHandler := handlers.NewAddressHandler(services.NewAddressService(cfg, services.NewHDWalletService(cfg), nil))
+	addressValidator, err := validation.NewAddressValidator(cfg.Bitcoin.Network)
+	if err != nil {
+		log.Fatalf("Failed to create address validator: %v", err)
+	}
+	withdrawHandler := handlers.NewWithdrawHandler(addressValidator)
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
@@ -53,10 +63,10 @@ func main() {
 	r.Use(middleware.Recovery())
 
 	// 健康检查路由
-	r.GET("/health", handlers.HealthCheck)
+	r.GET("/health", handlers.NewHealthHandler(nil).HealthCheck)
 	r.GET("/ready", handlers.ReadinessCheck)
 	r.GET("/live", handlers.LivenessCheck)
-	r.GET("/metrics", handlers.Metrics)
+	r.GET("/metrics", handlers.NewHealthHandler(nil).Metrics)
 
 	// API路由组
 	api := r.Group("/api/v1")
@@ -76,8 +86,8 @@ func main() {
 			addresses := authorized.Group("/addresses")
 			{
 				addresses.GET("", handlers.GetAddresses)
-				addresses.POST("/generate", handlers.GenerateAddress)
-				addresses.POST("/bind", handlers.BindAddress)
+				addresses.POST("/generate", addressHandler.GenerateAddress)
+				addresses.POST("/bind", addressHandler.BindAddress)
 			}
 
 			// 余额管理
@@ -91,7 +101,7 @@ func main() {
 			withdraws := authorized.Group("/withdraws")
 			{
 				withdraws.GET("", handlers.GetWithdraws)
-				withdraws.POST("", handlers.CreateWithdraw)
+				withdraws.POST("", withdrawHandler.CreateWithdraw)
 				withdraws.GET("/:id", handlers.GetWithdrawByID)
 			}
 
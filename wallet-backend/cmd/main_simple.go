@@ -8,6 +8,7 @@ import (
 	"wallet-backend/internal/middleware"
 	"wallet-backend/internal/models"
 	"wallet-backend/internal/services"
+	"wallet-backend/pkg/validation"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -37,6 +38,12 @@ func main() {
 
 	// 初始化服务
 	hdWalletService := services.NewHDWalletService(cfg)
+	addressHandler := handlers.NewAddressHandler(services.NewAddressService(cfg, hdWalletService, nil))
+	addressValidator, err := validation.NewAddressValidator(cfg.Bitcoin.Network)
+	if err != nil {
+		log.Fatalf("Failed to create address validator: %v", err)
+	}
+	withdrawHandler := handlers.NewWithdrawHandler(addressValidator)
 	wsService := services.NewWebSocketService()
 
 	// 启动WebSocket服务
@@ -62,10 +69,10 @@ func main() {
 	r.Use(middleware.Recovery())
 
 	// 健康检查路由
-	r.GET("/health", handlers.HealthCheck)
+	r.GET("/health", handlers.NewHealthHandler(nil).HealthCheck)
 	r.GET("/ready", handlers.ReadinessCheck)
 	r.GET("/live", handlers.LivenessCheck)
-	r.GET("/metrics", handlers.Metrics)
+	r.GET("/metrics", handlers.NewHealthHandler(nil).Metrics)
 
 	// API路由组
 	api := r.Group("/api/v1")
@@ -90,8 +97,8 @@ func main() {
 			addresses := authorized.Group("/addresses")
 			{
 				addresses.GET("", handlers.GetAddresses)
-				addresses.POST("/generate", handlers.GenerateAddress)
-				addresses.POST("/bind", handlers.BindAddress)
+				addresses.POST("/generate", addressHandler.GenerateAddress)
+				addresses.POST("/bind", addressHandler.BindAddress)
 			}
 
 			// 余额管理
@@ -105,7 +112,7 @@ func main() {
 			withdraws := authorized.Group("/withdraws")
 			{
 				withdraws.GET("", handlers.GetWithdraws)
-				withdraws.POST("", handlers.CreateWithdraw)
+				withdraws.POST("", withdrawHandler.CreateWithdraw)
 				withdraws.GET("/:id", handlers.GetWithdrawByID)
 			}
 
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/keystore"
+	"wallet-backend/internal/services"
+)
+
+// 独立签名服务：持有密钥库，只通过双向TLS对API主机提供哈希签名，私钥不出本进程
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "config file")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	hdWalletService := services.NewHDWalletService(cfg)
+
+	var ks *keystore.Keystore
+	if cfg.Wallet.Keystore.Dir != "" {
+		ks, err = keystore.Open(cfg.Wallet.Keystore)
+		if err != nil {
+			log.Fatalf("Failed to unlock keystore: %v", err)
+		}
+		mnemonic, err := ks.Mnemonic()
+		if err != nil {
+			log.Fatalf("Failed to read mnemonic from keystore: %v", err)
+		}
+		if err := hdWalletService.SetMnemonic(mnemonic); err != nil {
+			log.Fatalf("Failed to load mnemonic from keystore: %v", err)
+		}
+	}
+	if hdWalletService.IsWatchOnly() {
+		log.Fatalf("Signer requires a mnemonic, watch-only configuration is not allowed")
+	}
+
+	signerCfg := cfg.Wallet.Signer
+	tlsConfig, err := services.LoadMutualTLSConfig(signerCfg.CertFile, signerCfg.KeyFile, signerCfg.CAFile, true)
+	if err != nil {
+		log.Fatalf("Failed to load TLS config: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:         signerCfg.ListenAddr,
+		Handler:      services.NewSignerServer(services.NewHDSigner(hdWalletService, ks)),
+		TLSConfig:    tlsConfig,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	log.Printf("Signer listening on %s (mutual TLS)", signerCfg.ListenAddr)
+	if err := server.ListenAndServeTLS("", ""); err != nil {
+		log.Fatalf("Signer stopped: %v", err)
+	}
+}
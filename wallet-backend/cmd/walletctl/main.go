@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/keystore"
+	"wallet-backend/internal/services"
+
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/term"
+)
+
+const usage = `walletctl - 钱包运维命令行工具
+
+用法:
+  walletctl keystore create  [-mnemonic-file FILE]   创建密钥库（未指定助记词时随机生成）
+  walletctl keystore import  [-key-file FILE]        导入十六进制私钥
+  walletctl keystore export  [-address ADDR]         导出助记词，指定地址时导出对应私钥
+  walletctl keystore list                            列出已导入私钥的地址
+  walletctl keystore rotate  [-new-passphrase-file FILE]  轮换加密口令
+  walletctl recover -chain CHAIN [-gap N] [-start N] [-address-type TYPE] [-dry-run]
+                                                     按gap limit扫描链上活动，重建地址库
+
+通用参数:
+  -config FILE   配置文件 (默认 config/config.yaml)
+  -dir DIR       密钥库目录，覆盖配置中的 wallet.keystore.dir
+
+口令读取顺序: wallet.keystore.passphrase_file -> 环境变量 -> 终端输入
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch {
+	case os.Args[1] == "keystore" && len(os.Args) >= 3:
+		err = runKeystore(os.Args[2], os.Args[3:])
+	case os.Args[1] == "recover":
+		err = runRecover(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runRecover 执行gap limit地址恢复，并在终端输出进度
+func runRecover(args []string) error {
+	fs := flag.NewFlagSet("recover", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "config file")
+	chainType := fs.String("chain", "", "chain type, e.g. Ethereum")
+	addressType := fs.String("address-type", "", "bitcoin address type")
+	gapLimit := fs.Int("gap", 0, "gap limit (default from config)")
+	startIndex := fs.Uint("start", 0, "first derivation index")
+	dryRun := fs.Bool("dry-run", false, "scan only, do not write the address library")
+	fs.Parse(args)
+
+	if *chainType == "" {
+		return fmt.Errorf("-chain is required")
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if err := database.Init(cfg); err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+
+	hdWalletService := services.NewHDWalletService(cfg)
+	if cfg.Wallet.Keystore.Dir != "" {
+		ks := keystore.New(cfg.Wallet.Keystore.Dir, cfg.Wallet.Keystore.LightKDF)
+		if err := unlock(ks, cfg.Wallet.Keystore); err != nil {
+			return err
+		}
+		mnemonic, err := ks.Mnemonic()
+		if err != nil {
+			return err
+		}
+		if err := hdWalletService.SetMnemonic(mnemonic); err != nil {
+			return err
+		}
+	}
+
+	recoveryService, err := services.NewRecoveryService(cfg, hdWalletService)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	opts := services.RecoveryOptions{
+		ChainType:   *chainType,
+		AddressType: *addressType,
+		GapLimit:    *gapLimit,
+		StartIndex:  uint32(*startIndex),
+		DryRun:      *dryRun,
+	}
+	result, err := recoveryService.Recover(ctx, opts, func(p services.RecoveryProgress) {
+		fmt.Fprintf(os.Stderr, "\rindex %d: scanned %d, used %d, restored %d, updated %d, gap %d",
+			p.LastIndex, p.Scanned, p.Used, p.Restored, p.Updated, p.Gap)
+	})
+	fmt.Fprintln(os.Stderr)
+	if result != nil {
+		fmt.Printf("%s recovery finished: scanned %d, used %d, restored %d, updated %d\n",
+			result.ChainType, result.Scanned, result.Used, result.Restored, result.Updated)
+	}
+	return err
+}
+
+// runKeystore 执行密钥库子命令
+func runKeystore(command string, args []string) error {
+	fs := flag.NewFlagSet("keystore "+command, flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "config file")
+	dir := fs.String("dir", "", "keystore directory")
+	mnemonicFile := fs.String("mnemonic-file", "", "file containing the mnemonic to store")
+	keyFile := fs.String("key-file", "", "file containing the hex private key to import")
+	address := fs.String("address", "", "address of the imported key to export")
+	newPassphraseFile := fs.String("new-passphrase-file", "", "file containing the new passphrase")
+	fs.Parse(args)
+
+	ksCfg, err := loadKeystoreConfig(*configPath, *dir)
+	if err != nil {
+		return err
+	}
+	ks := keystore.New(ksCfg.Dir, ksCfg.LightKDF)
+
+	switch command {
+	case "create":
+		mnemonic, generated, err := readMnemonic(*mnemonicFile)
+		if err != nil {
+			return err
+		}
+		passphrase, err := newPassphrase(ksCfg, "")
+		if err != nil {
+			return err
+		}
+		if err := ks.Create(mnemonic, passphrase); err != nil {
+			return err
+		}
+		fmt.Printf("Keystore created in %s\n", ks.Dir())
+		if generated {
+			fmt.Println("Generated mnemonic, write it down and keep it offline:")
+			fmt.Println(mnemonic)
+		}
+		return nil
+
+	case "import":
+		if err := unlock(ks, ksCfg); err != nil {
+			return err
+		}
+		hexKey, err := readSecret(*keyFile, "Private key (hex): ")
+		if err != nil {
+			return err
+		}
+		addr, err := ks.ImportKeyHex(hexKey)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported key for %s\n", addr.Hex())
+		return nil
+
+	case "export":
+		if err := unlock(ks, ksCfg); err != nil {
+			return err
+		}
+		if *address == "" {
+			mnemonic, err := ks.Mnemonic()
+			if err != nil {
+				return err
+			}
+			fmt.Println(mnemonic)
+			return nil
+		}
+		key, err := ks.PrivateKey(*address)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%x\n", key.D.FillBytes(make([]byte, 32)))
+		return nil
+
+	case "list":
+		if err := unlock(ks, ksCfg); err != nil {
+			return err
+		}
+		for _, addr := range ks.Addresses() {
+			fmt.Println(addr)
+		}
+		return nil
+
+	case "rotate":
+		oldPassphrase, err := currentPassphrase(ksCfg)
+		if err != nil {
+			return err
+		}
+		passphrase, err := newPassphrase(config.KeystoreConfig{}, *newPassphraseFile)
+		if err != nil {
+			return err
+		}
+		if err := ks.ChangePassphrase(oldPassphrase, passphrase); err != nil {
+			return err
+		}
+		fmt.Println("Keystore passphrase rotated, update passphrase_file or the environment before restarting the server")
+		return nil
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown keystore command: %s", command)
+	}
+}
+
+// loadKeystoreConfig 读取配置中的密钥库设置，-dir 参数优先
+func loadKeystoreConfig(configPath string, dir string) (config.KeystoreConfig, error) {
+	ksCfg := config.KeystoreConfig{PassphraseEnv: "WALLET_KEYSTORE_PASSPHRASE"}
+	if _, err := os.Stat(configPath); err == nil {
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			return ksCfg, err
+		}
+		ksCfg = cfg.Wallet.Keystore
+	}
+
+	if dir != "" {
+		ksCfg.Dir = dir
+	}
+	if ksCfg.Dir == "" {
+		return ksCfg, fmt.Errorf("keystore directory not configured (set wallet.keystore.dir or -dir)")
+	}
+	return ksCfg, nil
+}
+
+// unlock 解锁密钥库
+func unlock(ks *keystore.Keystore, ksCfg config.KeystoreConfig) error {
+	passphrase, err := currentPassphrase(ksCfg)
+	if err != nil {
+		return err
+	}
+	return ks.Unlock(passphrase)
+}
+
+// currentPassphrase 获取当前口令，未配置时从终端读取
+func currentPassphrase(ksCfg config.KeystoreConfig) (string, error) {
+	if passphrase, err := keystore.ResolvePassphrase(ksCfg.PassphraseFile, ksCfg.PassphraseEnv); err == nil {
+		return passphrase, nil
+	}
+	return prompt("Passphrase: ")
+}
+
+// newPassphrase 获取新口令，优先使用文件，否则在终端输入两次确认
+func newPassphrase(ksCfg config.KeystoreConfig, file string) (string, error) {
+	if file == "" {
+		file = ksCfg.PassphraseFile
+	}
+	if file != "" || os.Getenv(ksCfg.PassphraseEnv) != "" {
+		return keystore.ResolvePassphrase(file, ksCfg.PassphraseEnv)
+	}
+
+	first, err := prompt("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	second, err := prompt("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if first != second {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	if first == "" {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+	return first, nil
+}
+
+// readMnemonic 从文件读取助记词，未指定文件时生成新的24词助记词
+func readMnemonic(file string) (string, bool, error) {
+	if file == "" {
+		entropy, err := bip39.NewEntropy(256)
+		if err != nil {
+			return "", false, err
+		}
+		mnemonic, err := bip39.NewMnemonic(entropy)
+		return mnemonic, true, err
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read mnemonic file: %v", err)
+	}
+	mnemonic := strings.Join(strings.Fields(string(data)), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return "", false, fmt.Errorf("invalid mnemonic in %s", file)
+	}
+	return mnemonic, false, nil
+}
+
+// readSecret 从文件读取敏感数据，未指定文件时从终端读取
+func readSecret(file string, label string) (string, error) {
+	if file == "" {
+		return prompt(label)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", file, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// prompt 在终端读取不回显的输入
+func prompt(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	data, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %v", err)
+	}
+	return string(data), nil
+}
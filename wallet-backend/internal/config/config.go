@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -12,6 +13,12 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	Ethereum EthereumConfig `mapstructure:"ethereum"`
 	BSC      *BSCConfig     `mapstructure:"bsc"`
+	Bitcoin  BitcoinConfig  `mapstructure:"bitcoin"`
+	Tron     TronConfig     `mapstructure:"tron"`
+	Solana   SolanaConfig   `mapstructure:"solana"`
+	RPCPool  RPCPoolConfig  `mapstructure:"rpc_pool"`
+	GasOracle GasOracleConfig `mapstructure:"gas_oracle"`
+	TxMonitor TxMonitorConfig `mapstructure:"tx_monitor"`
 	Wallet   WalletConfig   `mapstructure:"wallet"`
 	Scanner  ScannerConfig  `mapstructure:"scanner"`
 	Server   ServerConfig   `mapstructure:"server"`
@@ -41,6 +48,89 @@ type BSCConfig struct {
 	Confirmations int   `mapstructure:"confirmations"`
 }
 
+// BitcoinConfig 比特币配置
+type BitcoinConfig struct {
+	Network         string `mapstructure:"network"`      // mainnet / testnet / regtest
+	AddressType     string `mapstructure:"address_type"` // p2pkh / p2sh-p2wpkh / p2wpkh / p2tr
+	RPCURL          string `mapstructure:"rpc_url"`      // bitcoind JSON-RPC 地址，如 http://127.0.0.1:18443
+	RPCUser         string `mapstructure:"rpc_user"`
+	RPCPassword     string `mapstructure:"rpc_password"`
+	Confirmations   int    `mapstructure:"confirmations"`     // 充值入账所需确认数，默认6
+	FeeConfTarget   int    `mapstructure:"fee_conf_target"`   // estimatesmartfee 的目标确认区块数，默认6
+	FallbackFeeRate int64  `mapstructure:"fallback_fee_rate"` // 节点无法估算时使用的费率（聪/vB），默认10
+	MaxFeeRate      int64  `mapstructure:"max_fee_rate"`      // 费率上限（聪/vB），默认500
+}
+
+// TronConfig TRON配置
+type TronConfig struct {
+	RPCURL        string `mapstructure:"rpc_url"`       // 全节点HTTP API，如 https://api.trongrid.io
+	APIKey        string `mapstructure:"api_key"`       // TronGrid API Key，自建节点可为空
+	Confirmations int    `mapstructure:"confirmations"` // 扫描落后最新区块的数量，默认19（固化区块）
+	FeeLimit      int64  `mapstructure:"fee_limit"`     // TRC-20 转账的最大能量费用（sun）
+}
+
+// SolanaConfig Solana配置
+type SolanaConfig struct {
+	RPCURL     string `mapstructure:"rpc_url"`
+	Commitment string `mapstructure:"commitment"` // 扫描使用的确认级别，默认 finalized
+}
+
+// RPCPoolConfig 节点池配置，币种 rpc_url 可用逗号分隔多个节点，endpoints 中的节点追加到对应链
+type RPCPoolConfig struct {
+	Endpoints           map[string][]string `mapstructure:"endpoints"`             // 链类型 -> 备用节点
+	Timeout             int                 `mapstructure:"timeout"`               // 单次请求超时（秒），默认10
+	FailureThreshold    int                 `mapstructure:"failure_threshold"`     // 连续失败次数达到后熔断，默认3
+	Cooldown            int                 `mapstructure:"cooldown"`              // 熔断时间（秒），默认30
+	HealthCheckInterval int                 `mapstructure:"health_check_interval"` // 健康检查间隔（秒），默认15
+	MaxLagBlocks        uint64              `mapstructure:"max_lag_blocks"`        // 落后最高节点的区块数超过此值视为不同步，默认5
+}
+
+// EndpointsFor 获取指定链的备用节点
+func (c *RPCPoolConfig) EndpointsFor(chainType string) []string {
+	for key, endpoints := range c.Endpoints {
+		if strings.EqualFold(key, chainType) {
+			return endpoints
+		}
+	}
+	return nil
+}
+
+// GasOracleConfig Gas预言机配置，归集、提币和补充手续费分别选择档位和每条链的价格上限
+type GasOracleConfig struct {
+	Interval   int       `mapstructure:"interval"`   // 采样间隔（秒），默认15
+	Blocks     uint64    `mapstructure:"blocks"`     // 每次采样的最近区块数，默认20
+	Collection GasPolicy `mapstructure:"collection"` // 归集，默认 slow
+	Withdrawal GasPolicy `mapstructure:"withdrawal"` // 提币，默认 standard
+	TopUp      GasPolicy `mapstructure:"top_up"`     // 向充值地址补充手续费，默认 standard
+}
+
+// GasPolicy 手续费策略
+type GasPolicy struct {
+	Strategy   string             `mapstructure:"strategy"`     // slow / standard / fast
+	MaxFeeGwei map[string]float64 `mapstructure:"max_fee_gwei"` // 链类型 -> 每单位gas最高价格（gwei），未配置的链不限制
+}
+
+// MaxFeeFor 获取指定链的价格上限（gwei），未配置时返回0
+func (p *GasPolicy) MaxFeeFor(chainType string) float64 {
+	for key, maxFee := range p.MaxFeeGwei {
+		if strings.EqualFold(key, chainType) {
+			return maxFee
+		}
+	}
+	return 0
+}
+
+// TxMonitorConfig 卡住交易监控配置：超过 StuckAfter 仍未上链的转出交易被重新广播，或按加价策略以相同nonce替换
+type TxMonitorConfig struct {
+	Enabled         bool      `mapstructure:"enabled"`
+	Interval        int       `mapstructure:"interval"`         // 检查间隔（秒），默认60
+	StuckAfter      int       `mapstructure:"stuck_after"`      // 发送后超过此时间（秒）未上链视为卡住，默认600
+	AutoSpeedUp     bool      `mapstructure:"auto_speed_up"`    // 节点仍持有卡住的交易时自动加价替换
+	FeeBumpPercent  int64     `mapstructure:"fee_bump_percent"` // 替换交易相对原交易的最低加价比例（%），默认15，节点要求至少10
+	MaxReplacements int       `mapstructure:"max_replacements"` // 同一nonce自动替换的最多次数，默认3
+	SpeedUp         GasPolicy `mapstructure:"speed_up"`         // 替换交易至少按此档位的市场价格，并受每条链的价格上限限制，默认 fast
+}
+
 // TestnetConfig 测试网配置
 type TestnetConfig struct {
 	RPCURL       string `mapstructure:"rpc_url"`
@@ -64,33 +154,77 @@ type NetworkConfig struct {
 
 // WalletConfig 钱包配置
 type WalletConfig struct {
-	HDWallet   HDWalletConfig   `mapstructure:"hd_wallet"`
-	HotWallet  HotWalletConfig  `mapstructure:"hot_wallet"`
-	ColdWallet ColdWalletConfig `mapstructure:"cold_wallet"`
+	HDWallet    HDWalletConfig    `mapstructure:"hd_wallet"`
+	HotWallet   HotWalletConfig   `mapstructure:"hot_wallet"`
+	ColdWallet  ColdWalletConfig  `mapstructure:"cold_wallet"`
+	Keystore    KeystoreConfig    `mapstructure:"keystore"`
+	Signer      SignerConfig      `mapstructure:"signer"`
+	AddressPool AddressPoolConfig `mapstructure:"address_pool"`
+	Recovery    RecoveryConfig    `mapstructure:"recovery"`
 }
 
 // HDWalletConfig HD钱包配置
 type HDWalletConfig struct {
-	Mnemonic       string `mapstructure:"mnemonic"`
-	DerivationPath string `mapstructure:"derivation_path"`
+	Mnemonic           string            `mapstructure:"mnemonic"`
+	DerivationPath     string            `mapstructure:"derivation_path"`
+	WatchOnly          bool              `mapstructure:"watch_only"`
+	ExtendedPublicKeys map[string]string `mapstructure:"xpubs"` // 链类型 -> 账户级扩展公钥（观察模式）
+}
+
+// KeystoreConfig 加密密钥库配置，配置dir后从密钥库解锁助记词，不再使用明文mnemonic
+type KeystoreConfig struct {
+	Dir            string `mapstructure:"dir"`
+	PassphraseFile string `mapstructure:"passphrase_file"`
+	PassphraseEnv  string `mapstructure:"passphrase_env"`
+	LightKDF       bool   `mapstructure:"light_kdf"` // 仅用于开发环境，降低scrypt强度
+}
+
+// SignerConfig 交易签名配置
+// type=hd 在本进程内按HD路径派生私钥签名；type=remote 通过双向TLS调用独立的签名服务，私钥不进入API主机
+type SignerConfig struct {
+	Type       string `mapstructure:"type"`        // hd / remote
+	RemoteURL  string `mapstructure:"remote_url"`  // 远程签名服务地址，如 https://signer.internal:9443
+	ListenAddr string `mapstructure:"listen_addr"` // 签名服务监听地址（cmd/signer 使用）
+	CertFile   string `mapstructure:"cert_file"`   // 本端证书
+	KeyFile    string `mapstructure:"key_file"`    // 本端私钥
+	CAFile     string `mapstructure:"ca_file"`     // 用于校验对端证书的CA
+	Timeout    int    `mapstructure:"timeout"`     // 请求超时（秒）
+}
+
+// AddressPoolConfig 充值地址池配置，空闲地址数低于水位线时补足到池大小
+type AddressPoolConfig struct {
+	Size         int      `mapstructure:"size"`
+	LowWatermark int      `mapstructure:"low_watermark"`
+	ChainTypes   []string `mapstructure:"chain_types"`
+}
+
+// RecoveryConfig 地址恢复配置
+type RecoveryConfig struct {
+	GapLimit int `mapstructure:"gap_limit"` // 连续未使用地址数量达到此值后停止扫描
 }
 
 // HotWalletConfig 热钱包配置
 type HotWalletConfig struct {
-	MaxBalance          string `mapstructure:"max_balance"`
-	CollectionThreshold string `mapstructure:"collection_threshold"`
+	MaxBalance          string            `mapstructure:"max_balance"`
+	CollectionThreshold string            `mapstructure:"collection_threshold"`
+	GasPayers           map[string]string `mapstructure:"gas_payers"` // 链类型 -> 向代币提币地址补充手续费的付款地址，须为地址库中的地址
 }
 
 // ColdWalletConfig 冷钱包配置
 type ColdWalletConfig struct {
-	Address string `mapstructure:"address"`
+	Address   string            `mapstructure:"address"`
+	Addresses map[string]string `mapstructure:"addresses"` // 链类型 -> 冷钱包地址（非EVM链使用）
 }
 
 // ScannerConfig 扫描配置
 type ScannerConfig struct {
-	ScanInterval     int `mapstructure:"scan_interval"`
-	MaxBlocksPerScan int `mapstructure:"max_blocks_per_scan"`
-	RetryAttempts    int `mapstructure:"retry_attempts"`
+	ScanInterval     int  `mapstructure:"scan_interval"`
+	MaxBlocksPerScan int  `mapstructure:"max_blocks_per_scan"`
+	RetryAttempts    int  `mapstructure:"retry_attempts"`
+	ReorgDepth       int  `mapstructure:"reorg_depth"`   // 保存最近多少个已扫描区块的哈希用于检测链重组，默认64
+	Workers          int  `mapstructure:"workers"`       // 并发获取区块的协程数，默认4
+	BatchSize        int  `mapstructure:"batch_size"`    // 每个JSON-RPC批量请求包含的区块数，默认10
+	AddressBloom     bool `mapstructure:"address_bloom"` // 地址索引前加布隆过滤器
 }
 
 // ServerConfig 服务器配置
@@ -154,6 +288,102 @@ func (c *Config) setDefaults() {
 	if c.Scanner.RetryAttempts == 0 {
 		c.Scanner.RetryAttempts = 3
 	}
+	if c.Scanner.ReorgDepth == 0 {
+		c.Scanner.ReorgDepth = 64
+	}
+	if c.Scanner.Workers == 0 {
+		c.Scanner.Workers = 4
+	}
+	if c.Scanner.BatchSize == 0 {
+		c.Scanner.BatchSize = 10
+	}
+	if c.Wallet.Keystore.PassphraseEnv == "" {
+		c.Wallet.Keystore.PassphraseEnv = "WALLET_KEYSTORE_PASSPHRASE"
+	}
+	if c.Wallet.Signer.Type == "" {
+		c.Wallet.Signer.Type = "hd"
+	}
+	if c.Wallet.Signer.ListenAddr == "" {
+		c.Wallet.Signer.ListenAddr = ":9443"
+	}
+	if c.Wallet.Signer.Timeout == 0 {
+		c.Wallet.Signer.Timeout = 10
+	}
+	if c.Wallet.AddressPool.Size == 0 {
+		c.Wallet.AddressPool.Size = 100
+	}
+	if c.Wallet.AddressPool.LowWatermark == 0 {
+		c.Wallet.AddressPool.LowWatermark = c.Wallet.AddressPool.Size / 5
+	}
+	if len(c.Wallet.AddressPool.ChainTypes) == 0 {
+		c.Wallet.AddressPool.ChainTypes = []string{"Ethereum", "Bitcoin"}
+	}
+	if c.Wallet.Recovery.GapLimit == 0 {
+		c.Wallet.Recovery.GapLimit = 20
+	}
+	if c.Bitcoin.Confirmations == 0 {
+		c.Bitcoin.Confirmations = 6
+	}
+	if c.Bitcoin.FeeConfTarget == 0 {
+		c.Bitcoin.FeeConfTarget = 6
+	}
+	if c.Bitcoin.FallbackFeeRate == 0 {
+		c.Bitcoin.FallbackFeeRate = 10
+	}
+	if c.Bitcoin.MaxFeeRate == 0 {
+		c.Bitcoin.MaxFeeRate = 500
+	}
+	if c.Tron.Confirmations == 0 {
+		c.Tron.Confirmations = 19
+	}
+	if c.Tron.FeeLimit == 0 {
+		c.Tron.FeeLimit = 100000000 // 100 TRX
+	}
+	if c.GasOracle.Interval == 0 {
+		c.GasOracle.Interval = 15
+	}
+	if c.GasOracle.Blocks == 0 {
+		c.GasOracle.Blocks = 20
+	}
+	if c.GasOracle.Collection.Strategy == "" {
+		c.GasOracle.Collection.Strategy = "slow"
+	}
+	if c.GasOracle.Withdrawal.Strategy == "" {
+		c.GasOracle.Withdrawal.Strategy = "standard"
+	}
+	if c.GasOracle.TopUp.Strategy == "" {
+		c.GasOracle.TopUp.Strategy = "standard"
+	}
+	if c.TxMonitor.Interval == 0 {
+		c.TxMonitor.Interval = 60
+	}
+	if c.TxMonitor.StuckAfter == 0 {
+		c.TxMonitor.StuckAfter = 600
+	}
+	if c.TxMonitor.FeeBumpPercent == 0 {
+		c.TxMonitor.FeeBumpPercent = 15
+	}
+	if c.TxMonitor.MaxReplacements == 0 {
+		c.TxMonitor.MaxReplacements = 3
+	}
+	if c.TxMonitor.SpeedUp.Strategy == "" {
+		c.TxMonitor.SpeedUp.Strategy = "fast"
+	}
+	if c.RPCPool.Timeout == 0 {
+		c.RPCPool.Timeout = 10
+	}
+	if c.RPCPool.FailureThreshold == 0 {
+		c.RPCPool.FailureThreshold = 3
+	}
+	if c.RPCPool.Cooldown == 0 {
+		c.RPCPool.Cooldown = 30
+	}
+	if c.RPCPool.HealthCheckInterval == 0 {
+		c.RPCPool.HealthCheckInterval = 15
+	}
+	if c.RPCPool.MaxLagBlocks == 0 {
+		c.RPCPool.MaxLagBlocks = 5
+	}
 	if c.JWT.ExpirationHours == 0 {
 		c.JWT.ExpirationHours = 24
 	}
@@ -198,3 +428,23 @@ func (c *NetworkConfig) GetChainID() int64 {
 func (c *NetworkConfig) GetConfirmations() int {
 	return c.Confirmations
 }
+
+// GasPayerFor 获取指定链补充手续费的付款地址，未配置时返回空
+func (c *HotWalletConfig) GasPayerFor(chainType string) string {
+	for key, address := range c.GasPayers {
+		if strings.EqualFold(key, chainType) {
+			return address
+		}
+	}
+	return ""
+}
+
+// AddressFor 获取指定链的冷钱包地址，未单独配置时使用 address
+func (c *ColdWalletConfig) AddressFor(chainType string) string {
+	for key, address := range c.Addresses {
+		if strings.EqualFold(key, chainType) && address != "" {
+			return address
+		}
+	}
+	return c.Address
+}
@@ -54,6 +54,10 @@ func AutoMigrate() error {
 		&models.DepositRecord{},
 		&models.ChainBill{},
 		&models.CurrencyChainConfig{},
+		&models.BitcoinUTXO{},
+		&models.EVMNonce{},
+		&models.EVMNonceReservation{},
+		&models.ScannedBlock{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to auto migrate: %v", err)
@@ -73,6 +77,9 @@ func CreateDefaultData() error {
 	return nil
 }
 
+// tronUSDTContract TRON主网USDT合约地址
+var tronUSDTContract = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+
 // createDefaultCurrencies 创建默认币种配置
 func createDefaultCurrencies() error {
 	// 检查是否已存在币种配置
@@ -124,6 +131,7 @@ func createDefaultCurrencies() error {
 			RPCURL:            "https://bsc-dataseed1.binance.org/",
 			ChainID:           56,
 			Confirmations:     15,
+			LegacyTx:          true,
 			Decimals:          18,
 			CollectionEnabled: true,
 			CollectionThreshold: "0.1",
@@ -135,10 +143,34 @@ func createDefaultCurrencies() error {
 			RPCURL:            "https://bsc-dataseed1.binance.org/",
 			ChainID:           56,
 			Confirmations:     15,
+			LegacyTx:          true,
 			Decimals:          18,
 			CollectionEnabled: true,
 			CollectionThreshold: "10",
 		},
+		{
+			Symbol:            "TRX",
+			ChainType:         "TRON",
+			IsEnabled:         true,
+			RPCURL:            "https://api.trongrid.io",
+			ChainID:           728126428,
+			Confirmations:     19,
+			Decimals:          6,
+			CollectionEnabled: true,
+			CollectionThreshold: "10",
+		},
+		{
+			Symbol:            "USDT-TRC20",
+			ChainType:         "TRON",
+			IsEnabled:         true,
+			RPCURL:            "https://api.trongrid.io",
+			ChainID:           728126428,
+			Confirmations:     19,
+			TokenAddress:      &tronUSDTContract,
+			Decimals:          6,
+			CollectionEnabled: true,
+			CollectionThreshold: "10",
+		},
 	}
 
 	for _, currency := range defaultCurrencies {
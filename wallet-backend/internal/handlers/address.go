@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"time"
 	"wallet-backend/internal/database"
 	"wallet-backend/internal/models"
-	"wallet-backend/internal/utils"
+	"wallet-backend/internal/services"
+	"wallet-backend/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,8 +24,18 @@ func GetAddresses(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": addresses})
 }
 
-// GenerateAddress 生成新地址
-func GenerateAddress(c *gin.Context) {
+// AddressHandler 地址处理器
+type AddressHandler struct {
+	Addresses *services.AddressService
+}
+
+// NewAddressHandler 创建新的地址处理器
+func NewAddressHandler(addresses *services.AddressService) *AddressHandler {
+	return &AddressHandler{Addresses: addresses}
+}
+
+// GenerateAddress 生成新地址（从HD钱包派生，观察模式下使用扩展公钥派生）
+func (h *AddressHandler) GenerateAddress(c *gin.Context) {
 	var req struct {
 		ChainType string `json:"chain_type" binding:"required"`
 		Protocol  string `json:"protocol,omitempty"`
@@ -44,23 +55,13 @@ func GenerateAddress(c *gin.Context) {
 		return
 	}
 
-	// 这里应该调用钱包服务生成地址，暂时使用模拟数据
-	randomHex, err := utils.GenerateRandomHex(40)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate address"})
+	if !h.Addresses.IsSupportedChain(req.ChainType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported chain type"})
 		return
 	}
 
-	address := models.AddressLibrary{
-		UserID:      &userIDUint64,
-		Address:     "0x" + randomHex, // 模拟地址
-		ChainType:   req.ChainType,
-		Status:      0, // 未使用
-		IndexNum:    0,
-		CreatedTime: time.Now(),
-	}
-
-	if err := database.GetDB().Create(&address).Error; err != nil {
+	address, err := h.Addresses.GenerateAddress(userIDUint64, req.ChainType)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate address"})
 		return
 	}
@@ -68,8 +69,8 @@ func GenerateAddress(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"data": address})
 }
 
-// BindAddress 绑定地址
-func BindAddress(c *gin.Context) {
+// BindAddress 绑定地址，地址池中的地址会被原子领取
+func (h *AddressHandler) BindAddress(c *gin.Context) {
 	var req struct {
 		Address   string `json:"address" binding:"required"`
 		ChainType string `json:"chain_type" binding:"required"`
@@ -90,27 +91,63 @@ func BindAddress(c *gin.Context) {
 		return
 	}
 
-	// 检查地址是否已存在
-	var existingAddress models.AddressLibrary
-	if err := database.GetDB().Where("address = ? AND chain_type = ?", req.Address, req.ChainType).First(&existingAddress).Error; err == nil {
+	address, err := h.Addresses.BindAddress(userIDUint64, req.Address, req.ChainType, req.Note)
+	if abortInvalidAddress(c, err) {
+		return
+	}
+	if errors.Is(err, services.ErrAddressAlreadyBound) {
 		c.JSON(http.StatusConflict, gin.H{"error": "Address already exists"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bind address"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": address})
+}
+
+// FreezeAddress 冻结地址
+func (h *AddressHandler) FreezeAddress(c *gin.Context) {
+	h.setFrozen(c, true)
+}
+
+// UnfreezeAddress 解冻地址
+func (h *AddressHandler) UnfreezeAddress(c *gin.Context) {
+	h.setFrozen(c, false)
+}
+
+// setFrozen 修改地址冻结状态，地址索引同步更新
+func (h *AddressHandler) setFrozen(c *gin.Context, frozen bool) {
+	var req struct {
+		Address   string `json:"address" binding:"required"`
+		ChainType string `json:"chain_type" binding:"required"`
+	}
 
-	address := models.AddressLibrary{
-		UserID:      &userIDUint64,
-		Address:     req.Address,
-		ChainType:   req.ChainType,
-		Status:      1, // 已激活
-		BindTime:    &[]time.Time{time.Now()}[0],
-		Note:        req.Note,
-		CreatedTime: time.Now(),
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
 	}
 
-	if err := database.GetDB().Create(&address).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bind address"})
+	address, err := h.Addresses.SetFrozen(req.ChainType, req.Address, frozen)
+	if errors.Is(err, services.ErrAddressNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update address"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"data": address})
+	c.JSON(http.StatusOK, gin.H{"data": address})
+}
+
+// abortInvalidAddress 地址校验失败时返回400及错误码，err 不是地址校验错误时返回false
+func abortInvalidAddress(c *gin.Context, err error) bool {
+	code := validation.ErrorCode(err)
+	if code == "" {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": code})
+	return true
 }
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"wallet-backend/internal/database"
 	"wallet-backend/internal/models"
+	"wallet-backend/internal/services"
+	"wallet-backend/pkg/blockchain"
 
 	"github.com/gin-gonic/gin"
 )
@@ -36,24 +39,49 @@ func GetCurrencyBySymbol(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": currency})
 }
 
+// CurrencyHandler 币种配置管理处理器，配置变更后重新加载链适配器注册表
+type CurrencyHandler struct {
+	Chains *services.ChainRegistry
+}
+
+// NewCurrencyHandler 创建新的币种配置处理器
+func NewCurrencyHandler(chains *services.ChainRegistry) *CurrencyHandler {
+	return &CurrencyHandler{Chains: chains}
+}
+
+// reloadChains 重新加载链适配器，失败只记录日志，下次查询未知币种时会再次加载
+func (h *CurrencyHandler) reloadChains() {
+	if h.Chains == nil {
+		return
+	}
+	if err := h.Chains.Reload(); err != nil {
+		log.Printf("Failed to reload chain registry: %v", err)
+	}
+}
+
 // CreateCurrency 创建币种配置
-func CreateCurrency(c *gin.Context) {
+func (h *CurrencyHandler) CreateCurrency(c *gin.Context) {
 	var currency models.CurrencyChainConfig
 	if err := c.ShouldBindJSON(&currency); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
 		return
 	}
+	if !blockchain.IsEVMTraceMode(currency.TraceMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported trace mode"})
+		return
+	}
 
 	if err := database.DB.Create(&currency).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create currency"})
 		return
 	}
 
+	h.reloadChains()
 	c.JSON(http.StatusCreated, gin.H{"data": currency})
 }
 
 // UpdateCurrency 更新币种配置
-func UpdateCurrency(c *gin.Context) {
+func (h *CurrencyHandler) UpdateCurrency(c *gin.Context) {
 	symbol := c.Param("symbol")
 	if symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol is required"})
@@ -71,17 +99,22 @@ func UpdateCurrency(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
 		return
 	}
+	if !blockchain.IsEVMTraceMode(updateData.TraceMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported trace mode"})
+		return
+	}
 
 	if err := database.DB.Model(&currency).Updates(updateData).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update currency"})
 		return
 	}
 
+	h.reloadChains()
 	c.JSON(http.StatusOK, gin.H{"data": currency})
 }
 
 // DeleteCurrency 删除币种配置
-func DeleteCurrency(c *gin.Context) {
+func (h *CurrencyHandler) DeleteCurrency(c *gin.Context) {
 	symbol := c.Param("symbol")
 	if symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol is required"})
@@ -93,11 +126,12 @@ func DeleteCurrency(c *gin.Context) {
 		return
 	}
 
+	h.reloadChains()
 	c.JSON(http.StatusOK, gin.H{"data": "Currency deleted successfully"})
 }
 
 // EnableCurrency 启用币种
-func EnableCurrency(c *gin.Context) {
+func (h *CurrencyHandler) EnableCurrency(c *gin.Context) {
 	symbol := c.Param("symbol")
 	if symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol is required"})
@@ -109,11 +143,12 @@ func EnableCurrency(c *gin.Context) {
 		return
 	}
 
+	h.reloadChains()
 	c.JSON(http.StatusOK, gin.H{"data": "Currency enabled successfully"})
 }
 
 // DisableCurrency 禁用币种
-func DisableCurrency(c *gin.Context) {
+func (h *CurrencyHandler) DisableCurrency(c *gin.Context) {
 	symbol := c.Param("symbol")
 	if symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol is required"})
@@ -125,6 +160,7 @@ func DisableCurrency(c *gin.Context) {
 		return
 	}
 
+	h.reloadChains()
 	c.JSON(http.StatusOK, gin.H{"data": "Currency disabled successfully"})
 }
 
@@ -133,6 +169,7 @@ func GetSupportedChains(c *gin.Context) {
 	chains := []string{
 		"Ethereum",
 		"BSC",
+		"TRON",
 		"Polygon",
 		"Arbitrum",
 		"Optimism",
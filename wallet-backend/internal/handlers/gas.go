@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"wallet-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GasHandler Gas预言机处理器
+type GasHandler struct {
+	Oracle *services.GasOracleService
+}
+
+// NewGasHandler 创建Gas预言机处理器
+func NewGasHandler(oracle *services.GasOracleService) *GasHandler {
+	return &GasHandler{Oracle: oracle}
+}
+
+// GET /gas/fees?chain_type=Ethereum
+// GetFees 获取各EVM链当前的慢/标准/快三档手续费
+func (h *GasHandler) GetFees(c *gin.Context) {
+	if h.Oracle == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Gas oracle is not available"})
+		return
+	}
+
+	chainType := c.Query("chain_type")
+	levels := h.Oracle.Levels()
+	if chainType != "" {
+		filtered := make([]*services.GasFeeLevels, 0, 1)
+		for _, l := range levels {
+			if strings.EqualFold(l.ChainType, chainType) {
+				filtered = append(filtered, l)
+			}
+		}
+		levels = filtered
+	}
+	c.JSON(http.StatusOK, gin.H{"data": levels})
+}
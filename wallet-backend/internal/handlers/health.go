@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 	"wallet-backend/internal/database"
+	"wallet-backend/internal/services"
+	"wallet-backend/pkg/blockchain"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,8 +26,18 @@ type ServiceInfo struct {
 	Timestamp int64  `json:"timestamp"`
 }
 
-// HealthCheck 健康检查
-func HealthCheck(c *gin.Context) {
+// HealthHandler 健康检查和指标处理器，展示各链节点池状态
+type HealthHandler struct {
+	Chains *services.ChainRegistry
+}
+
+// NewHealthHandler 创建新的健康检查处理器，chains 为空时不展示节点状态
+func NewHealthHandler(chains *services.ChainRegistry) *HealthHandler {
+	return &HealthHandler{Chains: chains}
+}
+
+// HealthCheck 健康检查，任一链没有可用节点时整体不健康
+func (h *HealthHandler) HealthCheck(c *gin.Context) {
 	response := &HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now().Unix(),
@@ -58,6 +71,26 @@ func HealthCheck(c *gin.Context) {
 		Timestamp: time.Now().Unix(),
 	}
 	response.Services["blockchain"] = blockchainInfo
+	for chainType, endpoints := range h.chainEndpoints() {
+		healthy := 0
+		for _, endpoint := range endpoints {
+			if endpoint.Healthy {
+				healthy++
+			}
+		}
+		chainInfo := ServiceInfo{
+			Status:    "healthy",
+			Message:   fmt.Sprintf("%d/%d endpoints healthy", healthy, len(endpoints)),
+			Timestamp: time.Now().Unix(),
+		}
+		if healthy == 0 {
+			chainInfo.Status = "unhealthy"
+			blockchainInfo.Status = "unhealthy"
+			response.Status = "unhealthy"
+		}
+		response.Services["blockchain:"+chainType] = chainInfo
+	}
+	response.Services["blockchain"] = blockchainInfo
 
 	// 根据整体状态返回相应的HTTP状态码
 	if response.Status == "healthy" {
@@ -118,7 +151,7 @@ func LivenessCheck(c *gin.Context) {
 }
 
 // Metrics 指标接口
-func Metrics(c *gin.Context) {
+func (h *HealthHandler) Metrics(c *gin.Context) {
 	metrics := gin.H{
 		"timestamp": time.Now().Unix(),
 		"uptime":    time.Since(time.Now()).Seconds(), // 这里应该使用实际的启动时间
@@ -129,10 +162,34 @@ func Metrics(c *gin.Context) {
 		"websocket": gin.H{
 			"clients": 0, // 这里应该获取实际的客户端数
 		},
-		"blockchain": gin.H{
-			"last_block": 0, // 这里应该获取最新的区块高度
-		},
+		"blockchain": h.chainMetrics(),
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": metrics})
 }
+
+// chainEndpoints 各链节点池状态
+func (h *HealthHandler) chainEndpoints() map[string][]blockchain.RPCEndpointStatus {
+	if h.Chains == nil {
+		return nil
+	}
+	return h.Chains.Endpoints()
+}
+
+// chainMetrics 各链最新高度和节点指标
+func (h *HealthHandler) chainMetrics() gin.H {
+	metrics := gin.H{}
+	for chainType, endpoints := range h.chainEndpoints() {
+		var head uint64
+		for _, endpoint := range endpoints {
+			if !endpoint.WrongChain && endpoint.Head > head {
+				head = endpoint.Head
+			}
+		}
+		metrics[chainType] = gin.H{
+			"last_block": head,
+			"endpoints":  endpoints,
+		}
+	}
+	return metrics
+}
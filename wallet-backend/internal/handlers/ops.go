@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
 	"wallet-backend/internal/services"
+	"wallet-backend/pkg/blockchain"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,10 +16,13 @@ import (
 type OpsHandler struct {
 	Scanner    *services.BlockScannerService
 	Collector  *services.CollectionService
+	Recovery   *services.RecoveryService
+	TxMonitor  *services.TxMonitorService
+	Withdrawer *services.EthereumWalletService
 }
 
-func NewOpsHandler(scanner *services.BlockScannerService, collector *services.CollectionService) *OpsHandler {
-	return &OpsHandler{Scanner: scanner, Collector: collector}
+func NewOpsHandler(scanner *services.BlockScannerService, collector *services.CollectionService, recovery *services.RecoveryService, txMonitor *services.TxMonitorService, withdrawer *services.EthereumWalletService) *OpsHandler {
+	return &OpsHandler{Scanner: scanner, Collector: collector, Recovery: recovery, TxMonitor: txMonitor, Withdrawer: withdrawer}
 }
 
 // POST /ops/scanner/start
@@ -65,4 +73,158 @@ func (h *OpsHandler) TriggerCollection(c *gin.Context) {
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": "ok"})
-} 
\ No newline at end of file
+}
+
+// POST /ops/recovery/start
+func (h *OpsHandler) StartRecovery(c *gin.Context) {
+	if h.Recovery == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "recovery service is not available"})
+		return
+	}
+
+	var req services.RecoveryOptions
+	if err := c.ShouldBindJSON(&req); err != nil || req.ChainType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "chain_type is required"})
+		return
+	}
+
+	if err := h.Recovery.Start(req); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": "started"})
+}
+
+// GET /ops/recovery/status?chain_type=Ethereum
+func (h *OpsHandler) RecoveryStatus(c *gin.Context) {
+	if h.Recovery == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "recovery service is not available"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": h.Recovery.Progress(c.Query("chain_type"))})
+}
+
+// POST /ops/transactions/check
+func (h *OpsHandler) CheckStuckTransactions(c *gin.Context) {
+	if h.TxMonitor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "transaction monitor is not available"})
+		return
+	}
+	if err := h.TxMonitor.CheckOnce(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": "ok"})
+}
+
+// POST /ops/transactions/:id/speed-up
+func (h *OpsHandler) SpeedUpTransaction(c *gin.Context) {
+	h.replaceTransaction(c, services.TxReplaceSpeedUp)
+}
+
+// POST /ops/transactions/:id/cancel
+func (h *OpsHandler) CancelTransaction(c *gin.Context) {
+	h.replaceTransaction(c, services.TxReplaceCancel)
+}
+
+// replaceTransaction 以相同nonce加速或取消卡住的交易
+func (h *OpsHandler) replaceTransaction(c *gin.Context, action string) {
+	if h.TxMonitor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "transaction monitor is not available"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	replacement, err := h.TxMonitor.Replace(c.Request.Context(), id, action)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, blockchain.ErrEVMFeeAboveCap) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": replacement})
+}
+
+// GET /ops/transactions/:id/history
+func (h *OpsHandler) TransactionHistory(c *gin.Context) {
+	if h.TxMonitor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "transaction monitor is not available"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	history, err := h.TxMonitor.History(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+// POST /ops/withdraws/:id/send
+// 签名并广播待处理的EVM原生币提币，手续费超过提币价格上限时返回409，提币保持待处理
+func (h *OpsHandler) SendWithdraw(c *gin.Context) {
+	if h.Withdrawer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "withdrawals are not available"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid withdraw ID"})
+		return
+	}
+
+	var withdraw models.WithdrawRecord
+	if err := database.GetDB().First(&withdraw, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Withdraw not found"})
+		return
+	}
+	if _, err := h.Withdrawer.SendWithdrawal(c.Request.Context(), &withdraw); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, blockchain.ErrEVMFeeAboveCap) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": withdraw})
+}
+
+// POST /ops/withdraws/:id/top-up
+// 从配置的付款地址向待转手续费的EVM代币提币地址补充手续费，手续费超过补充手续费价格上限时返回409，提币保持待转手续费
+func (h *OpsHandler) TopUpWithdrawGas(c *gin.Context) {
+	if h.Withdrawer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "withdrawals are not available"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid withdraw ID"})
+		return
+	}
+
+	var withdraw models.WithdrawRecord
+	if err := database.GetDB().First(&withdraw, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Withdraw not found"})
+		return
+	}
+	if _, err := h.Withdrawer.TopUpWithdrawalGas(c.Request.Context(), &withdraw); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, blockchain.ErrEVMFeeAboveCap) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": withdraw})
+}
@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"net/http"
-	"strings"
 	"wallet-backend/internal/services"
+	"wallet-backend/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,13 +12,15 @@ import (
 type ToolsHandler struct {
 	Scanner    *services.BlockScannerService
 	Collector  *services.CollectionService
+	Validator  *validation.AddressValidator
 }
 
 // NewToolsHandler 创建新的工具处理器
-func NewToolsHandler(scanner *services.BlockScannerService, collector *services.CollectionService) *ToolsHandler {
+func NewToolsHandler(scanner *services.BlockScannerService, collector *services.CollectionService, validator *validation.AddressValidator) *ToolsHandler {
 	return &ToolsHandler{
 		Scanner:   scanner,
 		Collector: collector,
+		Validator: validator,
 	}
 }
 
@@ -34,9 +36,8 @@ func (h *ToolsHandler) TriggerCollection(c *gin.Context) {
 		return
 	}
 
-	// 验证地址格式
-	if !isValidAddress(req.Address) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address format"})
+	// 验证地址格式，归集和区块扫描工具只支持EVM链
+	if abortInvalidAddress(c, h.Validator.Validate("Ethereum", req.Address)) {
 		return
 	}
 
@@ -76,8 +77,7 @@ func (h *ToolsHandler) ScanBlocks(c *gin.Context) {
 	}
 
 	for _, addr := range req.Addresses {
-		if !isValidAddress(addr) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address format: " + addr})
+		if abortInvalidAddress(c, h.Validator.Validate("Ethereum", addr)) {
 			return
 		}
 	}
@@ -116,19 +116,3 @@ func (h *ToolsHandler) GetAddresses(c *gin.Context) {
 	// 暂时返回空数组
 	c.JSON(http.StatusOK, gin.H{"data": []interface{}{}})
 }
-
-// isValidAddress 验证地址格式
-func isValidAddress(address string) bool {
-	// 基本的地址格式验证
-	if len(address) < 26 || len(address) > 50 {
-		return false
-	}
-	
-	// 以太坊地址格式验证
-	if strings.HasPrefix(address, "0x") && len(address) == 42 {
-		return true
-	}
-	
-	// 其他地址格式可以在这里添加
-	return true
-} 
\ No newline at end of file
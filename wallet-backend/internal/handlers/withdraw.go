@@ -1,17 +1,33 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 	"wallet-backend/internal/database"
 	"wallet-backend/internal/models"
 	"wallet-backend/internal/utils"
+	"wallet-backend/pkg/validation"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// CreateWithdraw 创建提币申请
-func CreateWithdraw(c *gin.Context) {
+// errInsufficientBalance 冻结提币金额时可用余额不足
+var errInsufficientBalance = errors.New("insufficient balance")
+
+// WithdrawHandler 提币处理器
+type WithdrawHandler struct {
+	Validator *validation.AddressValidator
+}
+
+// NewWithdrawHandler 创建新的提币处理器
+func NewWithdrawHandler(validator *validation.AddressValidator) *WithdrawHandler {
+	return &WithdrawHandler{Validator: validator}
+}
+
+// CreateWithdraw 创建提币申请，目标地址需通过校验和与网络校验
+func (h *WithdrawHandler) CreateWithdraw(c *gin.Context) {
 	var req struct {
 		CurrencySymbol string  `json:"currency_symbol" binding:"required"`
 		ChainType      string  `json:"chain_type" binding:"required"`
@@ -26,6 +42,10 @@ func CreateWithdraw(c *gin.Context) {
 		return
 	}
 
+	if abortInvalidAddress(c, h.Validator.Validate(req.ChainType, req.ToAddress)) {
+		return
+	}
+
 	userID, _ := c.Get("user_id")
 
 	// 检查余额是否足够
@@ -36,14 +56,15 @@ func CreateWithdraw(c *gin.Context) {
 		return
 	}
 
-	if balance.Balance < req.Amount {
+	// 获取手续费配置
+	var fee float64 = 0.001 // 默认手续费，实际应该从配置表获取
+
+	// 冻结的是提币金额加手续费
+	if balance.Balance < req.Amount+fee {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
 		return
 	}
 
-	// 获取手续费配置
-	var fee float64 = 0.001 // 默认手续费，实际应该从配置表获取
-
 	withdraw := models.WithdrawRecord{
 		CurrencySymbol: req.CurrencySymbol,
 		ChainType:      req.ChainType,
@@ -60,7 +81,27 @@ func CreateWithdraw(c *gin.Context) {
 		Type:           &[]int{1}[0], // 1:提币
 	}
 
-	if err := database.GetDB().Create(&withdraw).Error; err != nil {
+	// 提币金额从可用余额转入冻结，链上确认后扣除，执行失败时退回
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Balance{}).
+			Where("id = ? AND balance >= ?", balance.ID, withdraw.TotalAmount).
+			Updates(map[string]interface{}{
+				"balance": gorm.Expr("balance - ?", withdraw.TotalAmount),
+				"frozen":  gorm.Expr("frozen + ?", withdraw.TotalAmount),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInsufficientBalance
+		}
+		return tx.Create(&withdraw).Error
+	})
+	if errors.Is(err, errInsufficientBalance) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create withdraw request"})
 		return
 	}
@@ -0,0 +1,414 @@
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"wallet-backend/internal/config"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+const (
+	masterFileName = "master.json"
+	keysDirName    = "keys"
+)
+
+var (
+	// ErrLocked 密钥库未解锁
+	ErrLocked = errors.New("keystore is locked")
+	// ErrNotInitialized 密钥库尚未创建
+	ErrNotInitialized = errors.New("keystore is not initialized")
+	// ErrAlreadyExists 密钥库已存在
+	ErrAlreadyExists = errors.New("keystore already exists")
+	// ErrKeyNotFound 找不到对应地址的私钥
+	ErrKeyNotFound = errors.New("key not found in keystore")
+)
+
+// masterFile 主助记词加密文件，crypto字段与 Web3 Secret Storage v3 一致
+type masterFile struct {
+	Version int                    `json:"version"`
+	ID      string                 `json:"id"`
+	Type    string                 `json:"type"`
+	Crypto  ethkeystore.CryptoJSON `json:"crypto"`
+}
+
+// Keystore 加密密钥库，保存主助记词和导入的私钥
+// 目录结构：<dir>/master.json 保存助记词，<dir>/keys/<address>.json 为标准v3私钥文件
+type Keystore struct {
+	dir     string
+	scryptN int
+	scryptP int
+
+	mu         sync.RWMutex
+	unlocked   bool
+	passphrase string
+	mnemonic   string
+	keys       map[string]*ecdsa.PrivateKey // 小写地址 -> 私钥
+}
+
+// New 创建密钥库实例，lightKDF 使用较弱的scrypt参数（仅用于测试和开发环境）
+func New(dir string, lightKDF bool) *Keystore {
+	ks := &Keystore{
+		dir:     dir,
+		scryptN: ethkeystore.StandardScryptN,
+		scryptP: ethkeystore.StandardScryptP,
+		keys:    make(map[string]*ecdsa.PrivateKey),
+	}
+	if lightKDF {
+		ks.scryptN = ethkeystore.LightScryptN
+		ks.scryptP = ethkeystore.LightScryptP
+	}
+	return ks
+}
+
+// Open 根据配置打开并解锁密钥库，口令来自口令文件或环境变量
+func Open(cfg config.KeystoreConfig) (*Keystore, error) {
+	ks := New(cfg.Dir, cfg.LightKDF)
+	if !ks.Exists() {
+		return nil, fmt.Errorf("%v: %s", ErrNotInitialized, cfg.Dir)
+	}
+
+	passphrase, err := ResolvePassphrase(cfg.PassphraseFile, cfg.PassphraseEnv)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.Unlock(passphrase); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Dir 密钥库目录
+func (ks *Keystore) Dir() string {
+	return ks.dir
+}
+
+// Exists 密钥库是否已创建
+func (ks *Keystore) Exists() bool {
+	_, err := os.Stat(filepath.Join(ks.dir, masterFileName))
+	return err == nil
+}
+
+// Create 用助记词和口令创建新的密钥库，创建后处于解锁状态
+func (ks *Keystore) Create(mnemonic string, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+	if ks.Exists() {
+		return ErrAlreadyExists
+	}
+	if err := os.MkdirAll(filepath.Join(ks.dir, keysDirName), 0700); err != nil {
+		return fmt.Errorf("failed to create keystore directory: %v", err)
+	}
+
+	if err := ks.writeMaster(mnemonic, passphrase); err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.unlocked = true
+	ks.passphrase = passphrase
+	ks.mnemonic = mnemonic
+	return nil
+}
+
+// Unlock 用口令解密助记词和全部导入私钥
+func (ks *Keystore) Unlock(passphrase string) error {
+	if !ks.Exists() {
+		return ErrNotInitialized
+	}
+
+	mnemonic, err := ks.readMaster(passphrase)
+	if err != nil {
+		return err
+	}
+
+	keys, err := ks.readKeys(passphrase)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.unlocked = true
+	ks.passphrase = passphrase
+	ks.mnemonic = mnemonic
+	ks.keys = keys
+	return nil
+}
+
+// Lock 清除内存中的明文密钥
+func (ks *Keystore) Lock() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.unlocked = false
+	ks.passphrase = ""
+	ks.mnemonic = ""
+	ks.keys = make(map[string]*ecdsa.PrivateKey)
+}
+
+// IsUnlocked 是否已解锁
+func (ks *Keystore) IsUnlocked() bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.unlocked
+}
+
+// Mnemonic 返回主助记词
+func (ks *Keystore) Mnemonic() (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if !ks.unlocked {
+		return "", ErrLocked
+	}
+	return ks.mnemonic, nil
+}
+
+// ImportKey 导入私钥并以v3格式加密落盘，返回对应的以太坊地址
+func (ks *Keystore) ImportKey(privateKey *ecdsa.PrivateKey) (common.Address, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if !ks.unlocked {
+		return common.Address{}, ErrLocked
+	}
+
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	if err := ks.writeKey(privateKey, ks.passphrase); err != nil {
+		return common.Address{}, err
+	}
+
+	ks.keys[strings.ToLower(address.Hex())] = privateKey
+	return address, nil
+}
+
+// ImportKeyHex 导入十六进制私钥
+func (ks *Keystore) ImportKeyHex(hexKey string) (common.Address, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid private key: %v", err)
+	}
+	return ks.ImportKey(privateKey)
+}
+
+// PrivateKey 根据地址获取已导入的私钥
+func (ks *Keystore) PrivateKey(address string) (*ecdsa.PrivateKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if !ks.unlocked {
+		return nil, ErrLocked
+	}
+
+	key, ok := ks.keys[strings.ToLower(address)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// Addresses 返回所有导入私钥对应的地址
+func (ks *Keystore) Addresses() []string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	addresses := make([]string, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		addresses = append(addresses, crypto.PubkeyToAddress(key.PublicKey).Hex())
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// ChangePassphrase 轮换加密口令。每个文件单独原子替换，
+// 中途写入失败时把已改写的文件恢复为原密文，保证旧口令仍能解锁全部文件
+func (ks *Keystore) ChangePassphrase(oldPassphrase, newPassphrase string) error {
+	if newPassphrase == "" {
+		return fmt.Errorf("new passphrase must not be empty")
+	}
+	if err := ks.Unlock(oldPassphrase); err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	addresses := make([]string, 0, len(ks.keys))
+	for address := range ks.keys {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	// 先保留所有旧密文，失败时用于回滚
+	paths := []string{filepath.Join(ks.dir, masterFileName)}
+	for _, address := range addresses {
+		paths = append(paths, ks.keyPath(common.HexToAddress(address)))
+	}
+	originals := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		originals[path] = data
+	}
+
+	written := make([]string, 0, len(paths))
+	err := ks.writeMaster(ks.mnemonic, newPassphrase)
+	if err == nil {
+		written = append(written, paths[0])
+		for i, address := range addresses {
+			if err = ks.writeKey(ks.keys[address], newPassphrase); err != nil {
+				break
+			}
+			written = append(written, paths[i+1])
+		}
+	}
+	if err != nil {
+		for _, path := range written {
+			if restoreErr := writeFileAtomic(path, originals[path]); restoreErr != nil {
+				return fmt.Errorf("%v; failed to restore %s: %v", err, path, restoreErr)
+			}
+		}
+		return err
+	}
+
+	ks.passphrase = newPassphrase
+	return nil
+}
+
+// writeMaster 加密并写入助记词
+func (ks *Keystore) writeMaster(mnemonic, passphrase string) error {
+	cryptoJSON, err := ethkeystore.EncryptDataV3([]byte(mnemonic), []byte(passphrase), ks.scryptN, ks.scryptP)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt mnemonic: %v", err)
+	}
+
+	data, err := json.MarshalIndent(masterFile{
+		Version: 3,
+		ID:      uuid.NewString(),
+		Type:    "mnemonic",
+		Crypto:  cryptoJSON,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(ks.dir, masterFileName), data)
+}
+
+// readMaster 读取并解密助记词
+func (ks *Keystore) readMaster(passphrase string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(ks.dir, masterFileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to read master key file: %v", err)
+	}
+
+	var master masterFile
+	if err := json.Unmarshal(data, &master); err != nil {
+		return "", fmt.Errorf("failed to parse master key file: %v", err)
+	}
+	if master.Version != 3 {
+		return "", fmt.Errorf("unsupported keystore version %d", master.Version)
+	}
+
+	plain, err := ethkeystore.DecryptDataV3(master.Crypto, passphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt master key: %v", err)
+	}
+	return string(plain), nil
+}
+
+// writeKey 以标准v3格式加密写入私钥
+func (ks *Keystore) writeKey(privateKey *ecdsa.PrivateKey, passphrase string) error {
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	key := &ethkeystore.Key{
+		Id:         uuid.New(),
+		Address:    address,
+		PrivateKey: privateKey,
+	}
+
+	data, err := ethkeystore.EncryptKey(key, passphrase, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt key %s: %v", address.Hex(), err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(ks.dir, keysDirName), 0700); err != nil {
+		return fmt.Errorf("failed to create keys directory: %v", err)
+	}
+	return writeFileAtomic(ks.keyPath(address), data)
+}
+
+// readKeys 解密全部导入私钥
+func (ks *Keystore) readKeys(passphrase string) (map[string]*ecdsa.PrivateKey, error) {
+	keys := make(map[string]*ecdsa.PrivateKey)
+
+	files, err := filepath.Glob(filepath.Join(ks.dir, keysDirName, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file %s: %v", file, err)
+		}
+		key, err := ethkeystore.DecryptKey(data, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt key file %s: %v", filepath.Base(file), err)
+		}
+		keys[strings.ToLower(key.Address.Hex())] = key.PrivateKey
+	}
+
+	return keys, nil
+}
+
+func (ks *Keystore) keyPath(address common.Address) string {
+	return filepath.Join(ks.dir, keysDirName, strings.ToLower(address.Hex()[2:])+".json")
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免口令轮换中途失败导致文件损坏
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}
+
+// ResolvePassphrase 按优先级从口令文件或环境变量读取口令
+func ResolvePassphrase(passphraseFile string, envName string) (string, error) {
+	if passphraseFile != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %v", err)
+		}
+		passphrase := strings.TrimRight(string(data), "\r\n")
+		if passphrase == "" {
+			return "", fmt.Errorf("passphrase file %s is empty", passphraseFile)
+		}
+		return passphrase, nil
+	}
+
+	if envName != "" {
+		if passphrase := os.Getenv(envName); passphrase != "" {
+			return passphrase, nil
+		}
+	}
+
+	return "", fmt.Errorf("keystore passphrase not provided (set passphrase_file or %s)", envName)
+}
@@ -0,0 +1,194 @@
+package keystore
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	ks := New(dir, true)
+	if err := ks.Create(testMnemonic, "secret"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := ks.Create(testMnemonic, "secret"); err != ErrAlreadyExists {
+		t.Fatalf("expected ErrAlreadyExists, got %v", err)
+	}
+
+	addr, err := ks.ImportKeyHex("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatalf("ImportKeyHex failed: %v", err)
+	}
+
+	// 明文不能出现在磁盘上
+	data, err := os.ReadFile(filepath.Join(dir, masterFileName))
+	if err != nil {
+		t.Fatalf("failed to read master file: %v", err)
+	}
+	if strings.Contains(string(data), "abandon") {
+		t.Fatal("master file contains plaintext mnemonic")
+	}
+
+	reopened := New(dir, true)
+	if err := reopened.Unlock("wrong"); err == nil {
+		t.Fatal("expected unlock with wrong passphrase to fail")
+	}
+	if _, err := reopened.Mnemonic(); err != ErrLocked {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if err := reopened.Unlock("secret"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	mnemonic, err := reopened.Mnemonic()
+	if err != nil || mnemonic != testMnemonic {
+		t.Fatalf("mnemonic mismatch: %q, %v", mnemonic, err)
+	}
+	key, err := reopened.PrivateKey(strings.ToLower(addr.Hex()))
+	if err != nil {
+		t.Fatalf("PrivateKey failed: %v", err)
+	}
+	if crypto.PubkeyToAddress(key.PublicKey) != addr {
+		t.Fatal("restored key does not match imported address")
+	}
+}
+
+func TestKeystoreImportedKeyIsWeb3V3(t *testing.T) {
+	dir := t.TempDir()
+
+	ks := New(dir, true)
+	if err := ks.Create(testMnemonic, "secret"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	privateKey, _ := crypto.GenerateKey()
+	addr, err := ks.ImportKey(privateKey)
+	if err != nil {
+		t.Fatalf("ImportKey failed: %v", err)
+	}
+
+	// 导入的私钥文件可以被标准v3实现直接解密
+	data, err := os.ReadFile(ks.keyPath(addr))
+	if err != nil {
+		t.Fatalf("failed to read key file: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil || raw["version"].(float64) != 3 {
+		t.Fatalf("key file is not v3 json: %v", err)
+	}
+	key, err := ethkeystore.DecryptKey(data, "secret")
+	if err != nil {
+		t.Fatalf("DecryptKey failed: %v", err)
+	}
+	if key.Address != addr {
+		t.Fatalf("address mismatch: %s != %s", key.Address.Hex(), addr.Hex())
+	}
+}
+
+func TestKeystoreChangePassphrase(t *testing.T) {
+	dir := t.TempDir()
+
+	ks := New(dir, true)
+	if err := ks.Create(testMnemonic, "old"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	addr, err := ks.ImportKeyHex("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatalf("ImportKeyHex failed: %v", err)
+	}
+
+	if err := ks.ChangePassphrase("bad", "new"); err == nil {
+		t.Fatal("expected rotation with wrong passphrase to fail")
+	}
+	if err := ks.ChangePassphrase("old", "new"); err != nil {
+		t.Fatalf("ChangePassphrase failed: %v", err)
+	}
+
+	reopened := New(dir, true)
+	if err := reopened.Unlock("old"); err == nil {
+		t.Fatal("old passphrase still unlocks keystore")
+	}
+	if err := reopened.Unlock("new"); err != nil {
+		t.Fatalf("Unlock with new passphrase failed: %v", err)
+	}
+	if _, err := reopened.PrivateKey(addr.Hex()); err != nil {
+		t.Fatalf("imported key lost after rotation: %v", err)
+	}
+
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*", "*.tmp")); len(tmp) > 0 {
+		t.Fatalf("temporary files left behind: %v", tmp)
+	}
+}
+
+func TestKeystoreChangePassphraseRollsBack(t *testing.T) {
+	dir := t.TempDir()
+
+	ks := New(dir, true)
+	if err := ks.Create(testMnemonic, "old"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	var addrs []common.Address
+	for i := 0; i < 3; i++ {
+		privateKey, _ := crypto.GenerateKey()
+		addr, err := ks.ImportKey(privateKey)
+		if err != nil {
+			t.Fatalf("ImportKey failed: %v", err)
+		}
+		addrs = append(addrs, addr)
+	}
+
+	// 让按地址排序后的最后一个私钥写入失败，此时主文件和其余私钥已经改写
+	last := addrs[0]
+	for _, addr := range addrs[1:] {
+		if strings.ToLower(addr.Hex()) > strings.ToLower(last.Hex()) {
+			last = addr
+		}
+	}
+	if err := os.Mkdir(ks.keyPath(last)+".tmp", 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ks.ChangePassphrase("old", "new"); err == nil {
+		t.Fatal("expected rotation to fail")
+	}
+
+	reopened := New(dir, true)
+	if err := reopened.Unlock("new"); err == nil {
+		t.Fatal("new passphrase unlocks keystore after failed rotation")
+	}
+	if err := reopened.Unlock("old"); err != nil {
+		t.Fatalf("old passphrase no longer unlocks keystore: %v", err)
+	}
+	for _, addr := range addrs {
+		if _, err := reopened.PrivateKey(addr.Hex()); err != nil {
+			t.Fatalf("key %s lost after failed rotation: %v", addr.Hex(), err)
+		}
+	}
+}
+
+func TestResolvePassphrase(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_KEYSTORE_PASSPHRASE", "from-env")
+
+	if p, err := ResolvePassphrase(file, "TEST_KEYSTORE_PASSPHRASE"); err != nil || p != "from-file" {
+		t.Fatalf("expected file passphrase, got %q, %v", p, err)
+	}
+	if p, err := ResolvePassphrase("", "TEST_KEYSTORE_PASSPHRASE"); err != nil || p != "from-env" {
+		t.Fatalf("expected env passphrase, got %q, %v", p, err)
+	}
+	if _, err := ResolvePassphrase("", "TEST_KEYSTORE_MISSING"); err == nil {
+		t.Fatal("expected error when no passphrase source is available")
+	}
+}
@@ -14,9 +14,10 @@ type AddressLibrary struct {
 	Status      int            `json:"status" gorm:"default:0;index"` // 0-未使用, 1-已激活, 2-已冻结
 	BindTime    *time.Time     `json:"bind_time" gorm:"index"`
 	IndexNum    uint64         `json:"index_num" gorm:"default:0"`
+	IsChange    bool           `json:"is_change" gorm:"not null;default:false;index"` // 比特币找零地址（BIP44 change=1），不分配给用户
 	Note        string         `json:"note" gorm:"type:varchar(100);default:''"`
 	CreatedTime time.Time      `json:"created_time" gorm:"not null;index"`
-	UpdatedTime time.Time      `json:"updated_time" gorm:"autoUpdateTime"`
+	UpdatedTime time.Time      `json:"updated_time" gorm:"autoUpdateTime;index"` // 地址索引按此字段增量同步
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// BitcoinUTXO 地址库中比特币地址收到的交易输出
+type BitcoinUTXO struct {
+	ID           uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID       uint64    `json:"userid" gorm:"not null;index"`
+	Address      string    `json:"address" gorm:"type:varchar(100);not null;index"`
+	TxID         string    `json:"txid" gorm:"type:varchar(64);not null;uniqueIndex:idx_outpoint"`
+	Vout         uint32    `json:"vout" gorm:"not null;uniqueIndex:idx_outpoint"`
+	Amount       int64     `json:"amount" gorm:"not null"` // 聪
+	ScriptPubKey string    `json:"script_pubkey" gorm:"type:varchar(200);not null"`
+	BlockHeight  uint64    `json:"block_height" gorm:"not null;index"`
+	BlockHash    string    `json:"block_hash" gorm:"type:varchar(64);not null"`
+	Credited     bool      `json:"credited" gorm:"not null;default:false;index"` // 是否已达到确认数并入账
+	LockedBy     *uint64   `json:"locked_by" gorm:"index"`                       // 占用该UTXO的提币记录ID
+	Spent        bool      `json:"spent" gorm:"not null;default:false;index"`
+	SpentTxID    *string   `json:"spent_txid" gorm:"type:varchar(64)"`
+	SpentHeight  *uint64   `json:"spent_height"`
+	CreatedTime  time.Time `json:"created_time" gorm:"not null;autoCreateTime"`
+	UpdatedTime  time.Time `json:"updated_time" gorm:"not null;autoUpdateTime"`
+}
+
+func (BitcoinUTXO) TableName() string {
+	return "bitcoin_utxo"
+}
@@ -20,7 +20,11 @@ type ChainBill struct {
 	Balance        float64        `json:"balance" gorm:"type:decimal(36,18);not null;default:0"`
 	BlockHeight    *uint64        `json:"block_height"`
 	Confirmations  int            `json:"confirmations" gorm:"not null;default:0"`
-	Status         int            `json:"status" gorm:"not null;default:0;index"` // 0:确认中 1:已确认 2:失败
+	Status         int            `json:"status" gorm:"not null;default:0;index"`  // 0:确认中 1:已确认 2:失败 3:已被替换
+	Nonce          *uint64        `json:"nonce"`                                   // EVM转出交易的nonce
+	RawTx          *string        `json:"-" gorm:"type:text"`                      // 已签名的原始交易（十六进制），用于重新广播和替换
+	Replaces       *string        `json:"replaces" gorm:"type:varchar(191);index"` // 被本交易替换（加速/取消）的原交易哈希
+	ReplacedBy     *string        `json:"replaced_by" gorm:"type:varchar(191)"`    // 替换本交易的新交易哈希
 	Remark         *string        `json:"remark" gorm:"type:varchar(255)"`
 	CreatedTime    time.Time      `json:"created_time" gorm:"not null;autoCreateTime;index"`
 	UpdatedTime    time.Time      `json:"updated_time" gorm:"not null;autoUpdateTime"`
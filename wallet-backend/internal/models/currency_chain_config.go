@@ -16,6 +16,9 @@ type CurrencyChainConfig struct {
 	Confirmations     int       `json:"confirmations" gorm:"default:12"`              // 确认数
 	TokenAddress      *string   `json:"token_address" gorm:"type:varchar(100)"`                                // 代币合约地址（如果是代币）
 	Decimals          int       `json:"decimals" gorm:"default:18"`                   // 小数位数
+	AddressType       string    `json:"address_type" gorm:"type:varchar(20);default:''"`       // 地址类型（比特币: p2pkh/p2sh-p2wpkh/p2wpkh/p2tr）
+	LegacyTx          bool      `json:"legacy_tx" gorm:"default:false"`               // EVM链未启用London时发送传统gasPrice交易，否则发送EIP-1559交易
+	TraceMode         string    `json:"trace_mode" gorm:"type:varchar(20);default:''"`         // EVM链内部交易追踪（debug: debug_traceBlockByNumber, parity: trace_block），为空时不追踪
 	CollectionEnabled bool      `json:"collection_enabled" gorm:"default:true"`       // 是否启用归集
 	CollectionThreshold string  `json:"collection_threshold" gorm:"type:varchar(50);default:'0.1'"`    // 归集阈值
 	CreatedTime       time.Time `json:"created_time" gorm:"autoCreateTime"`
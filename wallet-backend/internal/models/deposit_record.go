@@ -18,7 +18,9 @@ type DepositRecord struct {
 	Fee            float64        `json:"fee" gorm:"type:decimal(36,18);not null;default:0"`
 	TxID           string         `json:"txid" gorm:"type:varchar(191);not null;uniqueIndex"`
 	UniqueID       string         `json:"unique_id" gorm:"type:varchar(64);not null;uniqueIndex"`
-	Status         bool           `json:"status" gorm:"not null"` // 0:充值确认中 1:完成
+	LogIndex       *uint          `json:"log_index"`                           // 代币转账事件的日志序号，原生币转账为空
+	TracePath      *string        `json:"trace_path" gorm:"type:varchar(100)"` // 内部交易在调用树中的位置，交易本身的转账为空
+	Status         bool           `json:"status" gorm:"not null"`              // 0:充值确认中 1:完成
 	IsInternal     bool           `json:"is_internal" gorm:"not null;default:false;index"`
 	Confirmations  int            `json:"confirmations" gorm:"not null;default:0"`
 	BlockHeight    *uint64        `json:"block_height"`
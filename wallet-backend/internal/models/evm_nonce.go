@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// EVMNonce EVM地址的下一个可用nonce，发送交易前在行锁内分配，多个服务实例共享
+type EVMNonce struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ChainID     int64     `json:"chain_id" gorm:"not null;uniqueIndex:idx_chain_address"`
+	Address     string    `json:"address" gorm:"type:varchar(42);not null;uniqueIndex:idx_chain_address"`
+	NextNonce   uint64    `json:"next_nonce" gorm:"not null"`
+	SyncedTime  time.Time `json:"synced_time"` // 最近一次与节点同步的时间
+	CreatedTime time.Time `json:"created_time" gorm:"not null;autoCreateTime"`
+	UpdatedTime time.Time `json:"updated_time" gorm:"not null;autoUpdateTime"`
+}
+
+func (EVMNonce) TableName() string {
+	return "evm_nonce"
+}
+
+// EVMNonceReservation 已分配的nonce，转出交易最终确定或与节点同步时发现已确认后删除
+type EVMNonceReservation struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ChainID     int64     `json:"chain_id" gorm:"not null;uniqueIndex:idx_chain_address_nonce"`
+	Address     string    `json:"address" gorm:"type:varchar(42);not null;uniqueIndex:idx_chain_address_nonce"`
+	Nonce       uint64    `json:"nonce" gorm:"not null;uniqueIndex:idx_chain_address_nonce"`
+	Status      int       `json:"status" gorm:"not null;default:0;index"` // 0-已分配待发送,1-已发送,2-已释放（未发送，可重新分配）
+	TxHash      *string   `json:"tx_hash" gorm:"type:varchar(66)"`
+	CreatedTime time.Time `json:"created_time" gorm:"not null;autoCreateTime"`
+	UpdatedTime time.Time `json:"updated_time" gorm:"not null;autoUpdateTime"`
+}
+
+func (EVMNonceReservation) TableName() string {
+	return "evm_nonce_reservation"
+}
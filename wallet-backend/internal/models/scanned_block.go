@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ScannedBlock 扫描器最近处理过的区块，每条链保留最近 reorg_depth 个，用于检测链重组
+type ScannedBlock struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	ChainType   string    `json:"chain_type" gorm:"type:varchar(30);not null;uniqueIndex:idx_chain_number"`
+	Number      uint64    `json:"number" gorm:"not null;uniqueIndex:idx_chain_number"`
+	Hash        string    `json:"hash" gorm:"type:varchar(66);not null"`
+	ParentHash  string    `json:"parent_hash" gorm:"type:varchar(66);not null"`
+	CreatedTime time.Time `json:"created_time" gorm:"not null;autoCreateTime"`
+}
+
+func (ScannedBlock) TableName() string {
+	return "scanned_block"
+}
@@ -21,7 +21,7 @@ type WithdrawRecord struct {
 	Fee            float64        `json:"fee" gorm:"type:decimal(36,18);not null;default:0"`
 	TotalAmount    float64        `json:"total_amount" gorm:"type:decimal(36,18);not null;default:0"`
 	UniqueID       string         `json:"unique_id" gorm:"type:varchar(64);not null;uniqueIndex"`
-	Status         int            `json:"status" gorm:"not null;default:0;index"` // 0-待转手续费,1-待签名,2-签名成功,3-发送成功,4-确认成功,10-待转手续失败,11-签名失败,12-发送失败
+	Status         int            `json:"status" gorm:"not null;default:0;index"` // 0-待转手续费,1-待签名,2-签名成功,3-发送成功,4-确认成功,10-待转手续失败,11-签名失败,12-发送失败,13-链上执行失败
 	BlockHeight    *uint64        `json:"block_height"`
 	Confirmations  int            `json:"confirmations" gorm:"not null;default:0"`
 	IsInternal     bool           `json:"is_internal" gorm:"not null;default:false"`
@@ -1,10 +1,12 @@
 package routes
 
 import (
+	"log"
 	"wallet-backend/internal/handlers"
 	"wallet-backend/internal/middleware"
 	"wallet-backend/internal/models"
 	"wallet-backend/internal/services"
+	"wallet-backend/pkg/validation"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -26,10 +28,11 @@ func SetupRoutes(r *gin.Engine, cfg *services.Config) {
 	r.Use(middleware.Recovery())
 
 	// 健康检查路由
-	r.GET("/health", handlers.HealthCheck)
+	healthHandler := handlers.NewHealthHandler(cfg.ChainRegistry)
+	r.GET("/health", healthHandler.HealthCheck)
 	r.GET("/ready", handlers.ReadinessCheck)
 	r.GET("/live", handlers.LivenessCheck)
-	r.GET("/metrics", handlers.Metrics)
+	r.GET("/metrics", healthHandler.Metrics)
 
 	// API路由组
 	api := r.Group("/api/v1")
@@ -47,8 +50,16 @@ func SetupRoutes(r *gin.Engine, cfg *services.Config) {
 		api.GET("/ws/stats", middleware.AuthMiddleware(), wsHandler.GetWebSocketStats)
 
 		// 运维控制路由（需要认证）
-		opsHandler := handlers.NewOpsHandler(cfg.BlockScannerService, cfg.CollectionService)
-		toolsHandler := handlers.NewToolsHandler(cfg.BlockScannerService, cfg.CollectionService)
+		opsHandler := handlers.NewOpsHandler(cfg.BlockScannerService, cfg.CollectionService, cfg.RecoveryService, cfg.TxMonitorService, cfg.EthereumWalletService)
+		addressValidator, err := validation.NewAddressValidator(cfg.AppConfig.Bitcoin.Network)
+		if err != nil {
+			log.Fatalf("Failed to create address validator: %v", err)
+		}
+		toolsHandler := handlers.NewToolsHandler(cfg.BlockScannerService, cfg.CollectionService, addressValidator)
+		addressHandler := handlers.NewAddressHandler(cfg.AddressService)
+		withdrawHandler := handlers.NewWithdrawHandler(addressValidator)
+		currencyHandler := handlers.NewCurrencyHandler(cfg.ChainRegistry)
+		gasHandler := handlers.NewGasHandler(cfg.GasOracleService)
 
 		// 需要认证的路由
 		authorized := api.Group("/")
@@ -58,8 +69,8 @@ func SetupRoutes(r *gin.Engine, cfg *services.Config) {
 			addresses := authorized.Group("/addresses")
 			{
 				addresses.GET("", handlers.GetAddresses)
-				addresses.POST("/generate", handlers.GenerateAddress)
-				addresses.POST("/bind", handlers.BindAddress)
+				addresses.POST("/generate", addressHandler.GenerateAddress)
+				addresses.POST("/bind", addressHandler.BindAddress)
 			}
 
 			// 余额管理
@@ -73,7 +84,7 @@ func SetupRoutes(r *gin.Engine, cfg *services.Config) {
 			withdraws := authorized.Group("/withdraws")
 			{
 				withdraws.GET("", handlers.GetWithdraws)
-				withdraws.POST("", handlers.CreateWithdraw)
+				withdraws.POST("", withdrawHandler.CreateWithdraw)
 				withdraws.GET("/:id", handlers.GetWithdrawByID)
 			}
 
@@ -99,14 +110,20 @@ func SetupRoutes(r *gin.Engine, cfg *services.Config) {
 			{
 				currencies.GET("", handlers.GetCurrencies)
 				currencies.GET("/:symbol", handlers.GetCurrencyBySymbol)
-				currencies.POST("", handlers.CreateCurrency)
-				currencies.PUT("/:symbol", handlers.UpdateCurrency)
-				currencies.DELETE("/:symbol", handlers.DeleteCurrency)
-				currencies.POST("/:symbol/enable", handlers.EnableCurrency)
-				currencies.POST("/:symbol/disable", handlers.DisableCurrency)
+				currencies.POST("", currencyHandler.CreateCurrency)
+				currencies.PUT("/:symbol", currencyHandler.UpdateCurrency)
+				currencies.DELETE("/:symbol", currencyHandler.DeleteCurrency)
+				currencies.POST("/:symbol/enable", currencyHandler.EnableCurrency)
+				currencies.POST("/:symbol/disable", currencyHandler.DisableCurrency)
 				currencies.GET("/chains/supported", handlers.GetSupportedChains)
 			}
 
+			// 手续费
+			gas := authorized.Group("/gas")
+			{
+				gas.GET("/fees", gasHandler.GetFees)
+			}
+
 			// HD钱包相关
 			wallet := authorized.Group("/wallet")
 			{
@@ -134,9 +151,10 @@ func SetupRoutes(r *gin.Engine, cfg *services.Config) {
 
 				wallet.POST("/address/derive", func(c *gin.Context) {
 					var req struct {
-						Mnemonic  string `json:"mnemonic" binding:"required"`
-						ChainType string `json:"chain_type" binding:"required"`
-						Index     uint32 `json:"index"`
+						Mnemonic    string `json:"mnemonic" binding:"required"`
+						ChainType   string `json:"chain_type" binding:"required"`
+						Index       uint32 `json:"index"`
+						AddressType string `json:"address_type,omitempty"`
 					}
 					if err := c.ShouldBindJSON(&req); err != nil {
 						c.JSON(400, gin.H{"error": "Invalid request"})
@@ -149,7 +167,11 @@ func SetupRoutes(r *gin.Engine, cfg *services.Config) {
 					case "Ethereum":
 						address, err = cfg.HDWalletService.DeriveEthereumAddress(req.Mnemonic, req.Index)
 					case "Bitcoin":
-						address, err = cfg.HDWalletService.DeriveBitcoinAddress(req.Mnemonic, req.Index)
+						if req.AddressType != "" {
+							address, err = cfg.HDWalletService.DeriveBitcoinAddressWithType(req.Mnemonic, req.AddressType, req.Index)
+						} else {
+							address, err = cfg.HDWalletService.DeriveBitcoinAddress(req.Mnemonic, req.Index)
+						}
 					default:
 						c.JSON(400, gin.H{"error": "Unsupported chain type"})
 						return
@@ -184,6 +206,33 @@ func SetupRoutes(r *gin.Engine, cfg *services.Config) {
 					collection.POST("/stop", opsHandler.StopCollection)
 					collection.POST("/trigger", toolsHandler.TriggerCollection)
 				}
+
+				// 地址恢复
+				recovery := ops.Group("/recovery")
+				{
+					recovery.POST("/start", opsHandler.StartRecovery)
+					recovery.GET("/status", opsHandler.RecoveryStatus)
+				}
+
+				// 卡住交易的加速和取消
+				transactions := ops.Group("/transactions")
+				{
+					transactions.POST("/check", opsHandler.CheckStuckTransactions)
+					transactions.POST("/:id/speed-up", opsHandler.SpeedUpTransaction)
+					transactions.POST("/:id/cancel", opsHandler.CancelTransaction)
+					transactions.GET("/:id/history", opsHandler.TransactionHistory)
+				}
+
+				// EVM提币签名和广播
+				ops.POST("/withdraws/:id/send", opsHandler.SendWithdraw)
+				ops.POST("/withdraws/:id/top-up", opsHandler.TopUpWithdrawGas)
+
+				// 地址冻结
+				addresses := ops.Group("/addresses")
+				{
+					addresses.POST("/freeze", addressHandler.FreezeAddress)
+					addresses.POST("/unfreeze", addressHandler.UnfreezeAddress)
+				}
 			}
 
 			// 工具管理
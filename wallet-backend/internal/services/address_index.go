@@ -0,0 +1,318 @@
+package services
+
+import (
+	"fmt"
+	"hash/maphash"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// addressSyncOverlap 增量同步时向前多查询的时间，覆盖多个服务实例之间的时钟误差和同一时刻提交的事务
+const addressSyncOverlap = time.Minute
+
+// AddressLookup 按链上地址查找地址库记录，返回地址所属的用户ID（地址池中未分配的地址为0）
+type AddressLookup interface {
+	Lookup(address common.Address) (userID uint64, ok bool)
+}
+
+// EVMAddressSet 地址 -> 用户ID，用于只扫描指定地址或没有地址索引时
+type EVMAddressSet map[common.Address]uint64
+
+// Lookup 查找地址
+func (s EVMAddressSet) Lookup(address common.Address) (uint64, bool) {
+	userID, ok := s[address]
+	return userID, ok
+}
+
+// AddressEntry 索引中的一个地址
+type AddressEntry struct {
+	UserID uint64
+	Frozen bool
+}
+
+// AddressIndex 地址库的内存索引，按链分组。扫描器匹配交易时只查内存，不随地址数量增加查询数据库。
+// 启动时由 Load 全量加载；本进程分配、绑定、冻结地址时由地址服务直接更新，其它进程（或恢复、找零等其它路径）
+// 写入的地址由 Sync 按 updated_time 增量同步
+type AddressIndex struct {
+	bloom bool
+
+	mu       sync.RWMutex
+	chains   map[string]*chainAddressIndex // 链类型(小写) -> 地址
+	syncedAt time.Time                     // 已同步到的最大 updated_time
+}
+
+// chainAddressIndex 一条链的地址。EVM地址以20字节作为键，其它链使用原始字符串（Base58区分大小写）
+type chainAddressIndex struct {
+	mu      sync.RWMutex
+	evm     bool
+	entries map[string]AddressEntry
+	bloom   *addressBloom // 未开启时为空
+}
+
+// NewAddressIndex 创建地址索引，开启 scanner.address_bloom 时每条链的哈希表前加一层布隆过滤器
+func NewAddressIndex(cfg *config.Config) *AddressIndex {
+	return &AddressIndex{bloom: cfg.Scanner.AddressBloom, chains: make(map[string]*chainAddressIndex)}
+}
+
+// Load 从地址库全量加载所有链的地址
+func (ix *AddressIndex) Load() error {
+	var list []models.AddressLibrary
+	if err := database.DB.Find(&list).Error; err != nil {
+		return fmt.Errorf("failed to load address library: %v", err)
+	}
+
+	chains := make(map[string]*chainAddressIndex)
+	var syncedAt time.Time
+	for i := range list {
+		key := strings.ToLower(list[i].ChainType)
+		chain, ok := chains[key]
+		if !ok {
+			chain = newChainAddressIndex(list[i].ChainType)
+			chains[key] = chain
+		}
+		chain.put(&list[i])
+		if list[i].UpdatedTime.After(syncedAt) {
+			syncedAt = list[i].UpdatedTime
+		}
+	}
+	for _, chain := range chains {
+		if ix.bloom {
+			chain.rebuildBloom()
+		}
+	}
+
+	ix.mu.Lock()
+	ix.chains = chains
+	ix.syncedAt = syncedAt
+	ix.mu.Unlock()
+	log.Printf("Address index loaded with %d addresses on %d chains", len(list), len(chains))
+	return nil
+}
+
+// Sync 增量同步上次同步之后新增、修改或删除的地址
+func (ix *AddressIndex) Sync() error {
+	ix.mu.RLock()
+	since := ix.syncedAt
+	ix.mu.RUnlock()
+
+	var list []models.AddressLibrary
+	if err := database.DB.Unscoped().Where("updated_time >= ?", since.Add(-addressSyncOverlap)).
+		Find(&list).Error; err != nil {
+		return fmt.Errorf("failed to sync address library: %v", err)
+	}
+
+	for i := range list {
+		if list[i].DeletedAt.Valid {
+			ix.Remove(list[i].ChainType, list[i].Address)
+		} else {
+			ix.Put(&list[i])
+		}
+		if list[i].UpdatedTime.After(since) {
+			since = list[i].UpdatedTime
+		}
+	}
+
+	ix.mu.Lock()
+	if since.After(ix.syncedAt) {
+		ix.syncedAt = since
+	}
+	ix.mu.Unlock()
+	return nil
+}
+
+// Put 添加或更新地址
+func (ix *AddressIndex) Put(address *models.AddressLibrary) {
+	ix.chain(address.ChainType, true).put(address)
+}
+
+// Remove 删除地址
+func (ix *AddressIndex) Remove(chainType, address string) {
+	if chain := ix.chain(chainType, false); chain != nil {
+		chain.remove(address)
+	}
+}
+
+// Get 按地址字符串查找，EVM地址不区分大小写
+func (ix *AddressIndex) Get(chainType, address string) (AddressEntry, bool) {
+	chain := ix.chain(chainType, false)
+	if chain == nil {
+		return AddressEntry{}, false
+	}
+	return chain.get(chain.key(address))
+}
+
+// Len 链上的地址数量
+func (ix *AddressIndex) Len(chainType string) int {
+	chain := ix.chain(chainType, false)
+	if chain == nil {
+		return 0
+	}
+	chain.mu.RLock()
+	defer chain.mu.RUnlock()
+	return len(chain.entries)
+}
+
+// EVM 返回EVM链的地址查找接口，链上还没有地址时返回空集合
+func (ix *AddressIndex) EVM(chainType string) AddressLookup {
+	return ix.chain(chainType, true)
+}
+
+// EVMAddresses 链上的全部EVM地址及所属用户，会复制整个集合，只应在地址较少时使用
+func (ix *AddressIndex) EVMAddresses(chainType string) EVMAddressSet {
+	chain := ix.chain(chainType, false)
+	if chain == nil || !chain.evm {
+		return EVMAddressSet{}
+	}
+	chain.mu.RLock()
+	defer chain.mu.RUnlock()
+	addresses := make(EVMAddressSet, len(chain.entries))
+	for key, entry := range chain.entries {
+		addresses[common.BytesToAddress([]byte(key))] = entry.UserID
+	}
+	return addresses
+}
+
+// chain 获取链的索引，create 为 true 时不存在则创建
+func (ix *AddressIndex) chain(chainType string, create bool) *chainAddressIndex {
+	key := strings.ToLower(chainType)
+	ix.mu.RLock()
+	chain := ix.chains[key]
+	ix.mu.RUnlock()
+	if chain != nil || !create {
+		return chain
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if chain = ix.chains[key]; chain == nil {
+		chain = newChainAddressIndex(chainType)
+		if ix.bloom {
+			chain.rebuildBloom()
+		}
+		ix.chains[key] = chain
+	}
+	return chain
+}
+
+func newChainAddressIndex(chainType string) *chainAddressIndex {
+	return &chainAddressIndex{evm: IsEVMChain(chainType), entries: make(map[string]AddressEntry)}
+}
+
+// key EVM地址转为20字节，其它链保持原样
+func (c *chainAddressIndex) key(address string) string {
+	if c.evm && common.IsHexAddress(address) {
+		return string(common.HexToAddress(address).Bytes())
+	}
+	return address
+}
+
+// Lookup 按EVM地址查找
+func (c *chainAddressIndex) Lookup(address common.Address) (uint64, bool) {
+	entry, ok := c.get(string(address[:]))
+	return entry.UserID, ok
+}
+
+func (c *chainAddressIndex) get(key string) (AddressEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.bloom != nil && !c.bloom.mayContain(key) {
+		return AddressEntry{}, false
+	}
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+func (c *chainAddressIndex) put(address *models.AddressLibrary) {
+	entry := AddressEntry{Frozen: address.Status == 2}
+	if address.UserID != nil {
+		entry.UserID = *address.UserID
+	}
+	key := c.key(address.Address)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	if c.bloom != nil {
+		if len(c.entries) > c.bloom.capacity {
+			c.rebuildBloomLocked()
+		} else {
+			c.bloom.add(key)
+		}
+	}
+}
+
+func (c *chainAddressIndex) remove(address string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 布隆过滤器不支持删除，残留的位只会增加一次哈希表查询
+	delete(c.entries, c.key(address))
+}
+
+func (c *chainAddressIndex) rebuildBloom() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rebuildBloomLocked()
+}
+
+// rebuildBloomLocked 按当前地址数的2倍容量重建布隆过滤器
+func (c *chainAddressIndex) rebuildBloomLocked() {
+	c.bloom = newAddressBloom(max(2*len(c.entries), 1024))
+	for key := range c.entries {
+		c.bloom.add(key)
+	}
+}
+
+// addressBloom 地址布隆过滤器，每个地址10位、7个哈希函数，容量内误判率约1%
+type addressBloom struct {
+	seed     maphash.Seed
+	bits     []uint64
+	capacity int
+}
+
+const (
+	bloomBitsPerItem = 10
+	bloomHashes      = 7
+)
+
+func newAddressBloom(capacity int) *addressBloom {
+	return &addressBloom{
+		seed:     maphash.MakeSeed(),
+		bits:     make([]uint64, (capacity*bloomBitsPerItem+63)/64),
+		capacity: capacity,
+	}
+}
+
+// positions 双重哈希生成 bloomHashes 个位置
+func (b *addressBloom) positions(key string, visit func(bit uint64) bool) {
+	sum := maphash.String(b.seed, key)
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	size := uint64(len(b.bits) * 64)
+	for i := uint64(0); i < bloomHashes; i++ {
+		if !visit((h1 + i*h2) % size) {
+			return
+		}
+	}
+}
+
+func (b *addressBloom) add(key string) {
+	b.positions(key, func(bit uint64) bool {
+		b.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+}
+
+func (b *addressBloom) mayContain(key string) bool {
+	found := true
+	b.positions(key, func(bit uint64) bool {
+		found = b.bits[bit/64]&(1<<(bit%64)) != 0
+		return found
+	})
+	return found
+}
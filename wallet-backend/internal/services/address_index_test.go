@@ -0,0 +1,128 @@
+package services
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestAddressIndex(bloom bool) *AddressIndex {
+	cfg := &config.Config{}
+	cfg.Scanner.AddressBloom = bloom
+	return NewAddressIndex(cfg)
+}
+
+// indexAddress 第 i 个测试地址
+func indexAddress(i int) common.Address {
+	var address common.Address
+	binary.BigEndian.PutUint64(address[12:], uint64(i)+1)
+	address[0] = 0xaa
+	return address
+}
+
+func TestAddressIndex(t *testing.T) {
+	for _, bloom := range []bool{false, true} {
+		ix := newTestAddressIndex(bloom)
+		userID := uint64(9)
+		alice := common.HexToAddress("0x52908400098527886E0F7030069857D2E4169EE7")
+		ix.Put(&models.AddressLibrary{Address: alice.Hex(), ChainType: "Ethereum", UserID: &userID, Status: 1})
+		ix.Put(&models.AddressLibrary{Address: "TJRabPrwbZy45sbavfcjinPJC18kjpRTv8", ChainType: "TRON", Status: 2})
+		// 超过布隆过滤器初始容量后重建
+		for i := 0; i < 3000; i++ {
+			ix.Put(&models.AddressLibrary{Address: indexAddress(i).Hex(), ChainType: "Ethereum"})
+		}
+
+		if id, ok := ix.EVM("ethereum").Lookup(alice); !ok || id != 9 {
+			t.Errorf("bloom=%v: Lookup = %d, %v", bloom, id, ok)
+		}
+		if _, ok := ix.Get("Ethereum", "0x52908400098527886e0f7030069857d2e4169ee7"); !ok {
+			t.Errorf("bloom=%v: EVM addresses must match case-insensitively", bloom)
+		}
+		if _, ok := ix.EVM("Ethereum").Lookup(indexAddress(2999)); !ok {
+			t.Errorf("bloom=%v: address added after bloom rebuild not found", bloom)
+		}
+		if _, ok := ix.EVM("Ethereum").Lookup(common.HexToAddress("0x1")); ok {
+			t.Errorf("bloom=%v: unexpected match", bloom)
+		}
+		if entry, ok := ix.Get("TRON", "TJRabPrwbZy45sbavfcjinPJC18kjpRTv8"); !ok || !entry.Frozen {
+			t.Errorf("bloom=%v: TRON entry = %+v, %v", bloom, entry, ok)
+		}
+		if _, ok := ix.Get("TRON", "tjrabprwbzy45sbavfcjinpjc18kjprtv8"); ok {
+			t.Errorf("bloom=%v: Base58 addresses are case-sensitive", bloom)
+		}
+
+		ix.Remove("ethereum", alice.Hex())
+		if _, ok := ix.EVM("Ethereum").Lookup(alice); ok {
+			t.Errorf("bloom=%v: removed address still matches", bloom)
+		}
+		if ix.Len("Ethereum") != 3000 || len(ix.EVMAddresses("Ethereum")) != 3000 {
+			t.Errorf("bloom=%v: Len = %d", bloom, ix.Len("Ethereum"))
+		}
+	}
+}
+
+// BenchmarkAddressIndex 地址数量从一千增加到一百万时单次匹配的耗时，一半命中一半未命中
+func BenchmarkAddressIndex(b *testing.B) {
+	for _, size := range []int{1000, 100000, 1000000} {
+		for _, bloom := range []bool{false, true} {
+			ix := newTestAddressIndex(bloom)
+			for i := 0; i < size; i++ {
+				ix.Put(&models.AddressLibrary{Address: indexAddress(i).Hex(), ChainType: "Ethereum"})
+			}
+			lookup := ix.EVM("Ethereum")
+			probes := make([]common.Address, 1024)
+			for i := range probes {
+				probes[i] = indexAddress(i * (size / len(probes)))
+				if i%2 == 1 {
+					probes[i][1] = 0xff // 未命中
+				}
+			}
+
+			b.Run(fmt.Sprintf("addresses=%d/bloom=%v", size, bloom), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					lookup.Lookup(probes[i%len(probes)])
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkExtractEVMDeposits 每个区块200笔交易、其中2笔转入我方地址时的匹配耗时，不应随地址数量增加
+func BenchmarkExtractEVMDeposits(b *testing.B) {
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	var txs []*types.Transaction
+	for i := 0; i < 200; i++ {
+		to := common.BigToAddress(big.NewInt(int64(i + 1)))
+		if i%100 == 0 {
+			to = indexAddress(i)
+		}
+		tx, _ := types.SignNewTx(key, signer, &types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: uint64(i), To: &to, Value: big.NewInt(1), Gas: 21000})
+		txs = append(txs, tx)
+	}
+
+	for _, size := range []int{1000, 100000, 1000000} {
+		ix := newTestAddressIndex(false)
+		for i := 0; i < size; i++ {
+			ix.Put(&models.AddressLibrary{Address: indexAddress(i).Hex(), ChainType: "Ethereum"})
+		}
+		lookup := ix.EVM("Ethereum")
+		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}).WithBody(types.Body{Transactions: txs})
+
+		// 发送方在第一次恢复后缓存在交易中，结果主要反映地址匹配的耗时
+		b.Run(fmt.Sprintf("addresses=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if len(ExtractEVMDeposits("Ethereum", block, signer, lookup)) != 2 {
+					b.Fatal("expected 2 deposits")
+				}
+			}
+		})
+	}
+}
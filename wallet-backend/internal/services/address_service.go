@@ -0,0 +1,304 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/validation"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrAddressPoolEmpty 地址池中没有可分配的地址
+	ErrAddressPoolEmpty = errors.New("address pool is empty")
+	// ErrAddressAlreadyBound 地址已被绑定
+	ErrAddressAlreadyBound = errors.New("address already bound")
+	// ErrAddressNotFound 地址不在地址库中
+	ErrAddressNotFound = errors.New("address not found")
+)
+
+// AddressService 充值地址管理服务
+// 预先派生一批未分配地址（Status 0，UserID 为空）组成地址池，用户申请时从池中原子领取
+type AddressService struct {
+	config   *config.Config
+	hdWallet *HDWalletService
+	index    *AddressIndex
+}
+
+// NewAddressService 创建新的地址服务，分配、绑定和冻结的地址同步写入地址索引，index 可以为 nil
+func NewAddressService(cfg *config.Config, hdWallet *HDWalletService, index *AddressIndex) *AddressService {
+	return &AddressService{config: cfg, hdWallet: hdWallet, index: index}
+}
+
+// GenerateAddress 为用户分配一个充值地址
+// 优先从地址池领取，地址池为空时直接派生新地址
+func (as *AddressService) GenerateAddress(userID uint64, chainType string) (*models.AddressLibrary, error) {
+	address, err := as.ClaimAddress(userID, chainType)
+	if err == nil {
+		return address, nil
+	}
+	if !errors.Is(err, ErrAddressPoolEmpty) {
+		return nil, err
+	}
+
+	log.Printf("Address pool for %s is empty, deriving address on demand", chainType)
+	now := time.Now()
+	return as.deriveAndSave(chainType, func(address *models.AddressLibrary) {
+		address.UserID = &userID
+		address.Status = 1 // 已激活
+		address.BindTime = &now
+	})
+}
+
+// ClaimAddress 从地址池中原子领取一个空闲地址
+// 使用 SELECT ... FOR UPDATE SKIP LOCKED，并发请求会各自拿到不同的行
+func (as *AddressService) ClaimAddress(userID uint64, chainType string) (*models.AddressLibrary, error) {
+	var claimed models.AddressLibrary
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("chain_type = ? AND status = ? AND user_id IS NULL", chainType, 0).
+			Order("index_num ASC").
+			First(&claimed).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAddressPoolEmpty
+		}
+		if err != nil {
+			return fmt.Errorf("failed to select pooled address: %v", err)
+		}
+
+		return as.assign(tx, &claimed, userID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	as.indexAddress(&claimed)
+	return &claimed, nil
+}
+
+// BindAddress 为用户绑定指定地址，地址需通过校验和与网络校验
+// 地址在池中时原子领取该行；不在地址库中的外部地址直接登记为已激活
+func (as *AddressService) BindAddress(userID uint64, address string, chainType string, note string) (*models.AddressLibrary, error) {
+	validator, err := validation.NewAddressValidator(as.config.Bitcoin.Network)
+	if err != nil {
+		return nil, err
+	}
+	if err := validator.Validate(chainType, address); err != nil {
+		return nil, err
+	}
+
+	var bound models.AddressLibrary
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("address = ? AND chain_type = ?", address, chainType).
+			First(&bound).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			now := time.Now()
+			bound = models.AddressLibrary{
+				UserID:      &userID,
+				Address:     address,
+				ChainType:   chainType,
+				Status:      1, // 已激活
+				BindTime:    &now,
+				Note:        note,
+				CreatedTime: now,
+			}
+			if err := tx.Create(&bound).Error; err != nil {
+				return ErrAddressAlreadyBound
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query address: %v", err)
+		}
+
+		if bound.UserID != nil || bound.Status != 0 {
+			return ErrAddressAlreadyBound
+		}
+		return as.assign(tx, &bound, userID, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	as.indexAddress(&bound)
+	return &bound, nil
+}
+
+// assign 将池中地址分配给用户，条件更新保证同一地址只会被分配一次
+func (as *AddressService) assign(tx *gorm.DB, address *models.AddressLibrary, userID uint64, note string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"user_id":   userID,
+		"status":    1, // 已激活
+		"bind_time": now,
+	}
+	if note != "" {
+		updates["note"] = note
+	}
+
+	result := tx.Model(&models.AddressLibrary{}).
+		Where("id = ? AND user_id IS NULL AND status = ?", address.ID, 0).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to assign address: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAddressAlreadyBound
+	}
+
+	address.UserID = &userID
+	address.Status = 1
+	address.BindTime = &now
+	if note != "" {
+		address.Note = note
+	}
+	return nil
+}
+
+// SetFrozen 冻结或解冻地址，冻结的地址状态为2，解冻后已分配的地址回到已激活、池中地址回到未使用
+func (as *AddressService) SetFrozen(chainType, address string, frozen bool) (*models.AddressLibrary, error) {
+	var addr models.AddressLibrary
+	if err := database.DB.Where("address = ? AND chain_type = ?", address, chainType).First(&addr).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, fmt.Errorf("failed to query address: %v", err)
+	}
+
+	status := 2 // 已冻结
+	if !frozen {
+		status = 0 // 未使用
+		if addr.UserID != nil {
+			status = 1 // 已激活
+		}
+	}
+	if err := database.DB.Model(&addr).Update("status", status).Error; err != nil {
+		return nil, fmt.Errorf("failed to update address status: %v", err)
+	}
+
+	as.indexAddress(&addr)
+	return &addr, nil
+}
+
+// indexAddress 更新地址索引
+func (as *AddressService) indexAddress(address *models.AddressLibrary) {
+	if as.index != nil {
+		as.index.Put(address)
+	}
+}
+
+// PoolSize 统计链的空闲地址数量
+func (as *AddressService) PoolSize(chainType string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.AddressLibrary{}).
+		Where("chain_type = ? AND status = ? AND user_id IS NULL", chainType, 0).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pooled addresses: %v", err)
+	}
+	return count, nil
+}
+
+// RefillPool 空闲地址低于水位线时补足到配置的池大小，返回新生成的地址数量
+func (as *AddressService) RefillPool(chainType string) (int, error) {
+	poolCfg := as.config.Wallet.AddressPool
+
+	free, err := as.PoolSize(chainType)
+	if err != nil {
+		return 0, err
+	}
+	if free >= int64(poolCfg.LowWatermark) {
+		return 0, nil
+	}
+
+	created := 0
+	for i := free; i < int64(poolCfg.Size); i++ {
+		if _, err := as.deriveAndSave(chainType, nil); err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+// deriveAndSave 派生下一个索引的地址并入库
+// 派生索引取该链已使用的最大索引+1，并发冲突时由唯一索引兜底并重试
+func (as *AddressService) deriveAndSave(chainType string, prepare func(address *models.AddressLibrary)) (*models.AddressLibrary, error) {
+	const maxAttempts = 3
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		index, err := as.nextIndex(chainType)
+		if err != nil {
+			return nil, err
+		}
+
+		address, err := as.hdWallet.DeriveAddress(chainType, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive address: %v", err)
+		}
+
+		address.CreatedTime = time.Now()
+		if prepare != nil {
+			prepare(address)
+		}
+
+		if err := database.DB.Create(address).Error; err != nil {
+			lastErr = err
+			log.Printf("Failed to save derived address %s (index %d), retrying: %v", address.Address, index, err)
+			continue
+		}
+
+		as.indexAddress(address)
+		return address, nil
+	}
+
+	return nil, fmt.Errorf("failed to save derived address: %v", lastErr)
+}
+
+// nextIndex 获取链的下一个派生索引
+func (as *AddressService) nextIndex(chainType string) (uint32, error) {
+	var count int64
+	if err := database.DB.Model(&models.AddressLibrary{}).Unscoped().
+		Where("chain_type = ? AND is_change = ?", chainType, false).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count addresses: %v", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	var maxIndex uint64
+	if err := database.DB.Model(&models.AddressLibrary{}).Unscoped().
+		Where("chain_type = ? AND is_change = ?", chainType, false).
+		Select("COALESCE(MAX(index_num), 0)").Scan(&maxIndex).Error; err != nil {
+		return 0, fmt.Errorf("failed to query max address index: %v", err)
+	}
+
+	if maxIndex+1 >= uint64(1<<31) {
+		return 0, fmt.Errorf("address index space exhausted for chain %s", chainType)
+	}
+	return uint32(maxIndex + 1), nil
+}
+
+// IsSupportedChain 是否支持为该链派生地址
+func (as *AddressService) IsSupportedChain(chainType string) bool {
+	switch strings.ToLower(chainType) {
+	case "":
+		return false
+	case "bitcoin", "tron", "solana":
+		return true
+	default:
+		// 其余链类型由EVM适配器处理，按以太坊路径派生
+		return IsEVMChain(chainType)
+	}
+}
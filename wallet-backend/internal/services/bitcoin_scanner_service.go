@@ -0,0 +1,421 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"gorm.io/gorm"
+)
+
+// BitcoinOutput 区块中转入我方地址的一个交易输出
+type BitcoinOutput struct {
+	TxID         string
+	Vout         uint32
+	Address      string
+	Amount       int64 // 聪
+	ScriptPubKey string
+}
+
+// BitcoinSpend 区块中花费我方UTXO的交易输入
+type BitcoinSpend struct {
+	TxID      string // 被花费的输出
+	Vout      uint32
+	SpentTxID string
+}
+
+// bitcoinOutpoint UTXO标识 txid:vout
+func bitcoinOutpoint(txid string, vout uint32) string {
+	return fmt.Sprintf("%s:%d", txid, vout)
+}
+
+// bitcoinDepositTxID 写入账单的交易ID，同一交易内转入我方的第一个输出使用交易哈希，其余追加输出序号
+func bitcoinDepositTxID(utxo *models.BitcoinUTXO) (string, error) {
+	var earlier int64
+	if err := database.DB.Model(&models.BitcoinUTXO{}).Where("tx_id = ? AND vout < ?", utxo.TxID, utxo.Vout).Count(&earlier).Error; err != nil {
+		return "", err
+	}
+	if earlier == 0 {
+		return utxo.TxID, nil
+	}
+	return bitcoinOutpoint(utxo.TxID, utxo.Vout), nil
+}
+
+// bitcoinDepositUniqueID 充值唯一标识，由交易哈希和输出序号确定
+func bitcoinDepositUniqueID(txid string, vout uint32) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("bitcoin:%s:%d", txid, vout)))
+	return hex.EncodeToString(sum[:])
+}
+
+// BitcoinScannerService 比特币区块扫描服务
+// 通过 bitcoind 逐块扫描，记录转入地址库的输出为UTXO，达到确认数后入账，UTXO出现在交易输入中时标记为已花费
+type BitcoinScannerService struct {
+	config *config.Config
+	client *blockchain.BitcoinClient
+
+	mu         sync.Mutex
+	isScanning bool
+	stopChan   chan bool
+}
+
+// NewBitcoinScannerService 创建比特币扫描服务
+func NewBitcoinScannerService(cfg *config.Config) (*BitcoinScannerService, error) {
+	if cfg.Bitcoin.RPCURL == "" {
+		return nil, fmt.Errorf("bitcoin rpc_url is not configured")
+	}
+	params, err := blockchain.BitcoinNetworkByName(cfg.Bitcoin.Network)
+	if err != nil {
+		return nil, err
+	}
+	return &BitcoinScannerService{
+		config:   cfg,
+		client:   blockchain.NewBitcoinRPCClient(params, cfg.Bitcoin.RPCURL, cfg.Bitcoin.RPCUser, cfg.Bitcoin.RPCPassword),
+		stopChan: make(chan bool),
+	}, nil
+}
+
+// StartScanning 校验节点网络后开始扫描
+func (s *BitcoinScannerService) StartScanning() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isScanning {
+		return fmt.Errorf("bitcoin scanner is already running")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.client.CheckNetwork(ctx); err != nil {
+		return err
+	}
+
+	s.isScanning = true
+	go s.scanLoop()
+	return nil
+}
+
+// StopScanning 停止扫描
+func (s *BitcoinScannerService) StopScanning() {
+	s.mu.Lock()
+	running := s.isScanning
+	s.isScanning = false
+	s.mu.Unlock()
+
+	if running {
+		s.stopChan <- true
+	}
+}
+
+// Status 返回扫描状态
+func (s *BitcoinScannerService) Status() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isScanning
+}
+
+// scanLoop 扫描主循环
+func (s *BitcoinScannerService) scanLoop() {
+	ticker := time.NewTicker(time.Duration(s.config.Scanner.ScanInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			log.Println("Bitcoin scanner stopped")
+			return
+		case <-ticker.C:
+			if err := s.ScanOnce(); err != nil {
+				log.Printf("Error scanning Bitcoin blocks: %v", err)
+			}
+		}
+	}
+}
+
+// ScanOnce 从上次扫描位置扫描到最新区块，每次最多 max_blocks_per_scan 个区块，然后为达到确认数的UTXO入账
+func (s *BitcoinScannerService) ScanOnce() error {
+	ctx := context.Background()
+
+	var currency models.CurrencyChainConfig
+	err := database.DB.Where("chain_type = ? AND is_enabled = ? AND (token_address IS NULL OR token_address = '')", "Bitcoin", true).
+		First(&currency).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get Bitcoin currency: %v", err)
+	}
+
+	tip, err := s.client.GetBlockCount(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block count: %v", err)
+	}
+
+	start := tip
+	if currency.LastScannedBlock != nil {
+		start = *currency.LastScannedBlock + 1
+	}
+	if start <= tip {
+		end := tip
+		if maxBlocks := uint64(s.config.Scanner.MaxBlocksPerScan); maxBlocks > 0 && end-start+1 > maxBlocks {
+			end = start + maxBlocks - 1
+		}
+		if err := s.scanRange(ctx, start, end); err != nil {
+			return err
+		}
+	}
+
+	return s.creditConfirmed(ctx, &currency, tip)
+}
+
+// scanRange 扫描区块范围，记录新的UTXO和花费，并更新扫描位置
+func (s *BitcoinScannerService) scanRange(ctx context.Context, start, end uint64) error {
+	addresses, err := s.loadAddresses()
+	if err != nil {
+		return err
+	}
+	unspent, err := s.loadUnspent()
+	if err != nil {
+		return err
+	}
+
+	scanned := start - 1
+	for height := start; height <= end; height++ {
+		hash, err := s.client.GetBlockHash(ctx, height)
+		if err != nil {
+			log.Printf("Failed to get Bitcoin block hash %d: %v", height, err)
+			break
+		}
+		block, err := s.client.GetBlock(ctx, hash)
+		if err != nil {
+			log.Printf("Failed to get Bitcoin block %d: %v", height, err)
+			break
+		}
+
+		outputs, spends, err := ExtractBitcoinActivity(block, addresses, unspent)
+		if err != nil {
+			log.Printf("Failed to parse Bitcoin block %d: %v", height, err)
+			break
+		}
+		if err := s.saveBlockActivity(block, addresses, outputs, spends); err != nil {
+			return fmt.Errorf("failed to save Bitcoin block %d: %v", height, err)
+		}
+		scanned = height
+	}
+
+	if scanned < start {
+		return nil
+	}
+	return s.updateLastScanned(scanned)
+}
+
+// saveBlockActivity 在同一事务中写入区块内的新UTXO并标记被花费的UTXO
+func (s *BitcoinScannerService) saveBlockActivity(block *blockchain.BitcoinBlock, addresses map[string]uint64, outputs []BitcoinOutput, spends []BitcoinSpend) error {
+	if len(outputs) == 0 && len(spends) == 0 {
+		return nil
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, output := range outputs {
+			utxo := models.BitcoinUTXO{
+				UserID:       addresses[output.Address],
+				Address:      output.Address,
+				TxID:         output.TxID,
+				Vout:         output.Vout,
+				Amount:       output.Amount,
+				ScriptPubKey: output.ScriptPubKey,
+				BlockHeight:  block.Height,
+				BlockHash:    block.Hash,
+			}
+			if err := tx.Where("tx_id = ? AND vout = ?", output.TxID, output.Vout).FirstOrCreate(&utxo).Error; err != nil {
+				return err
+			}
+		}
+		for _, spend := range spends {
+			if err := tx.Model(&models.BitcoinUTXO{}).
+				Where("tx_id = ? AND vout = ?", spend.TxID, spend.Vout).
+				Updates(map[string]interface{}{"spent": true, "spent_tx_id": spend.SpentTxID, "spent_height": block.Height}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// creditConfirmed 为达到确认数的UTXO写入充值记录并增加余额，找零输出只标记为可花费
+// 入账前确认UTXO所在区块仍在主链上，已被重组掉的UTXO删除并回退扫描位置重新扫描
+func (s *BitcoinScannerService) creditConfirmed(ctx context.Context, currency *models.CurrencyChainConfig, tip uint64) error {
+	confirmations := uint64(s.config.Bitcoin.Confirmations)
+	if tip+1 < confirmations {
+		return nil
+	}
+	maxHeight := tip + 1 - confirmations
+
+	var utxos []models.BitcoinUTXO
+	if err := database.DB.Where("credited = ? AND block_height <= ?", false, maxHeight).
+		Order("block_height, tx_id, vout").Find(&utxos).Error; err != nil {
+		return fmt.Errorf("failed to load pending UTXOs: %v", err)
+	}
+
+	var changeAddresses []string
+	if err := database.DB.Model(&models.AddressLibrary{}).
+		Where("chain_type = ? AND is_change = ?", "Bitcoin", true).
+		Pluck("address", &changeAddresses).Error; err != nil {
+		return fmt.Errorf("failed to load change addresses: %v", err)
+	}
+	change := make(map[string]bool, len(changeAddresses))
+	for _, address := range changeAddresses {
+		change[address] = true
+	}
+
+	mainChain := make(map[uint64]string)
+	var rewind *uint64
+	for i := range utxos {
+		utxo := &utxos[i]
+		hash, ok := mainChain[utxo.BlockHeight]
+		if !ok {
+			var err error
+			if hash, err = s.client.GetBlockHash(ctx, utxo.BlockHeight); err != nil {
+				return fmt.Errorf("failed to get Bitcoin block hash %d: %v", utxo.BlockHeight, err)
+			}
+			mainChain[utxo.BlockHeight] = hash
+		}
+		if hash != utxo.BlockHash {
+			log.Printf("Bitcoin UTXO %s:%d was in orphaned block %s, rescanning from %d", utxo.TxID, utxo.Vout, utxo.BlockHash, utxo.BlockHeight)
+			if err := database.DB.Delete(utxo).Error; err != nil {
+				return fmt.Errorf("failed to delete orphaned UTXO: %v", err)
+			}
+			if rewind == nil || utxo.BlockHeight < *rewind {
+				height := utxo.BlockHeight
+				rewind = &height
+			}
+			continue
+		}
+
+		// 提币找零回到我方的输出只跟踪为UTXO，不是充值
+		if change[utxo.Address] {
+			if err := database.DB.Model(utxo).Update("credited", true).Error; err != nil {
+				return fmt.Errorf("failed to mark UTXO credited: %v", err)
+			}
+			continue
+		}
+
+		txID, err := bitcoinDepositTxID(utxo)
+		if err != nil {
+			return fmt.Errorf("failed to load UTXOs of %s: %v", utxo.TxID, err)
+		}
+		if err := saveDepositEntry(&depositEntry{
+			UserID:        utxo.UserID,
+			ChainType:     "Bitcoin",
+			Symbol:        currency.Symbol,
+			To:            utxo.Address,
+			TxID:          txID,
+			UniqueID:      bitcoinDepositUniqueID(utxo.TxID, utxo.Vout),
+			Height:        utxo.BlockHeight,
+			Amount:        unitsToFloat(big.NewInt(utxo.Amount), 8),
+			Confirmations: int(tip - utxo.BlockHeight + 1),
+		}); err != nil {
+			return fmt.Errorf("failed to save deposit %s:%d: %v", utxo.TxID, utxo.Vout, err)
+		}
+		if err := database.DB.Model(utxo).Update("credited", true).Error; err != nil {
+			return fmt.Errorf("failed to mark UTXO credited: %v", err)
+		}
+	}
+
+	if rewind != nil && *rewind > 0 {
+		return s.updateLastScanned(*rewind - 1)
+	}
+	return nil
+}
+
+// updateLastScanned 更新比特币币种的扫描位置
+func (s *BitcoinScannerService) updateLastScanned(height uint64) error {
+	if err := database.DB.Model(&models.CurrencyChainConfig{}).
+		Where("chain_type = ? AND is_enabled = ?", "Bitcoin", true).
+		Update("last_scanned_block", height).Error; err != nil {
+		return fmt.Errorf("failed to update last scanned block: %v", err)
+	}
+	return nil
+}
+
+// loadAddresses 加载比特币地址库，地址 -> 用户ID
+func (s *BitcoinScannerService) loadAddresses() (map[string]uint64, error) {
+	var list []models.AddressLibrary
+	if err := database.DB.Where("chain_type = ?", "Bitcoin").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load Bitcoin addresses: %v", err)
+	}
+
+	addresses := make(map[string]uint64, len(list))
+	for _, addr := range list {
+		var userID uint64
+		if addr.UserID != nil {
+			userID = *addr.UserID
+		}
+		addresses[addr.Address] = userID
+	}
+	return addresses, nil
+}
+
+// loadUnspent 加载未花费的UTXO标识
+func (s *BitcoinScannerService) loadUnspent() (map[string]bool, error) {
+	var list []models.BitcoinUTXO
+	if err := database.DB.Select("tx_id", "vout").Where("spent = ?", false).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load Bitcoin UTXOs: %v", err)
+	}
+
+	unspent := make(map[string]bool, len(list))
+	for _, utxo := range list {
+		unspent[bitcoinOutpoint(utxo.TxID, utxo.Vout)] = true
+	}
+	return unspent, nil
+}
+
+// ExtractBitcoinActivity 解析区块中转入我方地址的输出和花费我方UTXO的输入
+// 按交易顺序处理，同一区块内先收到再花费的输出也能识别；unspent 会随之更新
+func ExtractBitcoinActivity(block *blockchain.BitcoinBlock, addresses map[string]uint64, unspent map[string]bool) ([]BitcoinOutput, []BitcoinSpend, error) {
+	var outputs []BitcoinOutput
+	var spends []BitcoinSpend
+	for _, tx := range block.Tx {
+		for _, in := range tx.Vin {
+			if in.Coinbase != "" {
+				continue
+			}
+			outpoint := bitcoinOutpoint(in.TxID, in.Vout)
+			if !unspent[outpoint] {
+				continue
+			}
+			delete(unspent, outpoint)
+			spends = append(spends, BitcoinSpend{TxID: in.TxID, Vout: in.Vout, SpentTxID: tx.TxID})
+		}
+
+		for i := range tx.Vout {
+			out := &tx.Vout[i]
+			address := out.Address()
+			if _, ok := addresses[address]; address == "" || !ok {
+				continue
+			}
+			amount, err := out.Satoshis()
+			if err != nil {
+				return nil, nil, fmt.Errorf("transaction %s: %v", tx.TxID, err)
+			}
+			if amount <= 0 {
+				continue
+			}
+			outputs = append(outputs, BitcoinOutput{
+				TxID:         tx.TxID,
+				Vout:         out.N,
+				Address:      address,
+				Amount:       amount,
+				ScriptPubKey: out.ScriptPubKey.Hex,
+			})
+			unspent[bitcoinOutpoint(tx.TxID, out.N)] = true
+		}
+	}
+	return outputs, spends, nil
+}
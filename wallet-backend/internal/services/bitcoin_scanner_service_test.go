@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"wallet-backend/pkg/blockchain"
+)
+
+func TestBitcoinScanner_ExtractActivity(t *testing.T) {
+	blockJSON, err := os.ReadFile("testdata/bitcoin_get_block.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟 regtest 节点
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "rpcuser" || pass != "rpcpass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			ID     string        `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		result := map[string]string{
+			"getblockchaininfo": `{"chain":"regtest","blocks":205,"headers":205}`,
+			"getblockhash":      `"3f8e3a3ed64a1c3c7bd1c2d3a2bbcb9ac0a4c5b2f3e0e1d1a6b7c8d9e0f1a2b3"`,
+			"getblock":          string(blockJSON),
+		}[req.Method]
+		if result == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":"` + req.ID + `"}`))
+			return
+		}
+		w.Write([]byte(`{"result":` + result + `,"error":null,"id":"` + req.ID + `"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	if err := blockchain.NewBitcoinRPCClient(blockchain.BitcoinMainNet, server.URL, "rpcuser", "rpcpass").CheckNetwork(ctx); err == nil {
+		t.Error("expected mainnet client to reject a regtest node")
+	}
+	if _, err := blockchain.NewBitcoinRPCClient(blockchain.BitcoinRegTest, server.URL, "rpcuser", "wrong").GetBlockCount(ctx); err == nil {
+		t.Error("expected authentication failure")
+	}
+
+	client := blockchain.NewBitcoinRPCClient(blockchain.BitcoinRegTest, server.URL, "rpcuser", "rpcpass")
+	if err := client.CheckNetwork(ctx); err != nil {
+		t.Fatalf("CheckNetwork failed: %v", err)
+	}
+	hash, err := client.GetBlockHash(ctx, 205)
+	if err != nil {
+		t.Fatalf("GetBlockHash failed: %v", err)
+	}
+	block, err := client.GetBlock(ctx, hash)
+	if err != nil {
+		t.Fatalf("GetBlock failed: %v", err)
+	}
+	if block.Height != 205 || len(block.Tx) != 3 {
+		t.Fatalf("unexpected block: height %d, %d transactions", block.Height, len(block.Tx))
+	}
+
+	addresses := map[string]uint64{
+		"bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080": 1,
+		"2N6aE9fgr3XrPXgq4Hcqb2dxgbNcvi3xzFE":          2,
+	}
+	unspent := map[string]bool{
+		"e0d1c2b3a4f5e6d7c8b9a0f1e2d3c4b5a6f7e8d9c0b1a2f3e4d5c6b7a8f9e0d1:3": true,
+	}
+	outputs, spends, err := ExtractBitcoinActivity(block, addresses, unspent)
+	if err != nil {
+		t.Fatalf("ExtractBitcoinActivity failed: %v", err)
+	}
+
+	// coinbase 输出、普通转账的两个输出（含旧版 addresses 字段），找零不计入
+	if len(outputs) != 3 {
+		t.Fatalf("expected 3 outputs, got %d: %+v", len(outputs), outputs)
+	}
+	if outputs[0].Amount != 625000000 || outputs[1].Amount != 150000 || outputs[1].Vout != 0 {
+		t.Errorf("unexpected outputs: %+v", outputs)
+	}
+	if outputs[2].Address != "2N6aE9fgr3XrPXgq4Hcqb2dxgbNcvi3xzFE" || outputs[2].Amount != 1 {
+		t.Errorf("unexpected legacy output: %+v", outputs[2])
+	}
+
+	// 同一区块内收到后被花费的输出和之前已记录的UTXO都标记为花费
+	if len(spends) != 2 {
+		t.Fatalf("expected 2 spends, got %d: %+v", len(spends), spends)
+	}
+	spender := "d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5"
+	if spends[0].TxID != outputs[1].TxID || spends[0].Vout != 0 || spends[0].SpentTxID != spender {
+		t.Errorf("unexpected spend: %+v", spends[0])
+	}
+	if spends[1].Vout != 3 || spends[1].SpentTxID != spender {
+		t.Errorf("unexpected spend: %+v", spends[1])
+	}
+	if unspent[bitcoinOutpoint(outputs[1].TxID, 0)] || !unspent[bitcoinOutpoint(outputs[2].TxID, 2)] {
+		t.Errorf("unexpected unspent set: %v", unspent)
+	}
+}
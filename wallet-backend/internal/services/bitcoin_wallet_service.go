@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"gorm.io/gorm"
+)
+
+// BitcoinWalletService 比特币钱包服务：从已入账的UTXO中选币构建PSBT，通过签名器签名后广播
+// 只花费P2WPKH输出，找零发送到HD钱包内部链（change=1）上的新地址
+type BitcoinWalletService struct {
+	config *config.Config
+	client *blockchain.BitcoinClient
+	params *blockchain.BitcoinNetworkParams
+	hd     *HDWalletService
+	signer Signer
+}
+
+// NewBitcoinWalletService 创建比特币钱包服务
+func NewBitcoinWalletService(cfg *config.Config, hd *HDWalletService, signer Signer) (*BitcoinWalletService, error) {
+	if cfg.Bitcoin.RPCURL == "" {
+		return nil, fmt.Errorf("bitcoin rpc_url is not configured")
+	}
+	params, err := blockchain.BitcoinNetworkByName(cfg.Bitcoin.Network)
+	if err != nil {
+		return nil, err
+	}
+	return &BitcoinWalletService{
+		config: cfg,
+		client: blockchain.NewBitcoinRPCClient(params, cfg.Bitcoin.RPCURL, cfg.Bitcoin.RPCUser, cfg.Bitcoin.RPCPassword),
+		params: params,
+		hd:     hd,
+		signer: signer,
+	}, nil
+}
+
+// EstimateFeeRate 通过 estimatesmartfee 估算费率（聪/vB），节点数据不足（如regtest）时使用配置的备用费率
+func (bws *BitcoinWalletService) EstimateFeeRate(ctx context.Context) int64 {
+	rate, err := bws.client.EstimateSmartFee(ctx, bws.config.Bitcoin.FeeConfTarget)
+	if err != nil {
+		log.Printf("Bitcoin fee estimation failed, using fallback fee rate %d sat/vB: %v", bws.config.Bitcoin.FallbackFeeRate, err)
+		rate = bws.config.Bitcoin.FallbackFeeRate
+	}
+	if rate < 1 {
+		rate = 1
+	}
+	if max := bws.config.Bitcoin.MaxFeeRate; max > 0 && rate > max {
+		rate = max
+	}
+	return rate
+}
+
+// CreateWithdrawal 为提币记录选币并构建PSBT
+// 所选UTXO被该提币记录占用，PSBT 保存到 PreSignData，状态改为待签名；已构建过的记录直接返回原PSBT
+func (bws *BitcoinWalletService) CreateWithdrawal(ctx context.Context, withdraw *models.WithdrawRecord) (*blockchain.PSBT, error) {
+	if withdraw.ID == 0 {
+		return nil, fmt.Errorf("withdraw record must be saved before building a transaction")
+	}
+	if withdraw.PreSignData != nil && *withdraw.PreSignData != "" {
+		return blockchain.ParsePSBT(*withdraw.PreSignData)
+	}
+
+	var utxos []models.BitcoinUTXO
+	if err := database.DB.Where("credited = ? AND spent = ? AND locked_by IS NULL", true, false).Find(&utxos).Error; err != nil {
+		return nil, fmt.Errorf("failed to load UTXOs: %v", err)
+	}
+	coins := make([]blockchain.BitcoinCoin, 0, len(utxos))
+	ids := make(map[string]uint64, len(utxos))
+	for _, utxo := range utxos {
+		script, err := hex.DecodeString(utxo.ScriptPubKey)
+		if err != nil {
+			continue
+		}
+		coins = append(coins, blockchain.BitcoinCoin{TxID: utxo.TxID, Vout: utxo.Vout, Value: utxo.Amount, PkScript: script})
+		ids[bitcoinOutpoint(utxo.TxID, utxo.Vout)] = utxo.ID
+	}
+
+	change, err := bws.nextChangeAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	psbt, selection, err := blockchain.BuildBitcoinPSBT(blockchain.BitcoinTxRequest{
+		Coins:         coins,
+		ToAddress:     withdraw.ToAddress,
+		Amount:        floatToUnits(withdraw.Amount, 8).Int64(),
+		ChangeAddress: change.Address,
+		FeeRate:       bws.EstimateFeeRate(ctx),
+		RBF:           true,
+	}, bws.params)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := psbt.B64Encode()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if selection.Change > 0 {
+			if err := tx.Create(change).Error; err != nil {
+				return fmt.Errorf("failed to save change address: %v", err)
+			}
+		}
+
+		selected := make([]uint64, 0, len(selection.Coins))
+		for _, coin := range selection.Coins {
+			selected = append(selected, ids[bitcoinOutpoint(coin.TxID, coin.Vout)])
+		}
+		result := tx.Model(&models.BitcoinUTXO{}).
+			Where("id IN ? AND locked_by IS NULL", selected).
+			Update("locked_by", withdraw.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(selected)) {
+			return fmt.Errorf("selected UTXOs were taken by another withdrawal")
+		}
+
+		return tx.Model(withdraw).Updates(map[string]interface{}{
+			"pre_sign_data": encoded,
+			"status":        1, // 待签名
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save withdrawal transaction: %v", err)
+	}
+	withdraw.PreSignData = &encoded
+	withdraw.Status = 1
+	return psbt, nil
+}
+
+// SignWithdrawal 签名提币记录中的PSBT，签名后的交易保存到 PostSignData
+// 每个输入按脚本找到地址库记录，由签名器签名BIP143哈希，公钥从签名中恢复并与脚本核对
+func (bws *BitcoinWalletService) SignWithdrawal(ctx context.Context, withdraw *models.WithdrawRecord) (*blockchain.BitcoinWireTx, error) {
+	if withdraw.PreSignData == nil {
+		return nil, fmt.Errorf("withdraw %d has no unsigned transaction", withdraw.ID)
+	}
+	if bws.signer == nil {
+		return nil, fmt.Errorf("signer is not configured")
+	}
+
+	signed, err := bws.signPSBT(ctx, *withdraw.PreSignData)
+	if err != nil {
+		if err := failWithdrawal(withdraw, 11, err); err != nil { // 签名失败
+			log.Printf("Failed to record failure of withdraw %d: %v", withdraw.ID, err)
+		}
+		return nil, err
+	}
+
+	raw := hex.EncodeToString(signed.Serialize(true))
+	txID := signed.TxID()
+	if err := database.DB.Model(withdraw).Updates(map[string]interface{}{
+		"post_sign_data": raw,
+		"tx_id":          txID,
+		"status":         2, // 签名成功
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to save signed transaction: %v", err)
+	}
+	withdraw.PostSignData = &raw
+	withdraw.TxID = &txID
+	withdraw.Status = 2
+	return signed, nil
+}
+
+// SendWithdrawal 广播已签名的提币交易，失败时冻结的提币金额退回可用余额
+// 广播失败时UTXO保持占用，节点可能已收到交易，需人工确认后再释放
+func (bws *BitcoinWalletService) SendWithdrawal(ctx context.Context, withdraw *models.WithdrawRecord) error {
+	if withdraw.PostSignData == nil {
+		return fmt.Errorf("withdraw %d has no signed transaction", withdraw.ID)
+	}
+	raw, err := hex.DecodeString(*withdraw.PostSignData)
+	if err != nil {
+		return fmt.Errorf("invalid signed transaction: %v", err)
+	}
+
+	if _, err := bws.client.SendRawTransaction(ctx, raw); err != nil {
+		if err := failWithdrawal(withdraw, 12, err); err != nil { // 发送失败
+			log.Printf("Failed to record failure of withdraw %d: %v", withdraw.ID, err)
+		}
+		return err
+	}
+	if err := database.DB.Model(withdraw).Update("status", 3).Error; err != nil { // 发送成功
+		return fmt.Errorf("failed to update withdraw status: %v", err)
+	}
+	withdraw.Status = 3
+	return nil
+}
+
+// signPSBT 签名所有输入并提取可广播的交易
+func (bws *BitcoinWalletService) signPSBT(ctx context.Context, encoded string) (*blockchain.BitcoinWireTx, error) {
+	psbt, err := blockchain.ParsePSBT(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, input := range psbt.Inputs {
+		if input.WitnessUTXO == nil {
+			return nil, fmt.Errorf("input %d has no witness utxo", i)
+		}
+		address, err := blockchain.BitcoinScriptToAddress(input.WitnessUTXO.PkScript, bws.params)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+		addr, err := lookupAddress(address, "Bitcoin")
+		if err != nil {
+			return nil, err
+		}
+
+		hash, err := psbt.SigHash(i)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := bws.signer.SignHash(ctx, addr, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign input %d: %v", i, err)
+		}
+		pubKey, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover public key of input %d: %v", i, err)
+		}
+		der, err := blockchain.EncodeBitcoinSignature(sig, blockchain.BitcoinSigHashAll)
+		if err != nil {
+			return nil, err
+		}
+		if err := psbt.AddPartialSig(i, crypto.CompressPubkey(pubKey), der); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := psbt.Finalize(); err != nil {
+		return nil, err
+	}
+	return psbt.Extract()
+}
+
+// nextChangeAddress 派生下一个找零地址（尚未写入地址库）
+func (bws *BitcoinWalletService) nextChangeAddress() (*models.AddressLibrary, error) {
+	var count int64
+	if err := database.DB.Model(&models.AddressLibrary{}).Unscoped().
+		Where("chain_type = ? AND is_change = ?", "Bitcoin", true).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count change addresses: %v", err)
+	}
+
+	var index uint64
+	if count > 0 {
+		if err := database.DB.Model(&models.AddressLibrary{}).Unscoped().
+			Where("chain_type = ? AND is_change = ?", "Bitcoin", true).
+			Select("COALESCE(MAX(index_num), 0)").Scan(&index).Error; err != nil {
+			return nil, fmt.Errorf("failed to query max change index: %v", err)
+		}
+		index++
+	}
+	return bws.hd.DeriveBitcoinChangeAddress(uint32(index))
+}
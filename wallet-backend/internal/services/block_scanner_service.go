@@ -2,52 +2,53 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"math/big"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 	"wallet-backend/internal/config"
 	"wallet-backend/internal/database"
 	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
-// BlockScannerService 区块扫描服务
+// BlockScannerService 区块扫描服务，币种所属链及节点连接由链适配器注册表提供
 type BlockScannerService struct {
 	config     *config.Config
-	clients    map[string]*ethclient.Client // 支持多链
+	chains     *ChainRegistry
+	ws         *WebSocketService
+	addresses  *AddressIndex
 	isScanning bool
 	stopChan   chan bool
+
+	mu       sync.Mutex
+	chainIDs map[string]*big.Int // 链类型(小写) -> 链ID
 }
 
-// NewBlockScannerService 创建新的区块扫描服务
-func NewBlockScannerService(cfg *config.Config) (*BlockScannerService, error) {
-	clients := make(map[string]*ethclient.Client)
-	
-	// 初始化以太坊客户端
-	ethClient, err := ethclient.Dial(cfg.Ethereum.GetTestnetRPCURL())
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum testnet: %v", err)
-	}
-	clients["ETH"] = ethClient
-	
-	// 初始化BSC客户端（如果有配置）
-	if cfg.BSC != nil && cfg.BSC.RPCURL != "" {
-		bscClient, err := ethclient.Dial(cfg.BSC.RPCURL)
-		if err != nil {
-			log.Printf("Warning: failed to connect to BSC: %v", err)
-		} else {
-			clients["BSC"] = bscClient
-		}
+// NewBlockScannerService 创建新的区块扫描服务，ws 为空时链重组只记录日志；
+// addresses 为空时每轮扫描从数据库加载地址库
+func NewBlockScannerService(cfg *config.Config, chains *ChainRegistry, ws *WebSocketService, addresses *AddressIndex) (*BlockScannerService, error) {
+	if chains == nil {
+		return nil, fmt.Errorf("chain registry is required")
 	}
 
 	return &BlockScannerService{
-		config:   cfg,
-		clients:  clients,
-		stopChan: make(chan bool),
+		config:    cfg,
+		chains:    chains,
+		ws:        ws,
+		stopChan:  make(chan bool),
+		addresses: addresses,
+		chainIDs:  make(map[string]*big.Int),
 	}, nil
 }
 
@@ -120,18 +121,36 @@ func (bss *BlockScannerService) ScanBlocks(symbol string, startBlock uint64, end
 
 	log.Printf("Adjusted scan range: %d-%d", startBlock, endBlock)
 
-	for blockNumber := startBlock; blockNumber <= endBlock; blockNumber++ {
-		if err := bss.scanBlock(blockNumber, symbol, addresses, client); err != nil {
-			log.Printf("Failed to scan block %d for symbol %s: %v", blockNumber, symbol, err)
-			continue
+	currency, err := bss.chains.Currency(symbol)
+	if err != nil {
+		return err
+	}
+
+	// 代币通过 Transfer 事件识别充值
+	if isTokenCurrency(currency) {
+		if _, err := bss.scanTokenTransfers(context.Background(), currency, client, startBlock, endBlock, currentBlock, addresses, nil); err != nil {
+			return err
 		}
-		
-		// 每扫描10个区块输出一次进度
-		if blockNumber%10 == 0 {
-			log.Printf("Scan progress: %d/%d (%.1f%%)", 
-				blockNumber-startBlock+1, endBlock-startBlock+1, 
-				float64(blockNumber-startBlock+1)/float64(endBlock-startBlock+1)*100)
+		log.Printf("Block scan completed for symbol: %s, blocks: %d-%d", symbol, startBlock, endBlock)
+		return nil
+	}
+
+	owned, err := bss.evmAddresses(currency.ChainType)
+	if err != nil {
+		return err
+	}
+	targets := selectAddresses(owned, addresses)
+
+	// 每次最多处理 max_blocks_per_scan 个区块，限制并发获取时缓存的区块数
+	step := bss.blocksPerScan()
+	for from := startBlock; from <= endBlock; from += step {
+		to := min(from+step-1, endBlock)
+		if _, err := bss.scanNativeRange(context.Background(), currency, client, from, to, currentBlock, targets, owned, nil); err != nil {
+			return err
 		}
+		log.Printf("Scan progress: %d/%d (%.1f%%)",
+			to-startBlock+1, endBlock-startBlock+1,
+			float64(to-startBlock+1)/float64(endBlock-startBlock+1)*100)
 	}
 
 	log.Printf("Block scan completed for symbol: %s, blocks: %d-%d", symbol, startBlock, endBlock)
@@ -164,7 +183,39 @@ func (bss *BlockScannerService) scanLatestBlock() error {
 		return fmt.Errorf("failed to get enabled currencies: %v", err)
 	}
 
+	// 同步其它进程写入地址库的地址
+	if bss.addresses != nil {
+		if err := bss.addresses.Sync(); err != nil {
+			log.Printf("Failed to sync address index: %v", err)
+		}
+	}
+
+	// 先检查各链是否发生重组，回滚后已扫描位置会变化，需要重新加载币种配置
+	healthy := make(map[string]bool)
+	reorged := false
 	for _, currency := range currencies {
+		key := strings.ToLower(currency.ChainType)
+		if _, ok := healthy[key]; ok || !IsEVMChain(currency.ChainType) {
+			continue
+		}
+		rolledBack, err := bss.checkReorg(context.Background(), currency)
+		if err != nil {
+			log.Printf("Failed to check reorg on %s: %v", currency.ChainType, err)
+		}
+		healthy[key] = err == nil
+		reorged = reorged || rolledBack
+	}
+	if reorged {
+		if currencies, err = bss.getEnabledCurrencies(); err != nil {
+			return fmt.Errorf("failed to get enabled currencies: %v", err)
+		}
+	}
+
+	for _, currency := range currencies {
+		// 比特币、TRON、Solana 由各自的扫描服务处理
+		if !IsEVMChain(currency.ChainType) || !healthy[strings.ToLower(currency.ChainType)] {
+			continue
+		}
 		if err := bss.scanCurrencyLatestBlock(currency); err != nil {
 			log.Printf("Failed to scan latest block for currency %s: %v", currency.Symbol, err)
 			continue
@@ -197,254 +248,381 @@ func (bss *BlockScannerService) scanCurrencyLatestBlock(currency *models.Currenc
 		return nil
 	}
 
-	// 获取该币种的所有地址
-	addresses, err := bss.getAddressesForSymbol(currency.Symbol)
-	if err != nil {
-		return fmt.Errorf("failed to get addresses for symbol %s: %v", currency.Symbol, err)
-	}
+	// 每轮最多扫描 max_blocks_per_scan 个区块
+	endBlock := min(latestBlock, startBlock+bss.blocksPerScan()-1)
 
-	for blockNum := startBlock; blockNum <= latestBlock; blockNum++ {
-		if err := bss.scanBlock(blockNum, currency.Symbol, addresses, client); err != nil {
-			log.Printf("Failed to scan block %d for symbol %s: %v", blockNum, currency.Symbol, err)
-			continue
-		}
+	// 代币通过 Transfer 事件识别充值，每查询完一段记录区块哈希并保存扫描位置
+	if isTokenCurrency(currency) {
+		_, err := bss.scanTokenTransfers(context.Background(), currency, client, startBlock, endBlock, latestBlock, nil, func(scanned uint64) error {
+			if header, err := client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(scanned)); err == nil {
+				if err := recordScannedBlock(currency.ChainType, scanned, header.Hash().Hex(), header.ParentHash.Hex()); err != nil {
+					log.Printf("Failed to record scanned block %d: %v", scanned, err)
+				}
+			}
+			return bss.updateLastScannedBlock(currency.Symbol, scanned)
+		})
+		return err
 	}
 
-	// 更新最后扫描的区块号
-	if err := bss.updateLastScannedBlock(currency.Symbol, latestBlock); err != nil {
-		log.Printf("Failed to update last scanned block for symbol %s: %v", currency.Symbol, err)
+	// 获取该链地址库中的所有地址
+	addresses, err := bss.evmAddresses(currency.ChainType)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	// 每处理完一批按顺序保存扫描位置，中断后从未处理的区块继续
+	_, err = bss.scanNativeRange(context.Background(), currency, client, startBlock, endBlock, latestBlock, addresses, addresses, func(scanned uint64) error {
+		return bss.updateLastScannedBlock(currency.Symbol, scanned)
+	})
+	return err
+}
+
+// nativeBlock 工作协程获取并筛选后的区块
+type nativeBlock struct {
+	number     uint64
+	hash       string
+	parentHash string
+	deposits   []EVMNativeDeposit // 执行成功的原生币充值
 }
 
-// scanBlock 扫描单个区块
-func (bss *BlockScannerService) scanBlock(blockNumber uint64, symbol string, addresses []string, client *ethclient.Client) error {
-    // 获取区块信息
-    block, err := client.BlockByNumber(context.Background(), big.NewInt(int64(blockNumber)))
-    if err != nil {
-        return fmt.Errorf("failed to get block %d: %v", blockNumber, err)
-    }
-
-    // 检查区块是否包含我们关心的地址的交易
-    hasRelevantTx := false
-    
-    // 遍历区块中的交易
-    for _, tx := range block.Transactions() {
-        // 检查交易是否涉及指定的地址
-        relevant, err := bss.isRelevantTransaction(tx, addresses, client)
-        if err != nil {
-            log.Printf("Failed to check transaction relevance %s: %v", tx.Hash().Hex(), err)
-            continue
-        }
-        
-        if relevant {
-            hasRelevantTx = true
-            if err := bss.processTransaction(tx, blockNumber, symbol, client); err != nil {
-                log.Printf("Failed to process transaction %s in block %d: %v", tx.Hash().Hex(), blockNumber, err)
-            }
-        }
-    }
-    
-    if hasRelevantTx {
-        log.Printf("Block %d contains relevant transactions for symbol %s", blockNumber, symbol)
-    }
-
-    return nil
-}
-
-// processTransaction 处理交易
-func (bss *BlockScannerService) processTransaction(tx *types.Transaction, blockNumber uint64, symbol string, client *ethclient.Client) error {
-	// 获取交易收据
-	receipt, err := client.TransactionReceipt(context.Background(), tx.Hash())
+// nativeBatch 一个批量请求的结果
+type nativeBatch struct {
+	blocks []*nativeBlock
+	err    error
+}
+
+// scanNativeRange 扫描 [start, end] 中转入 addresses 的原生币充值，owned 为该链全部我方地址，用于识别内部转账；
+// head 为当前链高度，未达到币种确认数的充值记为确认中。
+// scanner.workers 个协程并发获取区块，每个 JSON-RPC 批量请求包含 scanner.batch_size 个区块；结果按区块顺序写入，
+// 每写完一批调用 commit 保存扫描位置（commit 可以为空）。返回已按顺序处理完的最高区块，某一批失败时停在这一批之前
+func (bss *BlockScannerService) scanNativeRange(ctx context.Context, currency *models.CurrencyChainConfig, client *ethclient.Client, start, end, head uint64, addresses, owned AddressLookup, commit func(uint64) error) (uint64, error) {
+	scanned := start - 1
+	if start > end {
+		return scanned, nil
+	}
+	chainID, err := bss.chainID(ctx, currency.ChainType, client)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction receipt: %v", err)
+		return scanned, err
 	}
+	signer := types.LatestSignerForChainID(chainID)
 
-	// 转换金额为float64
-	amountFloat, _ := new(big.Float).SetString(tx.Value().String())
-	amountFloat64, _ := amountFloat.Float64()
-
-	// 确定交易类型
-	txType := 1 // 默认充值
-	if bss.isOutgoingTransaction(tx, client) {
-		txType = 2 // 提币
+	size := uint64(max(bss.config.Scanner.BatchSize, 1))
+	var batches [][]uint64
+	for from := start; from <= end; from += size {
+		numbers := make([]uint64, 0, size)
+		for number := from; number <= min(from+size-1, end); number++ {
+			numbers = append(numbers, number)
+		}
+		batches = append(batches, numbers)
 	}
 
-	blockHeight := blockNumber
-	chainBill := &models.ChainBill{
-		TxID:           tx.Hash().Hex(),
-		Address:        bss.getToAddress(tx),
-		Amount:         amountFloat64,
-		Type:           txType,
-		Status:         bss.getTransactionStatus(receipt),
-		BlockHeight:    &blockHeight,
-		ChainType:      bss.getChainTypeForSymbol(symbol),
-		CurrencySymbol: symbol,
-		CreatedTime:    time.Now(),
-		UpdatedTime:    time.Now(),
+	// 处理失败提前返回时取消尚未完成的请求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int, len(batches))
+	results := make([]chan nativeBatch, len(batches))
+	for i := range batches {
+		results[i] = make(chan nativeBatch, 1)
+		jobs <- i
+	}
+	close(jobs)
+	for w := 0; w < min(max(bss.config.Scanner.Workers, 1), len(batches)); w++ {
+		go func() {
+			for i := range jobs {
+				blocks, err := fetchNativeBatch(ctx, client.Client(), currency.ChainType, currency.TraceMode, batches[i], signer, addresses)
+				results[i] <- nativeBatch{blocks: blocks, err: err}
+			}
+		}()
 	}
 
-	if err := bss.saveTransaction(chainBill); err != nil {
-		return fmt.Errorf("failed to save transaction: %v", err)
+	for i := range batches {
+		batch := <-results[i]
+		if batch.err != nil {
+			return scanned, batch.err
+		}
+		for _, block := range batch.blocks {
+			if err := bss.saveNativeBlock(currency, block, head, owned); err != nil {
+				return scanned, err
+			}
+			scanned = block.number
+		}
+		if commit != nil {
+			if err := commit(scanned); err != nil {
+				return scanned, err
+			}
+		}
 	}
+	return scanned, nil
+}
 
-	if bss.isIncomingTransaction(tx, client) {
-		if err := bss.updateBalance(tx, symbol); err != nil {
-			log.Printf("Failed to update balance: %v", err)
+// saveNativeBlock 写入区块中的充值记录并记录区块哈希
+func (bss *BlockScannerService) saveNativeBlock(currency *models.CurrencyChainConfig, block *nativeBlock, head uint64, owned AddressLookup) error {
+	for _, deposit := range block.deposits {
+		confirmations := confirmationsAt(head, block.number)
+		var tracePath *string
+		if deposit.TracePath != "" {
+			tracePath = &deposit.TracePath
+		}
+		if err := saveDepositEntry(&depositEntry{
+			UserID:        deposit.UserID,
+			ChainType:     currency.ChainType,
+			Symbol:        currency.Symbol,
+			From:          deposit.From.Hex(),
+			To:            deposit.To.Hex(),
+			TxID:          deposit.TxID(),
+			UniqueID:      deposit.UniqueID(),
+			TracePath:     tracePath,
+			Height:        block.number,
+			Amount:        unitsToFloat(deposit.Amount, currency.Decimals),
+			Confirmations: confirmations,
+			Pending:       confirmations < requiredConfirmations(currency),
+			IsInternal:    bss.isInternalSender(currency.ChainType, deposit.From, owned),
+		}); err != nil {
+			return fmt.Errorf("failed to save deposit %s: %v", deposit.TxID(), err)
 		}
+		log.Printf("Deposit %s in block %d: %s %s to %s", deposit.TxID(), block.number, deposit.Amount, currency.Symbol, deposit.To.Hex())
 	}
 
-	log.Printf("Processed transaction %s in block %d for symbol %s", tx.Hash().Hex(), blockNumber, symbol)
+	// 记录区块哈希用于检测链重组
+	if err := recordScannedBlock(currency.ChainType, block.number, block.hash, block.parentHash); err != nil {
+		log.Printf("Failed to record scanned block %d: %v", block.number, err)
+	}
 	return nil
 }
 
-// isRelevantTransaction 检查是否是相关交易
-func (bss *BlockScannerService) isRelevantTransaction(tx *types.Transaction, addresses []string, client *ethclient.Client) (bool, error) {
-    // 获取链ID
-    chainID, err := client.ChainID(context.Background())
-    if err != nil {
-        return false, fmt.Errorf("failed to get chain ID: %v", err)
-    }
-
-    // 获取交易的发送方地址
-    signer := types.NewEIP155Signer(chainID)
-    from, err := types.Sender(signer, tx)
-    if err != nil {
-        return false, fmt.Errorf("failed to get sender address: %v", err)
-    }
-
-    // 获取交易的接收方地址
-    to := tx.To()
-    
-    // 检查发送方地址
-    for _, addr := range addresses {
-        if strings.EqualFold(from.Hex(), addr) {
-            return true, nil
-        }
-    }
-    
-    // 检查接收方地址
-    if to != nil {
-        for _, addr := range addresses {
-            if strings.EqualFold(to.Hex(), addr) {
-                return true, nil
-            }
-        }
-    }
-    
-    return false, nil
-}
-
-// isOurAddress 检查是否是我们的地址
-func (bss *BlockScannerService) isOurAddress(address string, symbol string) bool {
-	if address == "" {
-		return false
-	}
-
-	var addressLib models.AddressLibrary
-	result := database.DB.Where("address = ? AND chain_type = ?", address, bss.getChainTypeForSymbol(symbol)).First(&addressLib)
-	return result.Error == nil
-}
-
-// getFromAddress 获取发送方地址
-func (bss *BlockScannerService) getFromAddress(tx *types.Transaction, client *ethclient.Client) string {
-	chainID, err := client.ChainID(context.Background())
+// fetchNativeBatch 批量获取区块并筛选转入 addresses 的原生币交易，再批量查询回执去掉执行失败的交易（执行失败不会转移金额）；
+// trace 不为空时再按该方式追踪区块，识别合约转入 addresses 的内部交易
+func fetchNativeBatch(ctx context.Context, client *rpc.Client, chainType, trace string, numbers []uint64, signer types.Signer, addresses AddressLookup) ([]*nativeBlock, error) {
+	blocks, err := blockchain.BatchBlocksByNumber(ctx, client, numbers)
 	if err != nil {
-		return ""
+		return nil, err
+	}
+
+	result := make([]*nativeBlock, len(blocks))
+	var withDeposits []uint64
+	var hashes []common.Hash
+	for i, block := range blocks {
+		result[i] = &nativeBlock{
+			number:     block.Block.NumberU64(),
+			hash:       block.Hash.Hex(),
+			parentHash: block.Block.ParentHash().Hex(),
+			deposits:   ExtractEVMDeposits(chainType, block.Block, signer, addresses),
+		}
+		if len(result[i].deposits) > 0 {
+			withDeposits = append(withDeposits, result[i].number)
+		}
+		for _, deposit := range result[i].deposits {
+			hashes = append(hashes, deposit.Hash)
+		}
 	}
-	from, err := types.Sender(types.NewEIP155Signer(chainID), tx)
+	if len(hashes) > 0 {
+		statuses, err := receiptStatuses(ctx, client, withDeposits, hashes)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range result {
+			successful := block.deposits[:0]
+			for _, deposit := range block.deposits {
+				if statuses[deposit.Hash] == types.ReceiptStatusSuccessful {
+					successful = append(successful, deposit)
+				}
+			}
+			block.deposits = successful
+		}
+	}
+
+	if trace == blockchain.EVMTraceNone {
+		return result, nil
+	}
+	// 追踪结果已排除执行失败的调用，不需要再查询回执
+	transfers, err := blockchain.BatchTraceBlocks(ctx, client, trace, blocks)
 	if err != nil {
-		return ""
+		return nil, err
 	}
-	return from.Hex()
+	for _, block := range result {
+		block.deposits = append(block.deposits, ExtractEVMInternalDeposits(chainType, block.number, transfers[block.number], addresses)...)
+	}
+	return result, nil
 }
 
-// getToAddress 获取接收方地址
-func (bss *BlockScannerService) getToAddress(tx *types.Transaction) string {
-	if tx.To() == nil {
-		return ""
+// receiptStatuses 查询交易的执行状态，优先按区块批量获取回执（eth_getBlockReceipts），节点不支持时按交易批量获取
+func receiptStatuses(ctx context.Context, client *rpc.Client, blocks []uint64, hashes []common.Hash) (map[common.Hash]uint64, error) {
+	var receipts []*types.Receipt
+	if byBlock, err := blockchain.BatchBlockReceipts(ctx, client, blocks); err == nil {
+		for _, list := range byBlock {
+			receipts = append(receipts, list...)
+		}
+	} else if receipts, err = blockchain.BatchTransactionReceipts(ctx, client, hashes); err != nil {
+		return nil, err
 	}
-	return tx.To().Hex()
-}
 
-// getTransactionStatus 获取交易状态
-func (bss *BlockScannerService) getTransactionStatus(receipt *types.Receipt) int {
-	if receipt.Status == 1 {
-		return 1
+	statuses := make(map[common.Hash]uint64, len(receipts))
+	for _, receipt := range receipts {
+		if receipt != nil {
+			statuses[receipt.TxHash] = receipt.Status
+		}
 	}
-	return 2
+	for _, hash := range hashes {
+		if _, ok := statuses[hash]; !ok {
+			return nil, fmt.Errorf("receipt %s not found", hash.Hex())
+		}
+	}
+	return statuses, nil
 }
 
-// isIncomingTransaction 检查是否是入账交易
-func (bss *BlockScannerService) isIncomingTransaction(tx *types.Transaction, client *ethclient.Client) bool {
-	to := bss.getToAddress(tx)
-	// 这里需要根据具体的币种来判断
-	return bss.isOurAddress(to, "ETH") // 暂时硬编码，后续需要改进
+// blocksPerScan 每轮扫描（代币为每次 eth_getLogs 查询）的最大区块数
+func (bss *BlockScannerService) blocksPerScan() uint64 {
+	if bss.config.Scanner.MaxBlocksPerScan <= 0 {
+		return 100
+	}
+	return uint64(bss.config.Scanner.MaxBlocksPerScan)
 }
 
-// isOutgoingTransaction 检查是否是出账交易
-func (bss *BlockScannerService) isOutgoingTransaction(tx *types.Transaction, client *ethclient.Client) bool {
-	from := bss.getFromAddress(tx, client)
-	// 这里需要根据具体的币种来判断
-	return bss.isOurAddress(from, "ETH") // 暂时硬编码，后续需要改进
-}
+// chainID 获取链ID并缓存：优先使用币种配置的链ID（注册表已校验节点），未配置时向节点查询一次
+func (bss *BlockScannerService) chainID(ctx context.Context, chainType string, client *ethclient.Client) (*big.Int, error) {
+	key := strings.ToLower(chainType)
+	bss.mu.Lock()
+	defer bss.mu.Unlock()
+	if id, ok := bss.chainIDs[key]; ok {
+		return id, nil
+	}
 
-// updateBalance 更新余额
-func (bss *BlockScannerService) updateBalance(tx *types.Transaction, symbol string) error {
-	to := bss.getToAddress(tx)
-	if to == "" {
-		return nil
+	var id *big.Int
+	if adapter, err := bss.chains.Adapter(chainType); err == nil {
+		if evm, ok := adapter.(*EVMChainAdapter); ok && evm.ChainID() != 0 {
+			id = big.NewInt(evm.ChainID())
+		}
 	}
+	if id == nil {
+		var err error
+		if id, err = client.ChainID(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get chain ID: %v", err)
+		}
+	}
+	bss.chainIDs[key] = id
+	return id, nil
+}
 
-	var balance models.Balance
-	result := database.DB.Where("address = ? AND currency_symbol = ?", to, symbol).First(&balance)
+// checkReorg 比对已记录的区块哈希与节点当前的链，发现分叉时回滚分叉点之后的入账并通知WebSocket客户端
+func (bss *BlockScannerService) checkReorg(ctx context.Context, currency *models.CurrencyChainConfig) (bool, error) {
+	client, err := bss.getClientForSymbol(currency.Symbol)
+	if err != nil {
+		return false, err
+	}
 
-	if result.Error != nil {
-		// 转换金额为float64
-		amountFloat, _ := new(big.Float).SetString(tx.Value().String())
-		amountFloat64, _ := amountFloat.Float64()
+	var stored []models.ScannedBlock
+	if err := database.DB.Where("chain_type = ?", currency.ChainType).
+		Order("number DESC").Limit(bss.config.Scanner.ReorgDepth).Find(&stored).Error; err != nil {
+		return false, fmt.Errorf("failed to load scanned blocks: %v", err)
+	}
+	if len(stored) == 0 {
+		return false, nil
+	}
 
-		balance = models.Balance{
-			Address:        to,
-			CurrencySymbol: symbol,
-			ChainType:      bss.getChainTypeForSymbol(symbol),
-			Balance:        amountFloat64,
-			CreatedTime:    time.Now(),
-			UpdatedTime:    time.Now(),
+	fork, reorged, err := findForkPoint(ctx, client, stored)
+	if err != nil {
+		return false, err
+	}
+	if !reorged {
+		if err := pruneScannedBlocks(currency.ChainType, stored[0].Number, bss.config.Scanner.ReorgDepth); err != nil {
+			log.Printf("Failed to prune scanned blocks of %s: %v", currency.ChainType, err)
 		}
-	} else {
-		// 转换当前余额为big.Int
-		currentBalance := new(big.Int)
-		currentBalance.SetString(fmt.Sprintf("%.0f", balance.Balance), 10)
-		newBalance := new(big.Int).Add(currentBalance, tx.Value())
+		return false, nil
+	}
+	if fork < stored[len(stored)-1].Number {
+		log.Printf("Reorg on %s is deeper than the %d recorded blocks", currency.ChainType, len(stored))
+	}
+
+	event, err := rollbackChain(currency.ChainType, fork)
+	if err != nil {
+		return false, err
+	}
+	log.Printf("Chain reorg detected on %s: rolled back blocks %d-%d, %d deposits reverted, %d outgoing transactions back to pending, %d withdrawals back to sent",
+		event.ChainType, event.ForkBlock+1, event.OldHead, event.RevertedDeposits, event.RevertedBills, event.RevertedWithdraws)
+	if bss.ws != nil {
+		bss.ws.SendReorgNotification(event)
+	}
+	return true, nil
+}
 
-		// 转换新余额为float64
-		newBalanceFloat, _ := new(big.Float).SetString(newBalance.String())
-		newBalanceFloat64, _ := newBalanceFloat.Float64()
+// scanTokenTransfers 通过 eth_getLogs 扫描代币合约转入我方地址的 Transfer 事件并入账，每次查询最多 max_blocks_per_scan 个区块
+// head 为当前链高度，用于计算确认数；only 不为空时只处理其中的地址；每扫描完一段调用 commit 保存扫描位置（commit 可以为空）；
+// 返回已完整扫描到的区块号，查询失败时停在失败的区块范围之前
+func (bss *BlockScannerService) scanTokenTransfers(ctx context.Context, currency *models.CurrencyChainConfig, client *ethclient.Client, start, end, head uint64, only []string, commit func(uint64) error) (uint64, error) {
+	if !common.IsHexAddress(*currency.TokenAddress) {
+		return start - 1, fmt.Errorf("invalid token address %q for symbol %s", *currency.TokenAddress, currency.Symbol)
+	}
+	contract := common.HexToAddress(*currency.TokenAddress)
+	tokens := map[common.Address]*models.CurrencyChainConfig{contract: currency}
 
-		balance.Balance = newBalanceFloat64
-		balance.UpdatedTime = time.Now()
+	owned, err := bss.evmAddresses(currency.ChainType)
+	if err != nil {
+		return start - 1, err
 	}
+	addresses := selectAddresses(owned, only)
 
-	if err := database.DB.Save(&balance).Error; err != nil {
-		return fmt.Errorf("failed to save balance: %v", err)
+	// 按接收地址分批过滤；地址过多时只按合约查询，由地址索引过滤
+	batches := [][]common.Address{nil}
+	if recipients := bss.tokenRecipients(currency.ChainType, addresses); recipients != nil {
+		if len(recipients) == 0 {
+			if commit != nil {
+				return end, commit(end)
+			}
+			return end, nil
+		}
+		batches = nil
+		for i := 0; i < len(recipients); i += erc20RecipientBatch {
+			batches = append(batches, recipients[i:min(i+erc20RecipientBatch, len(recipients))])
+		}
 	}
 
-	return nil
-}
+	step := bss.blocksPerScan()
+	scanned := start - 1
+	for from := start; from <= end; from += step {
+		to := from + step - 1
+		if to > end {
+			to = end
+		}
 
-// saveTransaction 保存交易记录
-func (bss *BlockScannerService) saveTransaction(chainBill *models.ChainBill) error {
-	var existing models.ChainBill
-	result := database.DB.Where("tx_id = ?", chainBill.TxID).First(&existing)
+		var logs []types.Log
+		for _, batch := range batches {
+			found, err := client.FilterLogs(ctx, blockchain.ERC20TransferQuery([]common.Address{contract}, from, to, batch))
+			if err != nil {
+				return scanned, fmt.Errorf("failed to get %s Transfer logs in blocks %d-%d: %v", currency.Symbol, from, to, err)
+			}
+			logs = append(logs, found...)
+		}
 
-	if result.Error != nil {
-		return database.DB.Create(chainBill).Error
-	} else {
-		chainBill.ID = existing.ID
-		return database.DB.Save(chainBill).Error
+		for _, deposit := range ExtractERC20Deposits(currency.ChainType, logs, tokens, addresses) {
+			confirmations := confirmationsAt(head, deposit.BlockNumber)
+			if err := saveDepositEntry(&depositEntry{
+				UserID:        deposit.UserID,
+				ChainType:     currency.ChainType,
+				Symbol:        deposit.Symbol,
+				From:          deposit.From.Hex(),
+				To:            deposit.To.Hex(),
+				TxID:          deposit.TxID(),
+				UniqueID:      deposit.UniqueID(),
+				LogIndex:      &deposit.LogIndex,
+				Height:        deposit.BlockNumber,
+				Amount:        unitsToFloat(deposit.Amount, deposit.Decimals),
+				Confirmations: confirmations,
+				Pending:       confirmations < requiredConfirmations(currency),
+				IsInternal:    bss.isInternalSender(currency.ChainType, deposit.From, owned),
+			}); err != nil {
+				return scanned, fmt.Errorf("failed to save deposit %s: %v", deposit.TxID(), err)
+			}
+			log.Printf("Token deposit %s: %s %s to %s", deposit.TxID(), deposit.Amount, deposit.Symbol, deposit.To.Hex())
+		}
+		scanned = to
+		if commit != nil {
+			if err := commit(scanned); err != nil {
+				return scanned, err
+			}
+		}
 	}
+	return scanned, nil
 }
 
 // getLastScannedBlock 获取最后扫描的区块号
@@ -474,42 +652,19 @@ func (bss *BlockScannerService) updateLastScannedBlock(symbol string, blockNumbe
 	return nil
 }
 
-// getClientForSymbol 根据币种获取对应的客户端
+// getClientForSymbol 根据币种配置的链类型获取对应的客户端
 func (bss *BlockScannerService) getClientForSymbol(symbol string) (*ethclient.Client, error) {
-	// 根据币种确定使用哪个客户端
-	switch symbol {
-	case "ETH", "USDT", "USDC":
-		if client, exists := bss.clients["ETH"]; exists {
-			return client, nil
-		}
-	case "BNB", "BUSD":
-		if client, exists := bss.clients["BSC"]; exists {
-			return client, nil
-		}
-		// 如果没有BSC客户端，使用ETH客户端
-		if client, exists := bss.clients["ETH"]; exists {
-			return client, nil
-		}
-	default:
-		// 默认使用ETH客户端
-		if client, exists := bss.clients["ETH"]; exists {
-			return client, nil
-		}
-	}
-	
-	return nil, fmt.Errorf("no client available for symbol %s", symbol)
+	return bss.chains.EVMClientForSymbol(symbol)
 }
 
-// getChainTypeForSymbol 根据币种获取链类型
+// getChainTypeForSymbol 根据币种配置获取链类型，未配置的币种返回空字符串
 func (bss *BlockScannerService) getChainTypeForSymbol(symbol string) string {
-	switch symbol {
-	case "ETH", "USDT", "USDC":
-		return "Ethereum"
-	case "BNB", "BUSD":
-		return "BSC"
-	default:
-		return "Ethereum"
+	chainType, err := bss.chains.ChainTypeForSymbol(symbol)
+	if err != nil {
+		log.Printf("Failed to get chain type for symbol %s: %v", symbol, err)
+		return ""
 	}
+	return chainType
 }
 
 // getEnabledCurrencies 获取所有启用的币种配置
@@ -522,30 +677,254 @@ func (bss *BlockScannerService) getEnabledCurrencies() ([]*models.CurrencyChainC
 	return currencies, nil
 }
 
-// getAddressesForSymbol 获取指定币种的所有地址
-func (bss *BlockScannerService) getAddressesForSymbol(symbol string) ([]string, error) {
-	var addresses []models.AddressLibrary
-	chainType := bss.getChainTypeForSymbol(symbol)
-	
-	result := database.DB.Where("chain_type = ?", chainType).Find(&addresses)
-	if result.Error != nil {
-		return nil, result.Error
+// Close 关闭服务，节点连接由链适配器注册表统一关闭
+func (bss *BlockScannerService) Close() {
+	bss.StopScanning()
+}
+
+// erc20RecipientBatch 每次 eth_getLogs 查询按接收地址过滤的最大地址数，节点对topics数量有限制
+const erc20RecipientBatch = 200
+
+// erc20MaxFilteredRecipients 按接收地址过滤的最大地址数（5次查询），超过后不再按地址过滤
+const erc20MaxFilteredRecipients = 5 * erc20RecipientBatch
+
+// EVMTokenDeposit 从 Transfer 事件中识别出的一笔ERC-20代币充值
+type EVMTokenDeposit struct {
+	ChainType   string
+	Hash        string
+	BlockNumber uint64
+	Symbol      string
+	Contract    common.Address
+	LogIndex    uint // 事件在区块中的日志序号
+	From        common.Address
+	To          common.Address
+	UserID      uint64
+	Amount      *big.Int
+	Decimals    int
+}
+
+// TxID 写入账单的交易ID，由交易哈希和日志序号组成，与扫描范围和同一交易内的其它转账无关
+func (d *EVMTokenDeposit) TxID() string {
+	return fmt.Sprintf("%s:%d", d.Hash, d.LogIndex)
+}
+
+// UniqueID 充值唯一标识，由链类型、交易哈希和日志序号确定
+func (d *EVMTokenDeposit) UniqueID() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", strings.ToLower(d.ChainType), d.Hash, d.LogIndex)))
+	return hex.EncodeToString(sum[:])
+}
+
+// ExtractERC20Deposits 从日志中识别已配置合约转入我方地址的代币，按区块和日志序号排序
+// 已被重组移除的日志、金额为0的转账和非ERC-20的同名事件被忽略
+func ExtractERC20Deposits(chainType string, logs []types.Log, tokens map[common.Address]*models.CurrencyChainConfig, addresses AddressLookup) []EVMTokenDeposit {
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	var deposits []EVMTokenDeposit
+	seen := make(map[string]bool)
+	for _, entry := range logs {
+		if entry.Removed {
+			continue
+		}
+		transfer, err := blockchain.ParseERC20Transfer(entry)
+		if err != nil {
+			continue
+		}
+		currency, ok := tokens[transfer.Contract]
+		if !ok {
+			continue
+		}
+		userID, ok := addresses.Lookup(transfer.To)
+		if !ok || transfer.Amount.Sign() <= 0 {
+			continue
+		}
+		// 分批查询可能返回重复的日志
+		key := fmt.Sprintf("%s:%d", transfer.TxHash.Hex(), transfer.LogIndex)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		deposits = append(deposits, EVMTokenDeposit{
+			ChainType:   chainType,
+			Hash:        transfer.TxHash.Hex(),
+			BlockNumber: transfer.BlockNumber,
+			Symbol:      currency.Symbol,
+			Contract:    transfer.Contract,
+			LogIndex:    transfer.LogIndex,
+			From:        transfer.From,
+			To:          transfer.To,
+			UserID:      userID,
+			Amount:      transfer.Amount,
+			Decimals:    currency.Decimals,
+		})
+	}
+	return deposits
+}
+
+// EVMNativeDeposit 区块交易中转入我方地址的一笔原生币充值
+type EVMNativeDeposit struct {
+	ChainType   string
+	Hash        common.Hash
+	BlockNumber uint64
+	TracePath   string // 内部交易在调用树中的位置，交易本身的转账为空
+	From        common.Address
+	To          common.Address
+	UserID      uint64
+	Amount      *big.Int
+}
+
+// TxID 写入账单的交易ID，内部交易追加 ":trace:调用位置"
+func (d *EVMNativeDeposit) TxID() string {
+	if d.TracePath == "" {
+		return d.Hash.Hex()
 	}
-	
-	var addressStrings []string
-	for _, addr := range addresses {
-		addressStrings = append(addressStrings, addr.Address)
+	return fmt.Sprintf("%s:trace:%s", d.Hash.Hex(), d.TracePath)
+}
+
+// UniqueID 充值唯一标识，由链类型、交易哈希和内部交易的调用位置确定
+func (d *EVMNativeDeposit) UniqueID() string {
+	key := fmt.Sprintf("%s:%s", strings.ToLower(d.ChainType), d.Hash.Hex())
+	if d.TracePath != "" {
+		key += ":trace:" + d.TracePath
 	}
-	
-	return addressStrings, nil
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
 }
 
-// Close 关闭服务
-func (bss *BlockScannerService) Close() {
-	bss.StopScanning()
-	for _, client := range bss.clients {
-		if client != nil {
-			client.Close()
+// ExtractEVMDeposits 从区块交易中识别转入我方地址的原生币，金额为0、创建合约和无法恢复发送方的交易被忽略
+func ExtractEVMDeposits(chainType string, block *types.Block, signer types.Signer, addresses AddressLookup) []EVMNativeDeposit {
+	var deposits []EVMNativeDeposit
+	for _, tx := range block.Transactions() {
+		if tx.To() == nil || tx.Value().Sign() <= 0 {
+			continue
+		}
+		userID, ok := addresses.Lookup(*tx.To())
+		if !ok {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Printf("Failed to get sender of %s: %v", tx.Hash().Hex(), err)
+			continue
+		}
+		deposits = append(deposits, EVMNativeDeposit{
+			ChainType:   chainType,
+			Hash:        tx.Hash(),
+			BlockNumber: block.NumberU64(),
+			From:        from,
+			To:          *tx.To(),
+			UserID:      userID,
+			Amount:      tx.Value(),
+		})
+	}
+	return deposits
+}
+
+// ExtractEVMInternalDeposits 从区块的内部交易中识别转入我方地址的原生币
+func ExtractEVMInternalDeposits(chainType string, blockNumber uint64, transfers []blockchain.EVMInternalTransfer, addresses AddressLookup) []EVMNativeDeposit {
+	var deposits []EVMNativeDeposit
+	for _, transfer := range transfers {
+		userID, ok := addresses.Lookup(transfer.To)
+		if !ok || transfer.Value.Sign() <= 0 {
+			continue
+		}
+		deposits = append(deposits, EVMNativeDeposit{
+			ChainType:   chainType,
+			Hash:        transfer.TxHash,
+			BlockNumber: blockNumber,
+			TracePath:   transfer.Path,
+			From:        transfer.From,
+			To:          transfer.To,
+			UserID:      userID,
+			Amount:      transfer.Value,
+		})
+	}
+	return deposits
+}
+
+// isInternalSender 发送方是否为我方地址：地址库中的地址（用户地址、热钱包）或冷钱包
+func (bss *BlockScannerService) isInternalSender(chainType string, from common.Address, owned AddressLookup) bool {
+	if _, ok := owned.Lookup(from); ok {
+		return true
+	}
+	cold := bss.config.Wallet.ColdWallet.AddressFor(chainType)
+	return common.IsHexAddress(cold) && common.HexToAddress(cold) == from
+}
+
+// selectAddresses 只保留 only 中在地址库里的地址，only 为空时返回全部
+func selectAddresses(addresses AddressLookup, only []string) AddressLookup {
+	if len(only) == 0 {
+		return addresses
+	}
+	selected := make(EVMAddressSet, len(only))
+	for _, address := range only {
+		account := common.HexToAddress(address)
+		if userID, ok := addresses.Lookup(account); ok {
+			selected[account] = userID
+		}
+	}
+	return selected
+}
+
+// evmAddresses 链上的我方地址，优先使用内存地址索引
+func (bss *BlockScannerService) evmAddresses(chainType string) (AddressLookup, error) {
+	if bss.addresses != nil {
+		return bss.addresses.EVM(chainType), nil
+	}
+	addresses, err := loadEVMAddresses(chainType)
+	if err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// tokenRecipients eth_getLogs 按接收地址过滤时使用的地址列表。地址超过 erc20MaxFilteredRecipients 个时返回 nil，
+// 改为查询合约的全部 Transfer 事件再按地址索引过滤，查询次数不随地址数量增加
+func (bss *BlockScannerService) tokenRecipients(chainType string, addresses AddressLookup) []common.Address {
+	set, ok := addresses.(EVMAddressSet)
+	if !ok {
+		if bss.addresses.Len(chainType) > erc20MaxFilteredRecipients {
+			return nil
+		}
+		set = bss.addresses.EVMAddresses(chainType)
+	}
+	if len(set) > erc20MaxFilteredRecipients {
+		return nil
+	}
+	recipients := make([]common.Address, 0, len(set))
+	for address := range set {
+		recipients = append(recipients, address)
+	}
+	return recipients
+}
+
+// isTokenCurrency 币种是否为合约代币
+func isTokenCurrency(currency *models.CurrencyChainConfig) bool {
+	return currency.TokenAddress != nil && *currency.TokenAddress != ""
+}
+
+// loadEVMAddresses 加载EVM链的地址库，地址 -> 用户ID
+func loadEVMAddresses(chainType string) (EVMAddressSet, error) {
+	var list []models.AddressLibrary
+	if err := database.DB.Where("chain_type = ?", chainType).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load %s addresses: %v", chainType, err)
+	}
+
+	addresses := make(EVMAddressSet, len(list))
+	for _, addr := range list {
+		if !common.IsHexAddress(addr.Address) {
+			continue
+		}
+		var userID uint64
+		if addr.UserID != nil {
+			userID = *addr.UserID
 		}
+		addresses[common.HexToAddress(addr.Address)] = userID
 	}
+	return addresses, nil
 }
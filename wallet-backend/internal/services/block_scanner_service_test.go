@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func transferLog(contract, from, to common.Address, amount int64, tx common.Hash, block uint64, index uint) types.Log {
+	return types.Log{
+		Address:     contract,
+		Topics:      []common.Hash{blockchain.ERC20TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		BlockNumber: block,
+		TxHash:      tx,
+		Index:       index,
+	}
+}
+
+func TestExtractERC20Deposits(t *testing.T) {
+	usdt := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	other := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	alice := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	bob := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	stranger := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	batch := common.HexToHash("0xaa")
+	single := common.HexToHash("0xbb")
+
+	tokens := map[common.Address]*models.CurrencyChainConfig{usdt: {Symbol: "USDT", Decimals: 6}}
+	addresses := EVMAddressSet{alice: 1, bob: 2}
+
+	removed := transferLog(usdt, sender, alice, 5, single, 101, 0)
+	removed.Removed = true
+	logs := []types.Log{
+		transferLog(usdt, sender, bob, 3000000, batch, 100, 5),
+		transferLog(usdt, sender, alice, 1500000, batch, 100, 2),
+		transferLog(usdt, sender, stranger, 1, batch, 100, 3),    // 不是我方地址
+		transferLog(other, sender, alice, 1, batch, 100, 4),      // 未配置的合约
+		transferLog(usdt, sender, alice, 1500000, batch, 100, 2), // 分批查询返回的重复日志
+		removed,
+		transferLog(usdt, sender, alice, 7000000, single, 102, 0),
+	}
+
+	deposits := ExtractERC20Deposits("Ethereum", logs, tokens, addresses)
+	if len(deposits) != 3 {
+		t.Fatalf("expected 3 deposits, got %d", len(deposits))
+	}
+
+	// 同一交易的两笔转账都入账，交易ID都带日志序号
+	first, second := deposits[0], deposits[1]
+	if first.TxID() != batch.Hex()+":2" || first.To != alice || first.UserID != 1 || first.LogIndex != 2 {
+		t.Errorf("unexpected first deposit %+v", first)
+	}
+	if second.TxID() != batch.Hex()+":5" || second.To != bob || second.UserID != 2 || unitsToFloat(second.Amount, second.Decimals) != 3 {
+		t.Errorf("unexpected second deposit %+v", second)
+	}
+	if first.UniqueID() == second.UniqueID() {
+		t.Error("transfers in one transaction must have different unique IDs")
+	}
+	if deposits[2].TxID() != single.Hex()+":0" || deposits[2].Symbol != "USDT" {
+		t.Errorf("unexpected third deposit %+v", deposits[2])
+	}
+}
+
+func TestExtractEVMDeposits(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	alice := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	stranger := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	var txs []*types.Transaction
+	for nonce, to := range []*common.Address{&alice, &stranger, nil, &alice} {
+		value := big.NewInt(1e18)
+		if nonce == 3 {
+			value = new(big.Int) // 0 金额的合约调用
+		}
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: uint64(nonce), To: to, Value: value, Gas: 21000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(100)}).WithBody(types.Body{Transactions: txs})
+
+	deposits := ExtractEVMDeposits("Ethereum", block, signer, EVMAddressSet{alice: 7})
+	if len(deposits) != 1 {
+		t.Fatalf("expected 1 deposit, got %d", len(deposits))
+	}
+	deposit := deposits[0]
+	if deposit.Hash != txs[0].Hash() || deposit.From != sender || deposit.UserID != 7 || deposit.BlockNumber != 100 || unitsToFloat(deposit.Amount, 18) != 1 {
+		t.Errorf("unexpected deposit %+v", deposit)
+	}
+	if deposit.UniqueID() != (&EVMNativeDeposit{ChainType: "ethereum", Hash: txs[0].Hash()}).UniqueID() {
+		t.Error("unique ID must not depend on chain type case")
+	}
+}
+
+func TestExtractEVMInternalDeposits(t *testing.T) {
+	contract := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	alice := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	stranger := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	hash := common.HexToHash("0x01")
+	transfers := []blockchain.EVMInternalTransfer{
+		{TxHash: hash, Path: "0", From: contract, To: stranger, Value: big.NewInt(1e18)},
+		{TxHash: hash, Path: "1", From: contract, To: alice, Value: big.NewInt(2e18)},
+		{TxHash: hash, Path: "1.0", From: contract, To: alice, Value: big.NewInt(3e18)},
+	}
+
+	deposits := ExtractEVMInternalDeposits("Ethereum", 100, transfers, EVMAddressSet{alice: 7})
+	if len(deposits) != 2 {
+		t.Fatalf("expected 2 deposits, got %d", len(deposits))
+	}
+	if deposits[0].TxID() != hash.Hex()+":trace:1" || deposits[1].TxID() != hash.Hex()+":trace:1.0" {
+		t.Errorf("unexpected transaction IDs %s, %s", deposits[0].TxID(), deposits[1].TxID())
+	}
+	if deposits[0].From != contract || deposits[0].UserID != 7 || unitsToFloat(deposits[0].Amount, 18) != 2 {
+		t.Errorf("unexpected deposit %+v", deposits[0])
+	}
+
+	// 同一交易的内部交易之间、内部交易与交易本身的转账之间唯一标识不同
+	direct := &EVMNativeDeposit{ChainType: "Ethereum", Hash: hash}
+	if deposits[0].UniqueID() == deposits[1].UniqueID() || deposits[0].UniqueID() == direct.UniqueID() {
+		t.Error("internal deposits must have distinct unique IDs")
+	}
+}
+
+func TestReceiptStatusesFallback(t *testing.T) {
+	ok, reverted := common.HexToHash("0x01"), common.HexToHash("0x02")
+	receipts := map[string]*types.Receipt{
+		ok.Hex():       {TxHash: ok, Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}},
+		reverted.Hex(): {TxHash: reverted, Status: types.ReceiptStatusFailed, Logs: []*types.Log{}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []string        `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&batch)
+		var responses []map[string]interface{}
+		for _, req := range batch {
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+			if req.Method == "eth_getBlockReceipts" {
+				// 节点不支持按区块获取回执
+				response["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+			} else {
+				response["result"] = receipts[common.HexToHash(req.Params[0]).Hex()]
+			}
+			responses = append(responses, response)
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	client, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	statuses, err := receiptStatuses(context.Background(), client, []uint64{100}, []common.Hash{ok, reverted})
+	if err != nil {
+		t.Fatalf("receiptStatuses failed: %v", err)
+	}
+	if statuses[ok] != types.ReceiptStatusSuccessful || statuses[reverted] != types.ReceiptStatusFailed {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+	if _, err := receiptStatuses(context.Background(), client, []uint64{100}, []common.Hash{common.HexToHash("0x03")}); err == nil {
+		t.Error("expected error for missing receipt")
+	}
+}
@@ -0,0 +1,474 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ChainAdapter 链适配器，封装一条链的节点池和链参数
+type ChainAdapter interface {
+	ChainType() string
+	ChainID() int64
+	RPCURL() string
+	LatestBlock(ctx context.Context) (uint64, error)
+	Endpoints() []blockchain.RPCEndpointStatus
+	Close()
+}
+
+// EVMChainAdapter 以太坊系链适配器（Ethereum、BSC、Polygon、Arbitrum、Base 等）
+type EVMChainAdapter struct {
+	chainType string
+	chainID   int64
+	rpcURL    string
+	pool      *blockchain.RPCPool
+	client    *ethclient.Client
+}
+
+// ChainType 链类型
+func (a *EVMChainAdapter) ChainType() string { return a.chainType }
+
+// ChainID 链ID
+func (a *EVMChainAdapter) ChainID() int64 { return a.chainID }
+
+// RPCURL 配置的节点地址
+func (a *EVMChainAdapter) RPCURL() string { return a.rpcURL }
+
+// Client 以太坊客户端，请求经节点池转发
+func (a *EVMChainAdapter) Client() *ethclient.Client { return a.client }
+
+// LatestBlock 最新区块号
+func (a *EVMChainAdapter) LatestBlock(ctx context.Context) (uint64, error) {
+	return a.client.BlockNumber(ctx)
+}
+
+// Endpoints 节点池中各节点的状态
+func (a *EVMChainAdapter) Endpoints() []blockchain.RPCEndpointStatus { return a.pool.Status() }
+
+// Close 停止健康检查并关闭连接
+func (a *EVMChainAdapter) Close() {
+	a.pool.Stop()
+	a.client.Close()
+}
+
+// TronChainAdapter TRON链适配器
+type TronChainAdapter struct {
+	chainID int64
+	rpcURL  string
+	pool    *blockchain.RPCPool
+	client  *blockchain.TronClient
+}
+
+// ChainType 链类型
+func (a *TronChainAdapter) ChainType() string { return "TRON" }
+
+// ChainID 链ID
+func (a *TronChainAdapter) ChainID() int64 { return a.chainID }
+
+// RPCURL 配置的节点地址
+func (a *TronChainAdapter) RPCURL() string { return a.rpcURL }
+
+// Client TRON客户端，请求经节点池转发
+func (a *TronChainAdapter) Client() *blockchain.TronClient { return a.client }
+
+// LatestBlock 最新区块号
+func (a *TronChainAdapter) LatestBlock(ctx context.Context) (uint64, error) {
+	block, err := a.client.GetNowBlock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return block.BlockHeader.RawData.Number, nil
+}
+
+// Endpoints 节点池中各节点的状态
+func (a *TronChainAdapter) Endpoints() []blockchain.RPCEndpointStatus { return a.pool.Status() }
+
+// Close 停止健康检查
+func (a *TronChainAdapter) Close() { a.pool.Stop() }
+
+// dialTimeout 建立连接时首次健康检查的超时时间
+const dialTimeout = 10 * time.Second
+
+// currencyMissTTL 未配置的币种在这段时间内不再触发重新加载
+const currencyMissTTL = 30 * time.Second
+
+// retiredAdapterGrace 被替换的适配器延迟关闭的时间。扫描、归集等每次操作都重新从注册表获取客户端，
+// 替换后不会再有新的引用，已取得旧客户端的操作在此期间完成
+const retiredAdapterGrace = 5 * time.Minute
+
+// NewChainAdapter 按链类型创建适配器
+// rpcURL 可用逗号分隔多个节点，并追加配置文件 rpc_pool.endpoints 中该链的备用节点。
+// 比特币和Solana由各自的扫描服务处理，不在注册表中；其余链类型一律按EVM链处理。
+// 节点不可达不会导致创建失败，由节点池在恢复后自动启用；配置了链ID而所有节点的链ID都不符时返回错误，
+// 防止币种被扫描到错误的链上
+func NewChainAdapter(ctx context.Context, cfg *config.Config, chainType, rpcURL string, chainID int64) (ChainAdapter, error) {
+	urls := append(splitRPCURLs(rpcURL), cfg.RPCPool.EndpointsFor(chainType)...)
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("rpc_url is not configured for chain %s", chainType)
+	}
+
+	switch strings.ToLower(chainType) {
+	case "bitcoin", "solana":
+		return nil, fmt.Errorf("chain %s is not served by the chain registry", chainType)
+	case "tron":
+		pool, err := blockchain.NewRPCPool(chainType, urls, rpcPoolOptions(cfg, 0, blockchain.TronHeadProbe(cfg.Tron.APIKey), nil))
+		if err != nil {
+			return nil, err
+		}
+		pool.CheckHealth(ctx)
+		pool.Start()
+		return &TronChainAdapter{
+			chainID: chainID,
+			rpcURL:  rpcURL,
+			pool:    pool,
+			client:  blockchain.NewTronClientWithHTTPClient(pool.PrimaryURL(), cfg.Tron.APIKey, pool.HTTPClient()),
+		}, nil
+	}
+
+	pool, err := blockchain.NewRPCPool(chainType, urls, rpcPoolOptions(cfg, chainID, blockchain.EVMHeadProbe, blockchain.EVMChainIDProbe))
+	if err != nil {
+		return nil, err
+	}
+	pool.CheckHealth(ctx)
+	if chainID != 0 && pool.AllWrongChain() {
+		return nil, fmt.Errorf("no %s endpoint reports chain ID %d", chainType, chainID)
+	}
+
+	rpcClient, err := rpc.DialOptions(ctx, pool.PrimaryURL(), rpc.WithHTTPClient(pool.HTTPClient()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", chainType, err)
+	}
+	pool.Start()
+
+	return &EVMChainAdapter{
+		chainType: chainType,
+		chainID:   chainID,
+		rpcURL:    rpcURL,
+		pool:      pool,
+		client:    ethclient.NewClient(rpcClient),
+	}, nil
+}
+
+// rpcPoolOptions 根据配置生成节点池参数
+func rpcPoolOptions(cfg *config.Config, chainID int64, head blockchain.HeadProbe, chain blockchain.ChainIDProbe) blockchain.RPCPoolOptions {
+	return blockchain.RPCPoolOptions{
+		Timeout:             time.Duration(cfg.RPCPool.Timeout) * time.Second,
+		FailureThreshold:    cfg.RPCPool.FailureThreshold,
+		Cooldown:            time.Duration(cfg.RPCPool.Cooldown) * time.Second,
+		HealthCheckInterval: time.Duration(cfg.RPCPool.HealthCheckInterval) * time.Second,
+		MaxLagBlocks:        cfg.RPCPool.MaxLagBlocks,
+		ChainID:             chainID,
+		HeadProbe:           head,
+		ChainIDProbe:        chain,
+	}
+}
+
+// splitRPCURLs 拆分逗号分隔的节点地址
+func splitRPCURLs(rpcURL string) []string {
+	var urls []string
+	for _, u := range strings.Split(rpcURL, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// ChainRegistry 链适配器注册表
+// 根据 currency_chain_config 的 chain_type、rpc_url、chain_id 为每条链建立一个适配器，
+// 币种通过所属链类型找到适配器；币种配置变更后调用 Reload 重新加载
+type ChainRegistry struct {
+	config *config.Config
+
+	reloading sync.Mutex // 同一时间只有一个 Reload 建立连接，避免并发创建的适配器被覆盖后泄漏
+
+	mu         sync.RWMutex
+	currencies map[string]*models.CurrencyChainConfig // 币种符号 -> 配置
+	adapters   map[string]ChainAdapter                // 链类型(小写) -> 适配器
+	misses     map[string]time.Time                   // 未配置的币种 -> 查找时间
+}
+
+// NewChainRegistry 创建链适配器注册表，需要调用 Reload 加载币种配置
+func NewChainRegistry(cfg *config.Config) *ChainRegistry {
+	return &ChainRegistry{
+		config:     cfg,
+		currencies: make(map[string]*models.CurrencyChainConfig),
+		adapters:   make(map[string]ChainAdapter),
+		misses:     make(map[string]time.Time),
+	}
+}
+
+// chainEndpoint 一条链的连接参数
+type chainEndpoint struct {
+	chainType string
+	rpcURL    string
+	chainID   int64
+}
+
+// Reload 从数据库重新加载币种配置，连接参数变化的链会重新建立连接
+// 同一条链的多个币种使用第一个币种（按ID）的连接参数，不一致时记录警告。
+// 并发调用依次执行；被替换的适配器在 retiredAdapterGrace 后关闭
+func (r *ChainRegistry) Reload() error {
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+	return r.reload()
+}
+
+// reload 重新加载，调用方持有 reloading
+func (r *ChainRegistry) reload() error {
+	var list []models.CurrencyChainConfig
+	if err := database.DB.Order("id").Find(&list).Error; err != nil {
+		return fmt.Errorf("failed to load currencies: %v", err)
+	}
+
+	currencies := make(map[string]*models.CurrencyChainConfig, len(list))
+	endpoints := make(map[string]chainEndpoint)
+	for i := range list {
+		currency := &list[i]
+		currencies[currency.Symbol] = currency
+
+		key := strings.ToLower(currency.ChainType)
+		endpoint := chainEndpoint{
+			chainType: currency.ChainType,
+			rpcURL:    r.defaultRPCURL(currency),
+			chainID:   currency.ChainID,
+		}
+		if existing, ok := endpoints[key]; ok {
+			if existing.rpcURL != endpoint.rpcURL || existing.chainID != endpoint.chainID {
+				log.Printf("Warning: currency %s uses a different rpc_url/chain_id than other %s currencies, ignored", currency.Symbol, currency.ChainType)
+			}
+			continue
+		}
+		endpoints[key] = endpoint
+	}
+
+	r.mu.RLock()
+	current := make(map[string]ChainAdapter, len(r.adapters))
+	for key, adapter := range r.adapters {
+		current[key] = adapter
+	}
+	r.mu.RUnlock()
+
+	// 在锁外建立连接，避免慢节点阻塞查询
+	adapters := make(map[string]ChainAdapter, len(endpoints))
+	var stale []ChainAdapter
+	for key, endpoint := range endpoints {
+		if adapter, ok := current[key]; ok && adapter.RPCURL() == endpoint.rpcURL && adapter.ChainID() == endpoint.chainID {
+			adapters[key] = adapter
+			delete(current, key)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+		adapter, err := NewChainAdapter(ctx, r.config, endpoint.chainType, endpoint.rpcURL, endpoint.chainID)
+		cancel()
+		if err != nil {
+			if key != "bitcoin" && key != "solana" {
+				log.Printf("Warning: chain %s unavailable: %v", endpoint.chainType, err)
+			}
+			continue
+		}
+		adapters[key] = adapter
+	}
+	for _, adapter := range current {
+		stale = append(stale, adapter)
+	}
+
+	r.mu.Lock()
+	r.currencies = currencies
+	r.adapters = adapters
+	r.misses = make(map[string]time.Time)
+	r.mu.Unlock()
+
+	for _, adapter := range stale {
+		retireAdapter(adapter)
+	}
+
+	log.Printf("Chain registry loaded: %d currencies, %d chains", len(currencies), len(adapters))
+	return nil
+}
+
+// defaultRPCURL 币种未配置 rpc_url 时使用配置文件中对应链的节点
+func (r *ChainRegistry) defaultRPCURL(currency *models.CurrencyChainConfig) string {
+	if currency.RPCURL != "" {
+		return currency.RPCURL
+	}
+	switch strings.ToLower(currency.ChainType) {
+	case "ethereum":
+		return r.config.Ethereum.GetTestnetRPCURL()
+	case "bsc":
+		if r.config.BSC != nil {
+			return r.config.BSC.RPCURL
+		}
+	case "tron":
+		return r.config.Tron.RPCURL
+	}
+	return ""
+}
+
+// retireAdapter 已从注册表移除的适配器延迟关闭
+func retireAdapter(adapter ChainAdapter) {
+	time.AfterFunc(retiredAdapterGrace, adapter.Close)
+}
+
+// Currency 获取币种配置，未找到时重新加载一次（币种可能刚被直接写入数据库）
+// 重新加载后仍未找到的币种在 currencyMissTTL 内直接返回错误，不再访问数据库
+func (r *ChainRegistry) Currency(symbol string) (*models.CurrencyChainConfig, error) {
+	if currency, missed := r.cachedCurrency(symbol); currency != nil {
+		return currency, nil
+	} else if missed {
+		return nil, fmt.Errorf("currency %s is not configured", symbol)
+	}
+
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+	// 等待期间其它请求可能已经加载了该币种或确认其未配置
+	if currency, missed := r.cachedCurrency(symbol); currency != nil {
+		return currency, nil
+	} else if missed {
+		return nil, fmt.Errorf("currency %s is not configured", symbol)
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	currency, ok := r.currencies[symbol]
+	if !ok {
+		r.misses[symbol] = time.Now()
+		return nil, fmt.Errorf("currency %s is not configured", symbol)
+	}
+	return currency, nil
+}
+
+// cachedCurrency 查询已加载的币种，missed 表示该币种最近已确认未配置
+func (r *ChainRegistry) cachedCurrency(symbol string) (currency *models.CurrencyChainConfig, missed bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if currency, ok := r.currencies[symbol]; ok {
+		return currency, false
+	}
+	at, ok := r.misses[symbol]
+	return nil, ok && time.Since(at) < currencyMissTTL
+}
+
+// NativeCurrency 获取链的原生币配置（未设置代币合约地址的币种）
+func (r *ChainRegistry) NativeCurrency(chainType string) (*models.CurrencyChainConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, currency := range r.currencies {
+		if strings.EqualFold(currency.ChainType, chainType) && (currency.TokenAddress == nil || *currency.TokenAddress == "") {
+			return currency, nil
+		}
+	}
+	return nil, fmt.Errorf("native currency of %s is not configured", chainType)
+}
+
+// ChainTypeForSymbol 获取币种所属链类型
+func (r *ChainRegistry) ChainTypeForSymbol(symbol string) (string, error) {
+	currency, err := r.Currency(symbol)
+	if err != nil {
+		return "", err
+	}
+	return currency.ChainType, nil
+}
+
+// Adapter 获取链类型对应的适配器
+func (r *ChainRegistry) Adapter(chainType string) (ChainAdapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	adapter, ok := r.adapters[strings.ToLower(chainType)]
+	if !ok {
+		return nil, fmt.Errorf("no adapter available for chain %s", chainType)
+	}
+	return adapter, nil
+}
+
+// AdapterForSymbol 获取币种所属链的适配器
+func (r *ChainRegistry) AdapterForSymbol(symbol string) (ChainAdapter, error) {
+	currency, err := r.Currency(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return r.Adapter(currency.ChainType)
+}
+
+// EVMClientForSymbol 获取币种所属EVM链的客户端，币种不在EVM链上时返回错误
+func (r *ChainRegistry) EVMClientForSymbol(symbol string) (*ethclient.Client, error) {
+	adapter, err := r.AdapterForSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	evm, ok := adapter.(*EVMChainAdapter)
+	if !ok {
+		return nil, fmt.Errorf("currency %s is on %s, which is not an EVM chain", symbol, adapter.ChainType())
+	}
+	return evm.Client(), nil
+}
+
+// EVMAdapters 所有EVM链的适配器
+func (r *ChainRegistry) EVMAdapters() []*EVMChainAdapter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var adapters []*EVMChainAdapter
+	for _, adapter := range r.adapters {
+		if evm, ok := adapter.(*EVMChainAdapter); ok {
+			adapters = append(adapters, evm)
+		}
+	}
+	return adapters
+}
+
+// LegacyTx 链上任一币种配置了 legacy_tx 时该链使用传统交易
+func (r *ChainRegistry) LegacyTx(chainType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, currency := range r.currencies {
+		if strings.EqualFold(currency.ChainType, chainType) && currency.LegacyTx {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEVMChain 判断链类型是否由EVM适配器处理
+func IsEVMChain(chainType string) bool {
+	switch strings.ToLower(chainType) {
+	case "bitcoin", "solana", "tron":
+		return false
+	default:
+		return true
+	}
+}
+
+// Endpoints 各链节点池状态，链类型 -> 节点状态
+func (r *ChainRegistry) Endpoints() map[string][]blockchain.RPCEndpointStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	status := make(map[string][]blockchain.RPCEndpointStatus, len(r.adapters))
+	for _, adapter := range r.adapters {
+		status[adapter.ChainType()] = adapter.Endpoints()
+	}
+	return status
+}
+
+// Close 关闭所有连接
+func (r *ChainRegistry) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, adapter := range r.adapters {
+		adapter.Close()
+		delete(r.adapters, key)
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-backend/internal/config"
+)
+
+func TestNewChainAdapter(t *testing.T) {
+	// 模拟 Polygon 节点，只响应 eth_chainId
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_chainId" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":-32601,"message":"method not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":"0x89"}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	cfg := &config.Config{}
+
+	// 新增EVM链只需配置，链类型不需要预先登记
+	adapter, err := NewChainAdapter(ctx, cfg, "Polygon", server.URL, 137)
+	if err != nil {
+		t.Fatalf("NewChainAdapter failed: %v", err)
+	}
+	defer adapter.Close()
+	if _, ok := adapter.(*EVMChainAdapter); !ok || adapter.ChainType() != "Polygon" || adapter.ChainID() != 137 {
+		t.Errorf("unexpected adapter %T %s/%d", adapter, adapter.ChainType(), adapter.ChainID())
+	}
+
+	// 节点链ID与配置不一致时拒绝，避免币种被扫描到错误的链上
+	if _, err := NewChainAdapter(ctx, cfg, "Base", server.URL, 8453); err == nil {
+		t.Error("expected chain ID mismatch to be rejected")
+	}
+
+	tron, err := NewChainAdapter(ctx, cfg, "TRON", "http://127.0.0.1:1", 728126428)
+	if err != nil {
+		t.Fatalf("NewChainAdapter failed for TRON: %v", err)
+	}
+	if _, ok := tron.(*TronChainAdapter); !ok {
+		t.Errorf("expected TronChainAdapter, got %T", tron)
+	}
+
+	if _, err := NewChainAdapter(ctx, cfg, "Bitcoin", server.URL, 0); err == nil {
+		t.Error("expected bitcoin to be served outside the chain registry")
+	}
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReorgEvent 一次链重组及回滚结果
+type ReorgEvent struct {
+	ChainType         string            `json:"chain_type"`
+	ForkBlock         uint64            `json:"fork_block"` // 与节点一致的最高区块，之后的区块被回滚
+	OldHead           uint64            `json:"old_head"`   // 回滚前已扫描的最高区块
+	RevertedDeposits  int               `json:"reverted_deposits"`
+	RevertedBills     int               `json:"reverted_bills"`     // 回到待确认状态的转出交易
+	RevertedWithdraws int               `json:"reverted_withdraws"` // 回到发送成功状态的已确认或执行失败的提币
+	NegativeBalances  []NegativeBalance `json:"negative_balances,omitempty"`
+	DetectedAt        time.Time         `json:"detected_at"`
+}
+
+// NegativeBalance 回滚充值后为负的余额：已入账的充值在重组前已被提币或转出，需要人工处理
+type NegativeBalance struct {
+	Address        string  `json:"address"`
+	CurrencySymbol string  `json:"currency_symbol"`
+	Balance        float64 `json:"balance"`
+}
+
+// HeaderClient 检测链重组所需的节点接口，*ethclient.Client 已实现
+type HeaderClient interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// recordScannedBlock 记录已扫描的区块哈希，同一高度已有记录时保留先记录的哈希，由下一次检查发现分叉
+func recordScannedBlock(chainType string, number uint64, hash, parentHash string) error {
+	return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ScannedBlock{
+		ChainType:  chainType,
+		Number:     number,
+		Hash:       hash,
+		ParentHash: parentHash,
+	}).Error
+}
+
+// pruneScannedBlocks 只保留链上最近 depth 个区块的记录
+func pruneScannedBlocks(chainType string, head uint64, depth int) error {
+	if head < uint64(depth) {
+		return nil
+	}
+	return database.DB.Where("chain_type = ? AND number <= ?", chainType, head-uint64(depth)).
+		Delete(&models.ScannedBlock{}).Error
+}
+
+// findForkPoint 从高到低逐个比对已记录区块与节点当前的区块哈希，返回与节点一致的最高区块
+// stored 按区块号从高到低排列；最高区块一致时 reorged 为 false。所有记录都不一致（重组深度超过保存的区块数）时
+// 返回最低记录的前一个区块
+func findForkPoint(ctx context.Context, client HeaderClient, stored []models.ScannedBlock) (fork uint64, reorged bool, err error) {
+	for i, block := range stored {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(block.Number))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return 0, false, fmt.Errorf("failed to get header %d: %v", block.Number, err)
+		}
+		// 节点没有该高度的区块时（重组后的链更短）视为不一致
+		if header != nil && strings.EqualFold(header.Hash().Hex(), block.Hash) {
+			return block.Number, i > 0, nil
+		}
+	}
+	if len(stored) == 0 {
+		return 0, false, nil
+	}
+	oldest := stored[len(stored)-1].Number
+	if oldest == 0 {
+		return 0, true, nil
+	}
+	return oldest - 1, true, nil
+}
+
+// rollbackChain 回滚链上 fork 之后的入账：删除充值记录和充值账单并扣回已入账的余额，
+// 已上链的转出交易回到待确认状态，已确认或执行失败的提币回到发送成功并恢复冻结金额，
+// 删除区块记录，已扫描位置退回到 fork 以便重新扫描；回滚后为负的余额记录在事件中并告警
+func rollbackChain(chainType string, fork uint64) (*ReorgEvent, error) {
+	event := &ReorgEvent{ChainType: chainType, ForkBlock: fork, DetectedAt: time.Now()}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var touched []models.Balance
+		var head models.ScannedBlock
+		if err := tx.Where("chain_type = ?", chainType).Order("number DESC").First(&head).Error; err == nil {
+			event.OldHead = head.Number
+		}
+
+		var bills []models.ChainBill
+		if err := tx.Where("chain_type = ? AND block_height > ?", chainType, fork).Find(&bills).Error; err != nil {
+			return err
+		}
+		for _, bill := range bills {
+			if bill.Type != 1 {
+				// 转出交易可能被重新打包，回到待确认由监控重新广播
+				if err := tx.Model(&bill).Updates(map[string]interface{}{
+					"status":        0,
+					"block_height":  nil,
+					"confirmations": 0,
+				}).Error; err != nil {
+					return err
+				}
+				event.RevertedBills++
+				continue
+			}
+
+			// 确认中的充值尚未入账
+			if bill.Status == 1 {
+				if err := tx.Model(&models.Balance{}).
+					Where("address = ? AND currency_symbol = ?", bill.Address, bill.CurrencySymbol).
+					Update("balance", gorm.Expr("balance - ?", bill.Amount)).Error; err != nil {
+					return err
+				}
+				touched = append(touched, models.Balance{Address: bill.Address, CurrencySymbol: bill.CurrencySymbol})
+			}
+			if err := tx.Unscoped().Where("tx_id = ?", bill.TxID).Delete(&models.DepositRecord{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(&bill).Error; err != nil {
+				return err
+			}
+			event.RevertedDeposits++
+		}
+
+		if err := tx.Unscoped().Where("chain_type = ? AND block_height > ?", chainType, fork).
+			Delete(&models.DepositRecord{}).Error; err != nil {
+			return err
+		}
+
+		// 提币交易可能被重新打包，回到发送成功由确认跟踪服务重新确认
+		var withdraws []models.WithdrawRecord
+		if err := tx.Where("chain_type = ? AND block_height > ? AND status IN ?", chainType, fork,
+			[]int{2, withdrawSent, withdrawConfirmed, withdrawReverted}).Find(&withdraws).Error; err != nil {
+			return err
+		}
+		for i := range withdraws {
+			withdraw := &withdraws[i]
+			status := withdraw.Status
+			if err := tx.Model(withdraw).Updates(map[string]interface{}{
+				"status":         withdrawSent,
+				"block_height":   nil,
+				"confirmations":  0,
+				"confirmed_time": nil,
+				"fail_reason":    "",
+			}).Error; err != nil {
+				return err
+			}
+
+			balance := tx.Model(&models.Balance{}).Where("address = ? AND currency_symbol = ?", withdraw.FromAddress, withdraw.CurrencySymbol)
+			switch status {
+			case withdrawConfirmed:
+				// 确认时扣除的冻结金额恢复
+				if err := balance.Update("frozen", gorm.Expr("frozen + ?", withdraw.TotalAmount)).Error; err != nil {
+					return err
+				}
+			case withdrawReverted:
+				// 执行失败时退回的金额重新冻结
+				if err := balance.Updates(map[string]interface{}{
+					"balance": gorm.Expr("balance - ?", withdraw.TotalAmount),
+					"frozen":  gorm.Expr("frozen + ?", withdraw.TotalAmount),
+				}).Error; err != nil {
+					return err
+				}
+				touched = append(touched, models.Balance{Address: withdraw.FromAddress, CurrencySymbol: withdraw.CurrencySymbol})
+			default:
+				continue
+			}
+			event.RevertedWithdraws++
+		}
+
+		negative, err := negativeBalances(tx, touched)
+		if err != nil {
+			return err
+		}
+		event.NegativeBalances = negative
+
+		if err := tx.Where("chain_type = ? AND number > ?", chainType, fork).
+			Delete(&models.ScannedBlock{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.CurrencyChainConfig{}).
+			Where("chain_type = ? AND last_scanned_block > ?", chainType, fork).
+			Update("last_scanned_block", fork).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll back %s to block %d: %v", chainType, fork, err)
+	}
+	for _, balance := range event.NegativeBalances {
+		log.Printf("WARNING: reorg on %s left balance of %s %s negative: %f", chainType, balance.Address, balance.CurrencySymbol, balance.Balance)
+	}
+	return event, nil
+}
+
+// negativeBalances 回滚后为负的余额，每个地址和币种只返回一次
+func negativeBalances(tx *gorm.DB, touched []models.Balance) ([]NegativeBalance, error) {
+	var negative []NegativeBalance
+	seen := make(map[string]bool)
+	for _, key := range touched {
+		id := key.Address + ":" + key.CurrencySymbol
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		var balance models.Balance
+		err := tx.Where("address = ? AND currency_symbol = ?", key.Address, key.CurrencySymbol).First(&balance).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if balance.Balance < 0 {
+			negative = append(negative, NegativeBalance{Address: balance.Address, CurrencySymbol: balance.CurrencySymbol, Balance: balance.Balance})
+		}
+	}
+	return negative, nil
+}
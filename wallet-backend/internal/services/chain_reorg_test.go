@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeHeaderClient 按区块号返回节点当前的区块头
+type fakeHeaderClient map[uint64]*types.Header
+
+func (c fakeHeaderClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, ok := c[number.Uint64()]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return header, nil
+}
+
+// buildChain 生成 [from, to] 的区块头，extra 区分不同分叉
+func buildChain(client fakeHeaderClient, parent common.Hash, from, to uint64, extra string) {
+	for number := from; number <= to; number++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), ParentHash: parent, Extra: []byte(extra), Difficulty: big.NewInt(1)}
+		client[number] = header
+		parent = header.Hash()
+	}
+}
+
+// storedBlocks 按区块号从高到低记录节点当前的区块
+func storedBlocks(client fakeHeaderClient, from, to uint64) []models.ScannedBlock {
+	var stored []models.ScannedBlock
+	for number := to; number >= from; number-- {
+		stored = append(stored, models.ScannedBlock{Number: number, Hash: client[number].Hash().Hex()})
+	}
+	return stored
+}
+
+func TestFindForkPoint(t *testing.T) {
+	ctx := context.Background()
+	client := fakeHeaderClient{}
+	buildChain(client, common.Hash{}, 100, 110, "a")
+	stored := storedBlocks(client, 100, 110)
+
+	if _, reorged, err := findForkPoint(ctx, client, stored); err != nil || reorged {
+		t.Fatalf("expected no reorg, got %v, %v", reorged, err)
+	}
+
+	// 107 之后的区块被替换
+	buildChain(client, client[107].Hash(), 108, 112, "b")
+	if fork, reorged, err := findForkPoint(ctx, client, stored); err != nil || !reorged || fork != 107 {
+		t.Errorf("expected fork at 107, got %d, %v, %v", fork, reorged, err)
+	}
+
+	// 重组后的链更短，节点还没有 109、110
+	delete(client, 109)
+	delete(client, 110)
+	delete(client, 111)
+	delete(client, 112)
+	if fork, reorged, err := findForkPoint(ctx, client, stored); err != nil || !reorged || fork != 107 {
+		t.Errorf("expected fork at 107 on a shorter chain, got %d, %v, %v", fork, reorged, err)
+	}
+
+	// 重组深度超过保存的区块数
+	buildChain(client, common.Hash{}, 100, 112, "c")
+	if fork, reorged, err := findForkPoint(ctx, client, stored); err != nil || !reorged || fork != 99 {
+		t.Errorf("expected fork below the recorded window, got %d, %v, %v", fork, reorged, err)
+	}
+}
+
+func TestRollbackChain(t *testing.T) {
+	setupTestDB(t)
+	height := func(n uint64) *uint64 { return &n }
+
+	// alice 的充值在区块105入账后已用掉一部分；bob 有在分叉前后确认的提币和一笔执行失败的提币
+	database.DB.Create(&models.Balance{Address: "0xalice", CurrencySymbol: "ETH", ChainType: "Ethereum", Balance: 1})
+	database.DB.Create(&models.Balance{Address: "0xbob", CurrencySymbol: "ETH", ChainType: "Ethereum", Balance: 3})
+	database.DB.Create(&models.ChainBill{CurrencySymbol: "ETH", ChainType: "Ethereum", Address: "0xalice", TxID: "0xd1", Type: 1, Amount: 2, Status: 1, BlockHeight: height(105)})
+	database.DB.Create(&models.DepositRecord{CurrencySymbol: "ETH", ChainType: "Ethereum", ToAddress: "0xalice", TxID: "0xd1", UniqueID: "d1", Amount: 2, Status: true, BlockHeight: height(105)})
+	database.DB.Create(&models.ChainBill{CurrencySymbol: "ETH", ChainType: "Ethereum", Address: "0xhot", TxID: "0xc1", Type: 3, Amount: 5, Status: 1, BlockHeight: height(105)})
+	withdraws := []struct {
+		status int
+		height uint64
+	}{{withdrawConfirmed, 106}, {withdrawReverted, 107}, {withdrawConfirmed, 99}}
+	for i, w := range withdraws {
+		txID := fmt.Sprintf("0xw%d", i)
+		database.DB.Create(&models.WithdrawRecord{
+			CurrencySymbol: "ETH", ChainType: "Ethereum", FromAddress: "0xbob", ToAddress: "0xdest", TxID: &txID,
+			Amount: 1, Fee: 0.001, TotalAmount: 1.001, UniqueID: fmt.Sprintf("w%d", i), Status: w.status, BlockHeight: height(w.height),
+		})
+	}
+
+	event, err := rollbackChain("Ethereum", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.RevertedDeposits != 1 || event.RevertedBills != 1 || event.RevertedWithdraws != 2 {
+		t.Errorf("unexpected rollback counts: %+v", event)
+	}
+	if len(event.NegativeBalances) != 1 || event.NegativeBalances[0].Address != "0xalice" || !floatEquals(event.NegativeBalances[0].Balance, -1) {
+		t.Errorf("expected alice's balance to be reported negative, got %+v", event.NegativeBalances)
+	}
+
+	// 分叉后的提币回到发送成功：确认扣除的冻结恢复，执行失败退回的金额重新冻结
+	for i, want := range []int{withdrawSent, withdrawSent, withdrawConfirmed} {
+		var withdraw models.WithdrawRecord
+		database.DB.Where("unique_id = ?", fmt.Sprintf("w%d", i)).First(&withdraw)
+		if withdraw.Status != want {
+			t.Errorf("withdraw %d: got status %d, want %d", i, withdraw.Status, want)
+		}
+	}
+	var bob models.Balance
+	database.DB.Where("address = ?", "0xbob").First(&bob)
+	if !floatEquals(bob.Balance, 1.999) || !floatEquals(bob.Frozen, 2.002) {
+		t.Errorf("unexpected balance of bob: balance %f frozen %f", bob.Balance, bob.Frozen)
+	}
+	var collection models.ChainBill
+	database.DB.Where("tx_id = ?", "0xc1").First(&collection)
+	if collection.Status != 0 || collection.BlockHeight != nil {
+		t.Errorf("outgoing transaction should be back to pending, got %+v", collection)
+	}
+}
@@ -2,53 +2,57 @@ package services
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"strconv"
+	"strings"
 	"time"
 	"wallet-backend/internal/config"
 	"wallet-backend/internal/database"
 	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// CollectionService 归集服务
+// CollectionService 归集服务，EVM币种的节点连接由链适配器注册表提供
 type CollectionService struct {
-	config  *config.Config
-	clients map[string]*ethclient.Client // 支持多链
-	stop    chan struct{}
+	config *config.Config
+	chains *ChainRegistry
+	signer Signer
+	gas    *GasOracleService
+	nonces *NonceManager
+	tron   *TronWalletService
+	stop   chan struct{}
 }
 
-// NewCollectionService 创建新的归集服务
-func NewCollectionService(cfg *config.Config) (*CollectionService, error) {
-	clients := make(map[string]*ethclient.Client)
-	
-	// 初始化以太坊客户端
-	ethClient, err := ethclient.Dial(cfg.Ethereum.GetTestnetRPCURL())
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum testnet: %v", err)
+// tronFeeReserve 归集TRX时在充值地址保留的带宽费用（sun）
+var tronFeeReserve = big.NewInt(1000000)
+
+// NewCollectionService 创建新的归集服务，gas 为空时每次归集直接估算手续费且不限制上限
+func NewCollectionService(cfg *config.Config, chains *ChainRegistry, signer Signer, gas *GasOracleService, nonces *NonceManager) (*CollectionService, error) {
+	if chains == nil {
+		return nil, fmt.Errorf("chain registry is required")
 	}
-	clients["ETH"] = ethClient
-	
-	// 初始化BSC客户端（如果有配置）
-	if cfg.BSC != nil && cfg.BSC.RPCURL != "" {
-		bscClient, err := ethclient.Dial(cfg.BSC.RPCURL)
-		if err != nil {
-			log.Printf("Warning: failed to connect to BSC: %v", err)
-		} else {
-			clients["BSC"] = bscClient
-		}
+
+	// 初始化TRON钱包（如果有配置）
+	var tron *TronWalletService
+	if cfg.Tron.RPCURL != "" {
+		tron, _ = NewTronWalletService(cfg, signer)
 	}
 
 	return &CollectionService{
-		config:  cfg,
-		clients: clients,
-		stop:    make(chan struct{}, 1),
+		config: cfg,
+		chains: chains,
+		signer: signer,
+		gas:    gas,
+		nonces: nonces,
+		tron:   tron,
+		stop:   make(chan struct{}, 1),
 	}, nil
 }
 
@@ -111,6 +115,13 @@ func (cs *CollectionService) checkAndCollect() error {
 
 // processCurrencyCollection 处理指定币种的归集
 func (cs *CollectionService) processCurrencyCollection(currency *models.CurrencyChainConfig) error {
+	if strings.EqualFold(currency.ChainType, "TRON") {
+		return cs.collectTron(currency)
+	}
+	if !IsEVMChain(currency.ChainType) {
+		return nil
+	}
+
 	// 获取该币种的热钱包地址
 	hotWallets, err := cs.getHotWalletsForSymbol(currency.Symbol)
 	if err != nil {
@@ -127,6 +138,83 @@ func (cs *CollectionService) processCurrencyCollection(currency *models.Currency
 	return nil
 }
 
+// collectTron 把TRON充值地址上超过归集阈值的余额转入冷钱包
+// 代币归集消耗的能量由充值地址上的TRX支付，余额不足时广播会失败并在下一轮重试
+func (cs *CollectionService) collectTron(currency *models.CurrencyChainConfig) error {
+	if cs.tron == nil {
+		return fmt.Errorf("tron is not configured")
+	}
+	if !currency.CollectionEnabled {
+		return nil
+	}
+
+	coldAddress := cs.config.Wallet.ColdWallet.AddressFor("TRON")
+	if err := blockchain.ValidateTronAddress(coldAddress); err != nil {
+		return fmt.Errorf("invalid TRON cold wallet address %q: %v", coldAddress, err)
+	}
+	threshold, _ := strconv.ParseFloat(currency.CollectionThreshold, 64)
+
+	var balances []models.Balance
+	if err := database.DB.Where("chain_type = ? AND currency_symbol = ? AND balance >= ?", "TRON", currency.Symbol, threshold).
+		Find(&balances).Error; err != nil {
+		return fmt.Errorf("failed to get balances: %v", err)
+	}
+
+	ctx := context.Background()
+	isToken := currency.TokenAddress != nil && *currency.TokenAddress != ""
+	for _, balance := range balances {
+		if balance.Address == coldAddress {
+			continue
+		}
+		from, err := lookupAddress(balance.Address, "TRON")
+		if err != nil {
+			log.Printf("Failed to collect %s from %s: %v", currency.Symbol, balance.Address, err)
+			continue
+		}
+
+		amount, err := cs.tron.GetBalance(ctx, currency, balance.Address)
+		if err != nil {
+			log.Printf("Failed to get %s balance of %s: %v", currency.Symbol, balance.Address, err)
+			continue
+		}
+		if !isToken {
+			amount.Sub(amount, tronFeeReserve)
+		}
+		if amount.Sign() <= 0 || unitsToFloat(amount, currency.Decimals) < threshold {
+			continue
+		}
+
+		tx, err := cs.tron.CreateTransfer(ctx, from, currency, coldAddress, amount)
+		if err != nil {
+			log.Printf("Failed to create collection transaction from %s: %v", balance.Address, err)
+			continue
+		}
+		if err := cs.tron.SendTransaction(ctx, tx); err != nil {
+			log.Printf("Failed to send collection transaction from %s: %v", balance.Address, err)
+			continue
+		}
+
+		chainBill := &models.ChainBill{
+			TxID:           tx.TxID,
+			Address:        balance.Address,
+			Amount:         unitsToFloat(amount, currency.Decimals),
+			Type:           3, // 归集
+			Status:         0, // 待处理
+			ChainType:      "TRON",
+			CurrencySymbol: currency.Symbol,
+			CreatedTime:    time.Now(),
+			UpdatedTime:    time.Now(),
+		}
+		if err := database.DB.Create(chainBill).Error; err != nil {
+			log.Printf("Failed to save collection transaction: %v", err)
+		}
+
+		log.Printf("Collection transaction sent: %s, symbol: %s, amount: %s", tx.TxID, currency.Symbol, amount.String())
+	}
+
+	return nil
+}
+
 // getHotWalletsForSymbol 获取指定币种的热钱包地址
 func (cs *CollectionService) getHotWalletsForSymbol(symbol string) ([]models.AddressLibrary, error) {
 	var addresses []models.AddressLibrary
@@ -157,8 +245,12 @@ func (cs *CollectionService) processWallet(wallet models.AddressLibrary, currenc
 		return nil
 	}
 
-	// 归集资金
+	// 归集资金，手续费超过上限时推迟到下一轮
 	if err := cs.CollectFunds(currency.Symbol, wallet.Address, cs.config.Wallet.ColdWallet.Address, balance, client); err != nil {
+		if errors.Is(err, blockchain.ErrEVMFeeAboveCap) {
+			log.Printf("Collection from %s for symbol %s deferred: %v", wallet.Address, currency.Symbol, err)
+			return nil
+		}
 		log.Printf("Failed to collect funds from %s for symbol %s: %v", wallet.Address, currency.Symbol, err)
 		return err
 	}
@@ -197,22 +289,23 @@ func (cs *CollectionService) CollectFunds(symbol string, fromAddress string, toA
 		return fmt.Errorf("invalid target address")
 	}
 
-	// 获取发送方私钥
-	privateKey, err := cs.getPrivateKeyByAddress(fromAddress, symbol)
+	// 获取发送方地址记录，由签名器按派生索引签名
+	if cs.signer == nil {
+		return fmt.Errorf("signer is not configured")
+	}
+	fromAddr, err := lookupAddress(fromAddress, cs.getChainTypeForSymbol(symbol))
 	if err != nil {
-		return fmt.Errorf("failed to get private key: %v", err)
+		return err
 	}
 
-	// 获取nonce
-	nonce, err := client.PendingNonceAt(context.Background(), common.HexToAddress(fromAddress))
+	// 估算手续费，未启用London的链使用传统gasPrice
+	currency, err := cs.chains.Currency(symbol)
 	if err != nil {
-		return fmt.Errorf("failed to get nonce: %v", err)
+		return err
 	}
-
-	// 估算gas
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	fee, err := cs.evmFee(context.Background(), client, currency)
 	if err != nil {
-		return fmt.Errorf("failed to suggest gas price: %v", err)
+		return err
 	}
 
 	// 估算gas limit
@@ -231,8 +324,8 @@ func (cs *CollectionService) CollectFunds(symbol string, fromAddress string, toA
 		return fmt.Errorf("failed to estimate gas: %v", err)
 	}
 
-	// 计算实际发送金额（减去gas费用）
-	gasCost := new(big.Int).Mul(gasPrice, big.NewInt(int64(gasLimit)))
+	// 计算实际发送金额（按最高gas价格预留手续费，EIP-1559交易未用完的部分留在充值地址）
+	gasCost := fee.MaxCost(gasLimit)
 	actualAmount := new(big.Int).Sub(amount, gasCost)
 
 	if actualAmount.Cmp(big.NewInt(0)) <= 0 {
@@ -240,30 +333,31 @@ func (cs *CollectionService) CollectFunds(symbol string, fromAddress string, toA
 	}
 
 	// 创建交易
-	tx := types.NewTransaction(
-		nonce,
-		common.HexToAddress(toAddress),
-		actualAmount,
-		gasLimit,
-		gasPrice,
-		nil,
-	)
-
-	// 签名交易
 	chainID, err := client.ChainID(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed to get chain ID: %v", err)
 	}
 
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	// 分配nonce，同一地址的并发交易不会取到相同的nonce
+	lease, err := reserveNonce(context.Background(), cs.nonces, client, chainID.Int64(), fromAddress)
+	if err != nil {
+		return err
+	}
+	tx := blockchain.NewEVMTransaction(chainID, lease.Nonce, common.HexToAddress(toAddress), actualAmount, gasLimit, nil, fee)
+
+	// 签名交易
+	signedTx, err := cs.signer.SignTx(context.Background(), fromAddr, tx, chainID)
 	if err != nil {
+		lease.Release(context.Background())
 		return fmt.Errorf("failed to sign transaction: %v", err)
 	}
 
 	// 发送交易
 	if err := client.SendTransaction(context.Background(), signedTx); err != nil {
+		lease.Release(context.Background())
 		return fmt.Errorf("failed to send transaction: %v", err)
 	}
+	lease.Commit(signedTx.Hash().Hex())
 
 	// 转换金额为float64
 	amountFloat, _ := new(big.Float).SetString(actualAmount.String())
@@ -281,6 +375,7 @@ func (cs *CollectionService) CollectFunds(symbol string, fromAddress string, toA
 		CreatedTime:    time.Now(),
 		UpdatedTime:    time.Now(),
 	}
+	setOutboundTx(chainBill, signedTx)
 
 	if err := database.DB.Create(chainBill).Error; err != nil {
 		log.Printf("Failed to save collection transaction: %v", err)
@@ -290,6 +385,14 @@ func (cs *CollectionService) CollectFunds(symbol string, fromAddress string, toA
 	return nil
 }
 
+// evmFee 归集手续费：配置了Gas预言机时按归集档位取价并检查上限，否则直接估算
+func (cs *CollectionService) evmFee(ctx context.Context, client *ethclient.Client, currency *models.CurrencyChainConfig) (*blockchain.EVMFee, error) {
+	if cs.gas != nil {
+		return cs.gas.Fee(ctx, currency.ChainType, GasPurposeCollection)
+	}
+	return blockchain.SuggestEVMFee(ctx, client, currency.LegacyTx)
+}
+
 // CollectFromAddress 从指定地址归集资金
 func (cs *CollectionService) CollectFromAddress(symbol string, address string) error {
 	// 获取对应链的客户端
@@ -317,50 +420,19 @@ func (cs *CollectionService) CollectFromAddress(symbol string, address string) e
 	return nil
 }
 
-// getPrivateKeyByAddress 根据地址获取私钥
-func (cs *CollectionService) getPrivateKeyByAddress(address string, symbol string) (*ecdsa.PrivateKey, error) {
-	// 这里需要实现从地址索引到私钥的映射
-	// 在实际应用中，这通常通过数据库查询或内存映射实现
-	// 暂时返回错误，需要实现完整的地址管理
-	return nil, fmt.Errorf("private key retrieval not implemented yet")
-}
-
-// getClientForSymbol 根据币种获取对应的客户端
+// getClientForSymbol 根据币种配置的链类型获取对应的客户端
 func (cs *CollectionService) getClientForSymbol(symbol string) (*ethclient.Client, error) {
-	// 根据币种确定使用哪个客户端
-	switch symbol {
-	case "ETH", "USDT", "USDC":
-		if client, exists := cs.clients["ETH"]; exists {
-			return client, nil
-		}
-	case "BNB", "BUSD":
-		if client, exists := cs.clients["BSC"]; exists {
-			return client, nil
-		}
-		// 如果没有BSC客户端，使用ETH客户端
-		if client, exists := cs.clients["ETH"]; exists {
-			return client, nil
-		}
-	default:
-		// 默认使用ETH客户端
-		if client, exists := cs.clients["ETH"]; exists {
-			return client, nil
-		}
-	}
-	
-	return nil, fmt.Errorf("no client available for symbol %s", symbol)
+	return cs.chains.EVMClientForSymbol(symbol)
 }
 
-// getChainTypeForSymbol 根据币种获取链类型
+// getChainTypeForSymbol 根据币种配置获取链类型，未配置的币种返回空字符串
 func (cs *CollectionService) getChainTypeForSymbol(symbol string) string {
-	switch symbol {
-	case "ETH", "USDT", "USDC":
-		return "Ethereum"
-	case "BNB", "BUSD":
-		return "BSC"
-	default:
-		return "Ethereum"
+	chainType, err := cs.chains.ChainTypeForSymbol(symbol)
+	if err != nil {
+		log.Printf("Failed to get chain type for symbol %s: %v", symbol, err)
+		return ""
 	}
+	return chainType
 }
 
 // getEnabledCurrencies 获取所有启用的币种配置
@@ -373,11 +445,7 @@ func (cs *CollectionService) getEnabledCurrencies() ([]*models.CurrencyChainConf
 	return currencies, nil
 }
 
-// Close 关闭服务
+// Close 关闭服务，节点连接由链适配器注册表统一关闭
 func (cs *CollectionService) Close() {
-	for _, client := range cs.clients {
-		if client != nil {
-			client.Close()
-		}
-	}
+	cs.Stop()
 }
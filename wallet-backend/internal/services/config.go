@@ -8,7 +8,19 @@ import (
 type Config struct {
 	AppConfig          *config.Config
 	HDWalletService    *HDWalletService
+	AddressService     *AddressService
+	Signer             Signer
+	ChainRegistry      *ChainRegistry
+	GasOracleService   *GasOracleService
+	NonceManager       *NonceManager
 	WSService          *WebSocketService
 	BlockScannerService *BlockScannerService
+	SolanaScannerService *SolanaScannerService
+	TronScannerService *TronScannerService
+	BitcoinScannerService *BitcoinScannerService
+	BitcoinWalletService *BitcoinWalletService
+	EthereumWalletService *EthereumWalletService
 	CollectionService  *CollectionService
+	RecoveryService    *RecoveryService
+	TxMonitorService   *TxMonitorService
 } 
\ No newline at end of file
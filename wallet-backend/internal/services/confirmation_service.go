@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"gorm.io/gorm"
+)
+
+// 提币记录状态
+const (
+	withdrawSent      = 3  // 发送成功
+	withdrawConfirmed = 4  // 确认成功
+	withdrawReverted  = 13 // 链上执行失败
+)
+
+// ConfirmationService 确认跟踪服务：按EVM链的当前高度重新检查确认中的充值、转出账单和已发送的提币
+// 确认数按币种配置的 confirmations 计算，达到后充值入账、提币扣除冻结金额；交易执行失败时充值标记失败、提币解冻
+// 比特币、TRON、Solana 的充值由各自的扫描器在达到确认数后直接入账
+type ConfirmationService struct {
+	config *config.Config
+	chains *ChainRegistry
+	ws     *WebSocketService
+
+	running sync.Mutex
+	heads   map[string]uint64 // 链类型(小写) -> 上次检查时的高度
+}
+
+// NewConfirmationService 创建确认跟踪服务，ws 为空时不推送入账通知
+func NewConfirmationService(cfg *config.Config, chains *ChainRegistry, ws *WebSocketService) (*ConfirmationService, error) {
+	if chains == nil {
+		return nil, fmt.Errorf("chain registry is required")
+	}
+	return &ConfirmationService{
+		config: cfg,
+		chains: chains,
+		ws:     ws,
+		heads:  make(map[string]uint64),
+	}, nil
+}
+
+// RunOnce 检查所有EVM链，上一次检查尚未结束时直接返回；链高度没有变化时跳过该链
+func (cs *ConfirmationService) RunOnce(ctx context.Context) {
+	if !cs.running.TryLock() {
+		return
+	}
+	defer cs.running.Unlock()
+
+	for _, adapter := range cs.chains.EVMAdapters() {
+		if err := cs.confirmChain(ctx, adapter); err != nil {
+			log.Printf("Failed to track confirmations on %s: %v", adapter.ChainType(), err)
+		}
+	}
+}
+
+// confirmChain 检查一条链上未最终确认的记录
+func (cs *ConfirmationService) confirmChain(ctx context.Context, adapter *EVMChainAdapter) error {
+	chainType, client := adapter.ChainType(), adapter.Client()
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block number: %v", err)
+	}
+	key := strings.ToLower(chainType)
+	if cs.heads[key] == head {
+		return nil
+	}
+
+	var deposits []models.DepositRecord
+	if err := database.DB.Where("chain_type = ? AND status = ? AND fail_reason = ?", chainType, false, "").
+		Find(&deposits).Error; err != nil {
+		return fmt.Errorf("failed to load pending deposits: %v", err)
+	}
+	for i := range deposits {
+		if err := cs.confirmDeposit(ctx, client, head, &deposits[i]); err != nil {
+			log.Printf("Failed to confirm deposit %s: %v", deposits[i].TxID, err)
+		}
+	}
+
+	var bills []models.ChainBill
+	if err := database.DB.Where("chain_type = ? AND status = ? AND type <> ? AND replaced_by IS NULL", chainType, 0, 1).
+		Find(&bills).Error; err != nil {
+		return fmt.Errorf("failed to load pending transactions: %v", err)
+	}
+	for i := range bills {
+		if err := cs.confirmBill(ctx, client, adapter.ChainID(), head, &bills[i]); err != nil {
+			log.Printf("Failed to confirm transaction %s: %v", bills[i].TxID, err)
+		}
+	}
+
+	var withdraws []models.WithdrawRecord
+	if err := database.DB.Where("chain_type = ? AND status IN ? AND tx_id IS NOT NULL", chainType, withdrawInFlight).
+		Find(&withdraws).Error; err != nil {
+		return fmt.Errorf("failed to load sent withdrawals: %v", err)
+	}
+	for i := range withdraws {
+		if err := cs.confirmWithdraw(ctx, client, head, &withdraws[i]); err != nil {
+			log.Printf("Failed to confirm withdraw %d: %v", withdraws[i].ID, err)
+		}
+	}
+
+	cs.heads[key] = head
+	return nil
+}
+
+// confirmDeposit 更新确认中充值的确认数，达到币种要求后入账
+func (cs *ConfirmationService) confirmDeposit(ctx context.Context, client *ethclient.Client, head uint64, record *models.DepositRecord) error {
+	receipt, err := transactionReceipt(ctx, client, record.TxID)
+	if err != nil || receipt == nil {
+		// 回执暂时查不到（节点延迟或交易已被重组移除，由扫描器回滚）
+		return err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(record).Update("fail_reason", "transaction reverted").Error; err != nil {
+				return err
+			}
+			return depositBills(tx, record.TxID).Update("status", 2).Error
+		})
+	}
+
+	height := receipt.BlockNumber.Uint64()
+	confirmations := confirmationsAt(head, height)
+	if confirmations < cs.requiredConfirmations(record.CurrencySymbol) {
+		return database.DB.Transaction(func(tx *gorm.DB) error {
+			updates := map[string]interface{}{"block_height": height, "confirmations": confirmations}
+			if err := tx.Model(record).Updates(updates).Error; err != nil {
+				return err
+			}
+			return depositBills(tx, record.TxID).Updates(updates).Error
+		})
+	}
+
+	credited, err := confirmDepositEntry(record, height, confirmations)
+	if err != nil {
+		return err
+	}
+	if credited {
+		log.Printf("Deposit %s confirmed with %d confirmations: %f %s to %s", record.TxID, confirmations, record.Amount, record.CurrencySymbol, record.ToAddress)
+		if cs.ws != nil && record.UserID != 0 {
+			record.Status = true
+			record.Confirmations = confirmations
+			cs.ws.SendDepositNotification(record.UserID, record)
+		}
+	}
+	return nil
+}
+
+// confirmBill 更新转出账单（归集、提币）的确认数，达到币种要求后改为已确认，执行失败时改为失败
+// 账单最终确定时交易的nonce已被使用，删除该地址到此nonce为止的分配记录
+func (cs *ConfirmationService) confirmBill(ctx context.Context, client *ethclient.Client, chainID int64, head uint64, bill *models.ChainBill) error {
+	receipt, err := transactionReceipt(ctx, client, bill.TxID)
+	if err != nil || receipt == nil {
+		// 尚未上链，由卡住交易监控处理
+		return err
+	}
+
+	height := receipt.BlockNumber.Uint64()
+	confirmations := confirmationsAt(head, height)
+	updates := map[string]interface{}{"block_height": height, "confirmations": confirmations}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		updates["status"] = 2 // 失败
+	} else if confirmations >= cs.requiredConfirmations(bill.CurrencySymbol) {
+		updates["status"] = 1 // 已确认
+	}
+	if err := updateBill(bill, updates); err != nil {
+		return err
+	}
+	if _, final := updates["status"]; final && bill.Nonce != nil {
+		if err := forgetUsedNonces(chainID, bill.Address, *bill.Nonce); err != nil {
+			log.Printf("Failed to delete nonce reservations of %s: %v", bill.Address, err)
+		}
+	}
+	return nil
+}
+
+// confirmWithdraw 已发送的提币达到确认数后改为确认成功并扣除冻结金额，执行失败时解冻
+func (cs *ConfirmationService) confirmWithdraw(ctx context.Context, client *ethclient.Client, head uint64, withdraw *models.WithdrawRecord) error {
+	receipt, err := transactionReceipt(ctx, client, *withdraw.TxID)
+	if err != nil || receipt == nil {
+		return err
+	}
+
+	height := receipt.BlockNumber.Uint64()
+	confirmations := confirmationsAt(head, height)
+	reverted := receipt.Status != types.ReceiptStatusSuccessful
+	if !reverted && confirmations < cs.requiredConfirmations(withdraw.CurrencySymbol) {
+		return database.DB.Model(withdraw).Updates(map[string]interface{}{
+			"block_height":  height,
+			"confirmations": confirmations,
+		}).Error
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":         withdrawConfirmed,
+			"block_height":   height,
+			"confirmations":  confirmations,
+			"confirmed_time": time.Now(),
+		}
+		if reverted {
+			updates = map[string]interface{}{
+				"status":       withdrawReverted,
+				"block_height": height,
+				"fail_reason":  "transaction reverted",
+			}
+		}
+		result := tx.Model(&models.WithdrawRecord{}).Where("id = ? AND status IN ?", withdraw.ID, withdrawInFlight).Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		// 提币金额在申请时从可用余额转入冻结，成功后扣除冻结，失败时退回可用余额
+		if reverted {
+			return unfreezeWithdrawal(tx, withdraw)
+		}
+		return tx.Model(&models.Balance{}).
+			Where("address = ? AND currency_symbol = ?", withdraw.FromAddress, withdraw.CurrencySymbol).
+			Update("frozen", gorm.Expr("frozen - ?", withdraw.TotalAmount)).Error
+	})
+}
+
+// requiredConfirmations 币种要求的确认数，币种未配置时使用默认的12
+func (cs *ConfirmationService) requiredConfirmations(symbol string) int {
+	currency, err := cs.chains.Currency(symbol)
+	if err != nil {
+		return 12
+	}
+	return requiredConfirmations(currency)
+}
+
+// requiredConfirmations 币种要求的确认数，至少为1
+func requiredConfirmations(currency *models.CurrencyChainConfig) int {
+	if currency.Confirmations < 1 {
+		return 1
+	}
+	return currency.Confirmations
+}
+
+// confirmationsAt 区块在当前高度下的确认数，所在区块计为1
+func confirmationsAt(head, height uint64) int {
+	if head < height {
+		return 0
+	}
+	return int(head - height + 1)
+}
+
+// transactionReceipt 按账单交易ID查询回执，代币充值的交易ID带有 ":日志序号" 后缀，内部交易带有 ":trace:调用位置" 后缀；尚未上链时返回 nil
+func transactionReceipt(ctx context.Context, client *ethclient.Client, txID string) (*types.Receipt, error) {
+	hash, _, _ := strings.Cut(txID, ":")
+	receipt, err := client.TransactionReceipt(ctx, common.HexToHash(hash))
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %v", err)
+	}
+	return receipt, nil
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestConfirmationsAt(t *testing.T) {
+	cases := []struct {
+		head, height uint64
+		want         int
+	}{
+		{100, 100, 1},
+		{111, 100, 12},
+		{99, 100, 0}, // 节点落后于回执所在区块
+	}
+	for _, c := range cases {
+		if got := confirmationsAt(c.head, c.height); got != c.want {
+			t.Errorf("confirmationsAt(%d, %d) = %d, want %d", c.head, c.height, got, c.want)
+		}
+	}
+}
+
+func TestRequiredConfirmations(t *testing.T) {
+	if got := requiredConfirmations(&models.CurrencyChainConfig{Confirmations: 12}); got != 12 {
+		t.Errorf("got %d, want 12", got)
+	}
+	if got := requiredConfirmations(&models.CurrencyChainConfig{}); got != 1 {
+		t.Errorf("got %d, want 1 for unset confirmations", got)
+	}
+}
+
+// fakeEVMNode 只实现 eth_chainId、eth_blockNumber 和 eth_getTransactionReceipt 的模拟节点
+type fakeEVMNode struct {
+	mu       sync.Mutex
+	head     uint64
+	receipts map[common.Hash]*types.Receipt
+}
+
+func newFakeEVMNode(t *testing.T, head uint64) (*fakeEVMNode, *EVMChainAdapter) {
+	node := &fakeEVMNode{head: head, receipts: make(map[common.Hash]*types.Receipt)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []common.Hash   `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		var result interface{}
+		switch req.Method {
+		case "eth_chainId":
+			result = "0x1"
+		case "eth_blockNumber":
+			result = fmt.Sprintf("0x%x", node.head)
+		case "eth_getTransactionReceipt":
+			if receipt, ok := node.receipts[req.Params[0]]; ok {
+				result = receipt
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	adapter, err := NewChainAdapter(context.Background(), &config.Config{}, "Ethereum", server.URL, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(adapter.Close)
+	return node, adapter.(*EVMChainAdapter)
+}
+
+// mine 记录交易回执
+func (n *fakeEVMNode) mine(hash common.Hash, height uint64, status uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.receipts[hash] = &types.Receipt{TxHash: hash, Status: status, BlockNumber: new(big.Int).SetUint64(height), Logs: []*types.Log{}}
+}
+
+func (n *fakeEVMNode) setHead(head uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.head = head
+}
+
+// newTestConfirmationService ETH 需要3个确认
+func newTestConfirmationService(t *testing.T) *ConfirmationService {
+	cfg := &config.Config{}
+	registry := NewChainRegistry(cfg)
+	registry.currencies["ETH"] = &models.CurrencyChainConfig{Symbol: "ETH", ChainType: "Ethereum", Confirmations: 3, Decimals: 18}
+	cs, err := NewConfirmationService(cfg, registry, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cs
+}
+
+func floatEquals(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestConfirmDepositCreditsAtFinality(t *testing.T) {
+	setupTestDB(t)
+	cs := newTestConfirmationService(t)
+	node, adapter := newFakeEVMNode(t, 100)
+	ctx := context.Background()
+
+	ok, reverted := common.HexToHash("0x01"), common.HexToHash("0x02")
+	for _, hash := range []common.Hash{ok, reverted} {
+		if err := saveDepositEntry(&depositEntry{
+			UserID: 7, ChainType: "Ethereum", Symbol: "ETH", From: "0xfrom", To: "0xalice",
+			TxID: hash.Hex(), UniqueID: hash.Hex(), Height: 100, Amount: 1.5, Confirmations: 1, Pending: true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	node.mine(ok, 100, types.ReceiptStatusSuccessful)
+	node.mine(reverted, 100, types.ReceiptStatusFailed)
+
+	// 2个确认：只更新确认数，不入账
+	node.setHead(101)
+	if err := cs.confirmChain(ctx, adapter); err != nil {
+		t.Fatal(err)
+	}
+	var deposit models.DepositRecord
+	database.DB.Where("tx_id = ?", ok.Hex()).First(&deposit)
+	if deposit.Status || deposit.Confirmations != 2 {
+		t.Errorf("deposit should still be pending with 2 confirmations, got status %v confirmations %d", deposit.Status, deposit.Confirmations)
+	}
+	var count int64
+	database.DB.Model(&models.Balance{}).Count(&count)
+	if count != 0 {
+		t.Error("pending deposit must not be credited")
+	}
+
+	// 3个确认：入账；同一高度和之后的检查不会重复入账
+	for _, head := range []uint64{102, 102, 103} {
+		node.setHead(head)
+		if err := cs.confirmChain(ctx, adapter); err != nil {
+			t.Fatal(err)
+		}
+	}
+	database.DB.Where("tx_id = ?", ok.Hex()).First(&deposit)
+	if !deposit.Status || deposit.ConfirmedTime == nil {
+		t.Errorf("deposit should be confirmed, got %+v", deposit)
+	}
+	var bill models.ChainBill
+	database.DB.Where("tx_id = ?", ok.Hex()).First(&bill)
+	if bill.Status != 1 {
+		t.Errorf("deposit bill should be confirmed, got status %d", bill.Status)
+	}
+	var balance models.Balance
+	database.DB.Where("address = ? AND currency_symbol = ?", "0xalice", "ETH").First(&balance)
+	if !floatEquals(balance.Balance, 1.5) {
+		t.Errorf("expected balance 1.5 credited once, got %f", balance.Balance)
+	}
+
+	// 执行失败的充值标记失败，不入账
+	var failed models.DepositRecord
+	database.DB.Where("tx_id = ?", reverted.Hex()).First(&failed)
+	if failed.Status || failed.FailReason == "" {
+		t.Errorf("reverted deposit should be failed, got %+v", failed)
+	}
+	var failedBill models.ChainBill
+	database.DB.Where("tx_id = ?", reverted.Hex()).First(&failedBill)
+	if failedBill.Status != 2 {
+		t.Errorf("reverted deposit bill should be failed, got status %d", failedBill.Status)
+	}
+}
+
+func TestConfirmOutboundBill(t *testing.T) {
+	setupTestDB(t)
+	cs := newTestConfirmationService(t)
+	node, adapter := newFakeEVMNode(t, 100)
+
+	// 热钱包的 nonce 3-5 已分配，3、4 的交易上链
+	hot := "0x00000000000000000000000000000000000000bb"
+	for nonce := uint64(3); nonce <= 5; nonce++ {
+		database.DB.Create(&models.EVMNonceReservation{ChainID: 1, Address: common.HexToAddress(hot).Hex(), Nonce: nonce, Status: nonceSent})
+	}
+	ok, reverted, unmined := common.HexToHash("0x11"), common.HexToHash("0x12"), common.HexToHash("0x13")
+	for i, hash := range []common.Hash{reverted, ok, unmined} {
+		nonce := uint64(3 + i)
+		database.DB.Create(&models.ChainBill{CurrencySymbol: "ETH", ChainType: "Ethereum", Address: hot, TxID: hash.Hex(), Type: 3, Amount: 1, Nonce: &nonce})
+	}
+	node.mine(ok, 100, types.ReceiptStatusSuccessful)
+	node.mine(reverted, 100, types.ReceiptStatusFailed)
+
+	node.setHead(102)
+	if err := cs.confirmChain(context.Background(), adapter); err != nil {
+		t.Fatal(err)
+	}
+	want := map[common.Hash]int{ok: 1, reverted: 2, unmined: 0}
+	for hash, status := range want {
+		var bill models.ChainBill
+		database.DB.Where("tx_id = ?", hash.Hex()).First(&bill)
+		if bill.Status != status {
+			t.Errorf("bill %s: got status %d, want %d", hash.Hex(), bill.Status, status)
+		}
+	}
+	// 已上链的 nonce 不再跟踪，未上链的保留
+	var reservations []models.EVMNonceReservation
+	database.DB.Find(&reservations)
+	if len(reservations) != 1 || reservations[0].Nonce != 5 {
+		t.Errorf("expected only nonce 5 to remain reserved, got %+v", reservations)
+	}
+}
+
+func TestConfirmWithdrawSettlesFrozenFunds(t *testing.T) {
+	setupTestDB(t)
+	cs := newTestConfirmationService(t)
+	node, adapter := newFakeEVMNode(t, 100)
+
+	// 两笔提币各冻结 1.001，成功的一笔广播后状态更新失败，停在签名成功
+	database.DB.Create(&models.Balance{Address: "0xuser", CurrencySymbol: "ETH", ChainType: "Ethereum", Balance: 5, Frozen: 2.002})
+	ok, reverted := common.HexToHash("0x21"), common.HexToHash("0x22")
+	for i, hash := range []common.Hash{ok, reverted} {
+		txID := hash.Hex()
+		status := withdrawSent
+		if hash == ok {
+			status = 2
+		}
+		database.DB.Create(&models.WithdrawRecord{
+			CurrencySymbol: "ETH", ChainType: "Ethereum", FromAddress: "0xuser", ToAddress: "0xdest",
+			TxID: &txID, Amount: 1, Fee: 0.001, TotalAmount: 1.001, UniqueID: fmt.Sprintf("w%d", i), Status: status,
+		})
+	}
+	node.mine(ok, 100, types.ReceiptStatusSuccessful)
+	node.mine(reverted, 100, types.ReceiptStatusFailed)
+
+	// 成功的提币未达到确认数时保持冻结，执行失败的提币立即解冻
+	node.setHead(101)
+	if err := cs.confirmChain(context.Background(), adapter); err != nil {
+		t.Fatal(err)
+	}
+	var balance models.Balance
+	database.DB.First(&balance)
+	if !floatEquals(balance.Balance, 6.001) || !floatEquals(balance.Frozen, 1.001) {
+		t.Errorf("reverted withdraw should be unfrozen, got balance %f frozen %f", balance.Balance, balance.Frozen)
+	}
+
+	// 达到确认数后扣除冻结，重复检查不会重复扣除
+	for _, head := range []uint64{102, 103} {
+		node.setHead(head)
+		if err := cs.confirmChain(context.Background(), adapter); err != nil {
+			t.Fatal(err)
+		}
+	}
+	database.DB.First(&balance)
+	if !floatEquals(balance.Balance, 6.001) || !floatEquals(balance.Frozen, 0) {
+		t.Errorf("confirmed withdraw should leave frozen, got balance %f frozen %f", balance.Balance, balance.Frozen)
+	}
+
+	want := map[common.Hash]int{ok: withdrawConfirmed, reverted: withdrawReverted}
+	for hash, status := range want {
+		var withdraw models.WithdrawRecord
+		database.DB.Where("tx_id = ?", hash.Hex()).First(&withdraw)
+		if withdraw.Status != status {
+			t.Errorf("withdraw %s: got status %d, want %d", hash.Hex(), withdraw.Status, status)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB 使用内存SQLite替换 database.DB 并迁移钱包相关的表，测试结束后恢复
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	// 内存数据库在最后一个连接关闭时删除，事务中的查询也必须使用同一个连接
+	sqlDB.SetMaxOpenConns(1)
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		sqlDB.Close()
+	})
+	if err := db.AutoMigrate(
+		&models.AddressLibrary{},
+		&models.Balance{},
+		&models.WithdrawRecord{},
+		&models.DepositRecord{},
+		&models.ChainBill{},
+		&models.CurrencyChainConfig{},
+		&models.EVMNonce{},
+		&models.EVMNonceReservation{},
+		&models.ScannedBlock{},
+	); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// depositEntry 扫描器识别出的一笔待入账充值
+type depositEntry struct {
+	UserID        uint64
+	ChainType     string
+	Symbol        string
+	From          string
+	To            string
+	TxID          string  // 账单交易ID（唯一）
+	UniqueID      string  // 充值唯一标识，用于幂等入账
+	LogIndex      *uint   // 代币转账事件的日志序号
+	TracePath     *string // 内部交易在调用树中的位置
+	Height        uint64
+	Amount        float64 // 按精度换算后的金额
+	Confirmations int     // 入账时的确认数
+	Pending       bool    // 未达到确认数，只记录不入账
+	IsInternal    bool    // 发送方也是我方地址（热钱包、冷钱包或其它用户）
+}
+
+// saveDepositEntry 在同一事务中写入充值记录、链上账单并增加余额，已入账的充值直接跳过
+// Pending 的充值只记录为确认中，由确认跟踪服务在达到确认数后入账
+func saveDepositEntry(entry *depositEntry) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.DepositRecord{}).Where("unique_id = ?", entry.UniqueID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		now := time.Now()
+		height := entry.Height
+
+		record := &models.DepositRecord{
+			UserID:         entry.UserID,
+			CurrencySymbol: entry.Symbol,
+			ChainType:      entry.ChainType,
+			FromAddress:    entry.From,
+			ToAddress:      entry.To,
+			Amount:         entry.Amount,
+			TxID:           entry.TxID,
+			UniqueID:       entry.UniqueID,
+			LogIndex:       entry.LogIndex,
+			TracePath:      entry.TracePath,
+			Status:         !entry.Pending,
+			IsInternal:     entry.IsInternal,
+			Confirmations:  entry.Confirmations,
+			BlockHeight:    &height,
+		}
+		if !entry.Pending {
+			record.ConfirmedTime = &now
+		}
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+
+		billTxID, err := depositBillTxID(tx, entry.TxID)
+		if err != nil {
+			return err
+		}
+		bill := &models.ChainBill{
+			UserID:         entry.UserID,
+			CurrencySymbol: entry.Symbol,
+			ChainType:      entry.ChainType,
+			Address:        entry.To,
+			TxID:           billTxID,
+			Type:           1,
+			Amount:         entry.Amount,
+			BlockHeight:    &height,
+			Confirmations:  entry.Confirmations,
+			Status:         1,
+		}
+		if entry.Pending {
+			bill.Status = 0
+		}
+		if err := tx.Create(bill).Error; err != nil {
+			return err
+		}
+
+		if entry.Pending {
+			return nil
+		}
+		return creditBalance(tx, entry.To, entry.Symbol, entry.ChainType, entry.Amount)
+	})
+}
+
+// confirmDepositEntry 确认中的充值达到确认数后标记完成并增加余额，返回是否本次入账；已完成的充值直接跳过
+func confirmDepositEntry(record *models.DepositRecord, height uint64, confirmations int) (bool, error) {
+	credited := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.DepositRecord{}).Where("id = ? AND status = ?", record.ID, false).Updates(map[string]interface{}{
+			"status":         true,
+			"block_height":   height,
+			"confirmations":  confirmations,
+			"confirmed_time": time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := depositBills(tx, record.TxID).Updates(map[string]interface{}{
+			"status":        1,
+			"block_height":  height,
+			"confirmations": confirmations,
+		}).Error; err != nil {
+			return err
+		}
+		credited = true
+		return creditBalance(tx, record.ToAddress, record.CurrencySymbol, record.ChainType, record.Amount)
+	})
+	return credited, err
+}
+
+// internalDepositSuffix 我方地址之间转账时，入账账单交易ID的后缀，转出方的账单已使用交易哈希
+const internalDepositSuffix = ":in"
+
+// depositBillTxID 充值账单的交易ID，交易已有转出账单（我方地址之间的转账）时追加后缀
+func depositBillTxID(tx *gorm.DB, txID string) (string, error) {
+	var count int64
+	if err := tx.Model(&models.ChainBill{}).Where("tx_id = ?", txID).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return txID + internalDepositSuffix, nil
+	}
+	return txID, nil
+}
+
+// depositBills 充值记录对应的充值账单
+func depositBills(tx *gorm.DB, txID string) *gorm.DB {
+	return tx.Model(&models.ChainBill{}).Where("tx_id IN ? AND type = ?", []string{txID, txID + internalDepositSuffix}, 1)
+}
+
+// creditBalance 增加地址余额，没有余额记录时创建
+func creditBalance(tx *gorm.DB, address, symbol, chainType string, amount float64) error {
+	var balance models.Balance
+	err := tx.Where("address = ? AND currency_symbol = ?", address, symbol).First(&balance).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		balance = models.Balance{
+			Address:        address,
+			CurrencySymbol: symbol,
+			ChainType:      chainType,
+			Balance:        amount,
+		}
+		return tx.Create(&balance).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&balance).Update("balance", gorm.Expr("balance + ?", amount)).Error
+}
+
+// unitsToFloat 链上最小单位金额按精度换算
+func unitsToFloat(amount *big.Int, decimals int) float64 {
+	value := new(big.Float).SetInt(amount)
+	value.Quo(value, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	result, _ := value.Float64()
+	return result
+}
+
+// floatToUnits 金额按精度换算为链上最小单位，先格式化为十进制字符串避免浮点误差
+func floatToUnits(amount float64, decimals int) *big.Int {
+	units, _ := new(big.Int).SetString(strings.Replace(strconv.FormatFloat(amount, 'f', decimals, 64), ".", "", 1), 10)
+	if units == nil {
+		return new(big.Int)
+	}
+	return units
+}
@@ -4,34 +4,47 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
+	"log"
 	"math/big"
+	"strings"
 	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
 	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"gorm.io/gorm"
 )
 
-// EthereumWalletService 以太坊钱包服务
+// tokenTransferGas 代币转账预留的gas上限，向代币提币地址补充手续费时按此估算
+const tokenTransferGas = 100000
+
+// EthereumWalletService EVM链钱包服务，币种所属链的节点连接由链适配器注册表提供
 type EthereumWalletService struct {
 	config *config.Config
-	client *ethclient.Client
+	chains *ChainRegistry
+	signer Signer
+	gas    *GasOracleService
+	nonces *NonceManager
 }
 
-// NewEthereumWalletService 创建新的以太坊钱包服务
-func NewEthereumWalletService(cfg *config.Config) (*EthereumWalletService, error) {
-	// 连接到以太坊测试网
-	client, err := ethclient.Dial(cfg.Ethereum.GetTestnetRPCURL())
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum testnet: %v", err)
+// NewEthereumWalletService 创建新的EVM链钱包服务，gas 为空时提币直接估算手续费且不限制上限
+func NewEthereumWalletService(cfg *config.Config, chains *ChainRegistry, signer Signer, gas *GasOracleService, nonces *NonceManager) (*EthereumWalletService, error) {
+	if chains == nil {
+		return nil, fmt.Errorf("chain registry is required")
 	}
 
 	return &EthereumWalletService{
 		config: cfg,
-		client: client,
+		chains: chains,
+		signer: signer,
+		gas:    gas,
+		nonces: nonces,
 	}, nil
 }
 
@@ -64,40 +77,71 @@ func (ews *EthereumWalletService) GenerateAddress(index uint32) (*models.Address
 	return addressModel, nil
 }
 
-// GetBalance 获取地址余额
-func (ews *EthereumWalletService) GetBalance(address string) (*big.Int, error) {
+// GetBalance 获取地址在币种所属链上的原生币余额
+func (ews *EthereumWalletService) GetBalance(symbol, address string) (*big.Int, error) {
+	client, err := ews.chains.EVMClientForSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
 	account := common.HexToAddress(address)
-	balance, err := ews.client.BalanceAt(context.Background(), account, nil)
+	balance, err := client.BalanceAt(context.Background(), account, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get balance: %v", err)
 	}
 	return balance, nil
 }
 
-// GetTransactionCount 获取地址交易数量
-func (ews *EthereumWalletService) GetTransactionCount(address string) (uint64, error) {
+// GetTransactionCount 获取地址在币种所属链上的交易数量
+func (ews *EthereumWalletService) GetTransactionCount(symbol, address string) (uint64, error) {
+	client, err := ews.chains.EVMClientForSymbol(symbol)
+	if err != nil {
+		return 0, err
+	}
 	account := common.HexToAddress(address)
-	nonce, err := ews.client.PendingNonceAt(context.Background(), account)
+	nonce, err := client.PendingNonceAt(context.Background(), account)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get transaction count: %v", err)
 	}
 	return nonce, nil
 }
 
-// CreateWithdrawal 创建提现交易
-func (ews *EthereumWalletService) CreateWithdrawal(fromAddress, toAddress string, amount *big.Int, gasPrice *big.Int) (*types.Transaction, error) {
-	// 获取发送方私钥（在实际应用中应该从安全的存储中获取）
-	privateKey, err := ews.getPrivateKeyByAddress(fromAddress)
+// CreateWithdrawal 在币种所属链上创建提现交易，币种配置为传统交易时使用gasPrice，否则创建EIP-1559交易
+// 手续费超过提币价格上限时返回 blockchain.ErrEVMFeeAboveCap，提币记录保持待处理，稍后重试
+func (ews *EthereumWalletService) CreateWithdrawal(fromAddress, toAddress string, amount *big.Int, currency *models.CurrencyChainConfig) (*types.Transaction, error) {
+	// 获取发送方地址记录，私钥只存在于签名器中
+	if ews.signer == nil {
+		return nil, fmt.Errorf("signer is not configured")
+	}
+	if currency == nil {
+		return nil, fmt.Errorf("currency is required")
+	}
+	client, err := ews.chains.EVMClientForSymbol(currency.Symbol)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get private key: %v", err)
+		return nil, err
+	}
+	fromAddr, err := lookupAddress(fromAddress, currency.ChainType)
+	if err != nil {
+		return nil, err
 	}
 
-	// 获取nonce
-	nonce, err := ews.GetTransactionCount(fromAddress)
+	tx, lease, chainID, err := ews.buildTransfer(context.Background(), client, fromAddress, toAddress, amount, currency, GasPurposeWithdrawal)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce: %v", err)
+		return nil, err
+	}
+
+	// 签名交易
+	signedTx, err := ews.signer.SignTx(context.Background(), fromAddr, tx, chainID)
+	if err != nil {
+		lease.Release(context.Background())
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
 	}
 
+	return signedTx, nil
+}
+
+// buildTransfer 估算gas并按用途对应的档位取价，分配nonce后构建未签名的原生币转账交易
+// 调用方在签名或广播失败时释放返回的nonce，广播成功后提交
+func (ews *EthereumWalletService) buildTransfer(ctx context.Context, client *ethclient.Client, fromAddress, toAddress string, amount *big.Int, currency *models.CurrencyChainConfig, purpose string) (*types.Transaction, *NonceLease, *big.Int, error) {
 	// 估算gas limit
 	msg := ethereum.CallMsg{
 		From:  common.HexToAddress(fromAddress),
@@ -111,56 +155,320 @@ func (ews *EthereumWalletService) CreateWithdrawal(fromAddress, toAddress string
 		msg.To = &toAddr
 	}
 
-	gasLimit, err := ews.client.EstimateGas(context.Background(), msg)
+	gasLimit, err := client.EstimateGas(ctx, msg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to estimate gas: %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to estimate gas: %v", err)
 	}
 
-	// 创建交易
-	tx := types.NewTransaction(
-		nonce,
-		common.HexToAddress(toAddress),
-		amount,
-		gasLimit,
-		gasPrice,
-		nil,
-	)
+	// 估算手续费
+	fee, err := ews.evmFee(ctx, client, currency, purpose)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	// 签名交易
-	chainID, err := ews.client.ChainID(context.Background())
+	// 创建交易，链ID优先使用币种配置（注册表已校验节点）
+	chainID := big.NewInt(currency.ChainID)
+	if currency.ChainID == 0 {
+		if chainID, err = client.ChainID(ctx); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get chain ID: %v", err)
+		}
+	}
+
+	// 分配nonce，由调用方记录广播结果
+	lease, err := reserveNonce(ctx, ews.nonces, client, chainID.Int64(), fromAddress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+		return nil, nil, nil, err
 	}
+	tx := blockchain.NewEVMTransaction(chainID, lease.Nonce, common.HexToAddress(toAddress), amount, gasLimit, nil, fee)
+	return tx, lease, chainID, nil
+}
 
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+// SendWithdrawal 签名并广播待处理的原生币提币，成功后记录提币账单，由确认服务跟踪上链结果
+// 手续费超过提币价格上限时返回 blockchain.ErrEVMFeeAboveCap，提币保持待处理；签名失败或广播被节点明确拒绝时冻结的提币金额退回可用余额
+func (ews *EthereumWalletService) SendWithdrawal(ctx context.Context, withdraw *models.WithdrawRecord) (*types.Transaction, error) {
+	if withdraw.Status != 0 && withdraw.Status != 1 {
+		return nil, fmt.Errorf("withdraw %d is not pending", withdraw.ID)
+	}
+	if ews.signer == nil {
+		return nil, fmt.Errorf("signer is not configured")
+	}
+	currency, err := ews.chains.Currency(withdraw.CurrencySymbol)
 	if err != nil {
+		return nil, err
+	}
+	if currency.TokenAddress != nil && *currency.TokenAddress != "" {
+		return nil, fmt.Errorf("token withdrawals are not supported: %s", currency.Symbol)
+	}
+	client, err := ews.chains.EVMClientForSymbol(currency.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	fromAddr, err := lookupAddress(withdraw.FromAddress, currency.ChainType)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := floatToUnits(withdraw.Amount, currency.Decimals)
+	tx, lease, chainID, err := ews.buildTransfer(ctx, client, withdraw.FromAddress, withdraw.ToAddress, amount, currency, GasPurposeWithdrawal)
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, err := ews.signer.SignTx(ctx, fromAddr, tx, chainID)
+	if err != nil {
+		lease.Release(ctx)
+		if err := failWithdrawal(withdraw, 11, err); err != nil { // 签名失败
+			log.Printf("Failed to record failure of withdraw %d: %v", withdraw.ID, err)
+		}
 		return nil, fmt.Errorf("failed to sign transaction: %v", err)
 	}
 
+	// 签名结果和提币账单一起写入，同一提币只有一个请求能继续广播；
+	// 账单在广播前落库，广播结果不明时卡住交易监控也能重新广播或替换
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		lease.Release(ctx)
+		return nil, fmt.Errorf("failed to encode transaction: %v", err)
+	}
+	encoded := hexutil.Encode(raw)
+	txID := signedTx.Hash().Hex()
+	bill := &models.ChainBill{
+		UserID:         withdraw.UserID,
+		CurrencySymbol: withdraw.CurrencySymbol,
+		ChainType:      withdraw.ChainType,
+		Address:        withdraw.FromAddress,
+		TxID:           txID,
+		Type:           2, // 提币
+		Amount:         withdraw.Amount,
+		Fee:            withdraw.Fee,
+		Status:         0, // 确认中
+	}
+	setOutboundTx(bill, signedTx)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.WithdrawRecord{}).
+			Where("id = ? AND status IN ?", withdraw.ID, []int{0, 1}).
+			Updates(map[string]interface{}{
+				"post_sign_data": encoded,
+				"tx_id":          txID,
+				"status":         2, // 签名成功
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to save signed transaction: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("withdraw %d is already being sent", withdraw.ID)
+		}
+		return tx.Create(bill).Error
+	})
+	if err != nil {
+		lease.Release(ctx)
+		return nil, err
+	}
+	withdraw.PostSignData = &encoded
+	withdraw.TxID = &txID
+	withdraw.Status = 2
+
+	if sendErr := client.SendTransaction(ctx, signedTx); sendErr != nil {
+		if isTxRejected(sendErr) {
+			lease.Release(ctx)
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Unscoped().Delete(bill).Error; err != nil {
+					return err
+				}
+				return failWithdrawalFrom(tx, withdraw, withdrawUnsent, 12, sendErr.Error()) // 发送失败
+			})
+			if err != nil {
+				log.Printf("Failed to record failure of withdraw %d: %v", withdraw.ID, err)
+			}
+			return nil, fmt.Errorf("failed to send transaction: %v", sendErr)
+		}
+		// 超时、节点切换或 already known 时交易可能已在内存池，按已发送处理，由卡住交易监控重新广播或替换
+		log.Printf("Broadcast of withdraw %d (%s) is uncertain, tracking it as sent: %v", withdraw.ID, txID, sendErr)
+	}
+	lease.Commit(txID)
+
+	// 状态更新失败时提币停在签名成功，确认服务同样会跟踪带交易ID的签名成功提币
+	if err := database.DB.Model(&models.WithdrawRecord{}).
+		Where("id = ? AND status = ?", withdraw.ID, 2).
+		Update("status", withdrawSent).Error; err != nil {
+		log.Printf("Failed to mark withdraw %d (%s) as sent: %v", withdraw.ID, txID, err)
+	} else {
+		withdraw.Status = withdrawSent
+	}
+
+	log.Printf("Withdraw %d sent: %s, %f %s to %s", withdraw.ID, txID, withdraw.Amount, withdraw.CurrencySymbol, withdraw.ToAddress)
 	return signedTx, nil
 }
 
-// SendTransaction 发送交易
-func (ews *EthereumWalletService) SendTransaction(tx *types.Transaction) error {
-	err := ews.client.SendTransaction(context.Background(), tx)
+// TopUpWithdrawalGas 从配置的付款地址向待转手续费的代币提币地址转入原生币，补足代币转账所需的手续费
+// 补充交易按补充手续费档位取价，超过价格上限时返回 blockchain.ErrEVMFeeAboveCap，提币保持待转手续费；
+// 地址的待确认余额已足够或补充交易已广播时提币进入待签名；签名失败或广播被节点明确拒绝时提币标记为待转手续失败并解冻
+func (ews *EthereumWalletService) TopUpWithdrawalGas(ctx context.Context, withdraw *models.WithdrawRecord) (*types.Transaction, error) {
+	if withdraw.Status != 0 {
+		return nil, fmt.Errorf("withdraw %d is not waiting for gas", withdraw.ID)
+	}
+	if ews.signer == nil {
+		return nil, fmt.Errorf("signer is not configured")
+	}
+	currency, err := ews.chains.Currency(withdraw.CurrencySymbol)
 	if err != nil {
+		return nil, err
+	}
+	if currency.TokenAddress == nil || *currency.TokenAddress == "" {
+		return nil, fmt.Errorf("native withdrawals pay their own gas: %s", currency.Symbol)
+	}
+	native, err := ews.chains.NativeCurrency(currency.ChainType)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ews.chains.EVMClientForSymbol(currency.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按提币档位估算代币转账的最高手续费，待确认余额包含已广播的补充交易，避免重复补充
+	fee, err := ews.evmFee(ctx, client, currency, GasPurposeWithdrawal)
+	if err != nil {
+		return nil, err
+	}
+	need := fee.MaxCost(tokenTransferGas)
+	balance, err := client.PendingBalanceAt(ctx, common.HexToAddress(withdraw.FromAddress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %v", err)
+	}
+	if balance.Cmp(need) >= 0 {
+		return nil, awaitWithdrawSignature(database.DB, withdraw)
+	}
+	amount := new(big.Int).Sub(need, balance)
+
+	payer := ews.config.Wallet.HotWallet.GasPayerFor(currency.ChainType)
+	if payer == "" {
+		return nil, fmt.Errorf("no gas payer configured for %s", currency.ChainType)
+	}
+	hot, err := lookupAddress(payer, currency.ChainType)
+	if err != nil {
+		return nil, err
+	}
+	tx, lease, chainID, err := ews.buildTransfer(ctx, client, hot.Address, withdraw.FromAddress, amount, native, GasPurposeTopUp)
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, err := ews.signer.SignTx(ctx, hot, tx, chainID)
+	if err != nil {
+		lease.Release(ctx)
+		if err := failWithdrawal(withdraw, 10, err); err != nil { // 待转手续失败
+			log.Printf("Failed to record failure of withdraw %d: %v", withdraw.ID, err)
+		}
+		return nil, fmt.Errorf("failed to sign top-up: %v", err)
+	}
+	txID := signedTx.Hash().Hex()
+
+	if sendErr := client.SendTransaction(ctx, signedTx); sendErr != nil {
+		if isTxRejected(sendErr) {
+			lease.Release(ctx)
+			if err := failWithdrawal(withdraw, 10, sendErr); err != nil { // 待转手续失败
+				log.Printf("Failed to record failure of withdraw %d: %v", withdraw.ID, err)
+			}
+			return nil, fmt.Errorf("failed to send top-up: %v", sendErr)
+		}
+		log.Printf("Broadcast of gas top-up %s for withdraw %d is uncertain, tracking it as sent: %v", txID, withdraw.ID, sendErr)
+	}
+	lease.Commit(txID)
+
+	// 记录手续费账单，由确认服务和卡住交易监控跟踪补充交易
+	bill := &models.ChainBill{
+		CurrencySymbol: native.Symbol,
+		ChainType:      native.ChainType,
+		Address:        hot.Address,
+		TxID:           txID,
+		Type:           4, // 手续费
+		Amount:         unitsToFloat(amount, native.Decimals),
+		Status:         0, // 确认中
+	}
+	setOutboundTx(bill, signedTx)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(bill).Error; err != nil {
+			return err
+		}
+		return awaitWithdrawSignature(tx, withdraw)
+	})
+	if err != nil {
+		log.Printf("Failed to record gas top-up %s for withdraw %d: %v", txID, withdraw.ID, err)
+	}
+
+	log.Printf("Gas top-up %s sent: %f %s from %s to %s for withdraw %d", txID, bill.Amount, native.Symbol, hot.Address, withdraw.FromAddress, withdraw.ID)
+	return signedTx, nil
+}
+
+// awaitWithdrawSignature 手续费已足够的提币从待转手续费进入待签名
+func awaitWithdrawSignature(db *gorm.DB, withdraw *models.WithdrawRecord) error {
+	result := db.Model(&models.WithdrawRecord{}).Where("id = ? AND status = ?", withdraw.ID, 0).Update("status", 1) // 待签名
+	if result.Error != nil {
+		return fmt.Errorf("failed to update withdraw %d status: %v", withdraw.ID, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		withdraw.Status = 1
+	}
+	return nil
+}
+
+// isTxRejected 判断广播错误是否为节点明确拒绝，此时交易不会进入内存池；超时等其他错误下交易可能已经广播
+func isTxRejected(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, reason := range []string{"nonce too low", "insufficient funds", "underpriced", "intrinsic gas too low", "exceeds block gas limit"} {
+		if strings.Contains(msg, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// evmFee 转账手续费：配置了Gas预言机时按用途对应的档位取价并检查上限，否则直接估算
+func (ews *EthereumWalletService) evmFee(ctx context.Context, client *ethclient.Client, currency *models.CurrencyChainConfig, purpose string) (*blockchain.EVMFee, error) {
+	if ews.gas != nil {
+		return ews.gas.Fee(ctx, currency.ChainType, purpose)
+	}
+	return blockchain.SuggestEVMFee(ctx, client, currency.LegacyTx)
+}
+
+// SendTransaction 在币种所属链上发送交易，并向nonce管理器记录广播结果
+func (ews *EthereumWalletService) SendTransaction(symbol string, tx *types.Transaction) error {
+	client, err := ews.chains.EVMClientForSymbol(symbol)
+	if err != nil {
+		return err
+	}
+	lease := &NonceLease{client: client, ChainID: tx.ChainId().Int64(), Nonce: tx.Nonce()}
+	if ews.nonces != nil {
+		if from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+			lease.manager, lease.Address = ews.nonces, from.Hex()
+		}
+	}
+
+	if err := client.SendTransaction(context.Background(), tx); err != nil {
+		lease.Release(context.Background())
 		return fmt.Errorf("failed to send transaction: %v", err)
 	}
+	lease.Commit(tx.Hash().Hex())
 	return nil
 }
 
-// GetTransactionStatus 获取交易状态
-func (ews *EthereumWalletService) GetTransactionStatus(txHash string) (*models.ChainBill, error) {
+// GetTransactionStatus 获取币种所属链上的交易状态
+func (ews *EthereumWalletService) GetTransactionStatus(symbol, txHash string) (*models.ChainBill, error) {
+	client, err := ews.chains.EVMClientForSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
 	hash := common.HexToHash(txHash)
 
 	// 获取交易
-	_, isPending, err := ews.client.TransactionByHash(context.Background(), hash)
+	_, isPending, err := client.TransactionByHash(context.Background(), hash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction: %v", err)
 	}
 
 	// 获取交易收据
-	receipt, err := ews.client.TransactionReceipt(context.Background(), hash)
+	receipt, err := client.TransactionReceipt(context.Background(), hash)
 	if err != nil {
 		if isPending {
 			// 交易仍在待处理状态
@@ -176,7 +484,7 @@ func (ews *EthereumWalletService) GetTransactionStatus(txHash string) (*models.C
 	}
 
 	// 获取当前区块号
-	currentBlock, err := ews.client.BlockNumber(context.Background())
+	currentBlock, err := client.BlockNumber(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current block number: %v", err)
 	}
@@ -204,17 +512,5 @@ func (ews *EthereumWalletService) GetTransactionStatus(txHash string) (*models.C
 	return chainBill, nil
 }
 
-// getPrivateKeyByAddress 根据地址获取私钥
-func (ews *EthereumWalletService) getPrivateKeyByAddress(address string) (*ecdsa.PrivateKey, error) {
-	// 这里需要实现从地址索引到私钥的映射
-	// 在实际应用中，这通常通过数据库查询或内存映射实现
-	// 暂时返回错误，需要实现完整的地址管理
-	return nil, fmt.Errorf("private key retrieval not implemented yet")
-}
-
-// Close 关闭客户端连接
-func (ews *EthereumWalletService) Close() {
-	if ews.client != nil {
-		ews.client.Close()
-	}
-}
+// Close 关闭服务，节点连接由链适配器注册表统一关闭
+func (ews *EthereumWalletService) Close() {}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// keySigner 用固定私钥签名的测试签名器
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *keySigner) SignHash(ctx context.Context, addr *models.AddressLibrary, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
+
+func (s *keySigner) SignTx(ctx context.Context, addr *models.AddressLibrary, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+func TestSendWithdrawal(t *testing.T) {
+	setupTestDB(t)
+	// 模拟以太坊节点，gasPrice 10 gwei，nonce 从7开始，sendError 非空时广播返回该错误
+	var sendError string
+	var sent int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var result string
+		switch req.Method {
+		case "eth_chainId":
+			result = `"0x1"`
+		case "eth_estimateGas":
+			result = `"0x5208"`
+		case "eth_gasPrice":
+			result = fmt.Sprintf(`"0x%x"`, 10000000000)
+		case "eth_getTransactionCount":
+			result = fmt.Sprintf(`"0x%x"`, 7+sent)
+		case "eth_sendRawTransaction":
+			if sendError != "" {
+				w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":-32000,"message":"` + sendError + `"}}`))
+				return
+			}
+			sent++
+			result = `"0x0000000000000000000000000000000000000000000000000000000000000001"`
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":-32601,"message":"method not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + result + `}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	adapter, err := NewChainAdapter(context.Background(), cfg, "Ethereum", server.URL, 1)
+	if err != nil {
+		t.Fatalf("NewChainAdapter failed: %v", err)
+	}
+	defer adapter.Close()
+	registry := NewChainRegistry(cfg)
+	registry.adapters = map[string]ChainAdapter{"ethereum": adapter}
+	registry.currencies["ETH"] = &models.CurrencyChainConfig{Symbol: "ETH", ChainType: "Ethereum", ChainID: 1, Decimals: 18, LegacyTx: true}
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	database.DB.Create(&models.AddressLibrary{Address: from, ChainType: "Ethereum"})
+	database.DB.Create(&models.Balance{Address: from, CurrencySymbol: "ETH", ChainType: "Ethereum", Balance: 3, Frozen: 2.503})
+	withdraws := make([]*models.WithdrawRecord, 3)
+	for i := range withdraws {
+		withdraws[i] = &models.WithdrawRecord{
+			CurrencySymbol: "ETH", ChainType: "Ethereum", FromAddress: from, ToAddress: "0x000000000000000000000000000000000000dEaD",
+			Amount: 1, Fee: 0.001, TotalAmount: 1.001, UniqueID: fmt.Sprintf("w%d", i),
+		}
+		if i == 2 {
+			withdraws[i].Amount, withdraws[i].TotalAmount = 0.5, 0.501
+		}
+		database.DB.Create(withdraws[i])
+	}
+
+	ews, _ := NewEthereumWalletService(cfg, registry, &keySigner{key: key}, nil, nil)
+	ctx := context.Background()
+
+	// 广播成功：提币改为发送成功并记录带nonce的提币账单，冻结金额等待确认后扣除
+	tx, err := ews.SendWithdrawal(ctx, withdraws[0])
+	if err != nil {
+		t.Fatalf("SendWithdrawal failed: %v", err)
+	}
+	if tx.Nonce() != 7 || tx.Value().Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("unexpected transaction: nonce %d value %v", tx.Nonce(), tx.Value())
+	}
+	var withdraw models.WithdrawRecord
+	database.DB.First(&withdraw, withdraws[0].ID)
+	if withdraw.Status != 3 || withdraw.TxID == nil || *withdraw.TxID != tx.Hash().Hex() {
+		t.Errorf("withdraw should be sent with tx %s, got status %d", tx.Hash().Hex(), withdraw.Status)
+	}
+	var bill models.ChainBill
+	database.DB.Where("tx_id = ?", tx.Hash().Hex()).First(&bill)
+	if bill.Type != 2 || bill.Status != 0 || bill.Nonce == nil || *bill.Nonce != 7 || bill.RawTx == nil {
+		t.Errorf("unexpected withdraw bill: %+v", bill)
+	}
+
+	// 已发送的提币不会重复广播
+	if _, err := ews.SendWithdrawal(ctx, withdraws[0]); err == nil || sent != 1 {
+		t.Errorf("expected sent withdraw to be rejected, got %v after %d broadcasts", err, sent)
+	}
+
+	// 节点明确拒绝：提币标记发送失败，冻结金额退回，不留下提币账单
+	sendError = "insufficient funds for gas * price + value"
+	if _, err := ews.SendWithdrawal(ctx, withdraws[1]); err == nil {
+		t.Fatal("expected broadcast failure")
+	}
+	var failed models.WithdrawRecord
+	database.DB.First(&failed, withdraws[1].ID)
+	if failed.Status != 12 || failed.FailReason == "" {
+		t.Errorf("withdraw should fail with status 12, got %d", failed.Status)
+	}
+	var balance models.Balance
+	database.DB.First(&balance)
+	if !floatEquals(balance.Balance, 4.001) || !floatEquals(balance.Frozen, 1.502) {
+		t.Errorf("failed withdraw should be unfrozen, got balance %f frozen %f", balance.Balance, balance.Frozen)
+	}
+	var count int64
+	database.DB.Model(&models.ChainBill{}).Where("tx_id = ?", *failed.TxID).Unscoped().Count(&count)
+	if count != 0 {
+		t.Errorf("rejected withdraw should not leave a bill")
+	}
+
+	// 广播结果不明：交易可能已在内存池，提币按发送成功跟踪，冻结金额不退回
+	sendError = "request timed out"
+	tx, err = ews.SendWithdrawal(ctx, withdraws[2])
+	if err != nil {
+		t.Fatalf("uncertain broadcast should be tracked as sent: %v", err)
+	}
+	var uncertain models.WithdrawRecord
+	database.DB.First(&uncertain, withdraws[2].ID)
+	if uncertain.Status != 3 || uncertain.TxID == nil || *uncertain.TxID != tx.Hash().Hex() {
+		t.Errorf("withdraw should be sent with tx %s, got status %d", tx.Hash().Hex(), uncertain.Status)
+	}
+	database.DB.Model(&models.ChainBill{}).Where("tx_id = ? AND status = ?", tx.Hash().Hex(), 0).Count(&count)
+	if count != 1 {
+		t.Errorf("uncertain withdraw should have a pending bill for the tx monitor")
+	}
+	database.DB.First(&balance)
+	if !floatEquals(balance.Frozen, 1.502) {
+		t.Errorf("uncertain withdraw should stay frozen, got frozen %f", balance.Frozen)
+	}
+}
+
+func TestTopUpWithdrawalGas(t *testing.T) {
+	setupTestDB(t)
+	// 模拟以太坊节点，gasPrice 10 gwei，提币地址余额为 balance，sendError 非空时广播返回该错误
+	var balance int64
+	var sendError string
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var result string
+		switch req.Method {
+		case "eth_chainId":
+			result = `"0x1"`
+		case "eth_estimateGas":
+			result = `"0x5208"`
+		case "eth_gasPrice":
+			result = fmt.Sprintf(`"0x%x"`, 10000000000)
+		case "eth_getTransactionCount":
+			result = fmt.Sprintf(`"0x%x"`, len(sent))
+		case "eth_getBalance":
+			result = fmt.Sprintf(`"0x%x"`, balance)
+		case "eth_sendRawTransaction":
+			if sendError != "" {
+				w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":-32000,"message":"` + sendError + `"}}`))
+				return
+			}
+			var raw string
+			json.Unmarshal(req.Params[0], &raw)
+			sent = append(sent, raw)
+			result = fmt.Sprintf(`"0x%064x"`, len(sent))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":-32601,"message":"method not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + result + `}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	adapter, err := NewChainAdapter(context.Background(), cfg, "Ethereum", server.URL, 1)
+	if err != nil {
+		t.Fatalf("NewChainAdapter failed: %v", err)
+	}
+	defer adapter.Close()
+	token := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	registry := NewChainRegistry(cfg)
+	registry.adapters = map[string]ChainAdapter{"ethereum": adapter}
+	registry.currencies["ETH"] = &models.CurrencyChainConfig{Symbol: "ETH", ChainType: "Ethereum", ChainID: 1, Decimals: 18, LegacyTx: true}
+	registry.currencies["USDT"] = &models.CurrencyChainConfig{Symbol: "USDT", ChainType: "Ethereum", ChainID: 1, Decimals: 6, LegacyTx: true, TokenAddress: &token}
+
+	key, _ := crypto.GenerateKey()
+	hot := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	user := "0x000000000000000000000000000000000000bEEF"
+	database.DB.Create(&models.AddressLibrary{Address: hot, ChainType: "Ethereum"})
+	cfg.Wallet.HotWallet.GasPayers = map[string]string{"ethereum": hot}
+	database.DB.Create(&models.Balance{Address: user, CurrencySymbol: "USDT", ChainType: "Ethereum", Balance: 0, Frozen: 30})
+	withdraws := make([]*models.WithdrawRecord, 3)
+	for i := range withdraws {
+		withdraws[i] = &models.WithdrawRecord{
+			CurrencySymbol: "USDT", ChainType: "Ethereum", FromAddress: user, ToAddress: "0x000000000000000000000000000000000000dEaD",
+			Amount: 10, TotalAmount: 10, UniqueID: fmt.Sprintf("w%d", i),
+		}
+		database.DB.Create(withdraws[i])
+	}
+
+	ews, _ := NewEthereumWalletService(cfg, registry, &keySigner{key: key}, nil, nil)
+	ctx := context.Background()
+
+	// 地址已有足够手续费：不发送补充交易，直接进入待签名
+	balance = 2e15
+	if tx, err := ews.TopUpWithdrawalGas(ctx, withdraws[0]); err != nil || tx != nil {
+		t.Fatalf("expected no top-up, got %v, %v", tx, err)
+	}
+	if withdraws[0].Status != 1 || len(sent) != 0 {
+		t.Errorf("withdraw should await signature without a top-up, got status %d after %d broadcasts", withdraws[0].Status, len(sent))
+	}
+
+	// 手续费不足：付款地址补足 100000 gas * 10 gwei 与余额的差额，记录手续费账单
+	balance = 4e14
+	tx, err := ews.TopUpWithdrawalGas(ctx, withdraws[1])
+	if err != nil {
+		t.Fatalf("TopUpWithdrawalGas failed: %v", err)
+	}
+	if tx.To() == nil || tx.To().Hex() != user || tx.Value().Cmp(big.NewInt(6e14)) != 0 {
+		t.Errorf("unexpected top-up: to %v value %v", tx.To(), tx.Value())
+	}
+	var withdraw models.WithdrawRecord
+	database.DB.First(&withdraw, withdraws[1].ID)
+	if withdraw.Status != 1 {
+		t.Errorf("withdraw should await signature after top-up, got status %d", withdraw.Status)
+	}
+	var bill models.ChainBill
+	database.DB.Where("tx_id = ?", tx.Hash().Hex()).First(&bill)
+	if bill.Type != 4 || bill.CurrencySymbol != "ETH" || bill.Address != hot || bill.RawTx == nil || !floatEquals(bill.Amount, 0.0006) {
+		t.Errorf("unexpected top-up bill: %+v", bill)
+	}
+
+	// 广播被拒绝：提币标记为待转手续失败并解冻
+	sendError = "insufficient funds for gas * price + value"
+	if _, err := ews.TopUpWithdrawalGas(ctx, withdraws[2]); err == nil {
+		t.Fatal("expected top-up failure")
+	}
+	var failed models.WithdrawRecord
+	database.DB.First(&failed, withdraws[2].ID)
+	if failed.Status != 10 || failed.FailReason == "" {
+		t.Errorf("withdraw should fail with status 10, got %d", failed.Status)
+	}
+	var userBalance models.Balance
+	database.DB.First(&userBalance)
+	if !floatEquals(userBalance.Balance, 10) || !floatEquals(userBalance.Frozen, 20) {
+		t.Errorf("failed withdraw should be unfrozen, got balance %f frozen %f", userBalance.Balance, userBalance.Frozen)
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/pkg/blockchain"
+)
+
+// Gas用途，分别对应 gas_oracle 中的策略配置
+const (
+	GasPurposeCollection = "collection"
+	GasPurposeWithdrawal = "withdrawal"
+	GasPurposeTopUp      = "top_up"
+)
+
+// GasFeeLevels 一条EVM链当前的慢/标准/快三档手续费
+type GasFeeLevels struct {
+	ChainType string `json:"chain_type"`
+	*blockchain.EVMFeeLevels
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GasOracleService Gas预言机，定期采样各EVM链最近区块的手续费
+// 归集、提币和补充手续费按各自的档位取价，超过该用途配置的链价格上限时推迟发送
+type GasOracleService struct {
+	config *config.Config
+	chains *ChainRegistry
+
+	mu        sync.RWMutex
+	levels    map[string]*GasFeeLevels // 链类型(小写) -> 手续费档位
+	isRunning bool
+	stopChan  chan bool
+}
+
+// NewGasOracleService 创建Gas预言机服务
+func NewGasOracleService(cfg *config.Config, chains *ChainRegistry) (*GasOracleService, error) {
+	if chains == nil {
+		return nil, fmt.Errorf("chain registry is required")
+	}
+	return &GasOracleService{
+		config:   cfg,
+		chains:   chains,
+		levels:   make(map[string]*GasFeeLevels),
+		stopChan: make(chan bool),
+	}, nil
+}
+
+// Start 开始定期采样
+func (g *GasOracleService) Start() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.isRunning {
+		return fmt.Errorf("gas oracle is already running")
+	}
+
+	g.isRunning = true
+	go g.sampleLoop()
+	return nil
+}
+
+// Stop 停止采样
+func (g *GasOracleService) Stop() {
+	g.mu.Lock()
+	running := g.isRunning
+	g.isRunning = false
+	g.mu.Unlock()
+
+	if running {
+		g.stopChan <- true
+	}
+}
+
+// sampleLoop 采样主循环
+func (g *GasOracleService) sampleLoop() {
+	g.Refresh(context.Background())
+
+	ticker := time.NewTicker(time.Duration(g.config.GasOracle.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.stopChan:
+			log.Println("Gas oracle stopped")
+			return
+		case <-ticker.C:
+			g.Refresh(context.Background())
+		}
+	}
+}
+
+// Refresh 采样所有EVM链，单条链失败时保留上次的结果
+func (g *GasOracleService) Refresh(ctx context.Context) {
+	for _, adapter := range g.chains.EVMAdapters() {
+		if _, err := g.refreshChain(ctx, adapter); err != nil {
+			log.Printf("Failed to sample gas fees on %s: %v", adapter.ChainType(), err)
+		}
+	}
+}
+
+// refreshChain 采样一条链的手续费档位
+func (g *GasOracleService) refreshChain(ctx context.Context, adapter *EVMChainAdapter) (*GasFeeLevels, error) {
+	sampled, err := blockchain.SampleEVMFeeLevels(ctx, adapter.Client(), g.config.GasOracle.Blocks, g.chains.LegacyTx(adapter.ChainType()))
+	if err != nil {
+		return nil, err
+	}
+
+	levels := &GasFeeLevels{ChainType: adapter.ChainType(), EVMFeeLevels: sampled, UpdatedAt: time.Now()}
+	g.mu.Lock()
+	g.levels[strings.ToLower(adapter.ChainType())] = levels
+	g.mu.Unlock()
+	return levels, nil
+}
+
+// Levels 各链当前的手续费档位，按链类型排序
+func (g *GasOracleService) Levels() []*GasFeeLevels {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	list := make([]*GasFeeLevels, 0, len(g.levels))
+	for _, levels := range g.levels {
+		list = append(list, levels)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ChainType < list[j].ChainType })
+	return list
+}
+
+// Fee 按用途的档位获取链上交易手续费，超过该用途的价格上限时返回 blockchain.ErrEVMFeeAboveCap，调用方应推迟发送
+func (g *GasOracleService) Fee(ctx context.Context, chainType, purpose string) (*blockchain.EVMFee, error) {
+	policy, err := g.policy(purpose)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := g.LevelsFor(ctx, chainType)
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := levels.Level(policy.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	return fee.Capped(levels.BaseFee, gweiToWei(policy.MaxFeeFor(chainType)))
+}
+
+// LevelsFor 获取一条链当前的手续费档位，采样结果过期（超过两个采样间隔）时重新采样
+func (g *GasOracleService) LevelsFor(ctx context.Context, chainType string) (*GasFeeLevels, error) {
+	g.mu.RLock()
+	levels := g.levels[strings.ToLower(chainType)]
+	g.mu.RUnlock()
+	if levels != nil && time.Since(levels.UpdatedAt) <= 2*time.Duration(g.config.GasOracle.Interval)*time.Second {
+		return levels, nil
+	}
+
+	adapter, err := g.chains.Adapter(chainType)
+	if err != nil {
+		return nil, err
+	}
+	evm, ok := adapter.(*EVMChainAdapter)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an EVM chain", chainType)
+	}
+	return g.refreshChain(ctx, evm)
+}
+
+// policy 获取用途对应的手续费策略
+func (g *GasOracleService) policy(purpose string) (*config.GasPolicy, error) {
+	switch purpose {
+	case GasPurposeCollection:
+		return &g.config.GasOracle.Collection, nil
+	case GasPurposeWithdrawal:
+		return &g.config.GasOracle.Withdrawal, nil
+	case GasPurposeTopUp:
+		return &g.config.GasOracle.TopUp, nil
+	default:
+		return nil, fmt.Errorf("unknown gas purpose: %s", purpose)
+	}
+}
+
+// gweiToWei 把以gwei为单位的价格转换为wei，0表示不限制
+func gweiToWei(gwei float64) *big.Int {
+	if gwei <= 0 {
+		return nil
+	}
+	wei, _ := new(big.Float).Mul(big.NewFloat(gwei), big.NewFloat(1e9)).Int(nil)
+	return wei
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-backend/internal/config"
+	"wallet-backend/pkg/blockchain"
+)
+
+func TestGasOracleFee(t *testing.T) {
+	gwei := func(n int64) string { return fmt.Sprintf("0x%x", n*1000000000) }
+	// 模拟以太坊节点：下一区块 baseFee 30 gwei，小费第10/50/90百分位为 1/2/5 gwei
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var result string
+		switch req.Method {
+		case "eth_chainId":
+			result = `"0x1"`
+		case "eth_feeHistory":
+			result = fmt.Sprintf(`{"oldestBlock":"0x64","baseFeePerGas":["%s","%s"],"gasUsedRatio":[0.5],"reward":[["%s","%s","%s"]]}`,
+				gwei(28), gwei(30), gwei(1), gwei(2), gwei(5))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":-32601,"message":"method not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + result + `}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{GasOracle: config.GasOracleConfig{
+		Interval:   15,
+		Blocks:     1,
+		Collection: config.GasPolicy{Strategy: "slow", MaxFeeGwei: map[string]float64{"ethereum": 50}},
+		Withdrawal: config.GasPolicy{Strategy: "fast", MaxFeeGwei: map[string]float64{"Ethereum": 40}},
+		TopUp:      config.GasPolicy{Strategy: "fast", MaxFeeGwei: map[string]float64{"ethereum": 20}},
+	}}
+	adapter, err := NewChainAdapter(context.Background(), cfg, "Ethereum", server.URL, 1)
+	if err != nil {
+		t.Fatalf("NewChainAdapter failed: %v", err)
+	}
+	defer adapter.Close()
+	registry := NewChainRegistry(cfg)
+	registry.adapters = map[string]ChainAdapter{"ethereum": adapter}
+
+	oracle, _ := NewGasOracleService(cfg, registry)
+	ctx := context.Background()
+
+	// 归集：慢速档 2*30+1 = 61 gwei 超过上限 50，但 baseFee+小费 = 31 gwei，降低 maxFeePerGas 到上限
+	fee, err := oracle.Fee(ctx, "Ethereum", GasPurposeCollection)
+	if err != nil {
+		t.Fatalf("collection fee failed: %v", err)
+	}
+	if fee.GasTipCap.Cmp(big.NewInt(1e9)) != 0 || fee.GasFeeCap.Cmp(big.NewInt(50e9)) != 0 {
+		t.Errorf("unexpected collection fee: tip %v cap %v", fee.GasTipCap, fee.GasFeeCap)
+	}
+
+	// 提币：快速档 2*30+5 = 65 gwei，baseFee+小费 = 35 gwei 未超过上限 40，按上限发送
+	fee, err = oracle.Fee(ctx, "ethereum", GasPurposeWithdrawal)
+	if err != nil || fee.GasTipCap.Cmp(big.NewInt(5e9)) != 0 || fee.GasFeeCap.Cmp(big.NewInt(40e9)) != 0 {
+		t.Errorf("unexpected withdrawal fee: %+v, %v", fee, err)
+	}
+
+	// 补充手续费：baseFee 已超过上限，推迟发送
+	if _, err := oracle.Fee(ctx, "Ethereum", GasPurposeTopUp); !errors.Is(err, blockchain.ErrEVMFeeAboveCap) {
+		t.Errorf("expected ErrEVMFeeAboveCap, got %v", err)
+	}
+
+	levels := oracle.Levels()
+	if len(levels) != 1 || levels[0].ChainType != "Ethereum" || levels[0].Standard.GasFeeCap.Cmp(big.NewInt(62e9)) != 0 {
+		t.Errorf("unexpected published levels: %+v", levels)
+	}
+}
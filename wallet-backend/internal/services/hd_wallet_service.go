@@ -2,27 +2,112 @@ package services
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"fmt"
+	"log"
 	"strings"
 	"wallet-backend/internal/config"
 	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+	"wallet-backend/pkg/hdkey"
+	"wallet-backend/pkg/validation"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gagliardetto/solana-go"
 	"github.com/tyler-smith/go-bip39"
 )
 
 // HDWalletService HD钱包服务
+// 支持两种模式：持有助记词的完整模式，以及只配置账户级扩展公钥的观察模式（不持有私钥）
 type HDWalletService struct {
-	config *config.Config
-	path   string
+	config   *config.Config
+	path     string
+	mnemonic string
+	xpubs    map[string]*watchOnlyKey // 链类型(小写) -> 账户级扩展公钥
+}
+
+// watchOnlyKey 观察模式下的账户级扩展公钥
+type watchOnlyKey struct {
+	key      *hdkey.ExtendedKey
+	addrType string // 由ypub/zpub等版本号推断出的比特币地址类型，xpub/tpub为空
+	testnet  bool
+}
+
+// 比特币扩展公钥版本号 (SLIP-0132)
+var bitcoinPublicVersions = map[[4]byte]struct {
+	addrType string
+	testnet  bool
+}{
+	{0x04, 0x88, 0xb2, 0x1e}: {"", false},                               // xpub
+	{0x04, 0x9d, 0x7c, 0xb2}: {blockchain.AddressTypeP2SHP2WPKH, false}, // ypub
+	{0x04, 0xb2, 0x47, 0x46}: {blockchain.AddressTypeP2WPKH, false},     // zpub
+	{0x04, 0x35, 0x87, 0xcf}: {"", true},                                // tpub
+	{0x04, 0x4a, 0x52, 0x62}: {blockchain.AddressTypeP2SHP2WPKH, true},  // upub
+	{0x04, 0x5f, 0x1c, 0xf6}: {blockchain.AddressTypeP2WPKH, true},      // vpub
 }
 
 // NewHDWalletService 创建新的HD钱包服务
 func NewHDWalletService(cfg *config.Config) *HDWalletService {
-	return &HDWalletService{
-		config: cfg,
-		path:   cfg.Wallet.HDWallet.DerivationPath,
+	hws := &HDWalletService{
+		config:   cfg,
+		path:     cfg.Wallet.HDWallet.DerivationPath,
+		mnemonic: cfg.Wallet.HDWallet.Mnemonic,
+		xpubs:    make(map[string]*watchOnlyKey),
+	}
+
+	for chainType, xpub := range cfg.Wallet.HDWallet.ExtendedPublicKeys {
+		if xpub == "" {
+			continue
+		}
+		if err := hws.SetExtendedPublicKey(chainType, xpub); err != nil {
+			log.Printf("Warning: ignoring extended public key for %s: %v", chainType, err)
+		}
+	}
+
+	if hws.IsWatchOnly() {
+		log.Println("HD wallet running in watch-only mode, private keys are not available")
+	}
+
+	return hws
+}
+
+// SetExtendedPublicKey 设置某条链的账户级扩展公钥（如 m/44'/60'/0' 或 m/84'/0'/0' 对应的xpub/zpub）
+func (hws *HDWalletService) SetExtendedPublicKey(chainType string, xpub string) error {
+	key, version, err := hdkey.ParseExtendedKey(strings.TrimSpace(xpub))
+	if err != nil {
+		return err
+	}
+	if key.IsPrivate() {
+		return fmt.Errorf("extended private key is not allowed, configure the public key instead")
 	}
+
+	watchKey := &watchOnlyKey{key: key}
+	if info, ok := bitcoinPublicVersions[version]; ok {
+		watchKey.addrType = info.addrType
+		watchKey.testnet = info.testnet
+	} else {
+		return fmt.Errorf("unknown extended public key version %x", version)
+	}
+
+	hws.xpubs[strings.ToLower(chainType)] = watchKey
+	return nil
+}
+
+// SetMnemonic 设置主助记词（通常来自解锁后的加密密钥库）
+func (hws *HDWalletService) SetMnemonic(mnemonic string) error {
+	if !hws.ValidateMnemonic(mnemonic) {
+		return fmt.Errorf("invalid mnemonic")
+	}
+	hws.mnemonic = mnemonic
+	return nil
+}
+
+// IsWatchOnly 是否为观察模式（不持有助记词，只能派生地址）
+func (hws *HDWalletService) IsWatchOnly() bool {
+	if hws.config.Wallet.HDWallet.WatchOnly {
+		return true
+	}
+	return hws.mnemonic == "" && len(hws.xpubs) > 0
 }
 
 // GenerateMnemonic 生成助记词
@@ -55,32 +140,57 @@ func (hws *HDWalletService) GenerateSeed(mnemonic string) ([]byte, error) {
 	return seed, nil
 }
 
-// DeriveEthereumAddress 派生以太坊地址（简化版本）
+// DeriveEthereumAddress 按BIP44路径派生以太坊地址 m/44'/60'/0'/0/index
 func (hws *HDWalletService) DeriveEthereumAddress(mnemonic string, index uint32) (*models.AddressLibrary, error) {
-	// 生成种子
-	seed, err := hws.GenerateSeed(mnemonic)
+	privateKey, err := hws.GetPrivateKey(mnemonic, "Ethereum", index)
 	if err != nil {
 		return nil, err
 	}
 
-	// 使用种子生成私钥（简化实现）
-	privateKey, err := crypto.ToECDSA(seed[:32])
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	addressModel := &models.AddressLibrary{
+		Address:   address.Hex(),
+		ChainType: "Ethereum",
+		Status:    0, // 未使用
+		IndexNum:  uint64(index),
+	}
+
+	return addressModel, nil
+}
+
+// DeriveBitcoinAddress 按配置的地址类型和网络派生比特币地址
+func (hws *HDWalletService) DeriveBitcoinAddress(mnemonic string, index uint32) (*models.AddressLibrary, error) {
+	return hws.DeriveBitcoinAddressWithType(mnemonic, hws.config.Bitcoin.AddressType, index)
+}
+
+// DeriveBitcoinAddressWithType 派生指定类型的比特币地址
+// 路径按地址类型选择 BIP44/49/84/86：m/purpose'/coin'/0'/0/index
+func (hws *HDWalletService) DeriveBitcoinAddressWithType(mnemonic string, addrType string, index uint32) (*models.AddressLibrary, error) {
+	addrType, err := blockchain.ParseBitcoinAddressType(addrType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate private key: %v", err)
+		return nil, err
 	}
 
-	// 生成公钥和地址
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("failed to get public key")
+	params, err := hws.bitcoinParams()
+	if err != nil {
+		return nil, err
 	}
 
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
+	path := fmt.Sprintf("%s/%d", hws.GetBitcoinDerivationPath(addrType), index)
+	key, err := hws.deriveKey(mnemonic, path)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := blockchain.EncodeBitcoinAddress(key.PublicKeyBytes(), addrType, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bitcoin address: %v", err)
+	}
 
 	addressModel := &models.AddressLibrary{
-		Address:   address.Hex(),
-		ChainType: "Ethereum",
+		Address:   address,
+		ChainType: "Bitcoin",
 		Status:    0, // 未使用
 		IndexNum:  uint64(index),
 	}
@@ -88,20 +198,21 @@ func (hws *HDWalletService) DeriveEthereumAddress(mnemonic string, index uint32)
 	return addressModel, nil
 }
 
-// DeriveBitcoinAddress 派生比特币地址（简化版本）
-func (hws *HDWalletService) DeriveBitcoinAddress(mnemonic string, index uint32) (*models.AddressLibrary, error) {
-	// 简化实现，生成一个模拟的比特币地址
-	// seed, err := hws.GenerateSeed(mnemonic)
-	// if err != nil {
-	// 	return nil, err
-	// }
+// DeriveTronAddress 按BIP44路径派生TRON地址 m/44'/195'/0'/0/index
+func (hws *HDWalletService) DeriveTronAddress(mnemonic string, index uint32) (*models.AddressLibrary, error) {
+	key, err := hws.deriveKey(mnemonic, hws.GetAddressPath("TRON", index))
+	if err != nil {
+		return nil, err
+	}
 
-	// 使用种子生成一个模拟地址
-	address := fmt.Sprintf("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa%d", index)
+	address, err := blockchain.EncodeTronAddress(key.PublicKeyBytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tron address: %v", err)
+	}
 
 	addressModel := &models.AddressLibrary{
 		Address:   address,
-		ChainType: "Bitcoin",
+		ChainType: "TRON",
 		Status:    0, // 未使用
 		IndexNum:  uint64(index),
 	}
@@ -109,71 +220,334 @@ func (hws *HDWalletService) DeriveBitcoinAddress(mnemonic string, index uint32)
 	return addressModel, nil
 }
 
-// GetPrivateKey 获取私钥（用于签名交易）
-func (hws *HDWalletService) GetPrivateKey(mnemonic string, chainType string, index uint32) (*ecdsa.PrivateKey, error) {
+// DeriveSolanaAddress 按SLIP-0010派生Solana地址 m/44'/501'/index'/0'
+func (hws *HDWalletService) DeriveSolanaAddress(mnemonic string, index uint32) (*models.AddressLibrary, error) {
+	privateKey, err := hws.GetSolanaPrivateKey(mnemonic, index)
+	if err != nil {
+		return nil, err
+	}
+
+	address := solana.PublicKeyFromBytes(privateKey.Public().(ed25519.PublicKey))
+
+	addressModel := &models.AddressLibrary{
+		Address:   address.String(),
+		ChainType: "Solana",
+		Status:    0, // 未使用
+		IndexNum:  uint64(index),
+	}
+
+	return addressModel, nil
+}
+
+// GetSolanaPrivateKey 获取Solana地址的ed25519私钥
+func (hws *HDWalletService) GetSolanaPrivateKey(mnemonic string, index uint32) (ed25519.PrivateKey, error) {
 	seed, err := hws.GenerateSeed(mnemonic)
 	if err != nil {
 		return nil, err
 	}
 
-	// 使用种子生成私钥（简化实现）
-	privateKey, err := crypto.ToECDSA(seed[:32])
+	master, err := hdkey.NewEd25519Master(seed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate private key: %v", err)
+		return nil, fmt.Errorf("failed to create ed25519 master key: %v", err)
 	}
 
-	return privateKey, nil
+	path := hws.GetAddressPath("Solana", index)
+	key, err := master.DerivePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive path %s: %v", path, err)
+	}
+
+	return key.PrivateKey(), nil
 }
 
-// ValidateAddress 验证地址格式
-func (hws *HDWalletService) ValidateAddress(address, chainType string) bool {
-	switch strings.ToLower(chainType) {
-	case "ethereum":
-		return hws.validateEthereumAddress(address)
+// DeriveAddressForCurrency 按币种配置派生地址，比特币币种可单独指定地址类型
+func (hws *HDWalletService) DeriveAddressForCurrency(mnemonic string, currency *models.CurrencyChainConfig, index uint32) (*models.AddressLibrary, error) {
+	switch strings.ToLower(currency.ChainType) {
 	case "bitcoin":
-		return hws.validateBitcoinAddress(address)
+		addrType := currency.AddressType
+		if addrType == "" {
+			addrType = hws.config.Bitcoin.AddressType
+		}
+		return hws.DeriveBitcoinAddressWithType(mnemonic, addrType, index)
+	case "tron":
+		return hws.DeriveTronAddress(mnemonic, index)
+	case "solana":
+		return hws.DeriveSolanaAddress(mnemonic, index)
 	default:
-		return false
+		if !IsEVMChain(currency.ChainType) {
+			return nil, fmt.Errorf("unsupported chain type: %s", currency.ChainType)
+		}
+		// EVM链与以太坊共用派生路径和地址格式
+		address, err := hws.DeriveEthereumAddress(mnemonic, index)
+		if err != nil {
+			return nil, err
+		}
+		address.ChainType = currency.ChainType
+		return address, nil
 	}
 }
 
-// validateEthereumAddress 验证以太坊地址
-func (hws *HDWalletService) validateEthereumAddress(address string) bool {
-	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
-		return false
+// DeriveAddress 为指定链派生第index个充值地址，完整模式和观察模式行为一致
+func (hws *HDWalletService) DeriveAddress(chainType string, index uint32) (*models.AddressLibrary, error) {
+	return hws.DeriveCurrencyAddress(&models.CurrencyChainConfig{ChainType: chainType}, index)
+}
+
+// DeriveCurrencyAddress 按币种配置派生第index个充值地址
+// 观察模式下使用扩展公钥做非硬化派生，否则使用配置中的助记词
+func (hws *HDWalletService) DeriveCurrencyAddress(currency *models.CurrencyChainConfig, index uint32) (*models.AddressLibrary, error) {
+	if !hws.IsWatchOnly() {
+		if hws.mnemonic == "" {
+			return nil, fmt.Errorf("HD wallet mnemonic is not configured")
+		}
+		return hws.DeriveAddressForCurrency(hws.mnemonic, currency, index)
 	}
 
-	// 检查地址格式
-	// if !keystore.ValidateAddressFormat(address) {
-	// 	return false
-	// }
+	if strings.ToLower(currency.ChainType) == "solana" {
+		// ed25519 只支持硬化派生，无法从公钥派生子地址
+		return nil, fmt.Errorf("watch-only derivation is not supported for %s", currency.ChainType)
+	}
 
-	return true
+	watchKey, ok := hws.xpubs[strings.ToLower(currency.ChainType)]
+	if !ok && IsEVMChain(currency.ChainType) {
+		// EVM链与以太坊共用账户级扩展公钥
+		watchKey, ok = hws.xpubs["ethereum"]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no extended public key configured for chain %s", currency.ChainType)
+	}
+
+	var address string
+	switch chain := strings.ToLower(currency.ChainType); {
+	case chain == "tron":
+		key, err := hws.deriveWatchOnlyKey(watchKey, hws.GetDerivationPath(currency.ChainType), index)
+		if err != nil {
+			return nil, err
+		}
+		if address, err = blockchain.EncodeTronAddress(key.PublicKeyBytes()); err != nil {
+			return nil, fmt.Errorf("failed to encode tron address: %v", err)
+		}
+	case chain == "bitcoin":
+		params, err := hws.bitcoinParams()
+		if err != nil {
+			return nil, err
+		}
+		if watchKey.testnet != (params != blockchain.BitcoinMainNet) {
+			return nil, fmt.Errorf("extended public key network does not match bitcoin network %s", params.Name)
+		}
+
+		addrType := watchKey.addrType
+		if addrType == "" {
+			addrType = currency.AddressType
+		}
+		if addrType == "" {
+			addrType = hws.config.Bitcoin.AddressType
+		}
+		if addrType, err = blockchain.ParseBitcoinAddressType(addrType); err != nil {
+			return nil, err
+		}
+
+		key, err := hws.deriveWatchOnlyKey(watchKey, hws.GetBitcoinDerivationPath(addrType), index)
+		if err != nil {
+			return nil, err
+		}
+		address, err = blockchain.EncodeBitcoinAddress(key.PublicKeyBytes(), addrType, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode bitcoin address: %v", err)
+		}
+	case IsEVMChain(chain):
+		key, err := hws.deriveWatchOnlyKey(watchKey, hws.GetDerivationPath(currency.ChainType), index)
+		if err != nil {
+			return nil, err
+		}
+		pubKey, err := crypto.DecompressPubkey(key.PublicKeyBytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress public key: %v", err)
+		}
+		address = crypto.PubkeyToAddress(*pubKey).Hex()
+	default:
+		return nil, fmt.Errorf("unsupported chain type: %s", currency.ChainType)
+	}
+
+	addressModel := &models.AddressLibrary{
+		Address:   address,
+		ChainType: currency.ChainType,
+		Status:    0, // 未使用
+		IndexNum:  uint64(index),
+	}
+
+	return addressModel, nil
 }
 
-// validateBitcoinAddress 验证比特币地址
-func (hws *HDWalletService) validateBitcoinAddress(address string) bool {
-	// 简化的比特币地址验证
-	if len(address) < 26 || len(address) > 35 {
-		return false
+// deriveWatchOnlyKey 从账户级扩展公钥派生地址公钥
+// 扩展公钥对应派生路径中最后一个硬化层级，其后的非硬化层级（通常为change=0）和地址索引在此补全
+func (hws *HDWalletService) deriveWatchOnlyKey(watchKey *watchOnlyKey, chainPath string, index uint32) (*hdkey.ExtendedKey, error) {
+	indexes, err := hdkey.ParsePath(chainPath)
+	if err != nil {
+		return nil, err
 	}
 
-	// 检查地址前缀
-	if address[0] != '1' && address[0] != '3' && !strings.HasPrefix(address, "bc1") {
-		return false
+	tail := indexes[:0:0]
+	for i := len(indexes) - 1; i >= 0 && indexes[i] < hdkey.HardenedKeyStart; i-- {
+		tail = append([]uint32{indexes[i]}, tail...)
+	}
+	if watchKey.key.Depth() != uint8(len(indexes)-len(tail)) {
+		return nil, fmt.Errorf("extended public key depth %d does not match account level of %s", watchKey.key.Depth(), chainPath)
 	}
 
-	return true
+	key := watchKey.key
+	for _, child := range append(tail, index) {
+		if key, err = key.Derive(child); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// GetBitcoinDerivationPath 获取比特币指定地址类型的派生路径（不含地址索引）
+func (hws *HDWalletService) GetBitcoinDerivationPath(addrType string) string {
+	addrType, err := blockchain.ParseBitcoinAddressType(addrType)
+	if err != nil {
+		addrType = blockchain.AddressTypeP2PKH
+	}
+
+	coinType := blockchain.BitcoinMainNet.HDCoinType
+	if params, err := hws.bitcoinParams(); err == nil {
+		coinType = params.HDCoinType
+	}
+
+	return fmt.Sprintf("m/%d'/%d'/0'/0", blockchain.BitcoinAddressPurpose(addrType), coinType)
+}
+
+// GetBitcoinChangeDerivationPath 获取比特币找零地址的派生路径（change=1，不含地址索引）
+func (hws *HDWalletService) GetBitcoinChangeDerivationPath(addrType string) string {
+	return strings.TrimSuffix(hws.GetBitcoinDerivationPath(addrType), "/0") + "/1"
+}
+
+// DeriveBitcoinChangeAddress 派生第index个P2WPKH找零地址 m/84'/coin'/0'/1/index
+// 观察模式下要求扩展公钥是P2WPKH账户（zpub/vpub，或配置地址类型为p2wpkh的xpub/tpub）
+func (hws *HDWalletService) DeriveBitcoinChangeAddress(index uint32) (*models.AddressLibrary, error) {
+	params, err := hws.bitcoinParams()
+	if err != nil {
+		return nil, err
+	}
+	path := hws.GetBitcoinChangeDerivationPath(blockchain.AddressTypeP2WPKH)
+
+	var key *hdkey.ExtendedKey
+	if !hws.IsWatchOnly() {
+		if hws.mnemonic == "" {
+			return nil, fmt.Errorf("HD wallet mnemonic is not configured")
+		}
+		if key, err = hws.deriveKey(hws.mnemonic, fmt.Sprintf("%s/%d", path, index)); err != nil {
+			return nil, err
+		}
+	} else {
+		watchKey, ok := hws.xpubs["bitcoin"]
+		if !ok {
+			return nil, fmt.Errorf("no extended public key configured for chain Bitcoin")
+		}
+		addrType := watchKey.addrType
+		if addrType == "" {
+			addrType, _ = blockchain.ParseBitcoinAddressType(hws.config.Bitcoin.AddressType)
+		}
+		if addrType != blockchain.AddressTypeP2WPKH {
+			return nil, fmt.Errorf("change addresses require a P2WPKH account key, got %s", addrType)
+		}
+		if key, err = hws.deriveWatchOnlyKey(watchKey, path, index); err != nil {
+			return nil, err
+		}
+	}
+
+	address, err := blockchain.EncodeBitcoinAddress(key.PublicKeyBytes(), blockchain.AddressTypeP2WPKH, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bitcoin address: %v", err)
+	}
+
+	return &models.AddressLibrary{
+		Address:   address,
+		ChainType: "Bitcoin",
+		Status:    1, // 找零地址不进入地址池
+		IndexNum:  uint64(index),
+		IsChange:  true,
+	}, nil
+}
+
+// bitcoinParams 获取配置的比特币网络参数
+func (hws *HDWalletService) bitcoinParams() (*blockchain.BitcoinNetworkParams, error) {
+	return blockchain.BitcoinNetworkByName(hws.config.Bitcoin.Network)
+}
+
+// GetPrivateKey 获取私钥（用于签名交易），路径为 GetDerivationPath(chainType)/index
+func (hws *HDWalletService) GetPrivateKey(mnemonic string, chainType string, index uint32) (*ecdsa.PrivateKey, error) {
+	key, err := hws.deriveKey(mnemonic, hws.GetAddressPath(chainType, index))
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := key.PrivateKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := crypto.ToECDSA(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert private key: %v", err)
+	}
+
+	return privateKey, nil
+}
+
+// deriveKey 从助记词生成主密钥并按路径派生
+func (hws *HDWalletService) deriveKey(mnemonic string, path string) (*hdkey.ExtendedKey, error) {
+	seed, err := hws.GenerateSeed(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := hdkey.NewMaster(seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create master key: %v", err)
+	}
+
+	key, err := master.DerivePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive path %s: %v", path, err)
+	}
+
+	return key, nil
+}
+
+// ValidateAddress 验证地址格式，包含校验和与比特币网络前缀校验
+func (hws *HDWalletService) ValidateAddress(address, chainType string) bool {
+	validator, err := validation.NewAddressValidator(hws.config.Bitcoin.Network)
+	if err != nil {
+		return false
+	}
+	return validator.Validate(chainType, address) == nil
 }
 
 // GetDerivationPath 获取派生路径
 func (hws *HDWalletService) GetDerivationPath(chainType string) string {
 	switch strings.ToLower(chainType) {
-	case "ethereum":
-		return "m/44'/60'/0'/0"
 	case "bitcoin":
-		return "m/44'/0'/0'/0"
+		return hws.GetBitcoinDerivationPath(hws.config.Bitcoin.AddressType)
+	case "tron":
+		return "m/44'/195'/0'/0"
+	case "solana":
+		return "m/44'/501'"
 	default:
-		return hws.path
+		// 以太坊及其它EVM链
+		if hws.path != "" {
+			return hws.path
+		}
+		return "m/44'/60'/0'/0"
+	}
+}
+
+// GetAddressPath 获取指定索引地址的完整派生路径
+// Solana 使用 SLIP-0010 全硬化路径 m/44'/501'/index'/0'
+func (hws *HDWalletService) GetAddressPath(chainType string, index uint32) string {
+	if strings.ToLower(chainType) == "solana" {
+		return fmt.Sprintf("%s/%d'/0'", hws.GetDerivationPath(chainType), index)
 	}
+	return fmt.Sprintf("%s/%d", strings.TrimSuffix(hws.GetDerivationPath(chainType), "/"), index)
 }
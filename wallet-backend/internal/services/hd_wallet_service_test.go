@@ -1,9 +1,14 @@
 package services
 
 import (
+	"encoding/hex"
 	"strings"
 	"testing"
 	"wallet-backend/internal/config"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/hdkey"
+
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 func TestHDWalletService_GenerateMnemonic(t *testing.T) {
@@ -68,11 +73,16 @@ func TestHDWalletService_ValidateAddress(t *testing.T) {
 	service := NewHDWalletService(cfg)
 
 	// 测试以太坊地址
-	validEthAddress := "0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6"
+	validEthAddress := "0x742d35Cc6634C0532925A3B8D4C9dB96C4B4d8B6"
 	if !service.ValidateAddress(validEthAddress, "Ethereum") {
 		t.Error("Valid Ethereum address should be accepted")
 	}
 
+	badChecksumAddress := "0x742d35Cc6634C0532925a3b8D4C9db96C4b4d8b6"
+	if service.ValidateAddress(badChecksumAddress, "Ethereum") {
+		t.Error("Ethereum address with bad EIP-55 checksum should be rejected")
+	}
+
 	invalidEthAddress := "0xinvalid"
 	if service.ValidateAddress(invalidEthAddress, "Ethereum") {
 		t.Error("Invalid Ethereum address should be rejected")
@@ -108,3 +118,249 @@ func TestHDWalletService_GetDerivationPath(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expectedBtcPath, btcPath)
 	}
 }
+
+// BIP44 测试向量（助记词 "abandon ... about"，空密码）
+func TestHDWalletService_DeriveEthereumAddress_BIP44Vectors(t *testing.T) {
+	cfg := &config.Config{}
+	service := NewHDWalletService(cfg)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	expected := []string{
+		"0x9858EfFD232B4033E47d90003D41EC34EcaEda94", // m/44'/60'/0'/0/0
+		"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0", // m/44'/60'/0'/0/1
+		"0xb6716976A3ebe8D39aCEB04372f22Ff8e6802D7A", // m/44'/60'/0'/0/2
+	}
+
+	for i, want := range expected {
+		address, err := service.DeriveEthereumAddress(mnemonic, uint32(i))
+		if err != nil {
+			t.Fatalf("Failed to derive address %d: %v", i, err)
+		}
+		if address.Address != want {
+			t.Errorf("Index %d: expected %s, got %s", i, want, address.Address)
+		}
+		if address.IndexNum != uint64(i) {
+			t.Errorf("Index %d: expected IndexNum %d, got %d", i, i, address.IndexNum)
+		}
+	}
+}
+
+func TestHDWalletService_DeriveTronAddress_Vectors(t *testing.T) {
+	cfg := &config.Config{}
+	service := NewHDWalletService(cfg)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	expected := []string{
+		"TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH", // m/44'/195'/0'/0/0
+		"TSeJkUh4Qv67VNFwY8LaAxERygNdy6NQZK", // m/44'/195'/0'/0/1
+	}
+
+	for i, want := range expected {
+		address, err := service.DeriveAddressForCurrency(mnemonic, &models.CurrencyChainConfig{ChainType: "TRON"}, uint32(i))
+		if err != nil {
+			t.Fatalf("Failed to derive address %d: %v", i, err)
+		}
+		if address.Address != want {
+			t.Errorf("Index %d: expected %s, got %s", i, want, address.Address)
+		}
+		if !service.ValidateAddress(address.Address, "TRON") {
+			t.Errorf("Derived address %s should be valid", address.Address)
+		}
+	}
+}
+
+func TestHDWalletService_DeriveSolanaAddress_Vectors(t *testing.T) {
+	cfg := &config.Config{}
+	service := NewHDWalletService(cfg)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	expected := []string{
+		"HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk", // m/44'/501'/0'/0'
+		"Hh8QwFUA6MtVu1qAoq12ucvFHNwCcVTV7hpWjeY1Hztb", // m/44'/501'/1'/0'
+	}
+
+	for i, want := range expected {
+		address, err := service.DeriveSolanaAddress(mnemonic, uint32(i))
+		if err != nil {
+			t.Fatalf("Failed to derive address %d: %v", i, err)
+		}
+		if address.Address != want {
+			t.Errorf("Index %d: expected %s, got %s", i, want, address.Address)
+		}
+		if !service.ValidateAddress(address.Address, "Solana") {
+			t.Errorf("Derived address %s should be valid", address.Address)
+		}
+	}
+
+	if service.ValidateAddress("HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpq0", "Solana") {
+		t.Error("Address with invalid base58 character should be rejected")
+	}
+	if service.ValidateAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94", "Solana") {
+		t.Error("Ethereum address should be rejected for Solana")
+	}
+}
+
+func TestHDWalletService_GetPrivateKey_MatchesDerivedAddress(t *testing.T) {
+	cfg := &config.Config{}
+	service := NewHDWalletService(cfg)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	privateKey, err := service.GetPrivateKey(mnemonic, "Ethereum", 0)
+	if err != nil {
+		t.Fatalf("Failed to get private key: %v", err)
+	}
+
+	expectedKey := "1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727"
+	if got := hex.EncodeToString(crypto.FromECDSA(privateKey)); got != expectedKey {
+		t.Errorf("Expected private key %s, got %s", expectedKey, got)
+	}
+
+	address, _ := service.DeriveEthereumAddress(mnemonic, 0)
+	if crypto.PubkeyToAddress(privateKey.PublicKey).Hex() != address.Address {
+		t.Error("Private key does not match derived address")
+	}
+}
+
+func TestHDWalletService_ConfiguredDerivationPath(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Wallet.HDWallet.DerivationPath = "m/44'/60'/1'/0"
+	service := NewHDWalletService(cfg)
+
+	if path := service.GetAddressPath("Ethereum", 7); path != "m/44'/60'/1'/0/7" {
+		t.Errorf("Expected m/44'/60'/1'/0/7, got %s", path)
+	}
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	custom, _ := service.DeriveEthereumAddress(mnemonic, 0)
+	standard, _ := NewHDWalletService(&config.Config{}).DeriveEthereumAddress(mnemonic, 0)
+	if custom.Address == standard.Address {
+		t.Error("Configured derivation path should change the derived address")
+	}
+}
+
+// BIP44/49/84/86 比特币测试向量（助记词 "abandon ... about"）
+func TestHDWalletService_DeriveBitcoinAddress_Vectors(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	cases := []struct {
+		network  string
+		addrType string
+		index    uint32
+		expected string
+	}{
+		{"mainnet", "p2pkh", 0, "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},                               // m/44'/0'/0'/0/0
+		{"testnet", "p2sh-p2wpkh", 0, "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2"},                        // m/49'/1'/0'/0/0
+		{"mainnet", "p2wpkh", 0, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},                      // m/84'/0'/0'/0/0
+		{"mainnet", "p2wpkh", 1, "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"},                      // m/84'/0'/0'/0/1
+		{"mainnet", "taproot", 0, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"}, // m/86'/0'/0'/0/0
+		{"mainnet", "p2tr", 1, "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh"},    // m/86'/0'/0'/0/1
+	}
+
+	for _, c := range cases {
+		cfg := &config.Config{}
+		cfg.Bitcoin.Network = c.network
+		service := NewHDWalletService(cfg)
+
+		address, err := service.DeriveBitcoinAddressWithType(mnemonic, c.addrType, c.index)
+		if err != nil {
+			t.Fatalf("Failed to derive %s address: %v", c.addrType, err)
+		}
+		if address.Address != c.expected {
+			t.Errorf("%s/%s index %d: expected %s, got %s", c.network, c.addrType, c.index, c.expected, address.Address)
+		}
+		if address.ChainType != "Bitcoin" || address.IndexNum != uint64(c.index) {
+			t.Errorf("Unexpected address model: %+v", address)
+		}
+		if !service.ValidateAddress(address.Address, "Bitcoin") {
+			t.Errorf("Derived address %s should pass validation on %s", address.Address, c.network)
+		}
+	}
+}
+
+func TestHDWalletService_BitcoinDerivationPath(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Bitcoin.Network = "regtest"
+	cfg.Bitcoin.AddressType = "p2wpkh"
+	service := NewHDWalletService(cfg)
+
+	if path := service.GetDerivationPath("Bitcoin"); path != "m/84'/1'/0'/0" {
+		t.Errorf("Expected m/84'/1'/0'/0, got %s", path)
+	}
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	address, err := service.DeriveBitcoinAddress(mnemonic, 0)
+	if err != nil {
+		t.Fatalf("Failed to derive regtest address: %v", err)
+	}
+	if !strings.HasPrefix(address.Address, "bcrt1q") {
+		t.Errorf("Expected regtest bech32 address, got %s", address.Address)
+	}
+}
+
+func TestHDWalletService_WatchOnlyDerivation(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	// 账户级扩展公钥 m/44'/60'/0'
+	seed, _ := NewHDWalletService(&config.Config{}).GenerateSeed(mnemonic)
+	master, _ := hdkey.NewMaster(seed)
+	ethAccount, _ := master.DerivePath("m/44'/60'/0'")
+
+	cfg := &config.Config{}
+	cfg.Bitcoin.Network = "mainnet"
+	cfg.Wallet.HDWallet.WatchOnly = true
+	cfg.Wallet.HDWallet.ExtendedPublicKeys = map[string]string{
+		"ethereum": ethAccount.Neuter().String(),
+		// BIP84 测试向量中的账户扩展公钥 m/84'/0'/0'
+		"bitcoin": "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs",
+	}
+	watchOnly := NewHDWalletService(cfg)
+
+	if !watchOnly.IsWatchOnly() {
+		t.Fatal("Expected watch-only mode")
+	}
+
+	fullCfg := &config.Config{}
+	fullCfg.Wallet.HDWallet.Mnemonic = mnemonic
+	full := NewHDWalletService(fullCfg)
+
+	for i := uint32(0); i < 3; i++ {
+		watched, err := watchOnly.DeriveAddress("Ethereum", i)
+		if err != nil {
+			t.Fatalf("Watch-only derivation failed: %v", err)
+		}
+		derived, err := full.DeriveAddress("Ethereum", i)
+		if err != nil {
+			t.Fatalf("Mnemonic derivation failed: %v", err)
+		}
+		if watched.Address != derived.Address || watched.IndexNum != derived.IndexNum {
+			t.Errorf("Index %d: watch-only %s != mnemonic %s", i, watched.Address, derived.Address)
+		}
+	}
+
+	btc, err := watchOnly.DeriveAddress("Bitcoin", 0)
+	if err != nil {
+		t.Fatalf("Watch-only bitcoin derivation failed: %v", err)
+	}
+	if btc.Address != "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" {
+		t.Errorf("Expected zpub to derive native segwit address, got %s", btc.Address)
+	}
+}
+
+func TestHDWalletService_WatchOnlyRejectsMismatch(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Bitcoin.Network = "testnet"
+	cfg.Wallet.HDWallet.ExtendedPublicKeys = map[string]string{
+		"bitcoin": "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs",
+	}
+	service := NewHDWalletService(cfg)
+
+	if _, err := service.DeriveAddress("Bitcoin", 0); err == nil {
+		t.Error("Mainnet zpub should be rejected on testnet")
+	}
+	if _, err := service.DeriveAddress("Ethereum", 0); err == nil {
+		t.Error("Chain without extended public key should fail in watch-only mode")
+	}
+	if err := service.SetExtendedPublicKey("ethereum", "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"); err == nil {
+		t.Error("Extended private key should be rejected")
+	}
+}
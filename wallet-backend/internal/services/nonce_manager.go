@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// nonce 分配状态
+const (
+	nonceReserved = 0 // 已分配待发送
+	nonceSent     = 1 // 已发送
+	nonceReleased = 2 // 已释放，可重新分配
+)
+
+// nonceReservationTimeout 分配后超过此时间仍未发送的nonce视为进程中断，重新同步时释放
+const nonceReservationTimeout = 10 * time.Minute
+
+// NonceClient 分配nonce所需的节点接口，*ethclient.Client 已实现
+type NonceClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// NonceManager EVM地址nonce管理器
+// 每个 (链ID, 地址) 的下一个nonce保存在 evm_nonce 表，在数据库行锁内分配，多个服务实例并发发送也不会取到相同的nonce；
+// 未能发送的nonce被释放后优先重新分配，避免链上出现空洞
+type NonceManager struct {
+	chains *ChainRegistry
+}
+
+// NewNonceManager 创建nonce管理器，chains 用于启动时找到各链的节点
+func NewNonceManager(chains *ChainRegistry) *NonceManager {
+	return &NonceManager{chains: chains}
+}
+
+// NonceLease 一次nonce分配，交易广播成功后调用 Commit，未能广播时调用 Release
+type NonceLease struct {
+	manager *NonceManager
+	client  NonceClient
+	ChainID int64
+	Address string
+	Nonce   uint64
+	done    bool
+}
+
+// Commit 记录使用该nonce的交易已广播
+func (l *NonceLease) Commit(txHash string) {
+	if l.manager == nil || l.done {
+		return
+	}
+	l.done = true
+	if err := l.manager.setStatus(l.ChainID, l.Address, l.Nonce, nonceSent, &txHash); err != nil {
+		log.Printf("Failed to record nonce %d of %s as sent: %v", l.Nonce, l.Address, err)
+	}
+}
+
+// Release 交易未能广播，释放nonce以便重新分配，并与节点重新同步
+func (l *NonceLease) Release(ctx context.Context) {
+	if l.manager == nil || l.done {
+		return
+	}
+	l.done = true
+	if err := l.manager.setStatus(l.ChainID, l.Address, l.Nonce, nonceReleased, nil); err != nil {
+		log.Printf("Failed to release nonce %d of %s: %v", l.Nonce, l.Address, err)
+	}
+	if _, err := l.manager.Resync(ctx, l.client, l.ChainID, l.Address); err != nil {
+		log.Printf("Failed to resync nonce of %s: %v", l.Address, err)
+	}
+}
+
+// Reserve 为地址分配下一个nonce
+// 节点的pending nonce更大时（有绕过管理器发送的交易）从节点的值继续
+func (m *NonceManager) Reserve(ctx context.Context, client NonceClient, chainID int64, address string) (*NonceLease, error) {
+	account := common.HexToAddress(address)
+	address = account.Hex()
+	pending, err := client.PendingNonceAt(ctx, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %v", err)
+	}
+
+	var nonce uint64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		state, err := lockNonceState(tx, chainID, address, pending)
+		if err != nil {
+			return err
+		}
+		next := state.NextNonce
+		if pending > next {
+			next = pending
+		}
+
+		// 低于pending nonce的已释放nonce已被其它交易使用
+		if err := tx.Where("chain_id = ? AND address = ? AND status = ? AND nonce < ?", chainID, address, nonceReleased, pending).
+			Delete(&models.EVMNonceReservation{}).Error; err != nil {
+			return err
+		}
+
+		// 优先重新分配已释放的nonce
+		var released models.EVMNonceReservation
+		err = tx.Where("chain_id = ? AND address = ? AND status = ?", chainID, address, nonceReleased).
+			Order("nonce").First(&released).Error
+		if err == nil {
+			nonce = released.Nonce
+			if err := tx.Model(&released).Updates(map[string]interface{}{"status": nonceReserved, "tx_hash": nil}).Error; err != nil {
+				return err
+			}
+			return tx.Model(state).Update("next_nonce", next).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		nonce = next
+		if err := tx.Model(state).Update("next_nonce", next+1).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EVMNonceReservation{
+			ChainID: chainID,
+			Address: address,
+			Nonce:   nonce,
+			Status:  nonceReserved,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve nonce for %s: %v", address, err)
+	}
+
+	return &NonceLease{manager: m, client: client, ChainID: chainID, Address: address, Nonce: nonce}, nil
+}
+
+// Resync 与节点同步地址的nonce，返回 [节点pending nonce, 下一个nonce) 之间没有已广播交易的nonce（空洞）
+// 已确认的分配记录被删除；没有待发送或已发送的交易时以节点的pending nonce为准；
+// 空洞记录为已释放，由下一次 Reserve 优先填补
+func (m *NonceManager) Resync(ctx context.Context, client NonceClient, chainID int64, address string) ([]uint64, error) {
+	account := common.HexToAddress(address)
+	address = account.Hex()
+	pending, err := client.PendingNonceAt(ctx, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending nonce: %v", err)
+	}
+	confirmed, err := client.NonceAt(ctx, account, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get confirmed nonce: %v", err)
+	}
+
+	var gaps []uint64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		state, err := lockNonceState(tx, chainID, address, pending)
+		if err != nil {
+			return err
+		}
+
+		// 已确认的nonce不再需要跟踪
+		if err := tx.Where("chain_id = ? AND address = ? AND nonce < ?", chainID, address, confirmed).
+			Delete(&models.EVMNonceReservation{}).Error; err != nil {
+			return err
+		}
+		// 长时间未发送的分配视为进程中断
+		if err := tx.Model(&models.EVMNonceReservation{}).
+			Where("chain_id = ? AND address = ? AND status = ? AND updated_time < ?", chainID, address, nonceReserved, time.Now().Add(-nonceReservationTimeout)).
+			Update("status", nonceReleased).Error; err != nil {
+			return err
+		}
+
+		var reservations []models.EVMNonceReservation
+		if err := tx.Where("chain_id = ? AND address = ? AND nonce >= ?", chainID, address, pending).
+			Order("nonce").Find(&reservations).Error; err != nil {
+			return err
+		}
+
+		next := state.NextNonce
+		if pending > next {
+			next = pending
+		}
+		if !hasInFlightNonce(reservations) {
+			// 没有待发送或已发送的交易，以节点为准
+			next = pending
+			if err := tx.Where("chain_id = ? AND address = ? AND nonce >= ?", chainID, address, pending).
+				Delete(&models.EVMNonceReservation{}).Error; err != nil {
+				return err
+			}
+		} else {
+			gaps = findNonceGaps(pending, next, reservations)
+			if err := releaseNonceGaps(tx, chainID, address, gaps, reservations); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(state).Updates(map[string]interface{}{
+			"next_nonce":  next,
+			"synced_time": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resync nonce for %s: %v", address, err)
+	}
+
+	if len(gaps) > 0 {
+		log.Printf("Nonce gap detected for %s on chain %d: %v", address, chainID, gaps)
+	}
+	return gaps, nil
+}
+
+// ResyncAll 启动时同步所有已记录地址的nonce
+func (m *NonceManager) ResyncAll(ctx context.Context) error {
+	var states []models.EVMNonce
+	if err := database.DB.Find(&states).Error; err != nil {
+		return fmt.Errorf("failed to load nonce states: %v", err)
+	}
+
+	clients := make(map[int64]NonceClient)
+	if m.chains != nil {
+		for _, adapter := range m.chains.EVMAdapters() {
+			clients[adapter.ChainID()] = adapter.Client()
+		}
+	}
+	for _, state := range states {
+		client, ok := clients[state.ChainID]
+		if !ok {
+			log.Printf("Skip nonce resync for %s: no node for chain %d", state.Address, state.ChainID)
+			continue
+		}
+		if _, err := m.Resync(ctx, client, state.ChainID, state.Address); err != nil {
+			log.Printf("Failed to resync nonce: %v", err)
+		}
+	}
+	return nil
+}
+
+// setStatus 更新nonce分配状态
+func (m *NonceManager) setStatus(chainID int64, address string, nonce uint64, status int, txHash *string) error {
+	return database.DB.Model(&models.EVMNonceReservation{}).
+		Where("chain_id = ? AND address = ? AND nonce = ?", chainID, address, nonce).
+		Updates(map[string]interface{}{"status": status, "tx_hash": txHash}).Error
+}
+
+// forgetUsedNonces 地址在链上使用了nonce的交易已最终确定，删除到此nonce为止的分配记录
+func forgetUsedNonces(chainID int64, address string, nonce uint64) error {
+	return database.DB.Where("chain_id = ? AND address = ? AND nonce <= ?", chainID, common.HexToAddress(address).Hex(), nonce).
+		Delete(&models.EVMNonceReservation{}).Error
+}
+
+// lockNonceState 锁定地址的nonce记录，首次使用的地址从节点的pending nonce开始
+func lockNonceState(tx *gorm.DB, chainID int64, address string, pending uint64) (*models.EVMNonce, error) {
+	var state models.EVMNonce
+	lock := func() error {
+		return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("chain_id = ? AND address = ?", chainID, address).First(&state).Error
+	}
+
+	err := lock()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 并发创建时以先创建的记录为准
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.EVMNonce{
+			ChainID:    chainID,
+			Address:    address,
+			NextNonce:  pending,
+			SyncedTime: time.Now(),
+		}).Error; err != nil {
+			return nil, err
+		}
+		err = lock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// hasInFlightNonce 是否有待发送或已发送的交易
+func hasInFlightNonce(reservations []models.EVMNonceReservation) bool {
+	for _, r := range reservations {
+		if r.Status == nonceReserved || r.Status == nonceSent {
+			return true
+		}
+	}
+	return false
+}
+
+// findNonceGaps [pending, next) 中没有分配记录或已释放的nonce；reservations 只需包含该范围内的记录
+func findNonceGaps(pending, next uint64, reservations []models.EVMNonceReservation) []uint64 {
+	status := make(map[uint64]int, len(reservations))
+	for _, r := range reservations {
+		status[r.Nonce] = r.Status
+	}
+	var gaps []uint64
+	for nonce := pending; nonce < next; nonce++ {
+		if s, ok := status[nonce]; !ok || s == nonceReleased {
+			gaps = append(gaps, nonce)
+		}
+	}
+	return gaps
+}
+
+// releaseNonceGaps 为没有分配记录的空洞补充已释放记录，使其可被重新分配
+func releaseNonceGaps(tx *gorm.DB, chainID int64, address string, gaps []uint64, reservations []models.EVMNonceReservation) error {
+	known := make(map[uint64]bool, len(reservations))
+	for _, r := range reservations {
+		known[r.Nonce] = true
+	}
+	for _, nonce := range gaps {
+		if known[nonce] {
+			continue
+		}
+		if err := tx.Create(&models.EVMNonceReservation{
+			ChainID: chainID,
+			Address: address,
+			Nonce:   nonce,
+			Status:  nonceReleased,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserveNonce 分配nonce，未配置nonce管理器时直接使用节点的pending nonce（不能防止并发发送取到相同的nonce）
+func reserveNonce(ctx context.Context, m *NonceManager, client NonceClient, chainID int64, address string) (*NonceLease, error) {
+	if m == nil {
+		nonce, err := client.PendingNonceAt(ctx, common.HexToAddress(address))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nonce: %v", err)
+		}
+		return &NonceLease{ChainID: chainID, Address: address, Nonce: nonce}, nil
+	}
+	return m.Reserve(ctx, client, chainID, address)
+}
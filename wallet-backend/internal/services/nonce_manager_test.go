@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFindNonceGaps(t *testing.T) {
+	reservations := []models.EVMNonceReservation{
+		{Nonce: 5, Status: nonceSent},
+		{Nonce: 7, Status: nonceReleased},
+		{Nonce: 8, Status: nonceReserved},
+	}
+
+	// 6 没有分配记录，7 已释放
+	if gaps := findNonceGaps(5, 10, reservations); !reflect.DeepEqual(gaps, []uint64{6, 7, 9}) {
+		t.Errorf("unexpected gaps %v", gaps)
+	}
+	if gaps := findNonceGaps(5, 5, reservations); len(gaps) != 0 {
+		t.Errorf("expected no gaps when next equals pending, got %v", gaps)
+	}
+}
+
+func TestHasInFlightNonce(t *testing.T) {
+	if hasInFlightNonce([]models.EVMNonceReservation{{Nonce: 3, Status: nonceReleased}}) {
+		t.Error("released nonces are not in flight")
+	}
+	if !hasInFlightNonce([]models.EVMNonceReservation{{Nonce: 3, Status: nonceReleased}, {Nonce: 4, Status: nonceReserved}}) {
+		t.Error("reserved nonce should be in flight")
+	}
+}
+
+// fakeNonceClient 节点返回的pending和已确认nonce
+type fakeNonceClient struct {
+	pending, confirmed uint64
+}
+
+func (c *fakeNonceClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return c.pending, nil
+}
+
+func (c *fakeNonceClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return c.confirmed, nil
+}
+
+func TestNonceManagerReserveAndResync(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	manager := NewNonceManager(nil)
+	client := &fakeNonceClient{pending: 5, confirmed: 5}
+	address := "0x00000000000000000000000000000000000000aa"
+
+	reserve := func() *NonceLease {
+		lease, err := manager.Reserve(ctx, client, 1, address)
+		if err != nil {
+			t.Fatalf("Reserve failed: %v", err)
+		}
+		return lease
+	}
+	leases := []*NonceLease{reserve(), reserve(), reserve()}
+	for i, lease := range leases {
+		if lease.Nonce != uint64(5+i) {
+			t.Fatalf("lease %d: got nonce %d, want %d", i, lease.Nonce, 5+i)
+		}
+	}
+
+	// nonce 5、7 广播成功，6 广播失败：节点的pending nonce停在6，7 在交易池中等待
+	leases[0].Commit("0x05")
+	leases[2].Commit("0x07")
+	client.pending = 6
+	leases[1].Release(ctx)
+
+	gaps, err := manager.Resync(ctx, client, 1, address)
+	if err != nil {
+		t.Fatalf("Resync failed: %v", err)
+	}
+	if len(gaps) != 1 || gaps[0] != 6 {
+		t.Errorf("expected gap [6], got %v", gaps)
+	}
+
+	// 空洞优先重新分配，之后从8继续
+	if lease := reserve(); lease.Nonce != 6 {
+		t.Errorf("expected released nonce 6 to be reused, got %d", lease.Nonce)
+	} else {
+		lease.Commit("0x06")
+	}
+	if lease := reserve(); lease.Nonce != 8 {
+		t.Errorf("expected nonce 8 after the gap was filled, got %d", lease.Nonce)
+	} else {
+		lease.Commit("0x08")
+	}
+
+	// 全部确认后分配记录被删除，下一个nonce以节点为准
+	client.pending, client.confirmed = 9, 9
+	if _, err := manager.Resync(ctx, client, 1, address); err != nil {
+		t.Fatalf("Resync failed: %v", err)
+	}
+	var count int64
+	database.DB.Model(&models.EVMNonceReservation{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected confirmed reservations to be deleted, %d left", count)
+	}
+	var state models.EVMNonce
+	database.DB.First(&state)
+	if state.NextNonce != 9 {
+		t.Errorf("expected next nonce 9, got %d", state.NextNonce)
+	}
+}
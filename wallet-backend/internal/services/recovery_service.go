@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"gorm.io/gorm"
+)
+
+// ActivityChecker 检查地址是否有过链上活动
+type ActivityChecker interface {
+	HasActivity(ctx context.Context, address string) (bool, error)
+}
+
+// EVMActivityChecker 以太坊系链上活动检查：nonce、原生币余额以及代币余额任一非零即视为已使用
+type EVMActivityChecker struct {
+	client *ethclient.Client
+	tokens []common.Address
+}
+
+// NewEVMActivityChecker 创建EVM链上活动检查器
+func NewEVMActivityChecker(client *ethclient.Client, tokens []common.Address) *EVMActivityChecker {
+	return &EVMActivityChecker{client: client, tokens: tokens}
+}
+
+// HasActivity 检查地址是否被使用过
+func (c *EVMActivityChecker) HasActivity(ctx context.Context, address string) (bool, error) {
+	account := common.HexToAddress(address)
+
+	nonce, err := c.client.NonceAt(ctx, account, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get nonce: %v", err)
+	}
+	if nonce > 0 {
+		return true, nil
+	}
+
+	balance, err := c.client.BalanceAt(ctx, account, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get balance: %v", err)
+	}
+	if balance.Sign() > 0 {
+		return true, nil
+	}
+
+	// balanceOf(address)
+	data := append(common.FromHex("0x70a08231"), common.LeftPadBytes(account.Bytes(), 32)...)
+	for _, token := range c.tokens {
+		token := token
+		result, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+		if err != nil {
+			return false, fmt.Errorf("failed to get token balance from %s: %v", token.Hex(), err)
+		}
+		if new(big.Int).SetBytes(result).Sign() > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RecoveryOptions 地址恢复参数
+type RecoveryOptions struct {
+	ChainType   string `json:"chain_type"`
+	AddressType string `json:"address_type,omitempty"` // 比特币地址类型，为空使用配置
+	GapLimit    int    `json:"gap_limit,omitempty"`    // 连续未使用地址达到此数量后停止，为空使用配置
+	StartIndex  uint32 `json:"start_index,omitempty"`
+	DryRun      bool   `json:"dry_run,omitempty"` // 只扫描不写库
+}
+
+// RecoveryProgress 地址恢复进度
+type RecoveryProgress struct {
+	ChainType  string     `json:"chain_type"`
+	Running    bool       `json:"running"`
+	Scanned    int        `json:"scanned"`    // 已检查的地址数量
+	LastIndex  uint32     `json:"last_index"` // 最近检查的派生索引
+	Used       int        `json:"used"`       // 有链上活动的地址数量
+	Restored   int        `json:"restored"`   // 新写入地址库的数量
+	Updated    int        `json:"updated"`    // 修正了派生索引的数量
+	Gap        int        `json:"gap"`        // 当前连续未使用数量
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// RecoveryService 地址恢复服务
+// 数据库丢失后按派生索引逐个检查链上活动，连续 gap limit 个未使用地址后停止，并重建地址库记录
+type RecoveryService struct {
+	config   *config.Config
+	hdWallet *HDWalletService
+	clients  map[string]*ethclient.Client
+
+	mu       sync.Mutex
+	checkers map[string]ActivityChecker
+	progress map[string]*RecoveryProgress
+}
+
+// NewRecoveryService 创建地址恢复服务
+func NewRecoveryService(cfg *config.Config, hdWallet *HDWalletService) (*RecoveryService, error) {
+	rs := &RecoveryService{
+		config:   cfg,
+		hdWallet: hdWallet,
+		clients:  make(map[string]*ethclient.Client),
+		checkers: make(map[string]ActivityChecker),
+		progress: make(map[string]*RecoveryProgress),
+	}
+
+	if rpcURL := cfg.Ethereum.GetTestnetRPCURL(); rpcURL != "" {
+		client, err := ethclient.Dial(rpcURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Ethereum testnet: %v", err)
+		}
+		rs.clients["ethereum"] = client
+	}
+
+	return rs, nil
+}
+
+// RegisterChecker 为链注册自定义的链上活动检查器
+func (rs *RecoveryService) RegisterChecker(chainType string, checker ActivityChecker) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.checkers[strings.ToLower(chainType)] = checker
+}
+
+// Start 在后台启动恢复任务，同一条链同时只允许一个任务
+func (rs *RecoveryService) Start(opts RecoveryOptions) error {
+	checker, err := rs.checkerFor(opts.ChainType)
+	if err != nil {
+		return err
+	}
+	progress, err := rs.begin(opts.ChainType)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := rs.run(context.Background(), opts, checker, progress, nil); err != nil {
+			log.Printf("Address recovery for %s failed: %v", opts.ChainType, err)
+		}
+	}()
+	return nil
+}
+
+// Recover 同步执行恢复任务，report 在每个索引检查完成后被调用
+func (rs *RecoveryService) Recover(ctx context.Context, opts RecoveryOptions, report func(RecoveryProgress)) (*RecoveryProgress, error) {
+	checker, err := rs.checkerFor(opts.ChainType)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := rs.begin(opts.ChainType)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rs.run(ctx, opts, checker, progress, report)
+	result := rs.snapshot(progress)
+	return &result, err
+}
+
+// Progress 获取恢复进度，chainType 为空时返回全部
+func (rs *RecoveryService) Progress(chainType string) []RecoveryProgress {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	var result []RecoveryProgress
+	for key, progress := range rs.progress {
+		if chainType == "" || key == strings.ToLower(chainType) {
+			result = append(result, *progress)
+		}
+	}
+	return result
+}
+
+// begin 登记新的恢复任务
+func (rs *RecoveryService) begin(chainType string) (*RecoveryProgress, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	key := strings.ToLower(chainType)
+	if existing, ok := rs.progress[key]; ok && existing.Running {
+		return nil, fmt.Errorf("address recovery for %s is already running", chainType)
+	}
+
+	progress := &RecoveryProgress{ChainType: chainType, Running: true, StartedAt: time.Now()}
+	rs.progress[key] = progress
+	return progress, nil
+}
+
+// snapshot 复制当前进度
+func (rs *RecoveryService) snapshot(progress *RecoveryProgress) RecoveryProgress {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return *progress
+}
+
+// checkerFor 获取链的活动检查器，EVM链默认使用节点查询nonce、余额和已配置代币余额
+func (rs *RecoveryService) checkerFor(chainType string) (ActivityChecker, error) {
+	key := strings.ToLower(chainType)
+
+	rs.mu.Lock()
+	checker, ok := rs.checkers[key]
+	rs.mu.Unlock()
+	if ok {
+		return checker, nil
+	}
+
+	if client, ok := rs.clients[key]; ok {
+		tokens, err := rs.tokenContracts(chainType)
+		if err != nil {
+			return nil, err
+		}
+		return NewEVMActivityChecker(client, tokens), nil
+	}
+
+	return nil, fmt.Errorf("no activity checker available for chain %s", chainType)
+}
+
+// tokenContracts 获取链上已配置的代币合约地址
+func (rs *RecoveryService) tokenContracts(chainType string) ([]common.Address, error) {
+	if database.DB == nil {
+		return nil, nil
+	}
+
+	var currencies []models.CurrencyChainConfig
+	if err := database.DB.Where("chain_type = ? AND token_address IS NOT NULL AND token_address <> ''", chainType).
+		Find(&currencies).Error; err != nil {
+		return nil, fmt.Errorf("failed to load token contracts: %v", err)
+	}
+
+	tokens := make([]common.Address, 0, len(currencies))
+	for _, currency := range currencies {
+		if common.IsHexAddress(*currency.TokenAddress) {
+			tokens = append(tokens, common.HexToAddress(*currency.TokenAddress))
+		}
+	}
+	return tokens, nil
+}
+
+// run 按派生索引逐个检查，直到连续未使用的地址数量达到 gap limit
+func (rs *RecoveryService) run(ctx context.Context, opts RecoveryOptions, checker ActivityChecker, progress *RecoveryProgress, report func(RecoveryProgress)) error {
+	gapLimit := opts.GapLimit
+	if gapLimit <= 0 {
+		gapLimit = rs.config.Wallet.Recovery.GapLimit
+	}
+
+	currency := &models.CurrencyChainConfig{ChainType: opts.ChainType, AddressType: opts.AddressType}
+
+	var runErr error
+	for index := opts.StartIndex; ; index++ {
+		if err := ctx.Err(); err != nil {
+			runErr = err
+			break
+		}
+		if index >= 1<<31 {
+			runErr = fmt.Errorf("address index space exhausted")
+			break
+		}
+
+		address, err := rs.hdWallet.DeriveCurrencyAddress(currency, index)
+		if err != nil {
+			runErr = fmt.Errorf("failed to derive index %d: %v", index, err)
+			break
+		}
+
+		used, err := checker.HasActivity(ctx, address.Address)
+		if err != nil {
+			runErr = fmt.Errorf("failed to check %s (index %d): %v", address.Address, index, err)
+			break
+		}
+
+		restored, updated := false, false
+		if used && !opts.DryRun {
+			if restored, updated, err = rs.restore(address); err != nil {
+				runErr = err
+				break
+			}
+		}
+
+		rs.mu.Lock()
+		progress.Scanned++
+		progress.LastIndex = index
+		if used {
+			progress.Used++
+			progress.Gap = 0
+		} else {
+			progress.Gap++
+		}
+		if restored {
+			progress.Restored++
+		}
+		if updated {
+			progress.Updated++
+		}
+		snapshot := *progress
+		rs.mu.Unlock()
+
+		if report != nil {
+			report(snapshot)
+		}
+		if snapshot.Gap >= gapLimit {
+			break
+		}
+	}
+
+	now := time.Now()
+	rs.mu.Lock()
+	progress.Running = false
+	progress.FinishedAt = &now
+	if runErr != nil {
+		progress.Error = runErr.Error()
+	}
+	rs.mu.Unlock()
+
+	log.Printf("Address recovery for %s finished: scanned %d, used %d, restored %d, updated %d",
+		opts.ChainType, progress.Scanned, progress.Used, progress.Restored, progress.Updated)
+	return runErr
+}
+
+// restore 写入或修正地址库记录，已存在的记录只修正派生索引
+func (rs *RecoveryService) restore(address *models.AddressLibrary) (bool, bool, error) {
+	var existing models.AddressLibrary
+	err := database.DB.Unscoped().
+		Where("address = ? AND chain_type = ?", address.Address, address.ChainType).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		address.Status = 1 // 已使用，不能再进入地址池
+		address.Note = "recovered"
+		address.CreatedTime = time.Now()
+		if err := database.DB.Create(address).Error; err != nil {
+			return false, false, fmt.Errorf("failed to restore address %s: %v", address.Address, err)
+		}
+		return true, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to query address %s: %v", address.Address, err)
+	}
+
+	updates := map[string]interface{}{}
+	if existing.IndexNum != address.IndexNum {
+		updates["index_num"] = address.IndexNum
+	}
+	if existing.Status == 0 && existing.UserID == nil {
+		// 池中地址已有链上活动，不能再分配给新用户
+		updates["status"] = 1
+	}
+	if len(updates) == 0 {
+		return false, false, nil
+	}
+
+	if err := database.DB.Unscoped().Model(&existing).Updates(updates).Error; err != nil {
+		return false, false, fmt.Errorf("failed to update address %s: %v", address.Address, err)
+	}
+	return false, true, nil
+}
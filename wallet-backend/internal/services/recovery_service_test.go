@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"wallet-backend/internal/config"
+)
+
+// fakeActivityChecker 按地址返回预设的链上活动
+type fakeActivityChecker map[string]bool
+
+func (f fakeActivityChecker) HasActivity(ctx context.Context, address string) (bool, error) {
+	return f[address], nil
+}
+
+func TestRecoveryService_GapLimit(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Wallet.HDWallet.Mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	cfg.Wallet.Recovery.GapLimit = 3
+	hd := NewHDWalletService(cfg)
+
+	used := fakeActivityChecker{}
+	for _, index := range []uint32{0, 2, 5} {
+		address, err := hd.DeriveAddress("Ethereum", index)
+		if err != nil {
+			t.Fatalf("DeriveAddress failed: %v", err)
+		}
+		used[address.Address] = true
+	}
+
+	rs, err := NewRecoveryService(cfg, hd)
+	if err != nil {
+		t.Fatalf("NewRecoveryService failed: %v", err)
+	}
+	rs.RegisterChecker("Ethereum", used)
+
+	reports := 0
+	result, err := rs.Recover(context.Background(), RecoveryOptions{ChainType: "Ethereum", DryRun: true}, func(RecoveryProgress) {
+		reports++
+	})
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+
+	// 索引5之后连续3个未使用地址(6,7,8)时停止
+	if result.Scanned != 9 || result.LastIndex != 8 || result.Used != 3 || result.Gap != 3 {
+		t.Fatalf("unexpected progress: %+v", result)
+	}
+	if reports != result.Scanned {
+		t.Fatalf("expected %d progress reports, got %d", result.Scanned, reports)
+	}
+	if result.Running || result.FinishedAt == nil {
+		t.Fatal("recovery should be marked finished")
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// signHashPath 远程签名服务的签名接口
+const signHashPath = "/v1/sign/hash"
+
+// SignHashRequest 远程签名请求，只传递派生信息和待签名哈希
+type SignHashRequest struct {
+	ChainType string `json:"chain_type"`
+	Index     uint64 `json:"index"`
+	Address   string `json:"address"`
+	Hash      string `json:"hash"` // 0x前缀的32字节哈希
+}
+
+// SignHashResponse 远程签名响应
+type SignHashResponse struct {
+	Signature string `json:"signature,omitempty"` // 0x前缀的65字节签名
+	Error     string `json:"error,omitempty"`
+}
+
+// RemoteSigner 远程签名器，通过双向TLS调用独立部署的签名服务
+type RemoteSigner struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewRemoteSigner 创建远程签名器，必须配置客户端证书和CA
+func NewRemoteSigner(cfg config.SignerConfig) (*RemoteSigner, error) {
+	if cfg.RemoteURL == "" {
+		return nil, fmt.Errorf("remote signer url is not configured")
+	}
+
+	tlsConfig, err := LoadMutualTLSConfig(cfg.CertFile, cfg.KeyFile, cfg.CAFile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	return NewRemoteSignerWithClient(cfg.RemoteURL, &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}), nil
+}
+
+// NewRemoteSignerWithClient 使用已配置好的HTTP客户端创建远程签名器
+func NewRemoteSignerWithClient(baseURL string, client *http.Client) *RemoteSigner {
+	return &RemoteSigner{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// SignHash 请求远程签名服务签名哈希
+func (s *RemoteSigner) SignHash(ctx context.Context, addr *models.AddressLibrary, hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+
+	body, err := json.Marshal(SignHashRequest{
+		ChainType: addr.ChainType,
+		Index:     addr.IndexNum,
+		Address:   addr.Address,
+		Hash:      "0x" + hex.EncodeToString(hash),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+signHashPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result SignHashResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid remote signer response (status %d): %v", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer error (status %d): %s", resp.StatusCode, result.Error)
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(result.Signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return nil, fmt.Errorf("invalid signature returned by remote signer")
+	}
+	return sig, nil
+}
+
+// SignTx 通过远程签名服务签名以太坊交易，本地只计算哈希并组装签名
+func (s *RemoteSigner) SignTx(ctx context.Context, addr *models.AddressLibrary, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signTxWithHash(ctx, s, addr, tx, chainID)
+}
+
+// NewSignerServer 创建签名服务的HTTP处理器，由 cmd/signer 以双向TLS方式对外提供
+func NewSignerServer(signer Signer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(signHashPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeSignerResponse(w, http.StatusMethodNotAllowed, SignHashResponse{Error: "method not allowed"})
+			return
+		}
+
+		var req SignHashRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil {
+			writeSignerResponse(w, http.StatusBadRequest, SignHashResponse{Error: "invalid request body"})
+			return
+		}
+
+		hash, err := hex.DecodeString(strings.TrimPrefix(req.Hash, "0x"))
+		if err != nil || len(hash) != 32 {
+			writeSignerResponse(w, http.StatusBadRequest, SignHashResponse{Error: "hash must be 32 bytes hex"})
+			return
+		}
+
+		addr := &models.AddressLibrary{
+			ChainType: req.ChainType,
+			IndexNum:  req.Index,
+			Address:   req.Address,
+		}
+		sig, err := signer.SignHash(r.Context(), addr, hash)
+		if err != nil {
+			writeSignerResponse(w, http.StatusUnprocessableEntity, SignHashResponse{Error: err.Error()})
+			return
+		}
+
+		writeSignerResponse(w, http.StatusOK, SignHashResponse{Signature: "0x" + hex.EncodeToString(sig)})
+	})
+	return mux
+}
+
+func writeSignerResponse(w http.ResponseWriter, status int, resp SignHashResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// LoadMutualTLSConfig 加载双向TLS配置
+// server=true 时要求并校验客户端证书，否则作为客户端校验服务端证书并出示本端证书
+func LoadMutualTLSConfig(certFile, keyFile, caFile string, server bool) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, fmt.Errorf("cert_file, key_file and ca_file are required for mutual TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %v", err)
+	}
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no valid certificates in CA file %s", caFile)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if server {
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"log"
 	"time"
 	"wallet-backend/internal/config"
@@ -11,15 +12,19 @@ type SchedulerService struct {
 	config           *config.Config
 	blockScanner     *BlockScannerService
 	collectionService *CollectionService
+	addressService   *AddressService
+	confirmations    *ConfirmationService
 	stopChan         chan bool
 }
 
 // NewSchedulerService 创建新的定时任务服务
-func NewSchedulerService(cfg *config.Config, scanner *BlockScannerService, collector *CollectionService) *SchedulerService {
+func NewSchedulerService(cfg *config.Config, scanner *BlockScannerService, collector *CollectionService, addresses *AddressService, confirmations *ConfirmationService) *SchedulerService {
 	return &SchedulerService{
 		config:           cfg,
 		blockScanner:     scanner,
 		collectionService: collector,
+		addressService:   addresses,
+		confirmations:    confirmations,
 		stopChan:         make(chan bool),
 	}
 }
@@ -122,16 +127,30 @@ func (ss *SchedulerService) startOtherTasks() {
 
 // processAddressGeneration 处理地址生成任务
 func (ss *SchedulerService) processAddressGeneration() {
-	// TODO: 实现地址生成逻辑
-	// 参考钱包控制台的 runNewAddress 方法
-	log.Println("Processing address generation...")
+	// 参考钱包控制台的 runNewAddress 方法：空闲地址低于水位线时补充地址池
+	if ss.addressService == nil {
+		return
+	}
+
+	for _, chainType := range ss.config.Wallet.AddressPool.ChainTypes {
+		created, err := ss.addressService.RefillPool(chainType)
+		if err != nil {
+			log.Printf("Failed to refill address pool for %s: %v", chainType, err)
+			continue
+		}
+		if created > 0 {
+			log.Printf("Address pool for %s refilled with %d addresses", chainType, created)
+		}
+	}
 }
 
 // processTransactionConfirmation 处理交易确认任务
 func (ss *SchedulerService) processTransactionConfirmation() {
-	// TODO: 实现交易确认逻辑
-	// 参考钱包控制台的 runUploadConfirmData 方法
-	log.Println("Processing transaction confirmation...")
+	// 参考钱包控制台的 runUploadConfirmData 方法：按链高度更新确认数，达到确认数后入账或解冻
+	if ss.confirmations == nil {
+		return
+	}
+	ss.confirmations.RunOnce(context.Background())
 }
 
 // processDataUpload 处理数据上传任务
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/keystore"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer 交易签名接口
+// 调用方只传入地址库记录（链类型 + 派生索引），私钥始终留在签名端
+type Signer interface {
+	// SignHash 用地址对应的私钥签名32字节哈希，返回65字节 [R || S || V] 签名
+	SignHash(ctx context.Context, addr *models.AddressLibrary, hash []byte) ([]byte, error)
+	// SignTx 签名以太坊交易
+	SignTx(ctx context.Context, addr *models.AddressLibrary, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// NewSigner 按配置创建签名器
+func NewSigner(cfg *config.Config, hd *HDWalletService, ks *keystore.Keystore) (Signer, error) {
+	switch strings.ToLower(cfg.Wallet.Signer.Type) {
+	case "", "hd":
+		if hd.IsWatchOnly() {
+			return nil, fmt.Errorf("hd signer is not available in watch-only mode, configure a remote signer")
+		}
+		return NewHDSigner(hd, ks), nil
+	case "remote":
+		remote, err := NewRemoteSigner(cfg.Wallet.Signer)
+		if err != nil {
+			return nil, err
+		}
+		return remote, nil
+	default:
+		return nil, fmt.Errorf("unsupported signer type: %s", cfg.Wallet.Signer.Type)
+	}
+}
+
+// signTxWithHash 计算交易签名哈希，通过签名器签名后组装已签名交易
+func signTxWithHash(ctx context.Context, s Signer, addr *models.AddressLibrary, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	txSigner := types.LatestSignerForChainID(chainID)
+	sig, err := s.SignHash(ctx, addr, txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, err := tx.WithSignature(txSigner, sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply signature: %v", err)
+	}
+
+	// 确认签名恢复出的发送方就是地址库中的地址
+	sender, err := types.Sender(txSigner, signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %v", err)
+	}
+	if !strings.EqualFold(sender.Hex(), addr.Address) {
+		return nil, fmt.Errorf("signature sender %s does not match %s", sender.Hex(), addr.Address)
+	}
+
+	return signedTx, nil
+}
+
+// lookupAddress 按地址查询地址库记录
+func lookupAddress(address string, chainType string) (*models.AddressLibrary, error) {
+	var addr models.AddressLibrary
+	err := database.DB.Where("address = ? AND chain_type = ?", address, chainType).First(&addr).Error
+	if err != nil {
+		return nil, fmt.Errorf("address %s not found in address library: %v", address, err)
+	}
+	return &addr, nil
+}
+
+// HDSigner 进程内签名器，按地址库记录的派生索引从助记词派生私钥
+// 不在HD路径上的地址（如导入的热钱包私钥）从密钥库中查找
+type HDSigner struct {
+	hd       *HDWalletService
+	keystore *keystore.Keystore
+}
+
+// NewHDSigner 创建进程内HD签名器，ks 可以为 nil
+func NewHDSigner(hd *HDWalletService, ks *keystore.Keystore) *HDSigner {
+	return &HDSigner{hd: hd, keystore: ks}
+}
+
+// SignHash 签名哈希
+func (s *HDSigner) SignHash(ctx context.Context, addr *models.AddressLibrary, hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+
+	privateKey, err := s.privateKey(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := crypto.Sign(hash, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign hash: %v", err)
+	}
+	return sig, nil
+}
+
+// SignTx 签名以太坊交易
+func (s *HDSigner) SignTx(ctx context.Context, addr *models.AddressLibrary, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signTxWithHash(ctx, s, addr, tx, chainID)
+}
+
+// privateKey 派生地址库记录对应的私钥，并校验派生出的地址与记录一致
+func (s *HDSigner) privateKey(addr *models.AddressLibrary) (*ecdsa.PrivateKey, error) {
+	if s.keystore != nil && s.keystore.IsUnlocked() {
+		if key, err := s.keystore.PrivateKey(addr.Address); err == nil {
+			return key, nil
+		}
+	}
+
+	if s.hd.mnemonic == "" {
+		return nil, fmt.Errorf("HD wallet mnemonic is not configured")
+	}
+	index := uint32(addr.IndexNum)
+
+	var paths []string
+	switch chain := strings.ToLower(addr.ChainType); {
+	case chain == "tron", IsEVMChain(chain):
+		// EVM链都使用以太坊派生路径
+		paths = []string{s.hd.GetAddressPath(addr.ChainType, index)}
+	case chain == "bitcoin":
+		// 地址库不记录比特币地址类型，逐个尝试各类型的派生路径
+		for _, addrType := range []string{blockchain.AddressTypeP2PKH, blockchain.AddressTypeP2SHP2WPKH, blockchain.AddressTypeP2WPKH, blockchain.AddressTypeP2TR} {
+			chainPath := s.hd.GetBitcoinDerivationPath(addrType)
+			if addr.IsChange {
+				chainPath = s.hd.GetBitcoinChangeDerivationPath(addrType)
+			}
+			paths = append(paths, fmt.Sprintf("%s/%d", chainPath, index))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported chain type: %s", addr.ChainType)
+	}
+
+	for _, path := range paths {
+		key, err := s.hd.deriveKey(s.hd.mnemonic, path)
+		if err != nil {
+			return nil, err
+		}
+		if !s.matches(key.PublicKeyBytes(), addr) {
+			continue
+		}
+
+		keyBytes, err := key.PrivateKeyBytes()
+		if err != nil {
+			return nil, err
+		}
+		return crypto.ToECDSA(keyBytes)
+	}
+
+	return nil, fmt.Errorf("address %s is not derived from index %d", addr.Address, addr.IndexNum)
+}
+
+// matches 判断公钥是否对应地址库中的地址
+func (s *HDSigner) matches(pubKey []byte, addr *models.AddressLibrary) bool {
+	switch chain := strings.ToLower(addr.ChainType); {
+	case chain == "tron":
+		encoded, err := blockchain.EncodeTronAddress(pubKey)
+		return err == nil && encoded == addr.Address
+	case IsEVMChain(chain):
+		pub, err := crypto.DecompressPubkey(pubKey)
+		if err != nil {
+			return false
+		}
+		return strings.EqualFold(crypto.PubkeyToAddress(*pub).Hex(), addr.Address)
+	case chain == "bitcoin":
+		params, err := s.hd.bitcoinParams()
+		if err != nil {
+			return false
+		}
+		for _, addrType := range []string{blockchain.AddressTypeP2PKH, blockchain.AddressTypeP2SHP2WPKH, blockchain.AddressTypeP2WPKH, blockchain.AddressTypeP2TR} {
+			if encoded, err := blockchain.EncodeBitcoinAddress(pubKey, addrType, params); err == nil && encoded == addr.Address {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const signerTestMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func newTestSigner(t *testing.T) *HDSigner {
+	cfg := &config.Config{}
+	cfg.Wallet.HDWallet.Mnemonic = signerTestMnemonic
+	return NewHDSigner(NewHDWalletService(cfg), nil)
+}
+
+func newTestTx() *types.Transaction {
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	return types.NewTransaction(7, to, big.NewInt(1e15), 21000, big.NewInt(2e9), nil)
+}
+
+func TestHDSigner_SignTx(t *testing.T) {
+	signer := newTestSigner(t)
+	chainID := big.NewInt(11155111)
+
+	addr := &models.AddressLibrary{ChainType: "Ethereum", IndexNum: 1, Address: "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"}
+	signedTx, err := signer.SignTx(context.Background(), addr, newTestTx(), chainID)
+	if err != nil {
+		t.Fatalf("SignTx failed: %v", err)
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+	if err != nil || sender.Hex() != addr.Address {
+		t.Fatalf("unexpected sender %s: %v", sender.Hex(), err)
+	}
+
+	// 索引与地址不匹配时必须拒绝签名
+	wrong := &models.AddressLibrary{ChainType: "Ethereum", IndexNum: 2, Address: addr.Address}
+	if _, err := signer.SignTx(context.Background(), wrong, newTestTx(), chainID); err == nil {
+		t.Fatal("expected signing with mismatched index to fail")
+	}
+}
+
+func TestHDSigner_SignHashBitcoin(t *testing.T) {
+	signer := newTestSigner(t)
+
+	// BIP84 m/84'/0'/0'/0/0
+	addr := &models.AddressLibrary{ChainType: "Bitcoin", IndexNum: 0, Address: "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"}
+	sig, err := signer.SignHash(context.Background(), addr, make([]byte, 32))
+	if err != nil {
+		t.Fatalf("SignHash failed: %v", err)
+	}
+	if len(sig) != 65 {
+		t.Fatalf("expected 65 byte signature, got %d", len(sig))
+	}
+}
+
+func TestRemoteSigner_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeTestCA(t, dir)
+	writeTestCert(t, dir, "server", ca, caKey, true)
+	writeTestCert(t, dir, "client", ca, caKey, false)
+
+	serverTLS, err := LoadMutualTLSConfig(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.pem"), true)
+	if err != nil {
+		t.Fatalf("failed to load server TLS config: %v", err)
+	}
+
+	local := newTestSigner(t)
+	server := httptest.NewUnstartedServer(NewSignerServer(local))
+	server.TLS = serverTLS
+	server.StartTLS()
+	defer server.Close()
+
+	remote, err := NewRemoteSigner(config.SignerConfig{
+		RemoteURL: server.URL,
+		CertFile:  filepath.Join(dir, "client.pem"),
+		KeyFile:   filepath.Join(dir, "client.key"),
+		CAFile:    filepath.Join(dir, "ca.pem"),
+	})
+	if err != nil {
+		t.Fatalf("NewRemoteSigner failed: %v", err)
+	}
+
+	chainID := big.NewInt(1)
+	addr := &models.AddressLibrary{ChainType: "Ethereum", IndexNum: 0, Address: "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"}
+	remoteTx, err := remote.SignTx(context.Background(), addr, newTestTx(), chainID)
+	if err != nil {
+		t.Fatalf("remote SignTx failed: %v", err)
+	}
+	localTx, err := local.SignTx(context.Background(), addr, newTestTx(), chainID)
+	if err != nil {
+		t.Fatalf("local SignTx failed: %v", err)
+	}
+	if remoteTx.Hash() != localTx.Hash() {
+		t.Fatalf("remote signature differs from local: %s != %s", remoteTx.Hash().Hex(), localTx.Hash().Hex())
+	}
+
+	// 签名服务拒绝不属于该索引的地址
+	wrong := &models.AddressLibrary{ChainType: "Ethereum", IndexNum: 5, Address: addr.Address}
+	if _, err := remote.SignTx(context.Background(), wrong, newTestTx(), chainID); err == nil {
+		t.Fatal("expected remote signer to reject mismatched address")
+	}
+
+	// 不出示客户端证书的连接会被拒绝
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	anonymous := NewRemoteSignerWithClient(server.URL, &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	})
+	if _, err := anonymous.SignHash(context.Background(), addr, make([]byte, 32)); err == nil {
+		t.Fatal("expected request without client certificate to fail")
+	}
+}
+
+func writeTestCA(t *testing.T, dir string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "wallet test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", der)
+	return cert, key
+}
+
+func writeTestCert(t *testing.T, dir, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, server bool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, name+".pem"), "CERTIFICATE", der)
+	writePEM(t, filepath.Join(dir, name+".key"), "EC PRIVATE KEY", keyDER)
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHDSigner_SignTxOnOtherEVMChain(t *testing.T) {
+	setupTestDB(t)
+	cfg := &config.Config{}
+	cfg.Wallet.HDWallet.Mnemonic = signerTestMnemonic
+	cfg.Wallet.AddressPool = config.AddressPoolConfig{Size: 2, LowWatermark: 1}
+	hd := NewHDWalletService(cfg)
+	addresses := NewAddressService(cfg, hd, nil)
+
+	// 以太坊以外的EVM链按以太坊路径派生地址，进入地址池后可被领取
+	if !addresses.IsSupportedChain("Polygon") {
+		t.Fatal("expected Polygon to be supported")
+	}
+	if created, err := addresses.RefillPool("Polygon"); err != nil || created != 2 {
+		t.Fatalf("RefillPool failed: %d, %v", created, err)
+	}
+	claimed, err := addresses.ClaimAddress(42, "Polygon")
+	if err != nil {
+		t.Fatalf("ClaimAddress failed: %v", err)
+	}
+	eth, err := hd.DeriveEthereumAddress(signerTestMnemonic, uint32(claimed.IndexNum))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed.ChainType != "Polygon" || claimed.Address != eth.Address {
+		t.Fatalf("unexpected claimed address %s on %s, want %s", claimed.Address, claimed.ChainType, eth.Address)
+	}
+
+	// 领取的地址可以用 secp256k1 签名器签名
+	chainID := big.NewInt(137)
+	signedTx, err := NewHDSigner(hd, nil).SignTx(context.Background(), claimed, newTestTx(), chainID)
+	if err != nil {
+		t.Fatalf("SignTx failed: %v", err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+	if err != nil || sender.Hex() != claimed.Address {
+		t.Fatalf("unexpected sender %s: %v", sender.Hex(), err)
+	}
+}
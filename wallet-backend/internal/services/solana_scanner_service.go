@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// Solana 节点对跳过或已清理的slot返回的错误码
+var solanaSkippedSlotCodes = map[int]bool{
+	-32004: true, // Block not available for slot
+	-32007: true, // Slot was skipped, or missing due to ledger jump to recent snapshot
+	-32009: true, // Slot was skipped, or missing in long-term storage
+}
+
+// SolanaDeposit 区块中识别出的一笔Solana充值（SOL或SPL代币）
+type SolanaDeposit struct {
+	Signature    string
+	Seq          int // 同一交易内的充值序号
+	Slot         uint64
+	Symbol       string
+	Mint         string // SPL代币mint，SOL为空
+	AccountIndex uint16 // 余额变化的账户索引
+	From         string
+	To           string // 钱包地址，SPL代币为token账户的owner
+	Amount       *big.Int
+	Decimals     int
+}
+
+// TxID 写入账单的交易ID，交易ID字段唯一，同一交易内的多笔充值追加序号
+func (d *SolanaDeposit) TxID() string {
+	if d.Seq == 0 {
+		return d.Signature
+	}
+	return fmt.Sprintf("%s:%d", d.Signature, d.Seq)
+}
+
+// UniqueID 充值唯一标识，由签名、mint和账户索引确定，重复扫描不会重复入账
+func (d *SolanaDeposit) UniqueID() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("solana:%s:%s:%d", d.Signature, d.Mint, d.AccountIndex)))
+	return hex.EncodeToString(sum[:])
+}
+
+// HumanAmount 按精度换算后的金额
+func (d *SolanaDeposit) HumanAmount() float64 {
+	return unitsToFloat(d.Amount, d.Decimals)
+}
+
+// SolanaScannerService Solana slot扫描服务，按finalized slot识别SOL和SPL代币充值
+type SolanaScannerService struct {
+	config *config.Config
+	client *rpc.Client
+
+	mu         sync.Mutex
+	isScanning bool
+	stopChan   chan bool
+}
+
+// NewSolanaScannerService 创建Solana扫描服务
+func NewSolanaScannerService(cfg *config.Config) (*SolanaScannerService, error) {
+	if cfg.Solana.RPCURL == "" {
+		return nil, fmt.Errorf("solana rpc_url is not configured")
+	}
+	return &SolanaScannerService{
+		config:   cfg,
+		client:   rpc.New(cfg.Solana.RPCURL),
+		stopChan: make(chan bool),
+	}, nil
+}
+
+// StartScanning 开始扫描
+func (s *SolanaScannerService) StartScanning() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isScanning {
+		return fmt.Errorf("solana scanner is already running")
+	}
+
+	s.isScanning = true
+	go s.scanLoop()
+	return nil
+}
+
+// StopScanning 停止扫描
+func (s *SolanaScannerService) StopScanning() {
+	s.mu.Lock()
+	running := s.isScanning
+	s.isScanning = false
+	s.mu.Unlock()
+
+	if running {
+		s.stopChan <- true
+	}
+}
+
+// Status 返回扫描状态
+func (s *SolanaScannerService) Status() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isScanning
+}
+
+// scanLoop 扫描主循环
+func (s *SolanaScannerService) scanLoop() {
+	ticker := time.NewTicker(time.Duration(s.config.Scanner.ScanInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			log.Println("Solana scanner stopped")
+			return
+		case <-ticker.C:
+			if err := s.ScanOnce(); err != nil {
+				log.Printf("Error scanning Solana slots: %v", err)
+			}
+		}
+	}
+}
+
+// ScanOnce 从上次扫描位置扫描到最新finalized slot，每次最多 max_blocks_per_scan 个slot
+func (s *SolanaScannerService) ScanOnce() error {
+	ctx := context.Background()
+
+	var currencies []models.CurrencyChainConfig
+	if err := database.DB.Where("chain_type = ? AND is_enabled = ?", "Solana", true).Find(&currencies).Error; err != nil {
+		return fmt.Errorf("failed to get Solana currencies: %v", err)
+	}
+	if len(currencies) == 0 {
+		return nil
+	}
+
+	latest, err := s.client.GetSlot(ctx, s.commitment())
+	if err != nil {
+		return fmt.Errorf("failed to get latest slot: %v", err)
+	}
+
+	// 从各币种中最小的扫描位置开始，首次运行从最新slot开始
+	var lastScanned *uint64
+	for _, currency := range currencies {
+		if currency.LastScannedBlock != nil && (lastScanned == nil || *currency.LastScannedBlock < *lastScanned) {
+			value := *currency.LastScannedBlock
+			lastScanned = &value
+		}
+	}
+	start := latest
+	if lastScanned != nil {
+		start = *lastScanned + 1
+	}
+	if start > latest {
+		return nil
+	}
+	end := latest
+	if maxSlots := uint64(s.config.Scanner.MaxBlocksPerScan); maxSlots > 0 && end-start+1 > maxSlots {
+		end = start + maxSlots - 1
+	}
+
+	native := ""
+	mints := make(map[string]*models.CurrencyChainConfig)
+	for i := range currencies {
+		currency := &currencies[i]
+		if currency.TokenAddress == nil || *currency.TokenAddress == "" {
+			native = currency.Symbol
+		} else {
+			mints[*currency.TokenAddress] = currency
+		}
+	}
+
+	addresses, err := s.loadAddresses()
+	if err != nil {
+		return err
+	}
+
+	scanned := start - 1
+	for slot := start; slot <= end; slot++ {
+		block, err := s.fetchBlock(ctx, slot)
+		if err != nil {
+			log.Printf("Failed to get Solana slot %d: %v", slot, err)
+			break
+		}
+
+		if block != nil {
+			deposits, err := ExtractSolanaDeposits(slot, block, addresses, native, mints)
+			if err != nil {
+				log.Printf("Failed to parse Solana slot %d: %v", slot, err)
+				break
+			}
+			for i := range deposits {
+				if err := s.saveDeposit(&deposits[i], addresses[deposits[i].To]); err != nil {
+					return fmt.Errorf("failed to save deposit %s: %v", deposits[i].TxID(), err)
+				}
+			}
+		}
+		scanned = slot
+	}
+
+	if scanned < start {
+		return nil
+	}
+	if err := database.DB.Model(&models.CurrencyChainConfig{}).
+		Where("chain_type = ? AND is_enabled = ?", "Solana", true).
+		Update("last_scanned_block", scanned).Error; err != nil {
+		return fmt.Errorf("failed to update last scanned slot: %v", err)
+	}
+	return nil
+}
+
+// commitment 扫描使用的确认级别，默认finalized
+func (s *SolanaScannerService) commitment() rpc.CommitmentType {
+	if s.config.Solana.Commitment != "" {
+		return rpc.CommitmentType(s.config.Solana.Commitment)
+	}
+	return rpc.CommitmentFinalized
+}
+
+// fetchBlock 获取slot对应的区块，跳过的slot返回nil
+func (s *SolanaScannerService) fetchBlock(ctx context.Context, slot uint64) (*rpc.GetBlockResult, error) {
+	rewards := false
+	block, err := s.client.GetBlockWithOpts(ctx, slot, &rpc.GetBlockOpts{
+		Encoding:                       solana.EncodingBase64,
+		TransactionDetails:             rpc.TransactionDetailsFull,
+		Rewards:                        &rewards,
+		Commitment:                     s.commitment(),
+		MaxSupportedTransactionVersion: &rpc.MaxSupportedTransactionVersion0,
+	})
+	if err != nil {
+		var rpcErr *jsonrpc.RPCError
+		if errors.As(err, &rpcErr) && solanaSkippedSlotCodes[rpcErr.Code] {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return block, nil
+}
+
+// loadAddresses 加载Solana地址库，地址 -> 用户ID
+func (s *SolanaScannerService) loadAddresses() (map[string]uint64, error) {
+	var list []models.AddressLibrary
+	if err := database.DB.Where("chain_type = ?", "Solana").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load Solana addresses: %v", err)
+	}
+
+	addresses := make(map[string]uint64, len(list))
+	for _, addr := range list {
+		var userID uint64
+		if addr.UserID != nil {
+			userID = *addr.UserID
+		}
+		addresses[addr.Address] = userID
+	}
+	return addresses, nil
+}
+
+// saveDeposit 写入充值记录、链上账单并增加余额
+func (s *SolanaScannerService) saveDeposit(deposit *SolanaDeposit, userID uint64) error {
+	return saveDepositEntry(&depositEntry{
+		UserID:    userID,
+		ChainType: "Solana",
+		Symbol:    deposit.Symbol,
+		From:      deposit.From,
+		To:        deposit.To,
+		TxID:      deposit.TxID(),
+		UniqueID:  deposit.UniqueID(),
+		Height:    deposit.Slot,
+		Amount:    deposit.HumanAmount(),
+	})
+}
+
+// ExtractSolanaDeposits 解析区块中转入我方地址的SOL和SPL代币
+// SOL按账户的前后余额差计算，SPL按token账户余额差计算并归属到token账户的owner，失败的交易不计入
+func ExtractSolanaDeposits(slot uint64, block *rpc.GetBlockResult, addresses map[string]uint64, native string, mints map[string]*models.CurrencyChainConfig) ([]SolanaDeposit, error) {
+	var deposits []SolanaDeposit
+
+	for _, txWithMeta := range block.Transactions {
+		meta := txWithMeta.Meta
+		if meta == nil || meta.Err != nil {
+			continue
+		}
+
+		tx, err := txWithMeta.GetTransaction()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		if len(tx.Signatures) == 0 {
+			continue
+		}
+		signature := tx.Signatures[0].String()
+
+		keys := append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+		keys = append(keys, meta.LoadedAddresses.Writable...)
+		keys = append(keys, meta.LoadedAddresses.ReadOnly...)
+
+		var found []SolanaDeposit
+
+		// SOL 充值
+		if native != "" && len(meta.PreBalances) == len(meta.PostBalances) {
+			var from string
+			var maxSpent uint64
+			for i := range meta.PreBalances {
+				if i < len(keys) && meta.PreBalances[i] > meta.PostBalances[i] && meta.PreBalances[i]-meta.PostBalances[i] > maxSpent {
+					maxSpent = meta.PreBalances[i] - meta.PostBalances[i]
+					from = keys[i].String()
+				}
+			}
+			for i := range meta.PostBalances {
+				if i >= len(keys) || meta.PostBalances[i] <= meta.PreBalances[i] {
+					continue
+				}
+				to := keys[i].String()
+				if _, ok := addresses[to]; !ok || to == from {
+					continue
+				}
+				found = append(found, SolanaDeposit{
+					Symbol:       native,
+					AccountIndex: uint16(i),
+					From:         from,
+					To:           to,
+					Amount:       new(big.Int).SetUint64(meta.PostBalances[i] - meta.PreBalances[i]),
+					Decimals:     9,
+				})
+			}
+		}
+
+		// SPL 代币充值
+		pre := make(map[uint16]*big.Int)
+		for _, balance := range meta.PreTokenBalances {
+			if amount, ok := solanaTokenAmount(balance); ok {
+				pre[balance.AccountIndex] = amount
+			}
+		}
+		for _, balance := range meta.PostTokenBalances {
+			currency, ok := mints[balance.Mint.String()]
+			if !ok || balance.Owner == nil {
+				continue
+			}
+			owner := balance.Owner.String()
+			if _, ok := addresses[owner]; !ok {
+				continue
+			}
+			post, ok := solanaTokenAmount(balance)
+			if !ok {
+				continue
+			}
+			delta := new(big.Int).Set(post)
+			if before, ok := pre[balance.AccountIndex]; ok {
+				delta.Sub(delta, before)
+			}
+			if delta.Sign() <= 0 {
+				continue
+			}
+			found = append(found, SolanaDeposit{
+				Symbol:       currency.Symbol,
+				Mint:         balance.Mint.String(),
+				AccountIndex: balance.AccountIndex,
+				From:         solanaTokenSender(meta, balance.Mint),
+				To:           owner,
+				Amount:       delta,
+				Decimals:     int(balance.UiTokenAmount.Decimals),
+			})
+		}
+
+		for i := range found {
+			found[i].Signature = signature
+			found[i].Seq = i
+			found[i].Slot = slot
+		}
+		deposits = append(deposits, found...)
+	}
+
+	return deposits, nil
+}
+
+// solanaTokenSender 找出交易中该mint余额减少最多的token账户owner，作为充值来源
+func solanaTokenSender(meta *rpc.TransactionMeta, mint solana.PublicKey) string {
+	post := make(map[uint16]*big.Int)
+	for _, balance := range meta.PostTokenBalances {
+		if amount, ok := solanaTokenAmount(balance); ok {
+			post[balance.AccountIndex] = amount
+		}
+	}
+
+	var sender string
+	maxSpent := new(big.Int)
+	for _, balance := range meta.PreTokenBalances {
+		if !balance.Mint.Equals(mint) || balance.Owner == nil {
+			continue
+		}
+		before, ok := solanaTokenAmount(balance)
+		if !ok {
+			continue
+		}
+		after := post[balance.AccountIndex]
+		if after == nil {
+			after = new(big.Int)
+		}
+		if spent := new(big.Int).Sub(before, after); spent.Cmp(maxSpent) > 0 {
+			maxSpent = spent
+			sender = balance.Owner.String()
+		}
+	}
+	return sender
+}
+
+// solanaTokenAmount 解析token余额的原始数量
+func solanaTokenAmount(balance rpc.TokenBalance) (*big.Int, bool) {
+	if balance.UiTokenAmount == nil {
+		return nil, false
+	}
+	return new(big.Int).SetString(balance.UiTokenAmount.Amount, 10)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/models"
+)
+
+// newSolanaFixtureServer 用录制的 getBlock 响应模拟 Solana 节点，312345679 模拟被跳过的slot
+func newSolanaFixtureServer(t *testing.T) *httptest.Server {
+	fixture, err := os.ReadFile("testdata/solana_get_block.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Method string        `json:"method"`
+			Params []json.Number `json:"params"`
+		}
+		json.Unmarshal(body, &req)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case req.Method == "getBlock" && len(req.Params) > 0 && req.Params[0] == "312345678":
+			w.Write(fixture)
+		case req.Method == "getBlock":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32007,"message":"Slot 312345679 was skipped, or missing due to ledger jump to recent snapshot"}}`))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`))
+		}
+	}))
+}
+
+func TestSolanaScanner_ExtractDeposits(t *testing.T) {
+	server := newSolanaFixtureServer(t)
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.Solana.RPCURL = server.URL
+	scanner, err := NewSolanaScannerService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := scanner.fetchBlock(context.Background(), 312345678)
+	if err != nil {
+		t.Fatalf("fetchBlock failed: %v", err)
+	}
+
+	ours := "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk"
+	mint := "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+	addresses := map[string]uint64{ours: 42}
+	mints := map[string]*models.CurrencyChainConfig{mint: {Symbol: "USDC-SPL", ChainType: "Solana", TokenAddress: &mint, Decimals: 6}}
+
+	deposits, err := ExtractSolanaDeposits(312345678, block, addresses, "SOL", mints)
+	if err != nil {
+		t.Fatalf("ExtractSolanaDeposits failed: %v", err)
+	}
+	// 第三笔交易执行失败，不应计入
+	if len(deposits) != 2 {
+		t.Fatalf("expected 2 deposits, got %d", len(deposits))
+	}
+
+	sol := deposits[0]
+	if sol.Symbol != "SOL" || sol.To != ours || sol.Amount.Int64() != 1500000000 || sol.HumanAmount() != 1.5 {
+		t.Errorf("unexpected SOL deposit: %+v", sol)
+	}
+	if sol.From != "AKnL4NNf3DGWZJS6cPknBuEGnVsV4A4m5tgebLHaRSZ9" {
+		t.Errorf("unexpected SOL sender %s", sol.From)
+	}
+	if sol.TxID() != "4PZgYMpK7Dy1RxA7Dqz6vhyESPctsRFxPDYgbBQkqdnJjjwGvDoB1HisyQpt4LyMBXqvXhLQQYELPizmEH8HYtPk" {
+		t.Errorf("unexpected SOL txid %s", sol.TxID())
+	}
+
+	token := deposits[1]
+	if token.Symbol != "USDC-SPL" || token.Mint != mint || token.To != ours || token.HumanAmount() != 5 {
+		t.Errorf("unexpected SPL deposit: %+v", token)
+	}
+	if token.From != "AKnL4NNf3DGWZJS6cPknBuEGnVsV4A4m5tgebLHaRSZ9" {
+		t.Errorf("unexpected SPL sender %s", token.From)
+	}
+	if token.UniqueID() == sol.UniqueID() || len(token.UniqueID()) != 64 {
+		t.Errorf("unique IDs must be distinct 64 char hashes")
+	}
+
+	// 跳过的slot返回空区块而不是错误
+	skipped, err := scanner.fetchBlock(context.Background(), 312345679)
+	if err != nil || skipped != nil {
+		t.Fatalf("expected skipped slot to return nil block, got %v, %v", skipped, err)
+	}
+}
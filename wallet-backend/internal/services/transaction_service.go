@@ -10,26 +10,29 @@ import (
 	"wallet-backend/internal/config"
 	"wallet-backend/internal/database"
 	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// TransactionService 交易服务
+// TransactionService 交易服务，币种所属链的节点连接由链适配器注册表提供
 type TransactionService struct {
 	config *config.Config
-	client *ethclient.Client
+	chains *ChainRegistry
+	nonces *NonceManager
 }
 
 // TransactionRequest 交易请求
 type TransactionRequest struct {
-	FromAddress string   `json:"from_address" binding:"required"`
-	ToAddress   string   `json:"to_address" binding:"required"`
-	Amount      string   `json:"amount" binding:"required"`
-	GasPrice    *big.Int `json:"gas_price,omitempty"`
-	GasLimit    uint64   `json:"gas_limit,omitempty"`
-	Data        []byte   `json:"data,omitempty"`
+	CurrencySymbol string   `json:"currency_symbol" binding:"required"` // 币种，决定交易发送到哪条链
+	FromAddress    string   `json:"from_address" binding:"required"`
+	ToAddress      string   `json:"to_address" binding:"required"`
+	Amount         string   `json:"amount" binding:"required"`
+	GasPrice       *big.Int `json:"gas_price,omitempty"`
+	GasLimit       uint64   `json:"gas_limit,omitempty"`
+	Data           []byte   `json:"data,omitempty"`
 }
 
 // TransactionResponse 交易响应
@@ -45,19 +48,32 @@ type TransactionResponse struct {
 	Timestamp int64  `json:"timestamp"`
 }
 
-// NewTransactionService 创建新的交易服务
-func NewTransactionService(cfg *config.Config) (*TransactionService, error) {
-	client, err := ethclient.Dial(cfg.Ethereum.GetTestnetRPCURL())
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum: %v", err)
+// NewTransactionService 创建新的交易服务，nonces 为空时直接使用节点的pending nonce
+func NewTransactionService(cfg *config.Config, chains *ChainRegistry, nonces *NonceManager) (*TransactionService, error) {
+	if chains == nil {
+		return nil, fmt.Errorf("chain registry is required")
 	}
 
 	return &TransactionService{
 		config: cfg,
-		client: client,
+		chains: chains,
+		nonces: nonces,
 	}, nil
 }
 
+// client 获取币种配置及其所属EVM链的客户端
+func (ts *TransactionService) client(symbol string) (*models.CurrencyChainConfig, *ethclient.Client, error) {
+	currency, err := ts.chains.Currency(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := ts.chains.EVMClientForSymbol(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	return currency, client, nil
+}
+
 // SendTransaction 发送交易
 func (ts *TransactionService) SendTransaction(req *TransactionRequest, privateKey *ecdsa.PrivateKey) (*TransactionResponse, error) {
 	// 验证地址
@@ -68,26 +84,25 @@ func (ts *TransactionService) SendTransaction(req *TransactionRequest, privateKe
 	fromAddress := common.HexToAddress(req.FromAddress)
 	toAddress := common.HexToAddress(req.ToAddress)
 
+	currency, client, err := ts.client(req.CurrencySymbol)
+	if err != nil {
+		return nil, err
+	}
+
 	// 解析金额
 	amount, ok := new(big.Int).SetString(req.Amount, 10)
 	if !ok {
 		return nil, fmt.Errorf("invalid amount format")
 	}
 
-	// 获取nonce
-	nonce, err := ts.client.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce: %v", err)
-	}
-
-	// 获取gas价格
-	var gasPrice *big.Int
+	// 获取手续费，指定gasPrice或币种配置为传统交易时发送传统交易，否则根据 eth_feeHistory 创建EIP-1559交易
+	var fee *blockchain.EVMFee
 	if req.GasPrice != nil {
-		gasPrice = req.GasPrice
+		fee = &blockchain.EVMFee{GasPrice: req.GasPrice}
 	} else {
-		gasPrice, err = ts.client.SuggestGasPrice(context.Background())
+		fee, err = blockchain.SuggestEVMFee(context.Background(), client, currency.LegacyTx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get gas price: %v", err)
+			return nil, err
 		}
 	}
 
@@ -100,37 +115,44 @@ func (ts *TransactionService) SendTransaction(req *TransactionRequest, privateKe
 		gasLimit = 21000
 	}
 
-	// 创建交易
-	tx := types.NewTransaction(
-		nonce,
-		toAddress,
-		amount,
-		gasLimit,
-		gasPrice,
-		req.Data,
-	)
+	// 创建交易，链ID优先使用币种配置（注册表已校验节点）
+	chainID := big.NewInt(currency.ChainID)
+	if currency.ChainID == 0 {
+		if chainID, err = client.ChainID(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to get chain ID: %v", err)
+		}
+	}
+	lease, err := reserveNonce(context.Background(), ts.nonces, client, chainID.Int64(), fromAddress.Hex())
+	if err != nil {
+		return nil, err
+	}
+	nonce := lease.Nonce
+	tx := blockchain.NewEVMTransaction(chainID, nonce, toAddress, amount, gasLimit, req.Data, fee)
 
 	// 签名交易
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(ts.config.Ethereum.Testnet.ChainID)), privateKey)
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(chainID), privateKey)
 	if err != nil {
+		lease.Release(context.Background())
 		return nil, fmt.Errorf("failed to sign transaction: %v", err)
 	}
 
 	// 广播交易
-	err = ts.client.SendTransaction(context.Background(), signedTx)
+	err = client.SendTransaction(context.Background(), signedTx)
 	if err != nil {
+		lease.Release(context.Background())
 		return nil, fmt.Errorf("failed to send transaction: %v", err)
 	}
+	lease.Commit(signedTx.Hash().Hex())
 
 	// 解析金额为float64
 	amountFloat, _ := strconv.ParseFloat(req.Amount, 64)
-	gasPriceFloat, _ := strconv.ParseFloat(gasPrice.String(), 64)
+	gasPriceFloat, _ := strconv.ParseFloat(fee.MaxPrice().String(), 64)
 
 	// 保存交易记录
 	chainBill := &models.ChainBill{
 		UserID:         0, // 需要从上下文获取
-		CurrencySymbol: "ETH",
-		ChainType:      "Ethereum",
+		CurrencySymbol: currency.Symbol,
+		ChainType:      currency.ChainType,
 		Address:        req.FromAddress,
 		TxID:           signedTx.Hash().Hex(),
 		Type:           2, // 提币
@@ -140,6 +162,7 @@ func (ts *TransactionService) SendTransaction(req *TransactionRequest, privateKe
 		Status:         0, // 待确认
 		CreatedTime:    time.Now(),
 	}
+	setOutboundTx(chainBill, signedTx)
 
 	if err := database.GetDB().Create(chainBill).Error; err != nil {
 		return nil, fmt.Errorf("failed to save transaction: %v", err)
@@ -150,7 +173,7 @@ func (ts *TransactionService) SendTransaction(req *TransactionRequest, privateKe
 		From:      req.FromAddress,
 		To:        req.ToAddress,
 		Amount:    req.Amount,
-		GasPrice:  gasPrice.String(),
+		GasPrice:  fee.MaxPrice().String(),
 		GasLimit:  gasLimit,
 		Nonce:     nonce,
 		Status:    "pending",
@@ -160,24 +183,32 @@ func (ts *TransactionService) SendTransaction(req *TransactionRequest, privateKe
 	return response, nil
 }
 
-// GetTransactionStatus 获取交易状态
-func (ts *TransactionService) GetTransactionStatus(txHash string) (*TransactionResponse, error) {
+// GetTransactionStatus 获取币种所属链上的交易状态
+func (ts *TransactionService) GetTransactionStatus(symbol, txHash string) (*TransactionResponse, error) {
+	_, client, err := ts.client(symbol)
+	if err != nil {
+		return nil, err
+	}
 	hash := common.HexToHash(txHash)
 
 	// 获取交易收据
-	receipt, err := ts.client.TransactionReceipt(context.Background(), hash)
+	receipt, err := client.TransactionReceipt(context.Background(), hash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction receipt: %v", err)
 	}
 
 	// 获取交易详情
-	tx, _, err := ts.client.TransactionByHash(context.Background(), hash)
+	tx, _, err := client.TransactionByHash(context.Background(), hash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction: %v", err)
 	}
 
 	// 获取发送方地址
-	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(ts.config.Ethereum.Testnet.ChainID)), tx)
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+	from, err := types.Sender(types.NewLondonSigner(chainID), tx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sender: %v", err)
 	}
@@ -218,8 +249,13 @@ func (ts *TransactionService) EstimateGas(req *TransactionRequest) (uint64, *big
 	// 使用默认gas limit
 	gasLimit := uint64(21000)
 
+	_, client, err := ts.client(req.CurrencySymbol)
+	if err != nil {
+		return 0, nil, err
+	}
+
 	// 获取gas价格
-	gasPrice, err := ts.client.SuggestGasPrice(context.Background())
+	gasPrice, err := client.SuggestGasPrice(context.Background())
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to get gas price: %v", err)
 	}
@@ -227,14 +263,18 @@ func (ts *TransactionService) EstimateGas(req *TransactionRequest) (uint64, *big
 	return gasLimit, gasPrice, nil
 }
 
-// GetBalance 获取余额
-func (ts *TransactionService) GetBalance(address string) (*big.Int, error) {
+// GetBalance 获取地址在币种所属链上的原生币余额
+func (ts *TransactionService) GetBalance(symbol, address string) (*big.Int, error) {
 	if !common.IsHexAddress(address) {
 		return nil, fmt.Errorf("invalid address format")
 	}
+	_, client, err := ts.client(symbol)
+	if err != nil {
+		return nil, err
+	}
 
 	account := common.HexToAddress(address)
-	balance, err := ts.client.BalanceAt(context.Background(), account, nil)
+	balance, err := client.BalanceAt(context.Background(), account, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get balance: %v", err)
 	}
@@ -242,14 +282,18 @@ func (ts *TransactionService) GetBalance(address string) (*big.Int, error) {
 	return balance, nil
 }
 
-// GetNonce 获取nonce
-func (ts *TransactionService) GetNonce(address string) (uint64, error) {
+// GetNonce 获取地址在币种所属链上的nonce
+func (ts *TransactionService) GetNonce(symbol, address string) (uint64, error) {
 	if !common.IsHexAddress(address) {
 		return 0, fmt.Errorf("invalid address format")
 	}
+	_, client, err := ts.client(symbol)
+	if err != nil {
+		return 0, err
+	}
 
 	account := common.HexToAddress(address)
-	nonce, err := ts.client.PendingNonceAt(context.Background(), account)
+	nonce, err := client.PendingNonceAt(context.Background(), account)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get nonce: %v", err)
 	}
@@ -271,19 +315,18 @@ func (ts *TransactionService) WaitForTransaction(txHash string, confirmations in
 	return nil
 }
 
-// UpdateTransactionStatus 更新交易状态
+// UpdateTransactionStatus 按账单所属币种的链更新交易状态
 func (ts *TransactionService) UpdateTransactionStatus(txHash string) error {
-	response, err := ts.GetTransactionStatus(txHash)
-	if err != nil {
-		return err
-	}
-
-	// 更新数据库中的交易状态
 	var chainBill models.ChainBill
-	if err := database.GetDB().Where("txid = ?", txHash).First(&chainBill).Error; err != nil {
+	if err := database.GetDB().Where("tx_id = ?", txHash).First(&chainBill).Error; err != nil {
 		return fmt.Errorf("failed to find transaction: %v", err)
 	}
 
+	response, err := ts.GetTransactionStatus(chainBill.CurrencySymbol, txHash)
+	if err != nil {
+		return err
+	}
+
 	// 更新状态
 	var status int
 	switch response.Status {
@@ -305,9 +348,5 @@ func (ts *TransactionService) UpdateTransactionStatus(txHash string) error {
 	return nil
 }
 
-// Close 关闭连接
-func (ts *TransactionService) Close() {
-	if ts.client != nil {
-		ts.client.Close()
-	}
-}
+// Close 关闭服务，节点连接由链适配器注册表统一关闭
+func (ts *TransactionService) Close() {}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+)
+
+// TronDeposit 区块中识别出的一笔TRON充值（TRX或TRC-20代币）
+type TronDeposit struct {
+	Hash        string
+	Seq         int // 同一交易内的充值序号
+	BlockNumber uint64
+	Symbol      string
+	Contract    string // TRC-20合约地址，TRX为空
+	LogIndex    int    // 事件在交易回执中的序号，TRX为-1
+	From        string
+	To          string
+	Amount      *big.Int
+	Decimals    int
+}
+
+// TxID 写入账单的交易ID，同一交易内的多笔充值追加序号
+func (d *TronDeposit) TxID() string {
+	if d.Seq == 0 {
+		return d.Hash
+	}
+	return fmt.Sprintf("%s:%d", d.Hash, d.Seq)
+}
+
+// UniqueID 充值唯一标识，由交易哈希、合约和事件序号确定
+func (d *TronDeposit) UniqueID() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("tron:%s:%s:%d", d.Hash, d.Contract, d.LogIndex)))
+	return hex.EncodeToString(sum[:])
+}
+
+// TronScannerService TRON区块扫描服务，识别TRX转账和TRC-20 Transfer事件
+type TronScannerService struct {
+	config *config.Config
+	client *blockchain.TronClient
+
+	mu         sync.Mutex
+	isScanning bool
+	stopChan   chan bool
+}
+
+// NewTronScannerService 创建TRON扫描服务
+func NewTronScannerService(cfg *config.Config) (*TronScannerService, error) {
+	if cfg.Tron.RPCURL == "" {
+		return nil, fmt.Errorf("tron rpc_url is not configured")
+	}
+	return &TronScannerService{
+		config:   cfg,
+		client:   blockchain.NewTronClient(cfg.Tron.RPCURL, cfg.Tron.APIKey),
+		stopChan: make(chan bool),
+	}, nil
+}
+
+// StartScanning 开始扫描
+func (s *TronScannerService) StartScanning() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isScanning {
+		return fmt.Errorf("tron scanner is already running")
+	}
+
+	s.isScanning = true
+	go s.scanLoop()
+	return nil
+}
+
+// StopScanning 停止扫描
+func (s *TronScannerService) StopScanning() {
+	s.mu.Lock()
+	running := s.isScanning
+	s.isScanning = false
+	s.mu.Unlock()
+
+	if running {
+		s.stopChan <- true
+	}
+}
+
+// Status 返回扫描状态
+func (s *TronScannerService) Status() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isScanning
+}
+
+// scanLoop 扫描主循环
+func (s *TronScannerService) scanLoop() {
+	ticker := time.NewTicker(time.Duration(s.config.Scanner.ScanInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			log.Println("Tron scanner stopped")
+			return
+		case <-ticker.C:
+			if err := s.ScanOnce(); err != nil {
+				log.Printf("Error scanning TRON blocks: %v", err)
+			}
+		}
+	}
+}
+
+// ScanOnce 从上次扫描位置扫描到 最新区块-确认数，每次最多 max_blocks_per_scan 个区块
+func (s *TronScannerService) ScanOnce() error {
+	ctx := context.Background()
+
+	var currencies []models.CurrencyChainConfig
+	if err := database.DB.Where("chain_type = ? AND is_enabled = ?", "TRON", true).Find(&currencies).Error; err != nil {
+		return fmt.Errorf("failed to get TRON currencies: %v", err)
+	}
+	if len(currencies) == 0 {
+		return nil
+	}
+
+	head, err := s.client.GetNowBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %v", err)
+	}
+	latest := head.BlockHeader.RawData.Number
+	confirmations := uint64(s.config.Tron.Confirmations)
+	if latest < confirmations {
+		return nil
+	}
+	latest -= confirmations
+
+	var lastScanned *uint64
+	for _, currency := range currencies {
+		if currency.LastScannedBlock != nil && (lastScanned == nil || *currency.LastScannedBlock < *lastScanned) {
+			value := *currency.LastScannedBlock
+			lastScanned = &value
+		}
+	}
+	start := latest
+	if lastScanned != nil {
+		start = *lastScanned + 1
+	}
+	if start > latest {
+		return nil
+	}
+	end := latest
+	if maxBlocks := uint64(s.config.Scanner.MaxBlocksPerScan); maxBlocks > 0 && end-start+1 > maxBlocks {
+		end = start + maxBlocks - 1
+	}
+
+	var native *models.CurrencyChainConfig
+	tokens := make(map[string]*models.CurrencyChainConfig)
+	for i := range currencies {
+		currency := &currencies[i]
+		if currency.TokenAddress == nil || *currency.TokenAddress == "" {
+			native = currency
+		} else {
+			tokens[*currency.TokenAddress] = currency
+		}
+	}
+
+	addresses, err := s.loadAddresses()
+	if err != nil {
+		return err
+	}
+
+	scanned := start - 1
+	for number := start; number <= end; number++ {
+		block, err := s.client.GetBlockByNum(ctx, number)
+		if err != nil {
+			log.Printf("Failed to get TRON block %d: %v", number, err)
+			break
+		}
+
+		var infos []blockchain.TronTransactionInfo
+		if len(tokens) > 0 && len(block.Transactions) > 0 {
+			if infos, err = s.client.GetTransactionInfoByBlockNum(ctx, number); err != nil {
+				log.Printf("Failed to get TRON transaction info for block %d: %v", number, err)
+				break
+			}
+		}
+
+		deposits, err := ExtractTronDeposits(number, block, infos, addresses, native, tokens)
+		if err != nil {
+			log.Printf("Failed to parse TRON block %d: %v", number, err)
+			break
+		}
+		for i := range deposits {
+			deposit := &deposits[i]
+			if err := saveDepositEntry(&depositEntry{
+				UserID:    addresses[deposit.To],
+				ChainType: "TRON",
+				Symbol:    deposit.Symbol,
+				From:      deposit.From,
+				To:        deposit.To,
+				TxID:      deposit.TxID(),
+				UniqueID:  deposit.UniqueID(),
+				Height:    deposit.BlockNumber,
+				Amount:    unitsToFloat(deposit.Amount, deposit.Decimals),
+			}); err != nil {
+				return fmt.Errorf("failed to save deposit %s: %v", deposit.TxID(), err)
+			}
+		}
+		scanned = number
+	}
+
+	if scanned < start {
+		return nil
+	}
+	if err := database.DB.Model(&models.CurrencyChainConfig{}).
+		Where("chain_type = ? AND is_enabled = ?", "TRON", true).
+		Update("last_scanned_block", scanned).Error; err != nil {
+		return fmt.Errorf("failed to update last scanned block: %v", err)
+	}
+	return nil
+}
+
+// loadAddresses 加载TRON地址库，地址 -> 用户ID
+func (s *TronScannerService) loadAddresses() (map[string]uint64, error) {
+	var list []models.AddressLibrary
+	if err := database.DB.Where("chain_type = ?", "TRON").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load TRON addresses: %v", err)
+	}
+
+	addresses := make(map[string]uint64, len(list))
+	for _, addr := range list {
+		var userID uint64
+		if addr.UserID != nil {
+			userID = *addr.UserID
+		}
+		addresses[addr.Address] = userID
+	}
+	return addresses, nil
+}
+
+// ExtractTronDeposits 解析区块中转入我方地址的TRX和TRC-20代币
+// TRX来自 TransferContract，代币来自交易回执中已配置合约的 Transfer 事件，执行失败的交易不计入
+func ExtractTronDeposits(number uint64, block *blockchain.TronBlock, infos []blockchain.TronTransactionInfo, addresses map[string]uint64, native *models.CurrencyChainConfig, tokens map[string]*models.CurrencyChainConfig) ([]TronDeposit, error) {
+	var deposits []TronDeposit
+	seq := make(map[string]int)
+	add := func(deposit TronDeposit) {
+		deposit.Seq = seq[deposit.Hash]
+		deposit.BlockNumber = number
+		seq[deposit.Hash]++
+		deposits = append(deposits, deposit)
+	}
+
+	if native != nil {
+		for i := range block.Transactions {
+			tx := &block.Transactions[i]
+			if !tx.Succeeded() {
+				continue
+			}
+			contracts, err := tx.Contracts()
+			if err != nil {
+				return nil, fmt.Errorf("transaction %s: %v", tx.TxID, err)
+			}
+			if len(contracts) == 0 || contracts[0].Type != "TransferContract" {
+				continue
+			}
+
+			var transfer blockchain.TronTransferContract
+			if err := json.Unmarshal(contracts[0].Parameter.Value, &transfer); err != nil {
+				return nil, fmt.Errorf("transaction %s: %v", tx.TxID, err)
+			}
+			to, err := blockchain.TronAddressFromHex(transfer.ToAddress)
+			if err != nil {
+				continue
+			}
+			if _, ok := addresses[to]; !ok || transfer.Amount <= 0 {
+				continue
+			}
+			from, _ := blockchain.TronAddressFromHex(transfer.OwnerAddress)
+
+			add(TronDeposit{
+				Hash:     tx.TxID,
+				Symbol:   native.Symbol,
+				LogIndex: -1,
+				From:     from,
+				To:       to,
+				Amount:   big.NewInt(transfer.Amount),
+				Decimals: 6,
+			})
+		}
+	}
+
+	for _, info := range infos {
+		if info.Result == "FAILED" || (info.Receipt.Result != "" && info.Receipt.Result != "SUCCESS") {
+			continue
+		}
+		for logIndex, event := range info.Log {
+			if len(event.Topics) != 3 || !strings.EqualFold(event.Topics[0], blockchain.TronTransferEventTopic) {
+				continue
+			}
+			contract, err := blockchain.TronAddressFromHex(event.Address)
+			if err != nil {
+				continue
+			}
+			currency, ok := tokens[contract]
+			if !ok {
+				continue
+			}
+			to, err := blockchain.TronAddressFromHex(event.Topics[2])
+			if err != nil {
+				continue
+			}
+			if _, ok := addresses[to]; !ok {
+				continue
+			}
+			amount, ok := new(big.Int).SetString(event.Data, 16)
+			if !ok || amount.Sign() <= 0 {
+				continue
+			}
+			from, _ := blockchain.TronAddressFromHex(event.Topics[1])
+
+			add(TronDeposit{
+				Hash:     info.ID,
+				Symbol:   currency.Symbol,
+				Contract: contract,
+				LogIndex: logIndex,
+				From:     from,
+				To:       to,
+				Amount:   amount,
+				Decimals: currency.Decimals,
+			})
+		}
+	}
+
+	return deposits, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+)
+
+func TestTronScanner_ExtractDeposits(t *testing.T) {
+	blockJSON, err := os.ReadFile("testdata/tron_get_block.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoJSON, err := os.ReadFile("testdata/tron_get_transaction_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wallet/getblockbynum":
+			w.Write(blockJSON)
+		case "/wallet/gettransactioninfobyblocknum":
+			w.Write(infoJSON)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := blockchain.NewTronClient(server.URL, "")
+	block, err := client.GetBlockByNum(context.Background(), 63496609)
+	if err != nil {
+		t.Fatalf("GetBlockByNum failed: %v", err)
+	}
+	infos, err := client.GetTransactionInfoByBlockNum(context.Background(), 63496609)
+	if err != nil {
+		t.Fatalf("GetTransactionInfoByBlockNum failed: %v", err)
+	}
+
+	usdt := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	addresses := map[string]uint64{
+		"TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH": 1,
+		"TSeJkUh4Qv67VNFwY8LaAxERygNdy6NQZK": 2,
+	}
+	native := &models.CurrencyChainConfig{Symbol: "TRX", ChainType: "TRON", Decimals: 6}
+	tokens := map[string]*models.CurrencyChainConfig{usdt: {Symbol: "USDT-TRC20", ChainType: "TRON", TokenAddress: &usdt, Decimals: 6}}
+
+	deposits, err := ExtractTronDeposits(63496609, block, infos, addresses, native, tokens)
+	if err != nil {
+		t.Fatalf("ExtractTronDeposits failed: %v", err)
+	}
+	// 第三笔交易执行失败，不应计入
+	if len(deposits) != 2 {
+		t.Fatalf("expected 2 deposits, got %d", len(deposits))
+	}
+
+	trx := deposits[0]
+	if trx.Symbol != "TRX" || trx.To != "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH" || trx.From != "TBXoTbWzjcYRJctYNGakstXYqrgjMP9gpR" {
+		t.Errorf("unexpected TRX deposit: %+v", trx)
+	}
+	if unitsToFloat(trx.Amount, trx.Decimals) != 25 {
+		t.Errorf("expected 25 TRX, got %s", trx.Amount)
+	}
+
+	token := deposits[1]
+	if token.Symbol != "USDT-TRC20" || token.Contract != usdt || token.To != "TSeJkUh4Qv67VNFwY8LaAxERygNdy6NQZK" || token.From != "TBXoTbWzjcYRJctYNGakstXYqrgjMP9gpR" {
+		t.Errorf("unexpected TRC-20 deposit: %+v", token)
+	}
+	if unitsToFloat(token.Amount, token.Decimals) != 120 {
+		t.Errorf("expected 120 USDT, got %s", token.Amount)
+	}
+	if token.TxID() != token.Hash || token.UniqueID() == trx.UniqueID() {
+		t.Errorf("unexpected identifiers: %s %s", token.TxID(), token.UniqueID())
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TronWalletService TRON钱包服务：构建TRX/TRC-20转账，通过签名器签名后广播
+type TronWalletService struct {
+	config *config.Config
+	client *blockchain.TronClient
+	signer Signer
+}
+
+// NewTronWalletService 创建TRON钱包服务
+func NewTronWalletService(cfg *config.Config, signer Signer) (*TronWalletService, error) {
+	if cfg.Tron.RPCURL == "" {
+		return nil, fmt.Errorf("tron rpc_url is not configured")
+	}
+	return &TronWalletService{
+		config: cfg,
+		client: blockchain.NewTronClient(cfg.Tron.RPCURL, cfg.Tron.APIKey),
+		signer: signer,
+	}, nil
+}
+
+// GetBalance 获取币种余额（最小单位），代币按合约查询 balanceOf
+func (tws *TronWalletService) GetBalance(ctx context.Context, currency *models.CurrencyChainConfig, address string) (*big.Int, error) {
+	if currency.TokenAddress != nil && *currency.TokenAddress != "" {
+		return tws.client.GetTRC20Balance(ctx, *currency.TokenAddress, address)
+	}
+	return tws.client.GetBalance(ctx, address)
+}
+
+// CreateTransfer 构建并签名转账交易，currency 为TRC-20代币时调用合约 transfer，amount 为最小单位
+func (tws *TronWalletService) CreateTransfer(ctx context.Context, from *models.AddressLibrary, currency *models.CurrencyChainConfig, toAddress string, amount *big.Int) (*blockchain.TronTransaction, error) {
+	if tws.signer == nil {
+		return nil, fmt.Errorf("signer is not configured")
+	}
+	if err := blockchain.ValidateTronAddress(toAddress); err != nil {
+		return nil, fmt.Errorf("invalid to address: %v", err)
+	}
+
+	var tx *blockchain.TronTransaction
+	var err error
+	if currency.TokenAddress != nil && *currency.TokenAddress != "" {
+		tx, err = tws.client.CreateTRC20TransferTransaction(ctx, from.Address, *currency.TokenAddress, toAddress, amount, tws.config.Tron.FeeLimit)
+	} else {
+		tx, err = tws.client.CreateTransferTransaction(ctx, from.Address, toAddress, amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tws.signTransaction(ctx, from, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// CreateWithdrawal 为提币记录构建并签名交易，金额按币种精度换算为最小单位
+func (tws *TronWalletService) CreateWithdrawal(ctx context.Context, withdraw *models.WithdrawRecord, currency *models.CurrencyChainConfig) (*blockchain.TronTransaction, error) {
+	from, err := lookupAddress(withdraw.FromAddress, "TRON")
+	if err != nil {
+		return nil, err
+	}
+
+	return tws.CreateTransfer(ctx, from, currency, withdraw.ToAddress, floatToUnits(withdraw.Amount, currency.Decimals))
+}
+
+// SendTransaction 广播已签名交易
+func (tws *TronWalletService) SendTransaction(ctx context.Context, tx *blockchain.TronTransaction) error {
+	if err := tws.client.BroadcastTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("failed to send transaction: %v", err)
+	}
+	return nil
+}
+
+// signTransaction 校验交易哈希后签名，并确认签名恢复出的地址就是发送方
+func (tws *TronWalletService) signTransaction(ctx context.Context, from *models.AddressLibrary, tx *blockchain.TronTransaction) error {
+	hash, err := tx.Hash()
+	if err != nil {
+		return err
+	}
+
+	sig, err := tws.signer.SignHash(ctx, from, hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return fmt.Errorf("failed to recover signer: %v", err)
+	}
+	signer, err := blockchain.EncodeTronAddress(crypto.CompressPubkey(pub))
+	if err != nil || signer != from.Address {
+		return fmt.Errorf("signature sender %s does not match %s", signer, from.Address)
+	}
+
+	tx.Signature = []string{hex.EncodeToString(sig)}
+	return nil
+}
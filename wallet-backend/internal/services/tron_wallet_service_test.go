@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestTronWalletService_TRC20Transfer(t *testing.T) {
+	usdt := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	to := "TSeJkUh4Qv67VNFwY8LaAxERygNdy6NQZK"
+	from := &models.AddressLibrary{ChainType: "TRON", IndexNum: 0, Address: "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH"}
+
+	var broadcast blockchain.TronTransaction
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/wallet/triggersmartcontract":
+			var req map[string]interface{}
+			json.Unmarshal(body, &req)
+			// 模拟节点：raw_data_hex 中包含合约地址和调用数据
+			raw, _ := hex.DecodeString("0a02e19f" + req["contract_address"].(string) + "a9059cbb" + req["parameter"].(string))
+			sum := sha256.Sum256(raw)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"result": map[string]interface{}{"result": true},
+				"transaction": map[string]interface{}{
+					"txID":         hex.EncodeToString(sum[:]),
+					"raw_data":     map[string]interface{}{"fee_limit": req["fee_limit"]},
+					"raw_data_hex": hex.EncodeToString(raw),
+				},
+			})
+		case "/wallet/broadcasttransaction":
+			json.Unmarshal(body, &broadcast)
+			w.Write([]byte(`{"result":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.Tron.RPCURL = server.URL
+	cfg.Tron.FeeLimit = 100000000
+	tws, err := NewTronWalletService(cfg, newTestSigner(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	currency := &models.CurrencyChainConfig{Symbol: "USDT-TRC20", ChainType: "TRON", TokenAddress: &usdt, Decimals: 6}
+	tx, err := tws.CreateTransfer(context.Background(), from, currency, to, big.NewInt(120000000))
+	if err != nil {
+		t.Fatalf("CreateTransfer failed: %v", err)
+	}
+	if err := tws.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("SendTransaction failed: %v", err)
+	}
+
+	// 广播的签名可恢复出发送方地址
+	if len(broadcast.Signature) != 1 {
+		t.Fatalf("expected one signature, got %d", len(broadcast.Signature))
+	}
+	sig, _ := hex.DecodeString(broadcast.Signature[0])
+	hash, _ := hex.DecodeString(broadcast.TxID)
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer, _ := blockchain.EncodeTronAddress(crypto.CompressPubkey(pub)); signer != from.Address {
+		t.Fatalf("signature recovers %s, expected %s", signer, from.Address)
+	}
+
+	// 派生索引不匹配时签名器拒绝签名
+	wrong := &models.AddressLibrary{ChainType: "TRON", IndexNum: 1, Address: from.Address}
+	if _, err := tws.CreateTransfer(context.Background(), wrong, currency, to, big.NewInt(1)); err == nil {
+		t.Fatal("expected signing with mismatched index to fail")
+	}
+}
@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+	"wallet-backend/internal/config"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"gorm.io/gorm"
+)
+
+// 卡住交易的替换方式
+const (
+	TxReplaceSpeedUp = "speed_up" // 相同交易内容，提高手续费
+	TxReplaceCancel  = "cancel"   // 0金额转给自己，占用nonce使原交易作废
+)
+
+// TxMonitorService 卡住交易监控：检查发送后长时间未上链的EVM转出交易（归集、提币）
+// 节点已丢弃的交易重新广播；仍在交易池中的交易可以按加价策略以相同nonce加速或取消，
+// 替换交易记为新的链上账单，原账单状态改为已被替换，两者通过 replaces / replaced_by 关联
+type TxMonitorService struct {
+	config *config.Config
+	chains *ChainRegistry
+	signer Signer
+	gas    *GasOracleService
+	nonces *NonceManager
+
+	mu        sync.Mutex
+	isRunning bool
+	stopChan  chan bool
+}
+
+// NewTxMonitorService 创建卡住交易监控服务，gas 为空时直接采样市场价格，nonces 为空时不记录替换交易的nonce
+func NewTxMonitorService(cfg *config.Config, chains *ChainRegistry, signer Signer, gas *GasOracleService, nonces *NonceManager) (*TxMonitorService, error) {
+	if chains == nil {
+		return nil, fmt.Errorf("chain registry is required")
+	}
+	return &TxMonitorService{
+		config:   cfg,
+		chains:   chains,
+		signer:   signer,
+		gas:      gas,
+		nonces:   nonces,
+		stopChan: make(chan bool),
+	}, nil
+}
+
+// Start 开始定期检查
+func (m *TxMonitorService) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isRunning {
+		return fmt.Errorf("transaction monitor is already running")
+	}
+
+	m.isRunning = true
+	go m.monitorLoop()
+	return nil
+}
+
+// Stop 停止检查
+func (m *TxMonitorService) Stop() {
+	m.mu.Lock()
+	running := m.isRunning
+	m.isRunning = false
+	m.mu.Unlock()
+
+	if running {
+		m.stopChan <- true
+	}
+}
+
+// monitorLoop 检查主循环
+func (m *TxMonitorService) monitorLoop() {
+	ticker := time.NewTicker(time.Duration(m.config.TxMonitor.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			log.Println("Transaction monitor stopped")
+			return
+		case <-ticker.C:
+			if err := m.CheckOnce(context.Background()); err != nil {
+				log.Printf("Transaction monitor error: %v", err)
+			}
+		}
+	}
+}
+
+// CheckOnce 检查所有超过 stuck_after 仍未上链的转出交易
+func (m *TxMonitorService) CheckOnce(ctx context.Context) error {
+	cutoff := time.Now().Add(-time.Duration(m.config.TxMonitor.StuckAfter) * time.Second)
+	var bills []models.ChainBill
+	if err := database.DB.Where("status = ? AND raw_tx IS NOT NULL AND replaced_by IS NULL AND block_height IS NULL AND created_time < ?", 0, cutoff).
+		Find(&bills).Error; err != nil {
+		return fmt.Errorf("failed to load pending transactions: %v", err)
+	}
+
+	for i := range bills {
+		if err := m.checkBill(ctx, &bills[i]); err != nil {
+			log.Printf("Failed to check transaction %s: %v", bills[i].TxID, err)
+		}
+	}
+	return nil
+}
+
+// checkBill 检查一笔待确认的转出交易
+func (m *TxMonitorService) checkBill(ctx context.Context, bill *models.ChainBill) error {
+	client, err := m.client(bill.ChainType)
+	if err != nil {
+		return err
+	}
+	tx, err := decodeRawTx(*bill.RawTx)
+	if err != nil {
+		return err
+	}
+
+	receipt, err := client.TransactionReceipt(ctx, tx.Hash())
+	if err == nil {
+		return m.markMined(bill, receipt)
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return fmt.Errorf("failed to get receipt: %v", err)
+	}
+
+	// nonce已被其它交易使用（如在其它实例上替换），原交易不会再上链
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return fmt.Errorf("failed to recover sender: %v", err)
+	}
+	confirmed, err := client.NonceAt(ctx, from, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmed nonce: %v", err)
+	}
+	if confirmed > tx.Nonce() {
+		return updateBill(bill, map[string]interface{}{"status": 2, "remark": "nonce used by another transaction"})
+	}
+
+	_, isPending, err := client.TransactionByHash(ctx, tx.Hash())
+	if errors.Is(err, ethereum.NotFound) {
+		// 节点已丢弃交易，重新广播
+		log.Printf("Transaction %s was dropped, rebroadcasting", bill.TxID)
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			return fmt.Errorf("failed to rebroadcast: %v", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get transaction: %v", err)
+	}
+	if !isPending || !m.config.TxMonitor.AutoSpeedUp {
+		return nil
+	}
+
+	var replacements int64
+	if err := database.DB.Model(&models.ChainBill{}).
+		Where("chain_type = ? AND address = ? AND nonce = ? AND replaces IS NOT NULL", bill.ChainType, bill.Address, tx.Nonce()).
+		Count(&replacements).Error; err != nil {
+		return err
+	}
+	if replacements >= int64(m.config.TxMonitor.MaxReplacements) {
+		log.Printf("Transaction %s is still pending after %d replacements", bill.TxID, replacements)
+		return nil
+	}
+
+	if _, err := m.Replace(ctx, bill.ID, TxReplaceSpeedUp); err != nil {
+		if errors.Is(err, blockchain.ErrEVMFeeAboveCap) {
+			log.Printf("Speed-up of %s deferred: %v", bill.TxID, err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// markMined 记录已上链交易的区块高度，达到确认数后由确认跟踪服务改为已确认；执行失败的交易直接标记失败
+func (m *TxMonitorService) markMined(bill *models.ChainBill, receipt *types.Receipt) error {
+	updates := map[string]interface{}{"block_height": receipt.BlockNumber.Uint64()}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		updates["status"] = 2 // 失败
+	}
+	return updateBill(bill, updates)
+}
+
+// Replace 以相同nonce替换一笔待确认的转出交易，返回替换交易的账单
+// 手续费按 BumpEVMFee 计算：至少比原交易高 fee_bump_percent%，且不低于 speed_up 档位的市场价格；
+// 超过 speed_up.max_fee_gwei 中该链的上限时返回 blockchain.ErrEVMFeeAboveCap
+// 原账单在签名前改为已被替换，同一笔交易同时只有一次替换；提币的交易ID随加速更新，取消时提币标记发送失败并解冻
+func (m *TxMonitorService) Replace(ctx context.Context, billID uint64, action string) (*models.ChainBill, error) {
+	if action != TxReplaceSpeedUp && action != TxReplaceCancel {
+		return nil, fmt.Errorf("unknown replace action: %s", action)
+	}
+	if m.signer == nil {
+		return nil, fmt.Errorf("signer is not configured")
+	}
+
+	var bill models.ChainBill
+	if err := database.DB.First(&bill, billID).Error; err != nil {
+		return nil, fmt.Errorf("transaction %d not found: %v", billID, err)
+	}
+	if bill.Status != 0 || bill.ReplacedBy != nil {
+		return nil, fmt.Errorf("transaction %s is not pending", bill.TxID)
+	}
+	if bill.RawTx == nil {
+		return nil, fmt.Errorf("transaction %s has no signed transaction to replace", bill.TxID)
+	}
+
+	client, err := m.client(bill.ChainType)
+	if err != nil {
+		return nil, err
+	}
+	old, err := decodeRawTx(*bill.RawTx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(old.ChainId()), old)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %v", err)
+	}
+	fromAddr, err := lookupAddress(bill.Address, bill.ChainType)
+	if err != nil {
+		return nil, err
+	}
+
+	fee := blockchain.BumpEVMFee(blockchain.EVMFeeOf(old), m.config.TxMonitor.FeeBumpPercent, m.marketFee(ctx, client, bill.ChainType))
+	if maxPrice := gweiToWei(m.config.TxMonitor.SpeedUp.MaxFeeFor(bill.ChainType)); maxPrice != nil && fee.MaxPrice().Cmp(maxPrice) > 0 {
+		return nil, fmt.Errorf("%w: %s wei > %s wei", blockchain.ErrEVMFeeAboveCap, fee.MaxPrice(), maxPrice)
+	}
+
+	// 抢占原账单，替换未能广播时恢复为待处理
+	claimed := database.DB.Model(&models.ChainBill{}).
+		Where("id = ? AND status = ? AND replaced_by IS NULL", bill.ID, 0).
+		Update("status", 3)
+	if claimed.Error != nil {
+		return nil, fmt.Errorf("failed to claim transaction %s: %v", bill.TxID, claimed.Error)
+	}
+	if claimed.RowsAffected == 0 {
+		return nil, fmt.Errorf("transaction %s is already being replaced", bill.TxID)
+	}
+	signedTx, err := m.signReplacement(ctx, &bill, old, from, fromAddr, fee, action)
+	if err == nil {
+		err = client.SendTransaction(ctx, signedTx)
+		if err != nil {
+			err = fmt.Errorf("failed to send replacement: %v", err)
+		}
+	}
+	if err != nil {
+		if err := database.DB.Model(&models.ChainBill{}).
+			Where("id = ? AND status = ? AND replaced_by IS NULL", bill.ID, 3).
+			Update("status", 0).Error; err != nil {
+			log.Printf("Failed to restore transaction %s: %v", bill.TxID, err)
+		}
+		return nil, err
+	}
+
+	hash := signedTx.Hash().Hex()
+	remark := action
+	amount := bill.Amount
+	if action == TxReplaceCancel {
+		amount = 0
+	}
+	replacement := &models.ChainBill{
+		UserID:         bill.UserID,
+		CurrencySymbol: bill.CurrencySymbol,
+		ChainType:      bill.ChainType,
+		Protocol:       bill.Protocol,
+		Address:        bill.Address,
+		TxID:           hash,
+		Type:           bill.Type,
+		Amount:         amount,
+		Status:         0, // 待处理
+		Replaces:       &bill.TxID,
+		Remark:         &remark,
+	}
+	setOutboundTx(replacement, signedTx)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(replacement).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&bill).Updates(map[string]interface{}{"status": 3, "replaced_by": hash}).Error; err != nil {
+			return err
+		}
+		return replaceWithdrawTx(tx, bill.TxID, hash, action)
+	})
+	if err != nil {
+		// 替换交易已广播，记录失败不影响链上结果
+		log.Printf("Failed to save replacement %s of %s: %v", hash, bill.TxID, err)
+	}
+
+	if m.nonces != nil {
+		if err := m.nonces.setStatus(old.ChainId().Int64(), from.Hex(), old.Nonce(), nonceSent, &hash); err != nil {
+			log.Printf("Failed to record replacement nonce: %v", err)
+		}
+	}
+
+	log.Printf("Transaction %s replaced by %s (%s), max gas price %s wei", bill.TxID, hash, action, fee.MaxPrice())
+	return replacement, nil
+}
+
+// signReplacement 构建并签名替换交易：加速保持原交易内容，取消改为0金额的自转账
+func (m *TxMonitorService) signReplacement(ctx context.Context, bill *models.ChainBill, old *types.Transaction, from common.Address, fromAddr *models.AddressLibrary, fee *blockchain.EVMFee, action string) (*types.Transaction, error) {
+	var tx *types.Transaction
+	if action == TxReplaceCancel {
+		tx = blockchain.NewEVMTransaction(old.ChainId(), old.Nonce(), from, big.NewInt(0), 21000, nil, fee)
+	} else {
+		if old.To() == nil {
+			return nil, fmt.Errorf("transaction %s creates a contract and cannot be replaced", bill.TxID)
+		}
+		tx = blockchain.NewEVMTransaction(old.ChainId(), old.Nonce(), *old.To(), old.Value(), old.Gas(), old.Data(), fee)
+	}
+
+	signedTx, err := m.signer.SignTx(ctx, fromAddr, tx, old.ChainId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign replacement: %v", err)
+	}
+	return signedTx, nil
+}
+
+// replaceWithdrawTx 提币交易被替换：加速后提币跟踪新交易；取消后提币不会到账，标记发送失败并解冻
+func replaceWithdrawTx(tx *gorm.DB, oldHash, newHash, action string) error {
+	var withdraw models.WithdrawRecord
+	err := tx.Where("tx_id = ? AND status IN ?", oldHash, withdrawInFlight).First(&withdraw).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if action == TxReplaceCancel {
+		return failWithdrawalFrom(tx, &withdraw, withdrawInFlight, 12, "cancelled by "+newHash)
+	}
+	return tx.Model(&withdraw).Update("tx_id", newHash).Error
+}
+
+// History 获取与账单相关的替换记录，按从原交易到最新替换交易的顺序
+func (m *TxMonitorService) History(billID uint64) ([]models.ChainBill, error) {
+	var bill models.ChainBill
+	if err := database.DB.First(&bill, billID).Error; err != nil {
+		return nil, fmt.Errorf("transaction %d not found: %v", billID, err)
+	}
+
+	// 向前找到原交易
+	for bill.Replaces != nil {
+		var prev models.ChainBill
+		if err := database.DB.Where("tx_id = ?", *bill.Replaces).First(&prev).Error; err != nil {
+			break
+		}
+		bill = prev
+	}
+
+	history := []models.ChainBill{bill}
+	for bill.ReplacedBy != nil {
+		var next models.ChainBill
+		if err := database.DB.Where("tx_id = ?", *bill.ReplacedBy).First(&next).Error; err != nil {
+			break
+		}
+		history = append(history, next)
+		bill = next
+	}
+	return history, nil
+}
+
+// marketFee 替换交易参考的市场价格，获取失败时只按原交易加价
+func (m *TxMonitorService) marketFee(ctx context.Context, client blockchain.EVMFeeClient, chainType string) *blockchain.EVMFee {
+	var levels *blockchain.EVMFeeLevels
+	if m.gas != nil {
+		sampled, err := m.gas.LevelsFor(ctx, chainType)
+		if err != nil {
+			log.Printf("Failed to get gas fee levels of %s: %v", chainType, err)
+			return nil
+		}
+		levels = sampled.EVMFeeLevels
+	} else {
+		sampled, err := blockchain.SampleEVMFeeLevels(ctx, client, m.config.GasOracle.Blocks, m.chains.LegacyTx(chainType))
+		if err != nil {
+			log.Printf("Failed to sample gas fees of %s: %v", chainType, err)
+			return nil
+		}
+		levels = sampled
+	}
+
+	fee, err := levels.Level(m.config.TxMonitor.SpeedUp.Strategy)
+	if err != nil {
+		log.Printf("Invalid speed-up strategy: %v", err)
+		return nil
+	}
+	return fee
+}
+
+// client 获取EVM链的节点连接
+func (m *TxMonitorService) client(chainType string) (*ethclient.Client, error) {
+	adapter, err := m.chains.Adapter(chainType)
+	if err != nil {
+		return nil, err
+	}
+	evm, ok := adapter.(*EVMChainAdapter)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an EVM chain", chainType)
+	}
+	return evm.Client(), nil
+}
+
+// updateBill 更新链上账单
+func updateBill(bill *models.ChainBill, updates map[string]interface{}) error {
+	if err := database.DB.Model(bill).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update transaction %s: %v", bill.TxID, err)
+	}
+	return nil
+}
+
+// setOutboundTx 在转出交易账单中记录nonce和已签名的原始交易，供卡住时重新广播或替换
+func setOutboundTx(bill *models.ChainBill, tx *types.Transaction) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		log.Printf("Failed to encode transaction %s: %v", tx.Hash().Hex(), err)
+		return
+	}
+	nonce := tx.Nonce()
+	encoded := hexutil.Encode(raw)
+	bill.Nonce = &nonce
+	bill.RawTx = &encoded
+}
+
+// decodeRawTx 解析账单中保存的原始交易
+func decodeRawTx(encoded string) (*types.Transaction, error) {
+	raw, err := hexutil.Decode(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %v", err)
+	}
+	return tx, nil
+}
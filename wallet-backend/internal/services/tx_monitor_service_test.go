@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestOutboundRawTxRoundTrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chainID := big.NewInt(11155111)
+	fee := &blockchain.EVMFee{GasTipCap: big.NewInt(2e9), GasFeeCap: big.NewInt(62e9)}
+	tx := blockchain.NewEVMTransaction(chainID, 42, common.HexToAddress("0x000000000000000000000000000000000000dEaD"), big.NewInt(1), 21000, nil, fee)
+	signed, err := types.SignTx(tx, types.NewLondonSigner(chainID), key)
+	if err != nil {
+		t.Fatalf("SignTx failed: %v", err)
+	}
+
+	bill := &models.ChainBill{}
+	setOutboundTx(bill, signed)
+	if bill.Nonce == nil || *bill.Nonce != 42 || bill.RawTx == nil {
+		t.Fatalf("outbound transaction not recorded: %+v", bill)
+	}
+
+	decoded, err := decodeRawTx(*bill.RawTx)
+	if err != nil {
+		t.Fatalf("decodeRawTx failed: %v", err)
+	}
+	if decoded.Hash() != signed.Hash() {
+		t.Errorf("hash = %s, expected %s", decoded.Hash().Hex(), signed.Hash().Hex())
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(decoded.ChainId()), decoded)
+	if err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("Sender = %s, %v", from.Hex(), err)
+	}
+
+	if _, err := decodeRawTx("0xzz"); err == nil {
+		t.Error("expected error for invalid raw transaction")
+	}
+}
+
+func TestReplaceWithdrawTx(t *testing.T) {
+	setupTestDB(t)
+	database.DB.Create(&models.Balance{Address: "0xuser", CurrencySymbol: "ETH", ChainType: "Ethereum", Balance: 5, Frozen: 2.002})
+	for i, hash := range []string{"0xa1", "0xb1"} {
+		txID := hash
+		database.DB.Create(&models.WithdrawRecord{
+			CurrencySymbol: "ETH", ChainType: "Ethereum", FromAddress: "0xuser", ToAddress: "0xdest",
+			TxID: &txID, Amount: 1, Fee: 0.001, TotalAmount: 1.001, UniqueID: fmt.Sprintf("w%d", i), Status: withdrawSent,
+		})
+	}
+
+	// 加速：提币跟踪替换交易，冻结金额不变
+	if err := replaceWithdrawTx(database.DB, "0xa1", "0xa2", TxReplaceSpeedUp); err != nil {
+		t.Fatal(err)
+	}
+	var sped models.WithdrawRecord
+	database.DB.Where("unique_id = ?", "w0").First(&sped)
+	if sped.Status != withdrawSent || sped.TxID == nil || *sped.TxID != "0xa2" {
+		t.Errorf("sped-up withdraw should track the replacement, got status %d", sped.Status)
+	}
+
+	// 取消：提币标记发送失败并解冻，重复调用不会重复解冻
+	for i := 0; i < 2; i++ {
+		if err := replaceWithdrawTx(database.DB, "0xb1", "0xb2", TxReplaceCancel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var cancelled models.WithdrawRecord
+	database.DB.Where("unique_id = ?", "w1").First(&cancelled)
+	if cancelled.Status != 12 || cancelled.FailReason == "" {
+		t.Errorf("cancelled withdraw should fail, got status %d", cancelled.Status)
+	}
+	var balance models.Balance
+	database.DB.First(&balance)
+	if !floatEquals(balance.Balance, 6.001) || !floatEquals(balance.Frozen, 1.001) {
+		t.Errorf("cancelled withdraw should be unfrozen once, got balance %f frozen %f", balance.Balance, balance.Frozen)
+	}
+}
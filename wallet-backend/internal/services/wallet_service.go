@@ -5,10 +5,11 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"wallet-backend/internal/keystore"
 	"wallet-backend/internal/models"
+	"wallet-backend/pkg/validation"
 
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/gagliardetto/solana-go"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	"crypto/sha256"
 	"golang.org/x/crypto/ripemd160"
@@ -16,22 +17,32 @@ import (
 )
 
 // WalletService 钱包服务
-type WalletService struct{}
+type WalletService struct {
+	keystore *keystore.Keystore
+	hdWallet *HDWalletService
+}
 
-// NewWalletService 创建新的钱包服务实例
-func NewWalletService() *WalletService {
-	return &WalletService{}
+// NewWalletService 创建新的钱包服务实例，随机生成的私钥会加密保存到密钥库
+func NewWalletService(ks *keystore.Keystore, hdWallet *HDWalletService) *WalletService {
+	return &WalletService{keystore: ks, hdWallet: hdWallet}
 }
 
 // GenerateEthereumAddress 生成以太坊地址
 func (ws *WalletService) GenerateEthereumAddress(index uint32) (*models.AddressLibrary, error) {
-	// 这里应该从配置中获取主密钥和HD路径
-	// 暂时使用随机生成的方式
+	// 随机私钥无法从助记词恢复，必须先保存到密钥库，否则地址上的资产将无法动用
+	if ws.keystore == nil || !ws.keystore.IsUnlocked() {
+		return nil, fmt.Errorf("an unlocked keystore is required to store generated keys")
+	}
+
 	privateKey, err := crypto.GenerateKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate private key: %v", err)
 	}
 
+	if _, err := ws.keystore.ImportKey(privateKey); err != nil {
+		return nil, fmt.Errorf("failed to store private key: %v", err)
+	}
+
 	publicKey := privateKey.Public()
 	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
 	if !ok {
@@ -85,6 +96,11 @@ func (ws *WalletService) GenerateAddress(chainType string, index uint32) (*model
 		return ws.GenerateEthereumAddress(index)
 	case "Bitcoin":
 		return ws.GenerateBitcoinTestnetAddress(index)
+	case "TRON":
+		if ws.hdWallet == nil {
+			return nil, fmt.Errorf("HD wallet is required to derive tron addresses")
+		}
+		return ws.hdWallet.DeriveAddress("TRON", index)
 	case "Solana":
 		return ws.GenerateSolanaTestnetAddress(index)
 	default:
@@ -124,53 +140,23 @@ func (ws *WalletService) GenerateBitcoinTestnetAddress(index uint32) (*models.Ad
 	return addressModel, nil
 }
 
-// GenerateSolanaTestnetAddress 生成Solana测试网地址
+// GenerateSolanaTestnetAddress 生成Solana测试网地址，从主助记词按 m/44'/501'/index'/0' 派生
 func (ws *WalletService) GenerateSolanaTestnetAddress(index uint32) (*models.AddressLibrary, error) {
-	acc := solana.NewWallet()
-	addressModel := &models.AddressLibrary{
-		Address:   acc.PublicKey().String(),
-		ChainType: "Solana",
-		Status:    0,
-		IndexNum:  uint64(index),
+	if ws.hdWallet == nil {
+		return nil, fmt.Errorf("HD wallet is required to derive solana addresses")
 	}
-	return addressModel, nil
+	return ws.hdWallet.DeriveAddress("Solana", index)
 }
 
-// ValidateAddress 验证地址格式
+// ValidateAddress 验证地址格式，包含校验和与比特币网络前缀校验
 func (ws *WalletService) ValidateAddress(address, chainType string) bool {
-	switch chainType {
-	case "Ethereum":
-		return ws.validateEthereumAddress(address)
-	case "Bitcoin":
-		return ws.validateBitcoinAddress(address)
-	default:
-		return false
-	}
-}
-
-// validateEthereumAddress 验证以太坊地址
-func (ws *WalletService) validateEthereumAddress(address string) bool {
-	if len(address) != 42 || address[:2] != "0x" {
-		return false
-	}
-
-	_, err := hex.DecodeString(address[2:])
-	return err == nil
-}
-
-// validateBitcoinAddress 验证比特币地址
-func (ws *WalletService) validateBitcoinAddress(address string) bool {
-	// 简单的比特币地址格式验证
-	if len(address) < 26 || len(address) > 35 {
-		return false
-	}
-
-	// 检查地址前缀
-	if address[0] != '1' && address[0] != '3' && address[:3] != "bc1" {
-		return false
+	if ws.hdWallet != nil {
+		return ws.hdWallet.ValidateAddress(address, chainType)
 	}
 
-	return true
+	// 未配置HD钱包时按比特币主网校验
+	validator, _ := validation.NewAddressValidator("mainnet")
+	return validator.Validate(chainType, address) == nil
 }
 
 // GenerateRandomHex 生成随机十六进制字符串
@@ -311,6 +311,16 @@ func (ws *WebSocketService) SendWithdrawNotification(userID uint64, withdraw int
 	ws.BroadcastToUser(userID, msg)
 }
 
+// SendReorgNotification 向所有客户端广播链重组事件
+func (ws *WebSocketService) SendReorgNotification(event interface{}) {
+	msg := Message{
+		Type: "reorg",
+		Data: event,
+		Time: time.Now().Unix(),
+	}
+	ws.BroadcastToAll(msg)
+}
+
 // GetConnectedClientsCount 获取连接的客户端数量
 func (ws *WebSocketService) GetConnectedClientsCount() int {
 	ws.mutex.RLock()
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"fmt"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// withdrawUnsent 尚未上链的提币状态：待转手续费、待签名、签名成功
+var withdrawUnsent = []int{0, 1, 2}
+
+// withdrawInFlight 已签名、交易可能已在链上的提币状态：签名成功、发送成功
+// 广播后状态更新失败的提币停在签名成功，确认和替换时与发送成功一同处理
+var withdrawInFlight = []int{2, withdrawSent}
+
+// failWithdrawal 记录提币失败状态和原因，并在同一事务中把申请时冻结的金额退回可用余额
+// 只有尚未上链的提币会被标记失败，重复调用不会重复解冻
+func failWithdrawal(withdraw *models.WithdrawRecord, status int, cause error) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return failWithdrawalFrom(tx, withdraw, withdrawUnsent, status, cause.Error())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update withdraw %d status: %v", withdraw.ID, err)
+	}
+	return nil
+}
+
+// failWithdrawalFrom 提币处于 from 中的状态时改为失败状态并解冻，由调用方提供事务
+func failWithdrawalFrom(tx *gorm.DB, withdraw *models.WithdrawRecord, from []int, status int, reason string) error {
+	if len(reason) > 100 {
+		reason = reason[:100]
+	}
+	result := tx.Model(&models.WithdrawRecord{}).
+		Where("id = ? AND status IN ?", withdraw.ID, from).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"fail_reason": reason,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	if err := unfreezeWithdrawal(tx, withdraw); err != nil {
+		return err
+	}
+	withdraw.Status = status
+	withdraw.FailReason = reason
+	return nil
+}
+
+// unfreezeWithdrawal 提币冻结的金额退回可用余额
+func unfreezeWithdrawal(tx *gorm.DB, withdraw *models.WithdrawRecord) error {
+	return tx.Model(&models.Balance{}).
+		Where("address = ? AND currency_symbol = ?", withdraw.FromAddress, withdraw.CurrencySymbol).
+		Updates(map[string]interface{}{
+			"frozen":  gorm.Expr("frozen - ?", withdraw.TotalAmount),
+			"balance": gorm.Expr("balance + ?", withdraw.TotalAmount),
+		}).Error
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"wallet-backend/internal/database"
+	"wallet-backend/internal/models"
+)
+
+func TestFailWithdrawalUnfreezes(t *testing.T) {
+	setupTestDB(t)
+	balance := models.Balance{Address: "0xabc", CurrencySymbol: "ETH", ChainType: "Ethereum", Balance: 8.999, Frozen: 1.001}
+	database.DB.Create(&balance)
+	withdraw := models.WithdrawRecord{
+		CurrencySymbol: "ETH", ChainType: "Ethereum", FromAddress: "0xabc", ToAddress: "0xdef",
+		Amount: 1, Fee: 0.001, TotalAmount: 1.001, UniqueID: "w1", Status: 2,
+	}
+	database.DB.Create(&withdraw)
+
+	if err := failWithdrawal(&withdraw, 12, errors.New("connection refused")); err != nil {
+		t.Fatal(err)
+	}
+	// 重复记录失败不会再次解冻
+	if err := failWithdrawal(&withdraw, 12, errors.New("connection refused")); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved models.WithdrawRecord
+	database.DB.First(&saved, withdraw.ID)
+	if saved.Status != 12 || saved.FailReason != "connection refused" {
+		t.Errorf("unexpected withdraw status %d (%s)", saved.Status, saved.FailReason)
+	}
+	database.DB.First(&balance, balance.ID)
+	if balance.Frozen > 1e-9 || balance.Balance < 9.999999 || balance.Balance > 10.000001 {
+		t.Errorf("expected funds unfrozen, got balance %f frozen %f", balance.Balance, balance.Frozen)
+	}
+
+	// 已上链的提币不能标记为发送失败
+	sent := models.WithdrawRecord{FromAddress: "0xabc", CurrencySymbol: "ETH", TotalAmount: 1, UniqueID: "w2", Status: withdrawSent}
+	database.DB.Create(&sent)
+	if err := failWithdrawal(&sent, 12, errors.New("late error")); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Status != withdrawSent {
+		t.Errorf("sent withdraw must not be failed, got status %d", sent.Status)
+	}
+}
@@ -0,0 +1,216 @@
+package blockchain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bech32Encoding bech32编码变体
+type Bech32Encoding int
+
+const (
+	// Bech32 BIP173 原始编码（隔离见证 v0）
+	Bech32 Bech32Encoding = iota + 1
+	// Bech32m BIP350 编码（隔离见证 v1+，如Taproot）
+	Bech32m
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func bech32Checksum(hrp string, data []byte, enc Bech32Encoding) []byte {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	constant := uint32(bech32Const)
+	if enc == Bech32m {
+		constant = bech32mConst
+	}
+	mod := bech32Polymod(values) ^ constant
+	out := make([]byte, 6)
+	for i := 0; i < 6; i++ {
+		out[i] = byte((mod >> uint(5*(5-i))) & 31)
+	}
+	return out
+}
+
+// Bech32Encode 按给定变体编码5位数据
+func Bech32Encode(hrp string, data []byte, enc Bech32Encoding) (string, error) {
+	hrp = strings.ToLower(hrp)
+	combined := append(append([]byte(nil), data...), bech32Checksum(hrp, data, enc)...)
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, b := range combined {
+		if int(b) >= len(bech32Charset) {
+			return "", fmt.Errorf("invalid bech32 data value %d", b)
+		}
+		sb.WriteByte(bech32Charset[b])
+	}
+	return sb.String(), nil
+}
+
+// Bech32Decode 解码bech32/bech32m字符串，返回hrp、5位数据及检测到的变体
+func Bech32Decode(s string) (string, []byte, Bech32Encoding, error) {
+	if len(s) > 90 {
+		return "", nil, 0, fmt.Errorf("bech32 string too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, fmt.Errorf("bech32 string has mixed case")
+	}
+	s = strings.ToLower(s)
+
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, 0, fmt.Errorf("invalid bech32 separator position")
+	}
+
+	hrp := s[:pos]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 hrp character")
+		}
+	}
+
+	data := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		idx := strings.IndexByte(bech32Charset, s[i])
+		if idx < 0 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 character %q", s[i])
+		}
+		data = append(data, byte(idx))
+	}
+
+	var enc Bech32Encoding
+	switch bech32Polymod(append(bech32HRPExpand(hrp), data...)) {
+	case bech32Const:
+		enc = Bech32
+	case bech32mConst:
+		enc = Bech32m
+	default:
+		return "", nil, 0, fmt.Errorf("bech32: %w", ErrInvalidChecksum)
+	}
+
+	return hrp, data[:len(data)-6], enc, nil
+}
+
+// ConvertBits 在不同位宽之间转换数据（如8位与5位）
+func ConvertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc := uint32(0)
+	bits := uint(0)
+	maxv := uint32(1)<<toBits - 1
+	out := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+
+	for _, value := range data {
+		if uint32(value)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data range: %d", value)
+		}
+		acc = acc<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || (acc<<(toBits-bits))&maxv != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+
+	return out, nil
+}
+
+// EncodeSegWitAddress 编码隔离见证地址，v0使用bech32，v1+使用bech32m
+func EncodeSegWitAddress(hrp string, version byte, program []byte) (string, error) {
+	data, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	enc := Bech32
+	if version > 0 {
+		enc = Bech32m
+	}
+
+	addr, err := Bech32Encode(hrp, append([]byte{version}, data...), enc)
+	if err != nil {
+		return "", err
+	}
+
+	// 编码后再解码一次，确保生成的地址合法
+	if _, _, err := DecodeSegWitAddress(hrp, addr); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+// DecodeSegWitAddress 解码并校验隔离见证地址，返回见证版本和见证程序
+func DecodeSegWitAddress(hrp string, addr string) (byte, []byte, error) {
+	gotHRP, data, enc, err := Bech32Decode(addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	if gotHRP != strings.ToLower(hrp) {
+		return 0, nil, fmt.Errorf("unexpected hrp %q, want %q", gotHRP, hrp)
+	}
+	if len(data) < 1 {
+		return 0, nil, fmt.Errorf("empty segwit data")
+	}
+
+	version := data[0]
+	if version > 16 {
+		return 0, nil, fmt.Errorf("invalid witness version %d", version)
+	}
+
+	program, err := ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return 0, nil, fmt.Errorf("invalid witness program length %d", len(program))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return 0, nil, fmt.Errorf("invalid witness v0 program length %d", len(program))
+	}
+	if (version == 0 && enc != Bech32) || (version != 0 && enc != Bech32m) {
+		return 0, nil, fmt.Errorf("witness version %d uses wrong checksum variant", version)
+	}
+
+	return version, program, nil
+}
@@ -0,0 +1,61 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// BIP173/BIP350 测试向量
+func TestDecodeSegWitAddress_Valid(t *testing.T) {
+	cases := []struct {
+		hrp     string
+		address string
+		version byte
+		program string
+	}{
+		{"bc", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", 0, "751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tb", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", 0, "1863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"bc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", 1, "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+	}
+
+	for _, c := range cases {
+		version, program, err := DecodeSegWitAddress(c.hrp, c.address)
+		if err != nil {
+			t.Fatalf("DecodeSegWitAddress(%s) failed: %v", c.address, err)
+		}
+		if version != c.version || hex.EncodeToString(program) != c.program {
+			t.Errorf("%s: got version %d program %x", c.address, version, program)
+		}
+
+		encoded, err := EncodeSegWitAddress(c.hrp, version, program)
+		if err != nil {
+			t.Fatalf("EncodeSegWitAddress failed: %v", err)
+		}
+		if encoded != strings.ToLower(c.address) {
+			t.Errorf("round trip mismatch: %s != %s", encoded, strings.ToLower(c.address))
+		}
+	}
+}
+
+func TestDecodeSegWitAddress_Invalid(t *testing.T) {
+	cases := []struct {
+		hrp     string
+		address string
+	}{
+		{"tb", "tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut"}, // 错误的hrp
+		{"bc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd"}, // v1使用bech32校验和
+		{"tb", "tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf"}, // v2使用bech32校验和
+		{"bc", "BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL"}, // v16使用bech32校验和
+		{"bc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh"},                     // v0使用bech32m校验和
+		{"bc", "bc1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47"}, // v0程序长度错误
+		{"bc", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},                     // 网络不匹配
+		{"bc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},                     // 校验和错误
+	}
+
+	for _, c := range cases {
+		if _, _, err := DecodeSegWitAddress(c.hrp, c.address); err == nil {
+			t.Errorf("expected %s to be rejected", c.address)
+		}
+	}
+}
@@ -1,44 +1,69 @@
 package blockchain
 
 import (
-	"fmt"
+	"net/http"
+	"strings"
+	"time"
 )
 
-// BitcoinClient 比特币客户端
+// BitcoinClient 比特币客户端，配置节点地址后可通过 bitcoind JSON-RPC 查询区块
 type BitcoinClient struct {
 	isTestnet bool
+	params    *BitcoinNetworkParams
+
+	rpcURL      string
+	rpcUser     string
+	rpcPassword string
+	httpClient  *http.Client
 }
 
 // NewBitcoinClient 创建新的比特币客户端
 func NewBitcoinClient(isTestnet bool) *BitcoinClient {
-	return &BitcoinClient{isTestnet: isTestnet}
+	params := BitcoinMainNet
+	if isTestnet {
+		params = BitcoinTestNet
+	}
+	return &BitcoinClient{isTestnet: isTestnet, params: params}
 }
 
-// GenerateAddress 生成比特币地址
-func (bc *BitcoinClient) GenerateAddress() (string, error) {
-	// 留空实现或调用服务层生成
-	return "", fmt.Errorf("not implemented")
+// NewBitcoinClientWithParams 使用指定网络参数创建比特币客户端（如regtest）
+func NewBitcoinClientWithParams(params *BitcoinNetworkParams) *BitcoinClient {
+	return &BitcoinClient{isTestnet: params != BitcoinMainNet, params: params}
 }
 
-// ValidateAddress 验证比特币地址
-func (bc *BitcoinClient) ValidateAddress(address string) bool {
-	// 简单的比特币地址格式验证
-	if len(address) < 26 || len(address) > 35 {
-		return false
-	}
+// NewBitcoinRPCClient 创建连接 bitcoind 的比特币客户端，rpcUser 为空时不使用认证
+func NewBitcoinRPCClient(params *BitcoinNetworkParams, rpcURL, rpcUser, rpcPassword string) *BitcoinClient {
+	client := NewBitcoinClientWithParams(params)
+	client.rpcURL = strings.TrimRight(rpcURL, "/")
+	client.rpcUser = rpcUser
+	client.rpcPassword = rpcPassword
+	client.httpClient = &http.Client{Timeout: 30 * time.Second}
+	return client
+}
 
-	// 检查地址前缀
-	if address[0] != '1' && address[0] != '3' && address[:3] != "bc1" {
-		return false
-	}
+// Params 返回网络参数
+func (bc *BitcoinClient) Params() *BitcoinNetworkParams {
+	return bc.params
+}
+
+// GenerateAddress 根据压缩公钥生成指定类型的比特币地址
+func (bc *BitcoinClient) GenerateAddress(pubKey []byte, addrType string) (string, error) {
+	return EncodeBitcoinAddress(pubKey, addrType, bc.params)
+}
 
-	return true
+// ValidateAddress 验证比特币地址
+func (bc *BitcoinClient) ValidateAddress(address string) bool {
+	return ValidateBitcoinAddress(address, bc.params) == nil
 }
 
 // GetNetworkName 获取网络名称
 func (bc *BitcoinClient) GetNetworkName() string {
-	if bc.isTestnet {
+	switch bc.params {
+	case BitcoinRegTest:
+		return "Regtest"
+	case BitcoinTestNet:
 		return "Testnet"
+	default:
+		return "Mainnet"
 	}
-	return "Mainnet"
 }
@@ -0,0 +1,232 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/mr-tron/base58/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// 比特币地址类型
+const (
+	AddressTypeP2PKH      = "p2pkh"       // 传统地址 1...
+	AddressTypeP2SHP2WPKH = "p2sh-p2wpkh" // 兼容隔离见证地址 3...
+	AddressTypeP2WPKH     = "p2wpkh"      // 原生隔离见证地址 bc1q...
+	AddressTypeP2TR       = "p2tr"        // Taproot地址 bc1p...
+)
+
+// ErrInvalidChecksum 地址编码正确但校验和不匹配（base58check / bech32 / bech32m）
+var ErrInvalidChecksum = errors.New("invalid checksum")
+
+// BitcoinNetworkParams 比特币网络参数
+type BitcoinNetworkParams struct {
+	Name             string
+	PubKeyHashAddrID byte
+	ScriptHashAddrID byte
+	Bech32HRP        string
+	HDCoinType       uint32
+}
+
+var (
+	// BitcoinMainNet 主网参数
+	BitcoinMainNet = &BitcoinNetworkParams{
+		Name:             "mainnet",
+		PubKeyHashAddrID: 0x00,
+		ScriptHashAddrID: 0x05,
+		Bech32HRP:        "bc",
+		HDCoinType:       0,
+	}
+	// BitcoinTestNet 测试网参数 (testnet3/signet)
+	BitcoinTestNet = &BitcoinNetworkParams{
+		Name:             "testnet",
+		PubKeyHashAddrID: 0x6f,
+		ScriptHashAddrID: 0xc4,
+		Bech32HRP:        "tb",
+		HDCoinType:       1,
+	}
+	// BitcoinRegTest 回归测试网参数
+	BitcoinRegTest = &BitcoinNetworkParams{
+		Name:             "regtest",
+		PubKeyHashAddrID: 0x6f,
+		ScriptHashAddrID: 0xc4,
+		Bech32HRP:        "bcrt",
+		HDCoinType:       1,
+	}
+)
+
+// BitcoinNetworkByName 根据名称获取网络参数，空字符串视为主网
+func BitcoinNetworkByName(name string) (*BitcoinNetworkParams, error) {
+	switch strings.ToLower(name) {
+	case "", "mainnet", "main":
+		return BitcoinMainNet, nil
+	case "testnet", "testnet3", "test", "signet":
+		return BitcoinTestNet, nil
+	case "regtest":
+		return BitcoinRegTest, nil
+	default:
+		return nil, fmt.Errorf("unsupported bitcoin network: %s", name)
+	}
+}
+
+// ParseBitcoinAddressType 解析地址类型，支持别名，空字符串视为传统地址
+func ParseBitcoinAddressType(addrType string) (string, error) {
+	switch strings.ToLower(addrType) {
+	case "", AddressTypeP2PKH, "legacy":
+		return AddressTypeP2PKH, nil
+	case AddressTypeP2SHP2WPKH, "nested-segwit", "p2sh-segwit":
+		return AddressTypeP2SHP2WPKH, nil
+	case AddressTypeP2WPKH, "native-segwit", "segwit", "bech32":
+		return AddressTypeP2WPKH, nil
+	case AddressTypeP2TR, "taproot", "bech32m":
+		return AddressTypeP2TR, nil
+	default:
+		return "", fmt.Errorf("unsupported bitcoin address type: %s", addrType)
+	}
+}
+
+// BitcoinAddressPurpose 地址类型对应的BIP44/49/84/86 purpose
+func BitcoinAddressPurpose(addrType string) uint32 {
+	switch addrType {
+	case AddressTypeP2SHP2WPKH:
+		return 49
+	case AddressTypeP2WPKH:
+		return 84
+	case AddressTypeP2TR:
+		return 86
+	default:
+		return 44
+	}
+}
+
+// EncodeBitcoinAddress 根据33字节压缩公钥生成指定类型的地址
+func EncodeBitcoinAddress(pubKey []byte, addrType string, params *BitcoinNetworkParams) (string, error) {
+	if len(pubKey) != 33 {
+		return "", fmt.Errorf("compressed public key required, got %d bytes", len(pubKey))
+	}
+
+	addrType, err := ParseBitcoinAddressType(addrType)
+	if err != nil {
+		return "", err
+	}
+
+	switch addrType {
+	case AddressTypeP2PKH:
+		return EncodeBase58Check(params.PubKeyHashAddrID, hash160(pubKey)), nil
+	case AddressTypeP2SHP2WPKH:
+		// redeemScript = OP_0 <20-byte-pubkey-hash>
+		redeemScript := append([]byte{0x00, 0x14}, hash160(pubKey)...)
+		return EncodeBase58Check(params.ScriptHashAddrID, hash160(redeemScript)), nil
+	case AddressTypeP2WPKH:
+		return EncodeSegWitAddress(params.Bech32HRP, 0, hash160(pubKey))
+	case AddressTypeP2TR:
+		outputKey, err := TaprootOutputKey(pubKey)
+		if err != nil {
+			return "", err
+		}
+		return EncodeSegWitAddress(params.Bech32HRP, 1, outputKey)
+	}
+
+	return "", fmt.Errorf("unsupported bitcoin address type: %s", addrType)
+}
+
+// ValidateBitcoinAddress 校验地址的编码、校验和以及网络前缀
+func ValidateBitcoinAddress(address string, params *BitcoinNetworkParams) error {
+	if strings.HasPrefix(strings.ToLower(address), params.Bech32HRP+"1") {
+		_, _, err := DecodeSegWitAddress(params.Bech32HRP, address)
+		return err
+	}
+
+	version, payload, err := DecodeBase58Check(address)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 20 {
+		return fmt.Errorf("invalid address payload length %d", len(payload))
+	}
+	if version != params.PubKeyHashAddrID && version != params.ScriptHashAddrID {
+		return fmt.Errorf("address version 0x%02x does not belong to %s", version, params.Name)
+	}
+	return nil
+}
+
+// TaprootOutputKey 按BIP86计算无脚本路径的Taproot输出公钥（32字节x坐标）
+func TaprootOutputKey(pubKey []byte) ([]byte, error) {
+	if len(pubKey) != 33 {
+		return nil, fmt.Errorf("compressed public key required, got %d bytes", len(pubKey))
+	}
+
+	// lift_x: 取偶数Y的内部公钥
+	internal := append([]byte{0x02}, pubKey[1:]...)
+	p, err := secp256k1.ParsePubKey(internal)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+
+	tweak := taggedHash("TapTweak", pubKey[1:])
+	var t secp256k1.ModNScalar
+	if overflow := t.SetByteSlice(tweak); overflow {
+		return nil, fmt.Errorf("taproot tweak out of range")
+	}
+
+	var tG, pPoint, q secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&t, &tG)
+	p.AsJacobian(&pPoint)
+	secp256k1.AddNonConst(&pPoint, &tG, &q)
+	if q.Z.IsZero() {
+		return nil, fmt.Errorf("taproot output key is infinity")
+	}
+	q.ToAffine()
+
+	x := q.X.Bytes()
+	return x[:], nil
+}
+
+// EncodeBase58Check base58check编码
+func EncodeBase58Check(version byte, payload []byte) string {
+	data := append([]byte{version}, payload...)
+	return base58.Encode(append(data, doubleSHA256(data)[:4]...))
+}
+
+// DecodeBase58Check base58check解码并校验
+func DecodeBase58Check(s string) (byte, []byte, error) {
+	raw, err := base58.Decode(s)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid base58 string: %v", err)
+	}
+	if len(raw) < 5 {
+		return 0, nil, fmt.Errorf("base58check data too short")
+	}
+
+	data, sum := raw[:len(raw)-4], raw[len(raw)-4:]
+	if !bytes.Equal(doubleSHA256(data)[:4], sum) {
+		return 0, nil, fmt.Errorf("base58check: %w", ErrInvalidChecksum)
+	}
+	return data[0], data[1:], nil
+}
+
+func hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
+
+func doubleSHA256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+func taggedHash(tag string, msg []byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	h.Write(msg)
+	return h.Sum(nil)
+}
@@ -0,0 +1,29 @@
+package blockchain
+
+import "testing"
+
+func TestValidateBitcoinAddress(t *testing.T) {
+	valid := map[*BitcoinNetworkParams][]string{
+		BitcoinMainNet: {"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		BitcoinTestNet: {"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", "2MzQwSSnBHWHqSAqtTVQ6v47XtaisrJa1Vc", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+	}
+	for params, addresses := range valid {
+		for _, addr := range addresses {
+			if err := ValidateBitcoinAddress(addr, params); err != nil {
+				t.Errorf("%s should be valid on %s: %v", addr, params.Name, err)
+			}
+		}
+	}
+
+	invalid := map[*BitcoinNetworkParams][]string{
+		BitcoinMainNet: {"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		BitcoinTestNet: {"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+	}
+	for params, addresses := range invalid {
+		for _, addr := range addresses {
+			if err := ValidateBitcoinAddress(addr, params); err == nil {
+				t.Errorf("%s should be rejected on %s", addr, params.Name)
+			}
+		}
+	}
+}
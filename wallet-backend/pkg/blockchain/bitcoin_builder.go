@@ -0,0 +1,81 @@
+package blockchain
+
+import (
+	"fmt"
+)
+
+// BitcoinTxRequest 构建比特币转账交易的参数
+type BitcoinTxRequest struct {
+	Coins         []BitcoinCoin // 可花费的UTXO，只使用P2WPKH输出
+	ToAddress     string
+	Amount        int64 // 聪
+	ChangeAddress string
+	FeeRate       int64 // 聪/vB
+	RBF           bool  // 输入序号声明可替换（BIP125），便于之后加速
+}
+
+// BuildBitcoinPSBT 选币并构建未签名交易，返回带有输入金额和脚本的PSBT
+func BuildBitcoinPSBT(req BitcoinTxRequest, params *BitcoinNetworkParams) (*PSBT, *BitcoinCoinSelection, error) {
+	toScript, err := BitcoinAddressToScript(req.ToAddress, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid recipient address: %v", err)
+	}
+	changeScript, err := BitcoinAddressToScript(req.ChangeAddress, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid change address: %v", err)
+	}
+	if req.Amount < BitcoinDustLimit(toScript) {
+		return nil, nil, fmt.Errorf("amount %d sats is below the dust limit", req.Amount)
+	}
+
+	var coins []BitcoinCoin
+	for _, coin := range req.Coins {
+		if _, ok := P2WPKHProgram(coin.PkScript); ok {
+			coins = append(coins, coin)
+		}
+	}
+
+	selection, err := SelectBitcoinCoins(coins, BitcoinCoinSelectParams{
+		Amount:          req.Amount,
+		FeeRate:         req.FeeRate,
+		OutputsVSize:    BitcoinOutputVSize(toScript),
+		ChangeVSize:     BitcoinOutputVSize(changeScript),
+		ChangeSpendSize: BitcoinP2WPKHInputVSize,
+		DustLimit:       BitcoinDustLimit(changeScript),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sequence := BitcoinSequenceFinal
+	if req.RBF {
+		sequence = BitcoinSequenceRBF
+	}
+	tx := &BitcoinWireTx{Version: 2}
+	for _, coin := range selection.Coins {
+		tx.Inputs = append(tx.Inputs, BitcoinWireInput{PrevTxID: coin.TxID, PrevVout: coin.Vout, Sequence: sequence})
+	}
+	tx.Outputs = append(tx.Outputs, BitcoinWireOutput{Value: req.Amount, PkScript: toScript})
+	if selection.Change > 0 {
+		tx.Outputs = append(tx.Outputs, BitcoinWireOutput{Value: selection.Change, PkScript: changeScript})
+	}
+
+	psbt, err := NewPSBT(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, coin := range selection.Coins {
+		psbt.Inputs[i].WitnessUTXO = &BitcoinWireOutput{Value: coin.Value, PkScript: coin.PkScript}
+		psbt.Inputs[i].SighashType = BitcoinSigHashAll
+	}
+	return psbt, selection, nil
+}
+
+// BitcoinDustLimit 输出的粉尘限制（按默认 3 聪/vB 的粉尘费率计算花费该输出的成本）
+func BitcoinDustLimit(pkScript []byte) int64 {
+	spendSize := int64(148)
+	if len(pkScript) >= 4 && (pkScript[0] == 0x00 || (pkScript[0] >= 0x51 && pkScript[0] <= 0x60)) {
+		spendSize = 67 // 隔离见证输入按见证折扣计算
+	}
+	return (BitcoinOutputVSize(pkScript) + spendSize) * 3
+}
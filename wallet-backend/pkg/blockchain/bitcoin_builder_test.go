@@ -0,0 +1,158 @@
+package blockchain
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSelectBitcoinCoins(t *testing.T) {
+	coin := func(value int64) BitcoinCoin { return BitcoinCoin{TxID: strings.Repeat("ab", 32), Value: value} }
+	params := BitcoinCoinSelectParams{
+		Amount:          100000,
+		FeeRate:         10,
+		OutputsVSize:    31,
+		ChangeVSize:     31,
+		ChangeSpendSize: BitcoinP2WPKHInputVSize,
+		DustLimit:       294,
+	}
+
+	// 100000 + 输入手续费680 + 固定开销和输出420 恰好命中，无需找零
+	exact, err := SelectBitcoinCoins([]BitcoinCoin{coin(500000), coin(101100), coin(20000)}, params)
+	if err != nil {
+		t.Fatalf("SelectBitcoinCoins failed: %v", err)
+	}
+	if !exact.Exact || exact.Change != 0 || len(exact.Coins) != 1 || exact.Coins[0].Value != 101100 || exact.Fee != 1100 {
+		t.Errorf("expected exact match without change, got %+v", exact)
+	}
+
+	fallback, err := SelectBitcoinCoins([]BitcoinCoin{coin(70000), coin(60000)}, params)
+	if err != nil {
+		t.Fatalf("SelectBitcoinCoins failed: %v", err)
+	}
+	if fallback.Exact || len(fallback.Coins) != 2 || fallback.Change <= 0 {
+		t.Fatalf("expected fallback selection with change, got %+v", fallback)
+	}
+	// 两个输入、两个输出：11 + 31*2 + 68*2 = 209 vB
+	if fallback.Fee != 2090 || fallback.Change != 130000-100000-2090 {
+		t.Errorf("unexpected fee/change: %+v", fallback)
+	}
+
+	if _, err := SelectBitcoinCoins([]BitcoinCoin{coin(50000)}, params); err == nil {
+		t.Errorf("expected insufficient funds error")
+	}
+}
+
+// 在 regtest 网络上构建、签名并提取交易，校验手续费率、RBF 和见证数据
+func TestBuildAndSignBitcoinPSBT(t *testing.T) {
+	key, _ := crypto.HexToECDSA("619c335025c7f4012e556c2a58b2506e30b8511b53ade95ea316fd8c3286feb9")
+	pubKey := crypto.CompressPubkey(&key.PublicKey)
+	from, err := EncodeBitcoinAddress(pubKey, AddressTypeP2WPKH, BitcoinRegTest)
+	if err != nil {
+		t.Fatalf("EncodeBitcoinAddress failed: %v", err)
+	}
+	fromScript, _ := BitcoinAddressToScript(from, BitcoinRegTest)
+
+	changeKey, _ := crypto.GenerateKey()
+	change, _ := EncodeBitcoinAddress(crypto.CompressPubkey(&changeKey.PublicKey), AddressTypeP2WPKH, BitcoinRegTest)
+	to := "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"
+
+	psbt, selection, err := BuildBitcoinPSBT(BitcoinTxRequest{
+		Coins: []BitcoinCoin{
+			{TxID: strings.Repeat("11", 32), Vout: 0, Value: 150000, PkScript: fromScript},
+			{TxID: strings.Repeat("22", 32), Vout: 1, Value: 80000, PkScript: fromScript},
+		},
+		ToAddress:     to,
+		Amount:        200000,
+		ChangeAddress: change,
+		FeeRate:       5,
+		RBF:           true,
+	}, BitcoinRegTest)
+	if err != nil {
+		t.Fatalf("BuildBitcoinPSBT failed: %v", err)
+	}
+	if len(psbt.UnsignedTx.Inputs) != 2 || len(psbt.UnsignedTx.Outputs) != 2 {
+		t.Fatalf("expected 2 inputs and 2 outputs, got %+v", psbt.UnsignedTx)
+	}
+	for _, input := range psbt.UnsignedTx.Inputs {
+		if input.Sequence != BitcoinSequenceRBF {
+			t.Errorf("input sequence %x does not signal RBF", input.Sequence)
+		}
+	}
+
+	// PSBT 编码往返
+	parsed, err := ParsePSBT(psbt.B64Encode())
+	if err != nil {
+		t.Fatalf("ParsePSBT failed: %v", err)
+	}
+	if !bytes.Equal(parsed.Serialize(), psbt.Serialize()) {
+		t.Fatalf("PSBT does not round-trip")
+	}
+	fee, err := parsed.Fee()
+	if err != nil || fee != selection.Fee {
+		t.Fatalf("PSBT fee = %d, %v; expected %d", fee, err, selection.Fee)
+	}
+
+	for i := range parsed.Inputs {
+		hash, err := parsed.SigHash(i)
+		if err != nil {
+			t.Fatalf("SigHash failed: %v", err)
+		}
+		sig, err := crypto.Sign(hash, key)
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		der, err := EncodeBitcoinSignature(sig, BitcoinSigHashAll)
+		if err != nil {
+			t.Fatalf("EncodeBitcoinSignature failed: %v", err)
+		}
+		if err := parsed.AddPartialSig(i, crypto.CompressPubkey(&changeKey.PublicKey), der); err == nil {
+			t.Errorf("signature with a foreign public key should be rejected")
+		}
+		if err := parsed.AddPartialSig(i, pubKey, der); err != nil {
+			t.Fatalf("AddPartialSig failed: %v", err)
+		}
+	}
+	if err := parsed.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	signed, err := parsed.Extract()
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	decoded, err := ParseBitcoinWireTx(signed.Serialize(true))
+	if err != nil {
+		t.Fatalf("ParseBitcoinWireTx failed: %v", err)
+	}
+	if decoded.TxID() != psbt.UnsignedTx.TxID() {
+		t.Errorf("witness must not change the txid")
+	}
+	for i, input := range decoded.Inputs {
+		if len(input.Witness) != 2 || !bytes.Equal(input.Witness[1], pubKey) {
+			t.Fatalf("unexpected witness for input %d: %x", i, input.Witness)
+		}
+		hash, _ := psbt.SigHash(i)
+		der := input.Witness[0]
+		if der[len(der)-1] != byte(BitcoinSigHashAll) {
+			t.Errorf("signature of input %d is missing the sighash type", i)
+		}
+		sig, err := ecdsa.ParseDERSignature(der[:len(der)-1])
+		if err != nil {
+			t.Fatalf("invalid DER signature for input %d: %v", i, err)
+		}
+		pub, _ := secp256k1.ParsePubKey(pubKey)
+		if !sig.Verify(hash, pub) {
+			t.Errorf("signature of input %d does not verify", i)
+		}
+	}
+
+	// 实际费率不低于目标费率
+	if rate := float64(selection.Fee) / float64(decoded.VSize()); rate < 5 {
+		t.Errorf("effective fee rate %.2f sat/vB below target (fee %d, vsize %d)", rate, selection.Fee, decoded.VSize())
+	}
+}
@@ -0,0 +1,183 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// BitcoinP2WPKHInputVSize P2WPKH输入的虚拟大小（41字节非见证 + 约108字节见证 / 4）
+	BitcoinP2WPKHInputVSize = 68
+	// bitcoinTxOverheadVSize 隔离见证交易的固定开销：版本、输入输出数量、锁定时间和见证标记
+	bitcoinTxOverheadVSize = 11
+	// bitcoinBnBMaxTries 分支定界搜索的最大尝试次数，与 Bitcoin Core 一致
+	bitcoinBnBMaxTries = 100000
+)
+
+// BitcoinCoin 可花费的UTXO
+type BitcoinCoin struct {
+	TxID     string
+	Vout     uint32
+	Value    int64 // 聪
+	PkScript []byte
+}
+
+// BitcoinCoinSelection 选币结果
+type BitcoinCoinSelection struct {
+	Coins  []BitcoinCoin
+	Fee    int64 // 聪
+	Change int64 // 找零金额，为0时不创建找零输出
+	Exact  bool  // 是否由分支定界找到无需找零的组合
+}
+
+// BitcoinCoinSelectParams 选币参数
+type BitcoinCoinSelectParams struct {
+	Amount          int64 // 转账金额（聪）
+	FeeRate         int64 // 聪/vB
+	OutputsVSize    int64 // 收款输出的虚拟大小
+	ChangeVSize     int64 // 找零输出的虚拟大小
+	ChangeSpendSize int64 // 以后花费找零输出的输入虚拟大小
+	DustLimit       int64 // 低于此金额的找零并入手续费
+}
+
+// SelectBitcoinCoins 选择输入：先用分支定界（BIP-BnB）寻找无需找零且浪费最小的组合，
+// 找不到时回退为按金额从大到小累加并创建找零输出
+func SelectBitcoinCoins(coins []BitcoinCoin, params BitcoinCoinSelectParams) (*BitcoinCoinSelection, error) {
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if params.FeeRate <= 0 {
+		return nil, fmt.Errorf("fee rate must be positive")
+	}
+
+	inputFee := params.FeeRate * BitcoinP2WPKHInputVSize
+	baseFee := params.FeeRate * (bitcoinTxOverheadVSize + params.OutputsVSize)
+	changeFee := params.FeeRate * params.ChangeVSize
+	costOfChange := changeFee + params.FeeRate*params.ChangeSpendSize
+
+	// 有效金额 = 金额 - 花费该输入的手续费，不为正的输入不值得花费
+	var candidates []BitcoinCoin
+	var available int64
+	for _, coin := range coins {
+		if coin.Value-inputFee > 0 {
+			candidates = append(candidates, coin)
+			available += coin.Value - inputFee
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Value > candidates[j].Value
+	})
+
+	target := params.Amount + baseFee
+	if available < target {
+		return nil, fmt.Errorf("insufficient funds: need %d sats plus fees, available %d", params.Amount, available)
+	}
+
+	if selected := branchAndBound(candidates, inputFee, target, costOfChange); selected != nil {
+		var total int64
+		for _, coin := range selected {
+			total += coin.Value
+		}
+		return &BitcoinCoinSelection{
+			Coins: selected,
+			Fee:   total - params.Amount,
+			Exact: true,
+		}, nil
+	}
+
+	// 回退：从大到小累加，直到足够支付金额、手续费和找零输出
+	var selected []BitcoinCoin
+	var total int64
+	for _, coin := range candidates {
+		selected = append(selected, coin)
+		total += coin.Value
+		fee := baseFee + inputFee*int64(len(selected))
+		if total < params.Amount+fee {
+			continue
+		}
+		change := total - params.Amount - fee - changeFee
+		if change >= params.DustLimit {
+			return &BitcoinCoinSelection{Coins: selected, Fee: fee + changeFee, Change: change}, nil
+		}
+		// 找零低于粉尘限制，直接作为手续费
+		return &BitcoinCoinSelection{Coins: selected, Fee: total - params.Amount}, nil
+	}
+	return nil, fmt.Errorf("insufficient funds: need %d sats plus fees, available %d", params.Amount, available)
+}
+
+// branchAndBound 深度优先搜索有效金额之和落在 [target, target+costOfChange] 内的组合，
+// 以超出目标的金额作为浪费，返回浪费最小的组合；candidates 需按金额降序
+func branchAndBound(candidates []BitcoinCoin, inputFee, target, costOfChange int64) []BitcoinCoin {
+	n := len(candidates)
+	effective := make([]int64, n)
+	remaining := int64(0)
+	for i, coin := range candidates {
+		effective[i] = coin.Value - inputFee
+		remaining += effective[i]
+	}
+
+	included := make([]bool, n)
+	var best []bool
+	bestWaste := int64(-1)
+	var current int64
+	depth := 0
+
+	for tries := 0; tries < bitcoinBnBMaxTries; tries++ {
+		backtrack := false
+		switch {
+		case current+remaining < target:
+			// 剩余输入全部加上也不够
+			backtrack = true
+		case current > target+costOfChange:
+			// 超出范围，需要找零
+			backtrack = true
+		case current >= target:
+			waste := current - target
+			if bestWaste < 0 || waste < bestWaste {
+				best = append(best[:0], included...)
+				bestWaste = waste
+			}
+			backtrack = true
+		}
+		if bestWaste == 0 {
+			// 恰好等于目标，不可能更优
+			break
+		}
+
+		if backtrack {
+			// 回退到最近一个被选中的输入，改为不选它
+			for depth > 0 && !included[depth-1] {
+				depth--
+				remaining += effective[depth]
+			}
+			if depth == 0 {
+				break
+			}
+			depth--
+			included[depth] = false
+			current -= effective[depth]
+			depth++
+			continue
+		}
+
+		if depth >= n {
+			continue
+		}
+		// 选中当前输入，继续向下搜索
+		remaining -= effective[depth]
+		included[depth] = true
+		current += effective[depth]
+		depth++
+	}
+
+	if best == nil {
+		return nil
+	}
+	var selected []BitcoinCoin
+	for i, ok := range best {
+		if ok {
+			selected = append(selected, candidates[i])
+		}
+	}
+	return selected
+}
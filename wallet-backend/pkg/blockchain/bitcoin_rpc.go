@@ -0,0 +1,237 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BitcoinChainInfo getblockchaininfo 返回的链信息
+type BitcoinChainInfo struct {
+	Chain         string `json:"chain"` // main / test / testnet4 / signet / regtest
+	Blocks        uint64 `json:"blocks"`
+	Headers       uint64 `json:"headers"`
+	BestBlockHash string `json:"bestblockhash"`
+}
+
+// BitcoinBlock getblock（verbosity=2）返回的区块，包含完整交易
+type BitcoinBlock struct {
+	Hash              string      `json:"hash"`
+	Height            uint64      `json:"height"`
+	Confirmations     int64       `json:"confirmations"`
+	PreviousBlockHash string      `json:"previousblockhash"`
+	Time              int64       `json:"time"`
+	Tx                []BitcoinTx `json:"tx"`
+}
+
+// BitcoinTx 区块中的交易
+type BitcoinTx struct {
+	TxID string         `json:"txid"`
+	Vin  []BitcoinTxIn  `json:"vin"`
+	Vout []BitcoinTxOut `json:"vout"`
+}
+
+// BitcoinTxIn 交易输入，coinbase 交易没有引用的输出
+type BitcoinTxIn struct {
+	TxID     string `json:"txid"`
+	Vout     uint32 `json:"vout"`
+	Coinbase string `json:"coinbase,omitempty"`
+}
+
+// BitcoinTxOut 交易输出，金额保留节点返回的十进制字符串避免浮点误差
+type BitcoinTxOut struct {
+	Value        json.Number `json:"value"`
+	N            uint32      `json:"n"`
+	ScriptPubKey struct {
+		Hex       string   `json:"hex"`
+		Type      string   `json:"type"`
+		Address   string   `json:"address"`   // bitcoind 22+
+		Addresses []string `json:"addresses"` // 旧版本节点
+	} `json:"scriptPubKey"`
+}
+
+// Address 输出的收款地址，多签等无法表示为单一地址的输出返回空
+func (o *BitcoinTxOut) Address() string {
+	if o.ScriptPubKey.Address != "" {
+		return o.ScriptPubKey.Address
+	}
+	if len(o.ScriptPubKey.Addresses) == 1 {
+		return o.ScriptPubKey.Addresses[0]
+	}
+	return ""
+}
+
+// Satoshis 输出金额（聪）
+func (o *BitcoinTxOut) Satoshis() (int64, error) {
+	return ParseBitcoinAmount(o.Value.String())
+}
+
+// ParseBitcoinAmount 把以BTC为单位的十进制金额（如 "0.00100000"）转换为聪
+func ParseBitcoinAmount(value string) (int64, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(value), ".")
+	if whole == "" || len(frac) > 8 || strings.HasPrefix(whole, "-") {
+		return 0, fmt.Errorf("invalid bitcoin amount %q", value)
+	}
+	btc, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bitcoin amount %q", value)
+	}
+	var sats int64
+	if frac != "" {
+		if sats, err = strconv.ParseInt(frac+strings.Repeat("0", 8-len(frac)), 10, 64); err != nil {
+			return 0, fmt.Errorf("invalid bitcoin amount %q", value)
+		}
+	}
+	return btc*100000000 + sats, nil
+}
+
+// GetBlockchainInfo 获取节点的链信息
+func (bc *BitcoinClient) GetBlockchainInfo(ctx context.Context) (*BitcoinChainInfo, error) {
+	var info BitcoinChainInfo
+	if err := bc.call(ctx, "getblockchaininfo", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// CheckNetwork 校验节点所在网络与客户端网络参数一致，避免把测试网币当成主网充值
+func (bc *BitcoinClient) CheckNetwork(ctx context.Context) error {
+	info, err := bc.GetBlockchainInfo(ctx)
+	if err != nil {
+		return err
+	}
+	expected := map[string][]string{
+		BitcoinMainNet.Name: {"main"},
+		BitcoinTestNet.Name: {"test", "testnet4", "signet"},
+		BitcoinRegTest.Name: {"regtest"},
+	}[bc.params.Name]
+	for _, chain := range expected {
+		if info.Chain == chain {
+			return nil
+		}
+	}
+	return fmt.Errorf("bitcoin node is on %s, expected %s", info.Chain, bc.params.Name)
+}
+
+// GetBlockCount 获取最新区块高度
+func (bc *BitcoinClient) GetBlockCount(ctx context.Context) (uint64, error) {
+	var height uint64
+	if err := bc.call(ctx, "getblockcount", nil, &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// GetBlockHash 获取主链上指定高度的区块哈希
+func (bc *BitcoinClient) GetBlockHash(ctx context.Context, height uint64) (string, error) {
+	var hash string
+	if err := bc.call(ctx, "getblockhash", []interface{}{height}, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// GetBlock 获取包含完整交易的区块
+func (bc *BitcoinClient) GetBlock(ctx context.Context, hash string) (*BitcoinBlock, error) {
+	var block BitcoinBlock
+	if err := bc.call(ctx, "getblock", []interface{}{hash, 2}, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// call 发送 bitcoind JSON-RPC 请求
+func (bc *BitcoinClient) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	if bc.rpcURL == "" {
+		return fmt.Errorf("bitcoin rpc_url is not configured")
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "1.0",
+		"id":      method,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, bc.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bc.rpcUser != "" {
+		req.SetBasicAuth(bc.rpcUser, bc.rpcPassword)
+	}
+
+	resp, err := bc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("bitcoin rpc %s failed: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read bitcoin rpc response: %v", err)
+	}
+
+	// bitcoind 对RPC错误返回 500 并附带错误信息，认证失败等返回空响应体
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("bitcoin rpc %s failed: %s %s", method, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if result.Error != nil {
+		return fmt.Errorf("bitcoin rpc %s failed: %s (code %d)", method, result.Error.Message, result.Error.Code)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(result.Result, out); err != nil {
+		return fmt.Errorf("failed to decode bitcoin rpc %s response: %v", method, err)
+	}
+	return nil
+}
+
+// EstimateSmartFee 估算在 confTarget 个区块内确认所需的费率（聪/vB），节点数据不足时返回错误
+func (bc *BitcoinClient) EstimateSmartFee(ctx context.Context, confTarget int) (int64, error) {
+	var result struct {
+		FeeRate *json.Number `json:"feerate"` // BTC/kvB
+		Errors  []string     `json:"errors"`
+	}
+	if err := bc.call(ctx, "estimatesmartfee", []interface{}{confTarget}, &result); err != nil {
+		return 0, err
+	}
+	if result.FeeRate == nil {
+		return 0, fmt.Errorf("fee estimation unavailable: %s", strings.Join(result.Errors, "; "))
+	}
+	perKvB, err := ParseBitcoinAmount(result.FeeRate.String())
+	if err != nil {
+		return 0, err
+	}
+	// 聪/kvB 转换为 聪/vB，向上取整
+	return (perKvB + 999) / 1000, nil
+}
+
+// SendRawTransaction 广播已签名交易，返回交易ID
+func (bc *BitcoinClient) SendRawTransaction(ctx context.Context, rawTx []byte) (string, error) {
+	var txid string
+	if err := bc.call(ctx, "sendrawtransaction", []interface{}{hex.EncodeToString(rawTx)}, &txid); err != nil {
+		return "", err
+	}
+	return txid, nil
+}
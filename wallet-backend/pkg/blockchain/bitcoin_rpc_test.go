@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseBitcoinAmount(t *testing.T) {
+	valid := map[string]int64{
+		"0":            0,
+		"0.00000001":   1,
+		"0.0015":       150000,
+		"6.25000000":   625000000,
+		"21000000.000": 2100000000000000,
+	}
+	for value, expected := range valid {
+		sats, err := ParseBitcoinAmount(value)
+		if err != nil || sats != expected {
+			t.Errorf("ParseBitcoinAmount(%q) = %d, %v; expected %d", value, sats, err, expected)
+		}
+	}
+
+	for _, value := range []string{"", "-1", "0.000000001", "1e-8", ".5"} {
+		if _, err := ParseBitcoinAmount(value); err == nil {
+			t.Errorf("ParseBitcoinAmount(%q) should fail", value)
+		}
+	}
+}
+
+func TestEstimateSmartFee(t *testing.T) {
+	responses := map[int]string{
+		2: `{"result":{"feerate":0.00012345,"blocks":2},"error":null,"id":"estimatesmartfee"}`,
+		6: `{"result":{"errors":["Insufficient data or no feerate found"],"blocks":0},"error":null,"id":"estimatesmartfee"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []json.Number `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		target, _ := req.Params[0].Int64()
+		w.Write([]byte(responses[int(target)]))
+	}))
+	defer server.Close()
+
+	client := NewBitcoinRPCClient(BitcoinRegTest, server.URL, "", "")
+	rate, err := client.EstimateSmartFee(context.Background(), 2)
+	if err != nil || rate != 13 {
+		t.Errorf("EstimateSmartFee = %d, %v; expected 13 sat/vB", rate, err)
+	}
+	// regtest 节点没有费率数据时返回错误，由调用方使用备用费率
+	if _, err := client.EstimateSmartFee(context.Background(), 6); err == nil {
+		t.Errorf("expected error when fee estimation is unavailable")
+	}
+}
@@ -0,0 +1,439 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+const (
+	// BitcoinSequenceFinal 不可替换的输入序号
+	BitcoinSequenceFinal uint32 = 0xffffffff
+	// BitcoinSequenceRBF 声明可替换（BIP125）的输入序号
+	BitcoinSequenceRBF uint32 = 0xfffffffd
+	// BitcoinSigHashAll SIGHASH_ALL
+	BitcoinSigHashAll uint32 = 0x01
+)
+
+// BitcoinWireInput 交易输入，PrevTxID 为区块浏览器显示的（字节反序）交易哈希
+type BitcoinWireInput struct {
+	PrevTxID  string
+	PrevVout  uint32
+	ScriptSig []byte
+	Sequence  uint32
+	Witness   [][]byte
+}
+
+// BitcoinWireOutput 交易输出
+type BitcoinWireOutput struct {
+	Value    int64 // 聪
+	PkScript []byte
+}
+
+// BitcoinWireTx 可序列化的比特币交易
+type BitcoinWireTx struct {
+	Version  int32
+	Inputs   []BitcoinWireInput
+	Outputs  []BitcoinWireOutput
+	LockTime uint32
+}
+
+// HasWitness 是否包含见证数据
+func (tx *BitcoinWireTx) HasWitness() bool {
+	for _, in := range tx.Inputs {
+		if len(in.Witness) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Serialize 序列化交易，witness 为 true 且有见证数据时使用BIP144格式
+func (tx *BitcoinWireTx) Serialize(witness bool) []byte {
+	witness = witness && tx.HasWitness()
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, tx.Version)
+	if witness {
+		buf.Write([]byte{0x00, 0x01})
+	}
+	writeVarInt(&buf, uint64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		hash, _ := txIDBytes(in.PrevTxID)
+		buf.Write(hash)
+		binary.Write(&buf, binary.LittleEndian, in.PrevVout)
+		writeVarBytes(&buf, in.ScriptSig)
+		binary.Write(&buf, binary.LittleEndian, in.Sequence)
+	}
+	writeVarInt(&buf, uint64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		binary.Write(&buf, binary.LittleEndian, out.Value)
+		writeVarBytes(&buf, out.PkScript)
+	}
+	if witness {
+		for _, in := range tx.Inputs {
+			writeWitness(&buf, in.Witness)
+		}
+	}
+	binary.Write(&buf, binary.LittleEndian, tx.LockTime)
+	return buf.Bytes()
+}
+
+// TxID 交易ID（不含见证数据的双SHA256，字节反序显示）
+func (tx *BitcoinWireTx) TxID() string {
+	return hex.EncodeToString(reverseBytes(doubleSHA256(tx.Serialize(false))))
+}
+
+// VSize 虚拟大小 ceil(weight / 4)
+func (tx *BitcoinWireTx) VSize() int64 {
+	base := int64(len(tx.Serialize(false)))
+	total := int64(len(tx.Serialize(true)))
+	weight := base*3 + total
+	return (weight + 3) / 4
+}
+
+// ParseBitcoinWireTx 解析序列化的交易（支持BIP144见证格式）
+func ParseBitcoinWireTx(data []byte) (*BitcoinWireTx, error) {
+	r := bytes.NewReader(data)
+	tx, err := readWireTx(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("invalid transaction: %d trailing bytes", r.Len())
+	}
+	return tx, nil
+}
+
+// readWireTx 从数据流中读取交易
+func readWireTx(r *bytes.Reader) (*BitcoinWireTx, error) {
+	tx := &BitcoinWireTx{}
+	if err := binary.Read(r, binary.LittleEndian, &tx.Version); err != nil {
+		return nil, err
+	}
+	count, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	witness := false
+	if count == 0 {
+		// BIP144 marker 0x00 + flag 0x01
+		flag, err := r.ReadByte()
+		if err != nil || flag != 0x01 {
+			return nil, fmt.Errorf("invalid witness flag")
+		}
+		witness = true
+		if count, err = readVarInt(r); err != nil {
+			return nil, err
+		}
+	}
+	if count > uint64(r.Len()) {
+		return nil, fmt.Errorf("input count %d too large", count)
+	}
+
+	tx.Inputs = make([]BitcoinWireInput, count)
+	for i := range tx.Inputs {
+		hash := make([]byte, 32)
+		if _, err := io.ReadFull(r, hash); err != nil {
+			return nil, err
+		}
+		in := &tx.Inputs[i]
+		in.PrevTxID = hex.EncodeToString(reverseBytes(hash))
+		if err := binary.Read(r, binary.LittleEndian, &in.PrevVout); err != nil {
+			return nil, err
+		}
+		if in.ScriptSig, err = readVarBytes(r); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &in.Sequence); err != nil {
+			return nil, err
+		}
+	}
+
+	if count, err = readVarInt(r); err != nil {
+		return nil, err
+	}
+	if count > uint64(r.Len()) {
+		return nil, fmt.Errorf("output count %d too large", count)
+	}
+	tx.Outputs = make([]BitcoinWireOutput, count)
+	for i := range tx.Outputs {
+		out := &tx.Outputs[i]
+		if err := binary.Read(r, binary.LittleEndian, &out.Value); err != nil {
+			return nil, err
+		}
+		if out.PkScript, err = readVarBytes(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if witness {
+		for i := range tx.Inputs {
+			if tx.Inputs[i].Witness, err = readWitness(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := binary.Read(r, binary.LittleEndian, &tx.LockTime); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// WitnessV0SigHash 按BIP143计算隔离见证v0输入的签名哈希
+// scriptCode 对P2WPKH为 OP_DUP OP_HASH160 <pubkey-hash> OP_EQUALVERIFY OP_CHECKSIG
+func (tx *BitcoinWireTx) WitnessV0SigHash(index int, scriptCode []byte, amount int64, hashType uint32) ([]byte, error) {
+	if index < 0 || index >= len(tx.Inputs) {
+		return nil, fmt.Errorf("input index %d out of range", index)
+	}
+	if hashType != BitcoinSigHashAll {
+		return nil, fmt.Errorf("unsupported sighash type 0x%02x", hashType)
+	}
+
+	var prevouts, sequences, outputs bytes.Buffer
+	for _, in := range tx.Inputs {
+		hash, err := txIDBytes(in.PrevTxID)
+		if err != nil {
+			return nil, err
+		}
+		prevouts.Write(hash)
+		binary.Write(&prevouts, binary.LittleEndian, in.PrevVout)
+		binary.Write(&sequences, binary.LittleEndian, in.Sequence)
+	}
+	for _, out := range tx.Outputs {
+		binary.Write(&outputs, binary.LittleEndian, out.Value)
+		writeVarBytes(&outputs, out.PkScript)
+	}
+
+	in := tx.Inputs[index]
+	hash, _ := txIDBytes(in.PrevTxID)
+
+	var preimage bytes.Buffer
+	binary.Write(&preimage, binary.LittleEndian, tx.Version)
+	preimage.Write(doubleSHA256(prevouts.Bytes()))
+	preimage.Write(doubleSHA256(sequences.Bytes()))
+	preimage.Write(hash)
+	binary.Write(&preimage, binary.LittleEndian, in.PrevVout)
+	writeVarBytes(&preimage, scriptCode)
+	binary.Write(&preimage, binary.LittleEndian, amount)
+	binary.Write(&preimage, binary.LittleEndian, in.Sequence)
+	preimage.Write(doubleSHA256(outputs.Bytes()))
+	binary.Write(&preimage, binary.LittleEndian, tx.LockTime)
+	binary.Write(&preimage, binary.LittleEndian, hashType)
+	return doubleSHA256(preimage.Bytes()), nil
+}
+
+// P2WPKHScriptCode P2WPKH输入签名使用的 scriptCode
+func P2WPKHScriptCode(pubKeyHash []byte) []byte {
+	return append(append([]byte{0x76, 0xa9, 0x14}, pubKeyHash...), 0x88, 0xac)
+}
+
+// P2WPKHProgram 若脚本为 OP_0 <20字节>，返回公钥哈希
+func P2WPKHProgram(pkScript []byte) ([]byte, bool) {
+	if len(pkScript) != 22 || pkScript[0] != 0x00 || pkScript[1] != 0x14 {
+		return nil, false
+	}
+	return pkScript[2:], true
+}
+
+// BitcoinAddressToScript 把地址转换为输出脚本，支持 P2PKH、P2SH、隔离见证v0和Taproot
+func BitcoinAddressToScript(address string, params *BitcoinNetworkParams) ([]byte, error) {
+	if strings.HasPrefix(strings.ToLower(address), params.Bech32HRP+"1") {
+		version, program, err := DecodeSegWitAddress(params.Bech32HRP, address)
+		if err != nil {
+			return nil, err
+		}
+		op := version
+		if version > 0 {
+			op = 0x50 + version // OP_1 .. OP_16
+		}
+		return append([]byte{op, byte(len(program))}, program...), nil
+	}
+
+	if err := ValidateBitcoinAddress(address, params); err != nil {
+		return nil, err
+	}
+	version, payload, _ := DecodeBase58Check(address)
+	if version == params.PubKeyHashAddrID {
+		return append(append([]byte{0x76, 0xa9, 0x14}, payload...), 0x88, 0xac), nil
+	}
+	return append(append([]byte{0xa9, 0x14}, payload...), 0x87), nil
+}
+
+// BitcoinScriptToAddress 把标准输出脚本转换为地址，非标准脚本返回错误
+func BitcoinScriptToAddress(pkScript []byte, params *BitcoinNetworkParams) (string, error) {
+	switch {
+	case len(pkScript) == 25 && pkScript[0] == 0x76 && pkScript[1] == 0xa9 && pkScript[2] == 0x14 && pkScript[23] == 0x88 && pkScript[24] == 0xac:
+		return EncodeBase58Check(params.PubKeyHashAddrID, pkScript[3:23]), nil
+	case len(pkScript) == 23 && pkScript[0] == 0xa9 && pkScript[1] == 0x14 && pkScript[22] == 0x87:
+		return EncodeBase58Check(params.ScriptHashAddrID, pkScript[2:22]), nil
+	case len(pkScript) >= 4 && len(pkScript) <= 42 && int(pkScript[1]) == len(pkScript)-2 && (pkScript[0] == 0x00 || (pkScript[0] >= 0x51 && pkScript[0] <= 0x60)):
+		version := pkScript[0]
+		if version != 0 {
+			version -= 0x50
+		}
+		return EncodeSegWitAddress(params.Bech32HRP, version, pkScript[2:])
+	}
+	return "", fmt.Errorf("non-standard output script %x", pkScript)
+}
+
+// BitcoinOutputVSize 输出到该脚本的虚拟大小（金额8字节 + 脚本长度 + 脚本）
+func BitcoinOutputVSize(pkScript []byte) int64 {
+	return int64(8 + varIntSize(uint64(len(pkScript))) + len(pkScript))
+}
+
+// EncodeBitcoinSignature 把 [R || S || V] 签名编码为DER格式并附加sighash类型，S 规范化为低位
+func EncodeBitcoinSignature(sig []byte, hashType uint32) ([]byte, error) {
+	if len(sig) < 64 {
+		return nil, fmt.Errorf("signature must be at least 64 bytes, got %d", len(sig))
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if s.Cmp(secp256k1HalfOrder) > 0 {
+		s.Sub(secp256k1Order, s)
+	}
+
+	encodeInt := func(v *big.Int) []byte {
+		b := v.Bytes()
+		if len(b) == 0 {
+			b = []byte{0}
+		}
+		if b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return append([]byte{0x02, byte(len(b))}, b...)
+	}
+	body := append(encodeInt(r), encodeInt(s)...)
+	der := append([]byte{0x30, byte(len(body))}, body...)
+	return append(der, byte(hashType)), nil
+}
+
+var (
+	secp256k1Order, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secp256k1HalfOrder = new(big.Int).Rsh(secp256k1Order, 1)
+)
+
+// txIDBytes 把显示格式的交易哈希转换为序列化使用的字节序
+func txIDBytes(txid string) ([]byte, error) {
+	hash, err := hex.DecodeString(txid)
+	if err != nil || len(hash) != 32 {
+		return nil, fmt.Errorf("invalid txid %q", txid)
+	}
+	return reverseBytes(hash), nil
+}
+
+// reverseBytes 返回字节反序的副本
+func reverseBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+// varIntSize CompactSize 编码长度
+func varIntSize(v uint64) int {
+	switch {
+	case v < 0xfd:
+		return 1
+	case v <= 0xffff:
+		return 3
+	case v <= 0xffffffff:
+		return 5
+	default:
+		return 9
+	}
+}
+
+// writeVarInt 写入 CompactSize 整数
+func writeVarInt(w *bytes.Buffer, v uint64) {
+	switch {
+	case v < 0xfd:
+		w.WriteByte(byte(v))
+	case v <= 0xffff:
+		w.WriteByte(0xfd)
+		binary.Write(w, binary.LittleEndian, uint16(v))
+	case v <= 0xffffffff:
+		w.WriteByte(0xfe)
+		binary.Write(w, binary.LittleEndian, uint32(v))
+	default:
+		w.WriteByte(0xff)
+		binary.Write(w, binary.LittleEndian, v)
+	}
+}
+
+// readVarInt 读取 CompactSize 整数
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch prefix {
+	case 0xfd:
+		var v uint16
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return uint64(v), err
+	case 0xfe:
+		var v uint32
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return uint64(v), err
+	case 0xff:
+		var v uint64
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return v, err
+	default:
+		return uint64(prefix), nil
+	}
+}
+
+// writeVarBytes 写入带长度前缀的字节串
+func writeVarBytes(w *bytes.Buffer, b []byte) {
+	writeVarInt(w, uint64(len(b)))
+	w.Write(b)
+}
+
+// readVarBytes 读取带长度前缀的字节串
+func readVarBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, fmt.Errorf("length %d exceeds remaining %d bytes", n, r.Len())
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
+
+// writeWitness 写入一个输入的见证栈
+func writeWitness(w *bytes.Buffer, witness [][]byte) {
+	writeVarInt(w, uint64(len(witness)))
+	for _, item := range witness {
+		writeVarBytes(w, item)
+	}
+}
+
+// readWitness 读取一个输入的见证栈
+func readWitness(r *bytes.Reader) ([][]byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, fmt.Errorf("witness item count %d too large", n)
+	}
+	var witness [][]byte
+	for i := uint64(0); i < n; i++ {
+		item, err := readVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		witness = append(witness, item)
+	}
+	return witness, nil
+}
@@ -0,0 +1,52 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// BIP143 原生P2WPKH示例
+func TestWitnessV0SigHash(t *testing.T) {
+	raw, _ := hex.DecodeString("0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000")
+	tx, err := ParseBitcoinWireTx(raw)
+	if err != nil {
+		t.Fatalf("ParseBitcoinWireTx failed: %v", err)
+	}
+	if !bytes.Equal(tx.Serialize(false), raw) {
+		t.Fatalf("serialized transaction does not round-trip")
+	}
+	if len(tx.Inputs) != 2 || tx.Inputs[0].Sequence != 0xffffffee || tx.LockTime != 17 {
+		t.Fatalf("unexpected parsed transaction: %+v", tx)
+	}
+
+	pkScript, _ := hex.DecodeString("00141d0f172a0ecb48aee1be1f2687d2963ae33f71a1")
+	program, ok := P2WPKHProgram(pkScript)
+	if !ok {
+		t.Fatalf("expected P2WPKH script")
+	}
+	hash, err := tx.WitnessV0SigHash(1, P2WPKHScriptCode(program), 600000000, BitcoinSigHashAll)
+	if err != nil {
+		t.Fatalf("WitnessV0SigHash failed: %v", err)
+	}
+	if got := hex.EncodeToString(hash); got != "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670" {
+		t.Errorf("sighash = %s", got)
+	}
+}
+
+func TestBitcoinAddressScriptRoundTrip(t *testing.T) {
+	for _, address := range []string{
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+	} {
+		script, err := BitcoinAddressToScript(address, BitcoinMainNet)
+		if err != nil {
+			t.Fatalf("BitcoinAddressToScript(%s) failed: %v", address, err)
+		}
+		back, err := BitcoinScriptToAddress(script, BitcoinMainNet)
+		if err != nil || back != address {
+			t.Errorf("BitcoinScriptToAddress = %s, %v; expected %s", back, err, address)
+		}
+	}
+}
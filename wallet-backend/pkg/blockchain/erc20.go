@@ -0,0 +1,60 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ERC20TransferTopic ERC-20 Transfer(address,address,uint256) 事件签名
+var ERC20TransferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// ERC20Transfer 从日志中解析出的一笔代币转账
+type ERC20Transfer struct {
+	Contract    common.Address
+	From        common.Address
+	To          common.Address
+	Amount      *big.Int
+	TxHash      common.Hash
+	BlockNumber uint64
+	BlockHash   common.Hash
+	LogIndex    uint // 日志在区块中的序号
+}
+
+// ERC20TransferQuery 构造 eth_getLogs 查询：指定合约在 [fromBlock, toBlock] 内转入 recipients 的 Transfer 事件
+// recipients 为空时查询所有转账
+func ERC20TransferQuery(contracts []common.Address, fromBlock, toBlock uint64, recipients []common.Address) ethereum.FilterQuery {
+	var to []common.Hash
+	for _, recipient := range recipients {
+		to = append(to, common.BytesToHash(recipient.Bytes()))
+	}
+	return ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: contracts,
+		Topics:    [][]common.Hash{{ERC20TransferTopic}, nil, to},
+	}
+}
+
+// ParseERC20Transfer 解析 Transfer 事件日志，ERC-721 等 tokenId 放在 topics 中的同名事件返回错误
+func ParseERC20Transfer(entry types.Log) (*ERC20Transfer, error) {
+	if len(entry.Topics) != 3 || entry.Topics[0] != ERC20TransferTopic {
+		return nil, fmt.Errorf("log %d of %s is not an ERC-20 Transfer event", entry.Index, entry.TxHash.Hex())
+	}
+	if len(entry.Data) != 32 {
+		return nil, fmt.Errorf("log %d of %s has invalid Transfer data length %d", entry.Index, entry.TxHash.Hex(), len(entry.Data))
+	}
+	return &ERC20Transfer{
+		Contract:    entry.Address,
+		From:        common.BytesToAddress(entry.Topics[1].Bytes()),
+		To:          common.BytesToAddress(entry.Topics[2].Bytes()),
+		Amount:      new(big.Int).SetBytes(entry.Data),
+		TxHash:      entry.TxHash,
+		BlockNumber: entry.BlockNumber,
+		BlockHash:   entry.BlockHash,
+		LogIndex:    entry.Index,
+	}, nil
+}
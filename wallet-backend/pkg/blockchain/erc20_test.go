@@ -0,0 +1,50 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestERC20TransferTopic(t *testing.T) {
+	if topic := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")); topic != ERC20TransferTopic {
+		t.Errorf("topic = %s", topic.Hex())
+	}
+}
+
+func TestParseERC20Transfer(t *testing.T) {
+	contract := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	entry := types.Log{
+		Address:     contract,
+		Topics:      []common.Hash{ERC20TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(2500000).Bytes(), 32),
+		BlockNumber: 100,
+		TxHash:      common.HexToHash("0xabc"),
+		Index:       7,
+	}
+
+	transfer, err := ParseERC20Transfer(entry)
+	if err != nil {
+		t.Fatalf("ParseERC20Transfer failed: %v", err)
+	}
+	if transfer.Contract != contract || transfer.From != from || transfer.To != to || transfer.Amount.Int64() != 2500000 || transfer.LogIndex != 7 {
+		t.Errorf("unexpected transfer %+v", transfer)
+	}
+
+	// ERC-721 的 tokenId 在第4个topic中
+	entry.Topics = append(entry.Topics, common.BigToHash(big.NewInt(1)))
+	entry.Data = nil
+	if _, err := ParseERC20Transfer(entry); err == nil {
+		t.Error("expected error for ERC-721 Transfer")
+	}
+
+	query := ERC20TransferQuery([]common.Address{contract}, 10, 20, []common.Address{to})
+	if len(query.Topics) != 3 || query.Topics[2][0] != common.BytesToHash(to.Bytes()) || query.ToBlock.Uint64() != 20 {
+		t.Errorf("unexpected query %+v", query)
+	}
+}
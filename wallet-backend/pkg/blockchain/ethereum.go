@@ -41,8 +41,7 @@ func (ec *EthereumClient) GetBalance(address string) (*big.Int, error) {
 
 // GetTransactionCount 获取地址交易数量
 func (ec *EthereumClient) GetTransactionCount(address string) (uint64, error) {
-	_ = common.HexToAddress(address) // 验证地址格式
-	count, err := ec.client.PendingTransactionCount(context.Background())
+	count, err := ec.client.NonceAt(context.Background(), common.HexToAddress(address), nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get transaction count: %v", err)
 	}
@@ -0,0 +1,116 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// EVMBlock 批量查询返回的区块，Hash 为节点返回的区块哈希（部分链的区块头含有额外字段，本地重新计算的哈希可能不同）
+type EVMBlock struct {
+	Hash  common.Hash
+	Block *types.Block
+}
+
+// rpcBlockBody 区块JSON中区块头以外的字段
+type rpcBlockBody struct {
+	Hash         common.Hash          `json:"hash"`
+	Transactions []*types.Transaction `json:"transactions"`
+}
+
+// BatchBlocksByNumber 通过一次 JSON-RPC 批量请求 eth_getBlockByNumber 获取多个区块及其完整交易，结果与 numbers 顺序一致
+// 任何一个区块获取失败或节点尚未同步到该区块时返回错误
+func BatchBlocksByNumber(ctx context.Context, client *rpc.Client, numbers []uint64) ([]*EVMBlock, error) {
+	raws := make([]json.RawMessage, len(numbers))
+	batch := make([]rpc.BatchElem, len(numbers))
+	for i, number := range numbers {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(number), true},
+			Result: &raws[i],
+		}
+	}
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("failed to get blocks %d-%d: %v", numbers[0], numbers[len(numbers)-1], err)
+	}
+
+	blocks := make([]*EVMBlock, len(numbers))
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("failed to get block %d: %v", numbers[i], elem.Error)
+		}
+		if len(raws[i]) == 0 || string(raws[i]) == "null" {
+			return nil, fmt.Errorf("block %d not found", numbers[i])
+		}
+		var header types.Header
+		if err := json.Unmarshal(raws[i], &header); err != nil {
+			return nil, fmt.Errorf("failed to decode block %d header: %v", numbers[i], err)
+		}
+		var body rpcBlockBody
+		if err := json.Unmarshal(raws[i], &body); err != nil {
+			return nil, fmt.Errorf("failed to decode block %d transactions: %v", numbers[i], err)
+		}
+		blocks[i] = &EVMBlock{
+			Hash:  body.Hash,
+			Block: types.NewBlockWithHeader(&header).WithBody(types.Body{Transactions: body.Transactions}),
+		}
+	}
+	return blocks, nil
+}
+
+// BatchBlockReceipts 批量请求 eth_getBlockReceipts 获取多个区块的全部回执，返回 区块号 -> 回执
+// 节点不支持该方法时返回错误，调用方可改用 BatchTransactionReceipts
+func BatchBlockReceipts(ctx context.Context, client *rpc.Client, numbers []uint64) (map[uint64][]*types.Receipt, error) {
+	results := make([][]*types.Receipt, len(numbers))
+	batch := make([]rpc.BatchElem, len(numbers))
+	for i, number := range numbers {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockReceipts",
+			Args:   []interface{}{hexutil.EncodeUint64(number)},
+			Result: &results[i],
+		}
+	}
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("failed to get block receipts: %v", err)
+	}
+
+	receipts := make(map[uint64][]*types.Receipt, len(numbers))
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("failed to get receipts of block %d: %v", numbers[i], elem.Error)
+		}
+		receipts[numbers[i]] = results[i]
+	}
+	return receipts, nil
+}
+
+// BatchTransactionReceipts 批量请求 eth_getTransactionReceipt，结果与 hashes 顺序一致
+func BatchTransactionReceipts(ctx context.Context, client *rpc.Client, hashes []common.Hash) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(hashes))
+	batch := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("failed to get transaction receipts: %v", err)
+	}
+
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("failed to get receipt %s: %v", hashes[i].Hex(), elem.Error)
+		}
+		if receipts[i] == nil {
+			return nil, fmt.Errorf("receipt %s not found", hashes[i].Hex())
+		}
+	}
+	return receipts, nil
+}
@@ -0,0 +1,92 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// blockJSON 按节点 eth_getBlockByNumber 的格式序列化区块
+func blockJSON(t *testing.T, block *types.Block) json.RawMessage {
+	fields := make(map[string]interface{})
+	header, _ := json.Marshal(block.Header())
+	if err := json.Unmarshal(header, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields["transactions"] = block.Transactions()
+	raw, _ := json.Marshal(fields)
+	return raw
+}
+
+func TestBatchBlocksByNumber(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tx, _ := types.SignNewTx(key, signer, &types.DynamicFeeTx{ChainID: big.NewInt(1), To: &to, Value: big.NewInt(5), Gas: 21000})
+
+	blocks := make(map[string]*types.Block)
+	for number := int64(10); number <= 11; number++ {
+		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(1)})
+		if number == 11 {
+			block = block.WithBody(types.Body{Transactions: []*types.Transaction{tx}})
+		}
+		blocks[hexutil.EncodeUint64(uint64(number))] = block
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var batch []struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&batch)
+		var responses []map[string]interface{}
+		for _, req := range batch {
+			var number string
+			json.Unmarshal(req.Params[0], &number)
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": nil}
+			if block, ok := blocks[number]; ok {
+				response["result"] = blockJSON(t, block)
+			}
+			responses = append(responses, response)
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	client, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	result, err := BatchBlocksByNumber(context.Background(), client, []uint64{10, 11})
+	if err != nil {
+		t.Fatalf("BatchBlocksByNumber failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single batch request, got %d", requests)
+	}
+	if result[0].Block.NumberU64() != 10 || result[1].Block.NumberU64() != 11 || result[1].Hash != blocks["0xb"].Hash() {
+		t.Errorf("unexpected blocks %+v", result)
+	}
+	if txs := result[1].Block.Transactions(); len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+		t.Errorf("unexpected transactions %v", txs)
+	}
+
+	// 节点尚未同步到的区块返回 null
+	if _, err := BatchBlocksByNumber(context.Background(), client, []uint64{11, 12}); err == nil {
+		t.Error("expected error for missing block")
+	}
+}
@@ -0,0 +1,259 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// EVMFeeSlow 慢速档：最近区块小费的第10百分位
+	EVMFeeSlow = "slow"
+	// EVMFeeStandard 标准档：第50百分位
+	EVMFeeStandard = "standard"
+	// EVMFeeFast 快速档：第90百分位
+	EVMFeeFast = "fast"
+
+	// evmFeeHistoryBlocks 默认查询的 eth_feeHistory 区块数
+	evmFeeHistoryBlocks = 10
+)
+
+// evmFeePercentiles 慢/标准/快三档对应的小费百分位
+var evmFeePercentiles = []float64{10, 50, 90}
+
+// ErrEVMFeeAboveCap 当前手续费超过配置的上限，交易应推迟发送
+var ErrEVMFeeAboveCap = errors.New("gas price exceeds the configured cap")
+
+// EVMFeeClient 估算手续费所需的节点接口，*ethclient.Client 已实现
+type EVMFeeClient interface {
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// EVMFee 交易手续费参数：EIP-1559 交易使用 GasTipCap/GasFeeCap，传统交易只有 GasPrice
+type EVMFee struct {
+	GasPrice  *big.Int `json:"gas_price,omitempty"`
+	GasTipCap *big.Int `json:"max_priority_fee_per_gas,omitempty"`
+	GasFeeCap *big.Int `json:"max_fee_per_gas,omitempty"`
+}
+
+// IsDynamic 是否为 EIP-1559 手续费
+func (f *EVMFee) IsDynamic() bool {
+	return f.GasFeeCap != nil
+}
+
+// MaxPrice 每单位gas的最高价格
+func (f *EVMFee) MaxPrice() *big.Int {
+	if f.IsDynamic() {
+		return f.GasFeeCap
+	}
+	return f.GasPrice
+}
+
+// MaxCost 按最高价格计算的手续费上限
+func (f *EVMFee) MaxCost(gasLimit uint64) *big.Int {
+	return new(big.Int).Mul(f.MaxPrice(), new(big.Int).SetUint64(gasLimit))
+}
+
+// Capped 按每单位gas的价格上限调整手续费
+// EIP-1559 交易在 baseFee + 小费 不超过上限时把 maxFeePerGas 降到上限；
+// 仍超过上限（或传统交易 gasPrice 超过上限）时返回 ErrEVMFeeAboveCap。maxPrice 为空时不限制
+func (f *EVMFee) Capped(baseFee, maxPrice *big.Int) (*EVMFee, error) {
+	if maxPrice == nil || maxPrice.Sign() <= 0 || f.MaxPrice().Cmp(maxPrice) <= 0 {
+		return f, nil
+	}
+	if f.IsDynamic() && baseFee != nil {
+		if new(big.Int).Add(baseFee, f.GasTipCap).Cmp(maxPrice) <= 0 {
+			return &EVMFee{GasTipCap: f.GasTipCap, GasFeeCap: new(big.Int).Set(maxPrice)}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s wei > %s wei", ErrEVMFeeAboveCap, f.MaxPrice(), maxPrice)
+}
+
+// EVMFeeOf 已创建交易的手续费参数
+func EVMFeeOf(tx *types.Transaction) *EVMFee {
+	if tx.Type() == types.DynamicFeeTxType {
+		return &EVMFee{GasTipCap: tx.GasTipCap(), GasFeeCap: tx.GasFeeCap()}
+	}
+	return &EVMFee{GasPrice: tx.GasPrice()}
+}
+
+// BumpEVMFee 计算以相同nonce替换交易的手续费：各价格至少比原交易高 percent%（节点要求至少10%），
+// 并且不低于当前市场价格 market（可以为空）。替换交易保持原交易的类型
+func BumpEVMFee(old *EVMFee, percent int64, market *EVMFee) *EVMFee {
+	bump := func(price *big.Int) *big.Int {
+		bumped := new(big.Int).Mul(price, big.NewInt(100+percent))
+		bumped.Add(bumped, big.NewInt(99)) // 向上取整
+		return bumped.Div(bumped, big.NewInt(100))
+	}
+	atLeast := func(price, floor *big.Int) *big.Int {
+		if floor != nil && floor.Cmp(price) > 0 {
+			return new(big.Int).Set(floor)
+		}
+		return price
+	}
+
+	if !old.IsDynamic() {
+		price := bump(old.GasPrice)
+		if market != nil {
+			price = atLeast(price, market.MaxPrice())
+		}
+		return &EVMFee{GasPrice: price}
+	}
+
+	tip, feeCap := bump(old.GasTipCap), bump(old.GasFeeCap)
+	if market != nil {
+		if market.IsDynamic() {
+			tip = atLeast(tip, market.GasTipCap)
+		}
+		feeCap = atLeast(feeCap, market.MaxPrice())
+	}
+	return &EVMFee{GasTipCap: tip, GasFeeCap: atLeast(feeCap, tip)}
+}
+
+// EVMFeeLevels 根据最近区块采样得到的慢/标准/快三档手续费
+type EVMFeeLevels struct {
+	Legacy   bool     `json:"legacy"`
+	BaseFee  *big.Int `json:"base_fee,omitempty"` // 下一区块的 baseFee，传统交易为空
+	Slow     *EVMFee  `json:"slow"`
+	Standard *EVMFee  `json:"standard"`
+	Fast     *EVMFee  `json:"fast"`
+}
+
+// Level 获取指定档位的手续费，strategy 为空时使用标准档
+func (l *EVMFeeLevels) Level(strategy string) (*EVMFee, error) {
+	switch strategy {
+	case EVMFeeSlow:
+		return l.Slow, nil
+	case "", EVMFeeStandard:
+		return l.Standard, nil
+	case EVMFeeFast:
+		return l.Fast, nil
+	default:
+		return nil, fmt.Errorf("unknown fee strategy: %s", strategy)
+	}
+}
+
+// SuggestEVMFee 估算标准档交易手续费，见 SampleEVMFeeLevels
+func SuggestEVMFee(ctx context.Context, client EVMFeeClient, legacy bool) (*EVMFee, error) {
+	levels, err := SampleEVMFeeLevels(ctx, client, evmFeeHistoryBlocks, legacy)
+	if err != nil {
+		return nil, err
+	}
+	return levels.Standard, nil
+}
+
+// SampleEVMFeeLevels 通过 eth_feeHistory 采样最近 blocks 个区块，按小费的第10/50/90百分位（各区块取中位数）生成三档手续费
+// EIP-1559 链：maxPriorityFeePerGas 为小费，maxFeePerGas = 2 * 下一区块 baseFee + 小费，可承受 baseFee 连续6个满块的上涨；
+// legacy 为 true 或节点没有 baseFee（链未启用 London）时返回传统 gasPrice = baseFee + 小费，
+// 传统链不支持 eth_feeHistory 或最近区块没有交易时三档都使用 eth_gasPrice
+func SampleEVMFeeLevels(ctx context.Context, client EVMFeeClient, blocks uint64, legacy bool) (*EVMFeeLevels, error) {
+	history, err := client.FeeHistory(ctx, blocks, nil, evmFeePercentiles)
+	if err != nil {
+		if legacy {
+			return suggestLegacyLevels(ctx, client)
+		}
+		return nil, fmt.Errorf("failed to get fee history: %v", err)
+	}
+
+	// BaseFee 最后一项是下一个区块的 baseFee
+	baseFee := new(big.Int)
+	if n := len(history.BaseFee); n > 0 && history.BaseFee[n-1] != nil {
+		baseFee.Set(history.BaseFee[n-1])
+	}
+	if baseFee.Sign() == 0 {
+		legacy = true
+	}
+
+	tips := make([]*big.Int, len(evmFeePercentiles))
+	var suggested *big.Int
+	for i := range evmFeePercentiles {
+		tips[i] = medianReward(history.Reward, i)
+		if tips[i].Sign() > 0 {
+			continue
+		}
+		if legacy {
+			// 最近区块都是空块，传统链直接使用 eth_gasPrice
+			return suggestLegacyLevels(ctx, client)
+		}
+		// 最近区块都是空块，使用节点建议的小费
+		if suggested == nil {
+			if suggested, err = client.SuggestGasTipCap(ctx); err != nil {
+				return nil, fmt.Errorf("failed to suggest gas tip cap: %v", err)
+			}
+		}
+		tips[i] = new(big.Int).Set(suggested)
+	}
+
+	levels := &EVMFeeLevels{Legacy: legacy}
+	fees := make([]*EVMFee, len(tips))
+	for i, tip := range tips {
+		if legacy {
+			fees[i] = &EVMFee{GasPrice: new(big.Int).Add(baseFee, tip)}
+			continue
+		}
+		feeCap := new(big.Int).Mul(baseFee, big.NewInt(2))
+		fees[i] = &EVMFee{GasTipCap: tip, GasFeeCap: feeCap.Add(feeCap, tip)}
+	}
+	if !legacy {
+		levels.BaseFee = baseFee
+	}
+	levels.Slow, levels.Standard, levels.Fast = fees[0], fees[1], fees[2]
+	return levels, nil
+}
+
+// suggestLegacyLevels 三档都使用 eth_gasPrice
+func suggestLegacyLevels(ctx context.Context, client EVMFeeClient) (*EVMFeeLevels, error) {
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+	}
+	fee := &EVMFee{GasPrice: gasPrice}
+	return &EVMFeeLevels{Legacy: true, Slow: fee, Standard: fee, Fast: fee}, nil
+}
+
+// medianReward 各区块第 column 个百分位小费的中位数
+func medianReward(rewards [][]*big.Int, column int) *big.Int {
+	var values []*big.Int
+	for _, reward := range rewards {
+		if len(reward) > column && reward[column] != nil {
+			values = append(values, reward[column])
+		}
+	}
+	if len(values) == 0 {
+		return new(big.Int)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Cmp(values[j]) < 0 })
+	return new(big.Int).Set(values[len(values)/2])
+}
+
+// NewEVMTransaction 按手续费类型创建未签名交易：EIP-1559 手续费创建 DynamicFeeTx，否则创建传统交易
+func NewEVMTransaction(chainID *big.Int, nonce uint64, to common.Address, value *big.Int, gasLimit uint64, data []byte, fee *EVMFee) *types.Transaction {
+	if fee.IsDynamic() {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: fee.GasTipCap,
+			GasFeeCap: fee.GasFeeCap,
+			Gas:       gasLimit,
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: fee.GasPrice,
+		Gas:      gasLimit,
+		To:       &to,
+		Value:    value,
+		Data:     data,
+	})
+}
@@ -0,0 +1,152 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type fakeFeeClient struct {
+	history  *ethereum.FeeHistory
+	tipCap   *big.Int
+	gasPrice *big.Int
+}
+
+func (c *fakeFeeClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return c.history, nil
+}
+
+func (c *fakeFeeClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return c.tipCap, nil
+}
+
+func (c *fakeFeeClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return c.gasPrice, nil
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9))
+}
+
+func TestSampleEVMFeeLevels(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeFeeClient{
+		history: &ethereum.FeeHistory{
+			Reward: [][]*big.Int{
+				{gwei(1), gwei(2), gwei(6)},
+				{gwei(1), gwei(4), gwei(9)},
+				{gwei(1), gwei(3), gwei(8)},
+			},
+			BaseFee: []*big.Int{gwei(20), gwei(22), gwei(25), gwei(30)},
+		},
+		tipCap:   gwei(5),
+		gasPrice: gwei(40),
+	}
+
+	levels, err := SampleEVMFeeLevels(ctx, client, 3, false)
+	if err != nil {
+		t.Fatalf("SampleEVMFeeLevels failed: %v", err)
+	}
+	if levels.Legacy || levels.BaseFee.Cmp(gwei(30)) != 0 {
+		t.Fatalf("unexpected levels: %+v", levels)
+	}
+	for strategy, tip := range map[string]int64{EVMFeeSlow: 1, EVMFeeStandard: 3, EVMFeeFast: 8} {
+		fee, _ := levels.Level(strategy)
+		if !fee.IsDynamic() || fee.GasTipCap.Cmp(gwei(tip)) != 0 || fee.GasFeeCap.Cmp(gwei(60+tip)) != 0 {
+			t.Errorf("%s: unexpected fee tip %v cap %v", strategy, fee.GasTipCap, fee.GasFeeCap)
+		}
+	}
+	if fee, _ := SuggestEVMFee(ctx, client, false); fee.MaxCost(21000).Cmp(new(big.Int).Mul(gwei(63), big.NewInt(21000))) != 0 {
+		t.Errorf("unexpected max cost %v", fee.MaxCost(21000))
+	}
+
+	// 币种配置为传统交易时 gasPrice = baseFee + 小费
+	if fee, _ := SuggestEVMFee(ctx, client, true); fee.IsDynamic() || fee.GasPrice.Cmp(gwei(33)) != 0 {
+		t.Errorf("expected legacy fee, got %+v", fee)
+	}
+
+	// 空块没有小费数据时使用节点建议的小费
+	client.history.Reward = [][]*big.Int{{big.NewInt(0), big.NewInt(0), big.NewInt(0)}}
+	if fee, _ := SuggestEVMFee(ctx, client, false); fee.GasTipCap.Cmp(gwei(5)) != 0 {
+		t.Errorf("expected suggested tip cap, got %v", fee.GasTipCap)
+	}
+
+	// 链没有 baseFee 时回退到 eth_gasPrice
+	client.history.BaseFee = []*big.Int{big.NewInt(0), big.NewInt(0)}
+	if fee, _ := SuggestEVMFee(ctx, client, false); fee.IsDynamic() || fee.GasPrice.Cmp(gwei(40)) != 0 {
+		t.Errorf("expected legacy fallback on a chain without London, got %+v", fee)
+	}
+}
+
+func TestEVMFeeCapped(t *testing.T) {
+	fee := &EVMFee{GasTipCap: gwei(2), GasFeeCap: gwei(62)}
+
+	if capped, err := fee.Capped(gwei(30), nil); err != nil || capped != fee {
+		t.Errorf("no cap should keep the fee unchanged")
+	}
+	// baseFee + 小费 低于上限时降低 maxFeePerGas
+	capped, err := fee.Capped(gwei(30), gwei(40))
+	if err != nil || capped.GasFeeCap.Cmp(gwei(40)) != 0 || capped.GasTipCap.Cmp(gwei(2)) != 0 {
+		t.Errorf("expected fee cap lowered to 40 gwei, got %+v, %v", capped, err)
+	}
+	if _, err := fee.Capped(gwei(30), gwei(31)); !errors.Is(err, ErrEVMFeeAboveCap) {
+		t.Errorf("expected ErrEVMFeeAboveCap, got %v", err)
+	}
+	if _, err := (&EVMFee{GasPrice: gwei(40)}).Capped(nil, gwei(35)); !errors.Is(err, ErrEVMFeeAboveCap) {
+		t.Errorf("expected ErrEVMFeeAboveCap for legacy fee, got %v", err)
+	}
+}
+
+func TestBumpEVMFee(t *testing.T) {
+	// 原交易加价15%高于市场价格
+	fee := BumpEVMFee(&EVMFee{GasTipCap: gwei(2), GasFeeCap: gwei(60)}, 15, &EVMFee{GasTipCap: gwei(1), GasFeeCap: gwei(50)})
+	if fee.GasTipCap.Cmp(big.NewInt(2300000000)) != 0 || fee.GasFeeCap.Cmp(gwei(69)) != 0 {
+		t.Errorf("unexpected bumped fee %+v", fee)
+	}
+	// 市场价格更高时取市场价格
+	fee = BumpEVMFee(&EVMFee{GasTipCap: gwei(2), GasFeeCap: gwei(60)}, 15, &EVMFee{GasTipCap: gwei(5), GasFeeCap: gwei(90)})
+	if fee.GasTipCap.Cmp(gwei(5)) != 0 || fee.GasFeeCap.Cmp(gwei(90)) != 0 {
+		t.Errorf("expected market fee, got %+v", fee)
+	}
+	// 传统交易保持传统类型，向上取整
+	fee = BumpEVMFee(&EVMFee{GasPrice: big.NewInt(101)}, 10, nil)
+	if fee.IsDynamic() || fee.GasPrice.Cmp(big.NewInt(112)) != 0 {
+		t.Errorf("unexpected legacy bump %+v", fee)
+	}
+}
+
+func TestNewEVMTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chainID := big.NewInt(11155111)
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	dynamic := NewEVMTransaction(chainID, 7, to, big.NewInt(1), 21000, nil, &EVMFee{GasTipCap: gwei(2), GasFeeCap: gwei(62)})
+	legacy := NewEVMTransaction(chainID, 7, to, big.NewInt(1), 21000, nil, &EVMFee{GasPrice: gwei(40)})
+	if dynamic.Type() != types.DynamicFeeTxType || legacy.Type() != types.LegacyTxType {
+		t.Fatalf("unexpected transaction types %d, %d", dynamic.Type(), legacy.Type())
+	}
+
+	signer := types.NewLondonSigner(chainID)
+	for _, tx := range []*types.Transaction{dynamic, legacy} {
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("SignTx failed: %v", err)
+		}
+		from, err := types.Sender(signer, signed)
+		if err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("Sender = %s, %v", from.Hex(), err)
+		}
+		if signed.ChainId().Cmp(chainID) != 0 {
+			t.Errorf("chain ID = %v, expected %v", signed.ChainId(), chainID)
+		}
+		if fee := EVMFeeOf(signed); fee.IsDynamic() != (tx.Type() == types.DynamicFeeTxType) || fee.MaxPrice().Cmp(tx.GasFeeCap()) != 0 {
+			t.Errorf("EVMFeeOf = %+v", fee)
+		}
+	}
+}
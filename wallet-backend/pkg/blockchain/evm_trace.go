@@ -0,0 +1,222 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// EVM 内部交易追踪方式
+const (
+	EVMTraceNone   = ""       // 不追踪，只识别交易本身的转账
+	EVMTraceDebug  = "debug"  // debug_traceBlockByNumber + callTracer（geth、erigon、reth 等）
+	EVMTraceParity = "parity" // trace_block（erigon、nethermind、openethereum 等）
+)
+
+// IsEVMTraceMode 是否为支持的追踪方式
+func IsEVMTraceMode(mode string) bool {
+	return mode == EVMTraceNone || mode == EVMTraceDebug || mode == EVMTraceParity
+}
+
+// EVMInternalTransfer 合约执行过程中的一笔原生币转账（内部交易）
+type EVMInternalTransfer struct {
+	TxHash common.Hash
+	Path   string // 调用在交易调用树中的位置，如 "0.2" 为交易第1个子调用的第3个子调用
+	From   common.Address
+	To     common.Address
+	Value  *big.Int
+}
+
+// EVMCallFrame callTracer 返回的调用帧
+type EVMCallFrame struct {
+	Type  string          `json:"type"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Error string          `json:"error"`
+	Calls []EVMCallFrame  `json:"calls"`
+}
+
+// callTraceResult debug_traceBlockByNumber 返回的一笔交易的追踪结果，较早的节点不返回 txHash
+type callTraceResult struct {
+	TxHash *common.Hash  `json:"txHash"`
+	Result *EVMCallFrame `json:"result"`
+	Error  string        `json:"error"`
+}
+
+// EVMParityTrace trace_block 返回的一条追踪记录
+type EVMParityTrace struct {
+	Type   string `json:"type"` // call / create / suicide / reward
+	Action struct {
+		CallType      string          `json:"callType"`
+		From          common.Address  `json:"from"`
+		To            *common.Address `json:"to"`
+		Value         *hexutil.Big    `json:"value"`
+		Address       common.Address  `json:"address"`       // suicide: 销毁的合约
+		RefundAddress *common.Address `json:"refundAddress"` // suicide: 接收余额的地址
+		Balance       *hexutil.Big    `json:"balance"`       // suicide: 转出的余额
+	} `json:"action"`
+	Error           string       `json:"error"`
+	TraceAddress    []int        `json:"traceAddress"`
+	TransactionHash *common.Hash `json:"transactionHash"`
+}
+
+// CallFrameTransfers 展开交易的调用树，返回子调用中的原生币转账（CALL 和 SELFDESTRUCT）
+// 根调用即交易本身不返回；执行失败的调用及其全部子调用的转账已被回滚，同样忽略
+func CallFrameTransfers(txHash common.Hash, root *EVMCallFrame) []EVMInternalTransfer {
+	if root == nil || root.Error != "" {
+		return nil
+	}
+	var transfers []EVMInternalTransfer
+	var walk func(frames []EVMCallFrame, prefix string)
+	walk = func(frames []EVMCallFrame, prefix string) {
+		for i := range frames {
+			frame := &frames[i]
+			if frame.Error != "" {
+				continue
+			}
+			path := prefix + strconv.Itoa(i)
+			kind := strings.ToUpper(frame.Type)
+			if (kind == "CALL" || kind == "SELFDESTRUCT") && frame.To != nil &&
+				frame.Value != nil && frame.Value.ToInt().Sign() > 0 {
+				transfers = append(transfers, EVMInternalTransfer{
+					TxHash: txHash,
+					Path:   path,
+					From:   frame.From,
+					To:     *frame.To,
+					Value:  frame.Value.ToInt(),
+				})
+			}
+			walk(frame.Calls, path+".")
+		}
+	}
+	walk(root.Calls, "")
+	return transfers
+}
+
+// ParityTraceTransfers 从 trace_block 的结果中返回子调用中的原生币转账（call 和 suicide），规则与 CallFrameTransfers 相同
+func ParityTraceTransfers(traces []EVMParityTrace) []EVMInternalTransfer {
+	// 交易 -> 执行失败的调用路径
+	failed := make(map[common.Hash][]string)
+	for _, trace := range traces {
+		if trace.TransactionHash != nil && trace.Error != "" {
+			failed[*trace.TransactionHash] = append(failed[*trace.TransactionHash], tracePath(trace.TraceAddress))
+		}
+	}
+
+	var transfers []EVMInternalTransfer
+	for _, trace := range traces {
+		if trace.TransactionHash == nil || len(trace.TraceAddress) == 0 {
+			continue
+		}
+		path := tracePath(trace.TraceAddress)
+		if revertedPath(failed[*trace.TransactionHash], path) {
+			continue
+		}
+
+		transfer := EVMInternalTransfer{TxHash: *trace.TransactionHash, Path: path}
+		switch {
+		case trace.Type == "call" && trace.Action.CallType == "call" && trace.Action.To != nil && trace.Action.Value != nil:
+			transfer.From, transfer.To, transfer.Value = trace.Action.From, *trace.Action.To, trace.Action.Value.ToInt()
+		case trace.Type == "suicide" && trace.Action.RefundAddress != nil && trace.Action.Balance != nil:
+			transfer.From, transfer.To, transfer.Value = trace.Action.Address, *trace.Action.RefundAddress, trace.Action.Balance.ToInt()
+		default:
+			continue
+		}
+		if transfer.Value.Sign() > 0 {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers
+}
+
+// tracePath 调用位置转为 "0.2" 形式
+func tracePath(address []int) string {
+	parts := make([]string, len(address))
+	for i, index := range address {
+		parts[i] = strconv.Itoa(index)
+	}
+	return strings.Join(parts, ".")
+}
+
+// revertedPath 调用自身或任一上层调用执行失败
+func revertedPath(failed []string, path string) bool {
+	for _, prefix := range failed {
+		if prefix == "" || path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// BatchTraceBlocks 按 mode 批量追踪区块中的交易，返回 区块号 -> 内部转账，blocks 为 BatchBlocksByNumber 的结果
+// 节点未开启 debug / trace 接口时返回错误
+func BatchTraceBlocks(ctx context.Context, client *rpc.Client, mode string, blocks []*EVMBlock) (map[uint64][]EVMInternalTransfer, error) {
+	raws := make([]json.RawMessage, len(blocks))
+	batch := make([]rpc.BatchElem, len(blocks))
+	for i, block := range blocks {
+		number := hexutil.EncodeUint64(block.Block.NumberU64())
+		switch mode {
+		case EVMTraceDebug:
+			batch[i] = rpc.BatchElem{
+				Method: "debug_traceBlockByNumber",
+				Args:   []interface{}{number, map[string]interface{}{"tracer": "callTracer"}},
+				Result: &raws[i],
+			}
+		case EVMTraceParity:
+			batch[i] = rpc.BatchElem{Method: "trace_block", Args: []interface{}{number}, Result: &raws[i]}
+		default:
+			return nil, fmt.Errorf("unsupported trace mode: %s", mode)
+		}
+	}
+	if len(batch) == 0 {
+		return nil, nil
+	}
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("failed to trace blocks: %v", err)
+	}
+
+	transfers := make(map[uint64][]EVMInternalTransfer, len(blocks))
+	for i, elem := range batch {
+		number := blocks[i].Block.NumberU64()
+		if elem.Error != nil {
+			return nil, fmt.Errorf("failed to trace block %d: %v", number, elem.Error)
+		}
+		if mode == EVMTraceParity {
+			var traces []EVMParityTrace
+			if err := json.Unmarshal(raws[i], &traces); err != nil {
+				return nil, fmt.Errorf("failed to decode traces of block %d: %v", number, err)
+			}
+			transfers[number] = ParityTraceTransfers(traces)
+			continue
+		}
+
+		var results []callTraceResult
+		if err := json.Unmarshal(raws[i], &results); err != nil {
+			return nil, fmt.Errorf("failed to decode traces of block %d: %v", number, err)
+		}
+		txs := blocks[i].Block.Transactions()
+		if len(results) != len(txs) {
+			return nil, fmt.Errorf("block %d has %d transactions but %d traces", number, len(txs), len(results))
+		}
+		for j, result := range results {
+			if result.Error != "" {
+				return nil, fmt.Errorf("failed to trace transaction %s: %s", txs[j].Hash().Hex(), result.Error)
+			}
+			// 结果与区块中的交易顺序一致
+			hash := txs[j].Hash()
+			if result.TxHash != nil {
+				hash = *result.TxHash
+			}
+			transfers[number] = append(transfers[number], CallFrameTransfers(hash, result.Result)...)
+		}
+	}
+	return transfers, nil
+}
@@ -0,0 +1,104 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCallFrameTransfers(t *testing.T) {
+	// 交易调用批量付款合约：第1个子调用转账，第2个子调用失败（其子调用的转账被回滚），
+	// 第3个子调用为 DELEGATECALL（不转移金额），其中的 CALL 和 SELFDESTRUCT 转账
+	raw := `{
+		"type": "CALL", "from": "0x1111111111111111111111111111111111111111", "to": "0xcccccccccccccccccccccccccccccccccccccccc", "value": "0x5",
+		"calls": [
+			{"type": "CALL", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "value": "0x64"},
+			{"type": "CALL", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xdddddddddddddddddddddddddddddddddddddddd", "value": "0x0", "error": "execution reverted",
+				"calls": [{"type": "CALL", "from": "0xdddddddddddddddddddddddddddddddddddddddd", "to": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "value": "0x1"}]},
+			{"type": "DELEGATECALL", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", "value": "0x7",
+				"calls": [
+					{"type": "STATICCALL", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"},
+					{"type": "CALL", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", "value": "0xc8"},
+					{"type": "SELFDESTRUCT", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "value": "0x3"}
+				]}
+		]
+	}`
+	var root EVMCallFrame
+	if err := json.Unmarshal([]byte(raw), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := common.HexToHash("0x01")
+	transfers := CallFrameTransfers(hash, &root)
+	want := []struct {
+		path  string
+		to    string
+		value int64
+	}{
+		{"0", "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", 100},
+		{"2.1", "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", 200},
+		{"2.2", "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", 3},
+	}
+	if len(transfers) != len(want) {
+		t.Fatalf("expected %d transfers, got %+v", len(want), transfers)
+	}
+	for i, w := range want {
+		got := transfers[i]
+		if got.TxHash != hash || got.Path != w.path || got.To != common.HexToAddress(w.to) || got.Value.Int64() != w.value {
+			t.Errorf("transfer %d: got %s %s %s", i, got.Path, got.To.Hex(), got.Value)
+		}
+	}
+
+	// 交易执行失败时没有任何转账
+	root.Error = "execution reverted"
+	if transfers := CallFrameTransfers(hash, &root); len(transfers) != 0 {
+		t.Errorf("expected no transfers from reverted transaction, got %d", len(transfers))
+	}
+}
+
+func TestParityTraceTransfers(t *testing.T) {
+	raw := `[
+		{"type": "call", "action": {"callType": "call", "from": "0x1111111111111111111111111111111111111111", "to": "0xcccccccccccccccccccccccccccccccccccccccc", "value": "0x5"},
+			"traceAddress": [], "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"type": "call", "action": {"callType": "call", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "value": "0x64"},
+			"traceAddress": [0], "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"type": "call", "action": {"callType": "call", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xdddddddddddddddddddddddddddddddddddddddd", "value": "0x0"},
+			"error": "Reverted", "traceAddress": [1], "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"type": "call", "action": {"callType": "call", "from": "0xdddddddddddddddddddddddddddddddddddddddd", "to": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "value": "0x1"},
+			"traceAddress": [1, 0], "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"type": "call", "action": {"callType": "delegatecall", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", "value": "0x7"},
+			"traceAddress": [10], "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"type": "suicide", "action": {"address": "0xcccccccccccccccccccccccccccccccccccccccc", "refundAddress": "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", "balance": "0xc8"},
+			"traceAddress": [10, 0], "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"type": "call", "action": {"callType": "call", "from": "0x2222222222222222222222222222222222222222", "to": "0xcccccccccccccccccccccccccccccccccccccccc", "value": "0x0"},
+			"error": "Reverted", "traceAddress": [], "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000002"},
+		{"type": "call", "action": {"callType": "call", "from": "0xcccccccccccccccccccccccccccccccccccccccc", "to": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "value": "0x9"},
+			"traceAddress": [0], "transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000002"},
+		{"type": "reward", "action": {"author": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "value": "0x1bc16d674ec80000", "rewardType": "block"},
+			"traceAddress": [], "transactionHash": null}
+	]`
+	var traces []EVMParityTrace
+	if err := json.Unmarshal([]byte(raw), &traces); err != nil {
+		t.Fatal(err)
+	}
+
+	transfers := ParityTraceTransfers(traces)
+	want := []struct {
+		path  string
+		to    string
+		value int64
+	}{
+		{"0", "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", 100},
+		{"10.0", "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", 200},
+	}
+	if len(transfers) != len(want) {
+		t.Fatalf("expected %d transfers, got %+v", len(want), transfers)
+	}
+	for i, w := range want {
+		got := transfers[i]
+		if got.TxHash != common.HexToHash("0x01") || got.Path != w.path || got.To != common.HexToAddress(w.to) || got.Value.Int64() != w.value {
+			t.Errorf("transfer %d: got %s %s %s", i, got.Path, got.To.Hex(), got.Value)
+		}
+	}
+}
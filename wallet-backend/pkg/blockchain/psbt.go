@@ -0,0 +1,388 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// PSBT 字段类型（BIP174）
+const (
+	psbtGlobalUnsignedTx      = 0x00
+	psbtInWitnessUTXO         = 0x01
+	psbtInPartialSig          = 0x02
+	psbtInSighashType         = 0x03
+	psbtInBIP32Derivation     = 0x06
+	psbtInFinalScriptWitness  = 0x08
+	psbtOutBIP32Derivation    = 0x02
+	psbtSeparator             = 0x00
+	psbtMaxFieldSize          = 4000000
+	psbtMagic                 = "psbt\xff"
+	psbtMasterFingerprintSize = 4
+)
+
+// PSBTDerivation 公钥的BIP32派生信息
+type PSBTDerivation struct {
+	PubKey      []byte
+	Fingerprint []byte // 主密钥指纹，4字节
+	Path        []uint32
+}
+
+// PSBTInput 输入的签名数据
+type PSBTInput struct {
+	WitnessUTXO        *BitcoinWireOutput
+	PartialSigs        map[string][]byte // 十六进制压缩公钥 -> DER签名 + sighash类型
+	SighashType        uint32
+	Derivations        []PSBTDerivation
+	FinalScriptWitness [][]byte
+	unknown            [][2][]byte
+}
+
+// PSBTOutput 输出的附加信息（找零输出的派生路径）
+type PSBTOutput struct {
+	Derivations []PSBTDerivation
+	unknown     [][2][]byte
+}
+
+// PSBT 部分签名比特币交易，签名前保存在提币记录中，签名端据此计算签名哈希
+type PSBT struct {
+	UnsignedTx *BitcoinWireTx
+	Inputs     []PSBTInput
+	Outputs    []PSBTOutput
+	unknown    [][2][]byte
+}
+
+// NewPSBT 由未签名交易创建PSBT，输入的脚本和见证必须为空
+func NewPSBT(tx *BitcoinWireTx) (*PSBT, error) {
+	for i, in := range tx.Inputs {
+		if len(in.ScriptSig) > 0 || len(in.Witness) > 0 {
+			return nil, fmt.Errorf("input %d of unsigned transaction is not empty", i)
+		}
+	}
+	return &PSBT{
+		UnsignedTx: tx,
+		Inputs:     make([]PSBTInput, len(tx.Inputs)),
+		Outputs:    make([]PSBTOutput, len(tx.Outputs)),
+	}, nil
+}
+
+// Serialize 序列化为BIP174二进制格式
+func (p *PSBT) Serialize() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(psbtMagic)
+
+	writePSBTField(&buf, []byte{psbtGlobalUnsignedTx}, p.UnsignedTx.Serialize(false))
+	writeUnknownFields(&buf, p.unknown)
+	buf.WriteByte(psbtSeparator)
+
+	for _, in := range p.Inputs {
+		if in.WitnessUTXO != nil {
+			var value bytes.Buffer
+			binary.Write(&value, binary.LittleEndian, in.WitnessUTXO.Value)
+			writeVarBytes(&value, in.WitnessUTXO.PkScript)
+			writePSBTField(&buf, []byte{psbtInWitnessUTXO}, value.Bytes())
+		}
+		for _, pubKey := range sortedKeys(in.PartialSigs) {
+			key, _ := hex.DecodeString(pubKey)
+			writePSBTField(&buf, append([]byte{psbtInPartialSig}, key...), in.PartialSigs[pubKey])
+		}
+		if in.SighashType != 0 {
+			value := make([]byte, 4)
+			binary.LittleEndian.PutUint32(value, in.SighashType)
+			writePSBTField(&buf, []byte{psbtInSighashType}, value)
+		}
+		for _, d := range in.Derivations {
+			writePSBTField(&buf, append([]byte{psbtInBIP32Derivation}, d.PubKey...), encodeDerivation(d))
+		}
+		if in.FinalScriptWitness != nil {
+			var value bytes.Buffer
+			writeWitness(&value, in.FinalScriptWitness)
+			writePSBTField(&buf, []byte{psbtInFinalScriptWitness}, value.Bytes())
+		}
+		writeUnknownFields(&buf, in.unknown)
+		buf.WriteByte(psbtSeparator)
+	}
+
+	for _, out := range p.Outputs {
+		for _, d := range out.Derivations {
+			writePSBTField(&buf, append([]byte{psbtOutBIP32Derivation}, d.PubKey...), encodeDerivation(d))
+		}
+		writeUnknownFields(&buf, out.unknown)
+		buf.WriteByte(psbtSeparator)
+	}
+	return buf.Bytes()
+}
+
+// B64Encode 序列化为base64字符串（bitcoind 和硬件钱包通用的交换格式）
+func (p *PSBT) B64Encode() string {
+	return base64.StdEncoding.EncodeToString(p.Serialize())
+}
+
+// ParsePSBT 解析base64编码的PSBT，未识别的字段原样保留
+func ParsePSBT(encoded string) (*PSBT, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid psbt encoding: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte(psbtMagic)) {
+		return nil, fmt.Errorf("invalid psbt magic")
+	}
+	r := bytes.NewReader(data[len(psbtMagic):])
+
+	p := &PSBT{}
+	err = readPSBTMap(r, func(key, value []byte) error {
+		if len(key) == 1 && key[0] == psbtGlobalUnsignedTx {
+			tx, err := ParseBitcoinWireTx(value)
+			if err != nil {
+				return err
+			}
+			p.UnsignedTx = tx
+			return nil
+		}
+		p.unknown = append(p.unknown, [2][]byte{key, value})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p.UnsignedTx == nil {
+		return nil, fmt.Errorf("psbt has no unsigned transaction")
+	}
+
+	p.Inputs = make([]PSBTInput, len(p.UnsignedTx.Inputs))
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		err := readPSBTMap(r, func(key, value []byte) error {
+			switch key[0] {
+			case psbtInWitnessUTXO:
+				vr := bytes.NewReader(value)
+				out := &BitcoinWireOutput{}
+				if err := binary.Read(vr, binary.LittleEndian, &out.Value); err != nil {
+					return err
+				}
+				script, err := readVarBytes(vr)
+				if err != nil {
+					return err
+				}
+				out.PkScript = script
+				in.WitnessUTXO = out
+			case psbtInPartialSig:
+				if in.PartialSigs == nil {
+					in.PartialSigs = make(map[string][]byte)
+				}
+				in.PartialSigs[hex.EncodeToString(key[1:])] = value
+			case psbtInSighashType:
+				if len(value) != 4 {
+					return fmt.Errorf("invalid sighash type")
+				}
+				in.SighashType = binary.LittleEndian.Uint32(value)
+			case psbtInBIP32Derivation:
+				d, err := decodeDerivation(key[1:], value)
+				if err != nil {
+					return err
+				}
+				in.Derivations = append(in.Derivations, d)
+			case psbtInFinalScriptWitness:
+				witness, err := readWitness(bytes.NewReader(value))
+				if err != nil {
+					return err
+				}
+				in.FinalScriptWitness = witness
+			default:
+				in.unknown = append(in.unknown, [2][]byte{key, value})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("psbt input %d: %v", i, err)
+		}
+	}
+
+	p.Outputs = make([]PSBTOutput, len(p.UnsignedTx.Outputs))
+	for i := range p.Outputs {
+		out := &p.Outputs[i]
+		err := readPSBTMap(r, func(key, value []byte) error {
+			if key[0] == psbtOutBIP32Derivation {
+				d, err := decodeDerivation(key[1:], value)
+				if err != nil {
+					return err
+				}
+				out.Derivations = append(out.Derivations, d)
+				return nil
+			}
+			out.unknown = append(out.unknown, [2][]byte{key, value})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("psbt output %d: %v", i, err)
+		}
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("psbt has %d trailing bytes", r.Len())
+	}
+	return p, nil
+}
+
+// Fee 手续费 = 输入金额 - 输出金额，需要所有输入都带有 WitnessUTXO
+func (p *PSBT) Fee() (int64, error) {
+	var in, out int64
+	for i, input := range p.Inputs {
+		if input.WitnessUTXO == nil {
+			return 0, fmt.Errorf("input %d has no witness utxo", i)
+		}
+		in += input.WitnessUTXO.Value
+	}
+	for _, output := range p.UnsignedTx.Outputs {
+		out += output.Value
+	}
+	return in - out, nil
+}
+
+// SigHash 计算P2WPKH输入的签名哈希
+func (p *PSBT) SigHash(index int) ([]byte, error) {
+	if index < 0 || index >= len(p.Inputs) {
+		return nil, fmt.Errorf("input index %d out of range", index)
+	}
+	in := p.Inputs[index]
+	if in.WitnessUTXO == nil {
+		return nil, fmt.Errorf("input %d has no witness utxo", index)
+	}
+	pubKeyHash, ok := P2WPKHProgram(in.WitnessUTXO.PkScript)
+	if !ok {
+		return nil, fmt.Errorf("input %d: only P2WPKH inputs can be signed", index)
+	}
+	hashType := in.SighashType
+	if hashType == 0 {
+		hashType = BitcoinSigHashAll
+	}
+	return p.UnsignedTx.WitnessV0SigHash(index, P2WPKHScriptCode(pubKeyHash), in.WitnessUTXO.Value, hashType)
+}
+
+// AddPartialSig 添加输入签名，校验公钥与P2WPKH脚本匹配
+func (p *PSBT) AddPartialSig(index int, pubKey, sig []byte) error {
+	if index < 0 || index >= len(p.Inputs) {
+		return fmt.Errorf("input index %d out of range", index)
+	}
+	in := &p.Inputs[index]
+	if in.WitnessUTXO == nil {
+		return fmt.Errorf("input %d has no witness utxo", index)
+	}
+	pubKeyHash, ok := P2WPKHProgram(in.WitnessUTXO.PkScript)
+	if !ok || len(pubKey) != 33 || !bytes.Equal(hash160(pubKey), pubKeyHash) {
+		return fmt.Errorf("input %d: public key does not match script", index)
+	}
+	if in.PartialSigs == nil {
+		in.PartialSigs = make(map[string][]byte)
+	}
+	in.PartialSigs[hex.EncodeToString(pubKey)] = sig
+	return nil
+}
+
+// Finalize 为所有P2WPKH输入生成最终见证 [签名, 公钥]，并清除中间签名数据
+func (p *PSBT) Finalize() error {
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		if in.FinalScriptWitness != nil {
+			continue
+		}
+		if len(in.PartialSigs) != 1 {
+			return fmt.Errorf("input %d: expected 1 signature, got %d", i, len(in.PartialSigs))
+		}
+		for pubKey, sig := range in.PartialSigs {
+			key, _ := hex.DecodeString(pubKey)
+			in.FinalScriptWitness = [][]byte{sig, key}
+		}
+		in.PartialSigs = nil
+		in.SighashType = 0
+		in.Derivations = nil
+	}
+	return nil
+}
+
+// Extract 从已完成的PSBT中提取可广播的交易
+func (p *PSBT) Extract() (*BitcoinWireTx, error) {
+	tx := *p.UnsignedTx
+	tx.Inputs = append([]BitcoinWireInput(nil), p.UnsignedTx.Inputs...)
+	for i := range tx.Inputs {
+		if p.Inputs[i].FinalScriptWitness == nil {
+			return nil, fmt.Errorf("input %d is not finalized", i)
+		}
+		tx.Inputs[i].Witness = p.Inputs[i].FinalScriptWitness
+	}
+	return &tx, nil
+}
+
+// writePSBTField 写入一个键值对
+func writePSBTField(w *bytes.Buffer, key, value []byte) {
+	writeVarBytes(w, key)
+	writeVarBytes(w, value)
+}
+
+// writeUnknownFields 原样写回未识别的字段
+func writeUnknownFields(w *bytes.Buffer, fields [][2][]byte) {
+	for _, field := range fields {
+		writePSBTField(w, field[0], field[1])
+	}
+}
+
+// readPSBTMap 读取一个键值映射直到分隔符
+func readPSBTMap(r *bytes.Reader, handle func(key, value []byte) error) error {
+	seen := make(map[string]bool)
+	for {
+		key, err := readVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("truncated psbt: %v", err)
+		}
+		if len(key) == 0 {
+			return nil
+		}
+		value, err := readVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("truncated psbt: %v", err)
+		}
+		if len(value) > psbtMaxFieldSize {
+			return fmt.Errorf("psbt field too large")
+		}
+		if seen[string(key)] {
+			return fmt.Errorf("duplicate psbt key %x", key)
+		}
+		seen[string(key)] = true
+		if err := handle(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// encodeDerivation 编码派生信息：指纹 + 小端序路径
+func encodeDerivation(d PSBTDerivation) []byte {
+	value := append([]byte(nil), d.Fingerprint...)
+	for _, index := range d.Path {
+		value = binary.LittleEndian.AppendUint32(value, index)
+	}
+	return value
+}
+
+// decodeDerivation 解析派生信息
+func decodeDerivation(pubKey, value []byte) (PSBTDerivation, error) {
+	if len(value) < psbtMasterFingerprintSize || (len(value)-psbtMasterFingerprintSize)%4 != 0 {
+		return PSBTDerivation{}, fmt.Errorf("invalid bip32 derivation")
+	}
+	d := PSBTDerivation{PubKey: pubKey, Fingerprint: value[:psbtMasterFingerprintSize]}
+	for i := psbtMasterFingerprintSize; i < len(value); i += 4 {
+		d.Path = append(d.Path, binary.LittleEndian.Uint32(value[i:]))
+	}
+	return d, nil
+}
+
+// sortedKeys 按字典序返回map的键，保证序列化结果稳定
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
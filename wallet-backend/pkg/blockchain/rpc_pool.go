@@ -0,0 +1,521 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// HeadProbe 查询节点最新区块高度，用于健康检查和高度一致性校验
+type HeadProbe func(ctx context.Context, client *http.Client, endpoint string) (uint64, error)
+
+// ChainIDProbe 查询节点链ID，用于剔除连错链的节点
+type ChainIDProbe func(ctx context.Context, client *http.Client, endpoint string) (int64, error)
+
+// RPCPoolOptions 节点池参数
+type RPCPoolOptions struct {
+	Timeout             time.Duration // 单次请求超时
+	FailureThreshold    int           // 连续失败次数达到后熔断
+	Cooldown            time.Duration // 熔断持续时间，到期后放行一次试探请求
+	HealthCheckInterval time.Duration // 健康检查间隔
+	MaxLagBlocks        uint64        // 落后最高节点超过此区块数视为不同步
+	ChainID             int64         // 期望的链ID，为0时不校验
+	HeadProbe           HeadProbe
+	ChainIDProbe        ChainIDProbe
+}
+
+// RPCEndpointStatus 节点状态，供健康检查和监控接口展示
+type RPCEndpointStatus struct {
+	URL                 string  `json:"url"`
+	Healthy             bool    `json:"healthy"`
+	CircuitOpen         bool    `json:"circuit_open"`
+	Lagging             bool    `json:"lagging"`
+	WrongChain          bool    `json:"wrong_chain"`
+	Head                uint64  `json:"head"`
+	LatencyMs           float64 `json:"latency_ms"`
+	ErrorRate           float64 `json:"error_rate"`
+	Score               float64 `json:"score"`
+	Requests            uint64  `json:"requests"`
+	Failures            uint64  `json:"failures"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	LastError           string  `json:"last_error,omitempty"`
+}
+
+// rpcEndpoint 单个节点的统计信息
+type rpcEndpoint struct {
+	url         *url.URL
+	raw         string
+	latency     float64 // 毫秒，指数移动平均
+	errorRate   float64 // 指数移动平均
+	requests    uint64
+	failures    uint64
+	consecutive int
+	openUntil   time.Time
+	head        uint64
+	lagging     bool
+	wrongChain  bool
+	lastError   string
+}
+
+// ewmaWeight 延迟和错误率指数移动平均的新样本权重
+const ewmaWeight = 0.2
+
+// score 节点评分，越低越优先：平均延迟按错误率放大
+func (e *rpcEndpoint) score() float64 {
+	latency := e.latency
+	if latency == 0 {
+		latency = 1
+	}
+	return latency * (1 + 10*e.errorRate)
+}
+
+// RPCPool 多节点连接池
+// 实现 http.RoundTripper：请求按评分选择节点，网络错误、5xx 和 429 自动切换到下一个节点；
+// 连续失败的节点熔断一段时间，定期健康检查剔除高度落后或链ID不符的节点
+type RPCPool struct {
+	name     string
+	opts     RPCPoolOptions
+	basePath string
+	probe    *http.Client
+	next     http.RoundTripper
+
+	mu        sync.Mutex
+	endpoints []*rpcEndpoint
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRPCPool 创建节点池，urls 的第一个地址作为客户端拨号地址，请求时改写为选中的节点
+func NewRPCPool(name string, urls []string, opts RPCPoolOptions) (*RPCPool, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.FailureThreshold == 0 {
+		opts.FailureThreshold = 3
+	}
+	if opts.Cooldown == 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	if opts.HealthCheckInterval == 0 {
+		opts.HealthCheckInterval = 15 * time.Second
+	}
+	if opts.MaxLagBlocks == 0 {
+		opts.MaxLagBlocks = 5
+	}
+
+	p := &RPCPool{
+		name:  name,
+		opts:  opts,
+		probe: &http.Client{Timeout: opts.Timeout},
+		next:  http.DefaultTransport,
+		stop:  make(chan struct{}),
+	}
+
+	seen := make(map[string]bool)
+	for _, raw := range urls {
+		raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+		if raw == "" || seen[raw] {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid rpc endpoint %q", raw)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("rpc endpoint %q: only http(s) endpoints can be pooled", raw)
+		}
+		seen[raw] = true
+		p.endpoints = append(p.endpoints, &rpcEndpoint{url: u, raw: raw})
+	}
+	if len(p.endpoints) == 0 {
+		return nil, fmt.Errorf("no rpc endpoints configured for %s", name)
+	}
+	p.basePath = p.endpoints[0].url.Path
+
+	return p, nil
+}
+
+// PrimaryURL 客户端拨号使用的地址
+func (p *RPCPool) PrimaryURL() string {
+	return p.endpoints[0].raw
+}
+
+// HTTPClient 返回经过节点池转发的HTTP客户端
+func (p *RPCPool) HTTPClient() *http.Client {
+	return &http.Client{Transport: p}
+}
+
+// Start 启动后台健康检查
+func (p *RPCPool) Start() {
+	go func() {
+		ticker := time.NewTicker(p.opts.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), p.opts.Timeout)
+				p.CheckHealth(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop 停止后台健康检查
+func (p *RPCPool) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// RoundTrip 按评分依次尝试节点，直到成功或所有节点都失败
+func (p *RPCPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	candidates := p.candidates()
+	var lastErr error
+	for _, endpoint := range candidates {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+
+		resp, err := p.try(req, endpoint, body)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("%s: all %d rpc endpoints failed, last error: %v", p.name, len(candidates), lastErr)
+}
+
+// try 向单个节点发送请求并读取完整响应，记录延迟和结果
+func (p *RPCPool) try(req *http.Request, endpoint *rpcEndpoint, body []byte) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), p.opts.Timeout)
+	defer cancel()
+
+	out := req.Clone(ctx)
+	out.URL = p.rewrite(req.URL, endpoint.url)
+	out.Host = ""
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+		out.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+
+	start := time.Now()
+	resp, err := p.next.RoundTrip(out)
+	if err != nil {
+		p.recordFailure(endpoint, err)
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		p.recordFailure(endpoint, err)
+		return nil, err
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		err := fmt.Errorf("%s returned %s", endpoint.url.Host, resp.Status)
+		p.recordFailure(endpoint, err)
+		return nil, err
+	}
+
+	p.recordSuccess(endpoint, time.Since(start))
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	return resp, nil
+}
+
+// rewrite 把基于拨号地址的请求改写到目标节点，保留拨号地址之后的路径（如TRON的 /wallet/...）
+func (p *RPCPool) rewrite(reqURL, target *url.URL) *url.URL {
+	u := *target
+	u.Path = target.Path + strings.TrimPrefix(reqURL.Path, p.basePath)
+	switch {
+	case reqURL.RawQuery == "":
+	case u.RawQuery == "":
+		u.RawQuery = reqURL.RawQuery
+	default:
+		u.RawQuery = u.RawQuery + "&" + reqURL.RawQuery
+	}
+	return &u
+}
+
+// candidates 可用节点按评分排序；没有可用节点时退回到所有链ID正确的节点，避免整体不可用
+func (p *RPCPool) candidates() []*rpcEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var healthy, fallback []*rpcEndpoint
+	for _, e := range p.endpoints {
+		if e.wrongChain {
+			continue
+		}
+		fallback = append(fallback, e)
+		if !e.lagging && !now.Before(e.openUntil) {
+			healthy = append(healthy, e)
+		}
+	}
+
+	list := healthy
+	if len(list) == 0 {
+		list = fallback
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].score() < list[j].score()
+	})
+	return list
+}
+
+// recordSuccess 记录成功请求，关闭熔断
+func (p *RPCPool) recordSuccess(e *rpcEndpoint, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ms := float64(latency) / float64(time.Millisecond)
+	if e.requests == 0 {
+		e.latency = ms
+	} else {
+		e.latency = (1-ewmaWeight)*e.latency + ewmaWeight*ms
+	}
+	e.errorRate = (1 - ewmaWeight) * e.errorRate
+	e.requests++
+	e.consecutive = 0
+	e.openUntil = time.Time{}
+}
+
+// recordFailure 记录失败请求，连续失败达到阈值后熔断
+func (p *RPCPool) recordFailure(e *rpcEndpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e.errorRate = (1-ewmaWeight)*e.errorRate + ewmaWeight
+	e.requests++
+	e.failures++
+	e.consecutive++
+	e.lastError = err.Error()
+	if e.consecutive >= p.opts.FailureThreshold {
+		e.openUntil = time.Now().Add(p.opts.Cooldown)
+	}
+}
+
+// CheckHealth 并发探测所有节点的最新高度（以及链ID），标记落后和连错链的节点
+func (p *RPCPool) CheckHealth(ctx context.Context) {
+	if p.opts.HeadProbe == nil {
+		return
+	}
+
+	p.mu.Lock()
+	endpoints := append([]*rpcEndpoint(nil), p.endpoints...)
+	p.mu.Unlock()
+
+	type result struct {
+		head    uint64
+		chainID int64
+		err     error
+		latency time.Duration
+	}
+	results := make([]result, len(endpoints))
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func(i int, e *rpcEndpoint) {
+			defer wg.Done()
+			start := time.Now()
+			if p.opts.ChainID != 0 && p.opts.ChainIDProbe != nil {
+				chainID, err := p.opts.ChainIDProbe(ctx, p.probe, e.raw)
+				if err != nil {
+					results[i].err = err
+					return
+				}
+				results[i].chainID = chainID
+				if chainID != p.opts.ChainID {
+					// 链ID不符时不再探测高度，其高度不参与一致性比较
+					return
+				}
+			}
+			results[i].head, results[i].err = p.opts.HeadProbe(ctx, p.probe, e.raw)
+			results[i].latency = time.Since(start)
+		}(i, e)
+	}
+	wg.Wait()
+
+	wrongChain := func(r result) bool {
+		return p.opts.ChainID != 0 && p.opts.ChainIDProbe != nil && r.chainID != p.opts.ChainID
+	}
+
+	var maxHead uint64
+	for i, r := range results {
+		if r.err != nil {
+			p.recordFailure(endpoints[i], r.err)
+			continue
+		}
+		if wrongChain(r) {
+			continue
+		}
+		p.recordSuccess(endpoints[i], r.latency)
+		if r.head > maxHead {
+			maxHead = r.head
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, r := range results {
+		e := endpoints[i]
+		if r.err != nil {
+			continue
+		}
+		if wrongChain(r) {
+			e.wrongChain = true
+			e.lastError = fmt.Sprintf("chain ID %d, expected %d", r.chainID, p.opts.ChainID)
+			continue
+		}
+		e.wrongChain = false
+		e.head = r.head
+		e.lagging = maxHead-r.head > p.opts.MaxLagBlocks
+	}
+}
+
+// Head 健康节点中的最高区块高度
+func (p *RPCPool) Head() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var head uint64
+	for _, e := range p.endpoints {
+		if !e.wrongChain && e.head > head {
+			head = e.head
+		}
+	}
+	return head
+}
+
+// Healthy 是否至少有一个可用节点
+func (p *RPCPool) Healthy() bool {
+	for _, status := range p.Status() {
+		if status.Healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// AllWrongChain 所有节点的链ID都与配置不符
+func (p *RPCPool) AllWrongChain() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.endpoints {
+		if !e.wrongChain {
+			return false
+		}
+	}
+	return true
+}
+
+// Status 各节点当前状态
+func (p *RPCPool) Status() []RPCEndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	list := make([]RPCEndpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		open := now.Before(e.openUntil)
+		list = append(list, RPCEndpointStatus{
+			URL:                 redactURL(e.url),
+			Healthy:             !open && !e.lagging && !e.wrongChain,
+			CircuitOpen:         open,
+			Lagging:             e.lagging,
+			WrongChain:          e.wrongChain,
+			Head:                e.head,
+			LatencyMs:           e.latency,
+			ErrorRate:           e.errorRate,
+			Score:               e.score(),
+			Requests:            e.requests,
+			Failures:            e.failures,
+			ConsecutiveFailures: e.consecutive,
+			LastError:           e.lastError,
+		})
+	}
+	return list
+}
+
+// redactURL 隐藏地址中的凭据和路径（常包含API Key）
+func redactURL(u *url.URL) string {
+	redacted := u.Scheme + "://" + u.Host
+	if u.Path != "" && u.Path != "/" {
+		redacted += "/***"
+	}
+	return redacted
+}
+
+// EVMHeadProbe 通过 eth_blockNumber 查询EVM节点高度
+func EVMHeadProbe(ctx context.Context, client *http.Client, endpoint string) (uint64, error) {
+	result, err := jsonRPCCall(ctx, client, endpoint, "eth_blockNumber")
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.DecodeUint64(result)
+}
+
+// EVMChainIDProbe 通过 eth_chainId 查询EVM节点链ID
+func EVMChainIDProbe(ctx context.Context, client *http.Client, endpoint string) (int64, error) {
+	result, err := jsonRPCCall(ctx, client, endpoint, "eth_chainId")
+	if err != nil {
+		return 0, err
+	}
+	chainID, err := hexutil.DecodeUint64(result)
+	return int64(chainID), err
+}
+
+// jsonRPCCall 发送无参数的JSON-RPC请求，返回字符串结果
+func jsonRPCCall(ctx context.Context, client *http.Client, endpoint, method string) (string, error) {
+	payload := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":%q,"params":[]}`, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", method, resp.Status)
+	}
+
+	var out struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("invalid %s response: %v", method, err)
+	}
+	if out.Error != nil {
+		return "", fmt.Errorf("%s: %s", method, out.Error.Message)
+	}
+	return out.Result, nil
+}
@@ -0,0 +1,119 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// newTestEVMNode 模拟EVM节点，返回固定的链ID和高度
+func newTestEVMNode(chainID, head string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		result := head
+		if req.Method == "eth_chainId" {
+			result = chainID
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":"` + result + `"}`))
+	}))
+}
+
+func TestRPCPoolFailover(t *testing.T) {
+	var failed int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&failed, 1)
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer down.Close()
+	up := newTestEVMNode("0x1", "0x64")
+	defer up.Close()
+
+	pool, err := NewRPCPool("Ethereum", []string{down.URL, up.URL}, RPCPoolOptions{FailureThreshold: 1})
+	if err != nil {
+		t.Fatalf("NewRPCPool failed: %v", err)
+	}
+	rpcClient, err := rpc.DialOptions(context.Background(), pool.PrimaryURL(), rpc.WithHTTPClient(pool.HTTPClient()))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	client := ethclient.NewClient(rpcClient)
+	defer client.Close()
+
+	// 主节点返回502，请求切换到备用节点
+	for i := 0; i < 3; i++ {
+		head, err := client.BlockNumber(context.Background())
+		if err != nil {
+			t.Fatalf("BlockNumber failed: %v", err)
+		}
+		if head != 100 {
+			t.Errorf("expected head 100, got %d", head)
+		}
+	}
+
+	// 失败达到阈值后熔断，不再向故障节点发请求
+	if got := atomic.LoadInt32(&failed); got != 1 {
+		t.Errorf("expected failing endpoint to be tried once before the circuit opens, got %d", got)
+	}
+	status := pool.Status()
+	if !status[0].CircuitOpen || status[0].Healthy {
+		t.Errorf("expected circuit open on failing endpoint: %+v", status[0])
+	}
+	if !status[1].Healthy || status[1].Requests != 3 {
+		t.Errorf("unexpected healthy endpoint status: %+v", status[1])
+	}
+	if !pool.Healthy() {
+		t.Error("expected pool to be healthy while one endpoint is up")
+	}
+}
+
+func TestRPCPoolCheckHealth(t *testing.T) {
+	synced := newTestEVMNode("0x1", "0x64")
+	defer synced.Close()
+	lagging := newTestEVMNode("0x1", "0x50")
+	defer lagging.Close()
+	wrong := newTestEVMNode("0x38", "0x100")
+	defer wrong.Close()
+
+	pool, err := NewRPCPool("Ethereum", []string{synced.URL, lagging.URL, wrong.URL + "/secret-key"}, RPCPoolOptions{
+		MaxLagBlocks: 10,
+		ChainID:      1,
+		HeadProbe:    EVMHeadProbe,
+		ChainIDProbe: EVMChainIDProbe,
+	})
+	if err != nil {
+		t.Fatalf("NewRPCPool failed: %v", err)
+	}
+	pool.CheckHealth(context.Background())
+
+	// 连错链的节点高度不参与比较
+	if head := pool.Head(); head != 100 {
+		t.Errorf("expected head 100, got %d", head)
+	}
+	status := pool.Status()
+	if !status[0].Healthy || status[0].Head != 100 {
+		t.Errorf("unexpected synced endpoint status: %+v", status[0])
+	}
+	if !status[1].Lagging || status[1].Healthy {
+		t.Errorf("expected lagging endpoint to be unhealthy: %+v", status[1])
+	}
+	if !status[2].WrongChain || status[2].Healthy {
+		t.Errorf("expected wrong chain endpoint to be unhealthy: %+v", status[2])
+	}
+	if strings.Contains(status[2].URL, "secret-key") {
+		t.Errorf("endpoint URL not redacted: %s", status[2].URL)
+	}
+	if pool.AllWrongChain() {
+		t.Error("expected pool to have endpoints on the configured chain")
+	}
+}
@@ -0,0 +1,380 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TRC-20 Transfer(address,address,uint256) 事件签名
+const TronTransferEventTopic = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// TronClient TRON全节点HTTP API客户端（java-tron /wallet 接口，兼容TronGrid）
+type TronClient struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// TronTransaction 节点返回的交易，raw_data 原样保留用于签名后广播
+type TronTransaction struct {
+	TxID       string          `json:"txID"`
+	RawData    json.RawMessage `json:"raw_data"`
+	RawDataHex string          `json:"raw_data_hex"`
+	Signature  []string        `json:"signature,omitempty"`
+	Ret        []TronResult    `json:"ret,omitempty"`
+}
+
+// TronResult 交易执行结果
+type TronResult struct {
+	ContractRet string `json:"contractRet"`
+}
+
+// TronContract 交易中的合约调用
+type TronContract struct {
+	Type      string `json:"type"`
+	Parameter struct {
+		Value   json.RawMessage `json:"value"`
+		TypeURL string          `json:"type_url"`
+	} `json:"parameter"`
+}
+
+// TronTransferContract TRX转账参数，地址为41开头的十六进制
+type TronTransferContract struct {
+	Amount       int64  `json:"amount"`
+	OwnerAddress string `json:"owner_address"`
+	ToAddress    string `json:"to_address"`
+}
+
+// TronBlock 区块
+type TronBlock struct {
+	BlockID     string `json:"blockID"`
+	BlockHeader struct {
+		RawData struct {
+			Number     uint64 `json:"number"`
+			Timestamp  int64  `json:"timestamp"`
+			ParentHash string `json:"parentHash"`
+		} `json:"raw_data"`
+	} `json:"block_header"`
+	Transactions []TronTransaction `json:"transactions"`
+}
+
+// TronLog 合约事件日志，address 为不带41前缀的20字节十六进制
+type TronLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// TronTransactionInfo 交易回执
+type TronTransactionInfo struct {
+	ID              string    `json:"id"`
+	BlockNumber     uint64    `json:"blockNumber"`
+	ContractAddress string    `json:"contract_address"`
+	Result          string    `json:"result"` // 失败时为 FAILED
+	Log             []TronLog `json:"log"`
+	Receipt         struct {
+		Result string `json:"result"`
+	} `json:"receipt"`
+}
+
+// NewTronClient 创建TRON客户端，apiKey 用于TronGrid，可以为空
+func NewTronClient(url string, apiKey string) *TronClient {
+	return NewTronClientWithHTTPClient(url, apiKey, &http.Client{Timeout: 30 * time.Second})
+}
+
+// NewTronClientWithHTTPClient 使用指定的HTTP客户端创建TRON客户端（如节点池客户端）
+func NewTronClientWithHTTPClient(url string, apiKey string, httpClient *http.Client) *TronClient {
+	return &TronClient{
+		url:        strings.TrimRight(url, "/"),
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+// TronHeadProbe 通过 /wallet/getnowblock 查询TRON节点高度，用于节点池健康检查
+func TronHeadProbe(apiKey string) HeadProbe {
+	return func(ctx context.Context, client *http.Client, endpoint string) (uint64, error) {
+		block, err := NewTronClientWithHTTPClient(endpoint, apiKey, client).GetNowBlock(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return block.BlockHeader.RawData.Number, nil
+	}
+}
+
+// Contracts 解析交易中的合约调用
+func (tx *TronTransaction) Contracts() ([]TronContract, error) {
+	var raw struct {
+		Contract []TronContract `json:"contract"`
+	}
+	if err := json.Unmarshal(tx.RawData, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode raw_data: %v", err)
+	}
+	return raw.Contract, nil
+}
+
+// Succeeded 交易是否执行成功
+func (tx *TronTransaction) Succeeded() bool {
+	return len(tx.Ret) == 0 || tx.Ret[0].ContractRet == "" || tx.Ret[0].ContractRet == "SUCCESS"
+}
+
+// Hash 校验 txID 等于 sha256(raw_data)，返回待签名的哈希
+func (tx *TronTransaction) Hash() ([]byte, error) {
+	raw, err := hex.DecodeString(tx.RawDataHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw_data_hex: %v", err)
+	}
+	hash := sha256.Sum256(raw)
+	if !strings.EqualFold(hex.EncodeToString(hash[:]), tx.TxID) {
+		return nil, fmt.Errorf("txID %s does not match raw_data_hex", tx.TxID)
+	}
+	return hash[:], nil
+}
+
+// GetNowBlock 获取最新区块
+func (tc *TronClient) GetNowBlock(ctx context.Context) (*TronBlock, error) {
+	var block TronBlock
+	if err := tc.post(ctx, "/wallet/getnowblock", struct{}{}, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// GetBlockByNum 按高度获取区块
+func (tc *TronClient) GetBlockByNum(ctx context.Context, num uint64) (*TronBlock, error) {
+	var block TronBlock
+	if err := tc.post(ctx, "/wallet/getblockbynum", map[string]interface{}{"num": num}, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// GetTransactionInfoByBlockNum 获取区块内所有交易回执（包含合约事件）
+func (tc *TronClient) GetTransactionInfoByBlockNum(ctx context.Context, num uint64) ([]TronTransactionInfo, error) {
+	var infos []TronTransactionInfo
+	if err := tc.post(ctx, "/wallet/gettransactioninfobyblocknum", map[string]interface{}{"num": num}, &infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// GetBalance 获取TRX余额（单位sun），未激活账户返回0
+func (tc *TronClient) GetBalance(ctx context.Context, address string) (*big.Int, error) {
+	hexAddress, err := TronAddressToHex(address)
+	if err != nil {
+		return nil, err
+	}
+	var account struct {
+		Balance int64 `json:"balance"`
+	}
+	if err := tc.post(ctx, "/wallet/getaccount", map[string]interface{}{"address": hexAddress}, &account); err != nil {
+		return nil, err
+	}
+	return big.NewInt(account.Balance), nil
+}
+
+// GetTRC20Balance 调用 balanceOf 查询TRC-20代币余额
+func (tc *TronClient) GetTRC20Balance(ctx context.Context, contract string, address string) (*big.Int, error) {
+	ownerHex, err := TronAddressToHex(address)
+	if err != nil {
+		return nil, err
+	}
+	contractHex, err := TronAddressToHex(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		ConstantResult []string `json:"constant_result"`
+		Result         struct {
+			Result  bool   `json:"result"`
+			Message string `json:"message"`
+		} `json:"result"`
+	}
+	req := map[string]interface{}{
+		"owner_address":     ownerHex,
+		"contract_address":  contractHex,
+		"function_selector": "balanceOf(address)",
+		"parameter":         tronABIAddress(ownerHex),
+	}
+	if err := tc.post(ctx, "/wallet/triggerconstantcontract", req, &result); err != nil {
+		return nil, err
+	}
+	if len(result.ConstantResult) == 0 {
+		return nil, fmt.Errorf("balanceOf failed: %s", decodeTronMessage(result.Result.Message))
+	}
+
+	balance, ok := new(big.Int).SetString(result.ConstantResult[0], 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid balanceOf result: %s", result.ConstantResult[0])
+	}
+	return balance, nil
+}
+
+// CreateTransferTransaction 创建未签名的TRX转账交易，金额单位sun
+func (tc *TronClient) CreateTransferTransaction(ctx context.Context, from string, to string, amount *big.Int) (*TronTransaction, error) {
+	ownerHex, err := TronAddressToHex(from)
+	if err != nil {
+		return nil, err
+	}
+	toHex, err := TronAddressToHex(to)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.IsInt64() || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount %s", amount.String())
+	}
+
+	var tx struct {
+		TronTransaction
+		Error string `json:"Error"`
+	}
+	req := map[string]interface{}{
+		"owner_address": ownerHex,
+		"to_address":    toHex,
+		"amount":        amount.Int64(),
+	}
+	if err := tc.post(ctx, "/wallet/createtransaction", req, &tx); err != nil {
+		return nil, err
+	}
+	if tx.Error != "" {
+		return nil, fmt.Errorf("failed to create transaction: %s", tx.Error)
+	}
+
+	// 节点返回的交易必须包含请求中的收款地址
+	if !strings.Contains(strings.ToLower(tx.RawDataHex), toHex) {
+		return nil, fmt.Errorf("node returned a transaction for a different recipient")
+	}
+	return &tx.TronTransaction, nil
+}
+
+// CreateTRC20TransferTransaction 创建未签名的TRC-20 transfer 交易，feeLimit 单位sun
+func (tc *TronClient) CreateTRC20TransferTransaction(ctx context.Context, from string, contract string, to string, amount *big.Int, feeLimit int64) (*TronTransaction, error) {
+	ownerHex, err := TronAddressToHex(from)
+	if err != nil {
+		return nil, err
+	}
+	contractHex, err := TronAddressToHex(contract)
+	if err != nil {
+		return nil, err
+	}
+	toHex, err := TronAddressToHex(to)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount %s", amount.String())
+	}
+
+	parameter := tronABIAddress(toHex) + fmt.Sprintf("%064x", amount)
+	var result struct {
+		Result struct {
+			Result  bool   `json:"result"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"result"`
+		Transaction *TronTransaction `json:"transaction"`
+	}
+	req := map[string]interface{}{
+		"owner_address":     ownerHex,
+		"contract_address":  contractHex,
+		"function_selector": "transfer(address,uint256)",
+		"parameter":         parameter,
+		"fee_limit":         feeLimit,
+		"call_value":        0,
+	}
+	if err := tc.post(ctx, "/wallet/triggersmartcontract", req, &result); err != nil {
+		return nil, err
+	}
+	if !result.Result.Result || result.Transaction == nil {
+		return nil, fmt.Errorf("failed to create TRC-20 transfer: %s %s", result.Result.Code, decodeTronMessage(result.Result.Message))
+	}
+
+	// 节点返回的交易必须调用请求中的合约并携带相同的调用数据
+	rawHex := strings.ToLower(result.Transaction.RawDataHex)
+	if !strings.Contains(rawHex, contractHex) || !strings.Contains(rawHex, "a9059cbb"+parameter) {
+		return nil, fmt.Errorf("node returned a transaction that does not match the transfer request")
+	}
+	return result.Transaction, nil
+}
+
+// BroadcastTransaction 广播已签名交易
+func (tc *TronClient) BroadcastTransaction(ctx context.Context, tx *TronTransaction) error {
+	var result struct {
+		Result  bool   `json:"result"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := tc.post(ctx, "/wallet/broadcasttransaction", tx, &result); err != nil {
+		return err
+	}
+	if !result.Result {
+		return fmt.Errorf("broadcast rejected: %s %s", result.Code, decodeTronMessage(result.Message))
+	}
+	return nil
+}
+
+// post 调用节点HTTP接口
+func (tc *TronClient) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tc.url+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if tc.apiKey != "" {
+		req.Header.Set("TRON-PRO-API-KEY", tc.apiKey)
+	}
+
+	resp, err := tc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("tron request %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read tron response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("tron request %s failed: %s %s", path, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	// 部分接口在没有数据时返回空对象 {}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("{}")) {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode tron response: %v", err)
+	}
+	return nil
+}
+
+// tronABIAddress 把41开头的地址编码为ABI的32字节address参数
+func tronABIAddress(hexAddress string) string {
+	return strings.Repeat("0", 24) + strings.TrimPrefix(hexAddress, "41")
+}
+
+// decodeTronMessage 节点错误信息通常为十六进制编码的文本
+func decodeTronMessage(message string) string {
+	if decoded, err := hex.DecodeString(message); err == nil {
+		return string(decoded)
+	}
+	return message
+}
@@ -0,0 +1,79 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"golang.org/x/crypto/sha3"
+)
+
+// TronAddressPrefix TRON主网地址版本字节，base58check编码后以 T 开头
+const TronAddressPrefix = 0x41
+
+// EncodeTronAddress 根据secp256k1公钥（压缩或非压缩）生成TRON地址
+// 地址为 0x41 + keccak256(公钥X||Y) 的后20字节，再做base58check编码
+func EncodeTronAddress(pubKey []byte) (string, error) {
+	key, err := secp256k1.ParsePubKey(pubKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %v", err)
+	}
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write(key.SerializeUncompressed()[1:])
+	return EncodeBase58Check(TronAddressPrefix, h.Sum(nil)[12:]), nil
+}
+
+// ValidateTronAddress 校验TRON地址的base58check编码和版本字节
+func ValidateTronAddress(address string) error {
+	_, err := DecodeTronAddress(address)
+	return err
+}
+
+// DecodeTronAddress 解码TRON地址，返回20字节地址
+func DecodeTronAddress(address string) ([]byte, error) {
+	version, payload, err := DecodeBase58Check(address)
+	if err != nil {
+		return nil, err
+	}
+	if version != TronAddressPrefix {
+		return nil, fmt.Errorf("address version 0x%02x is not a TRON address", version)
+	}
+	if len(payload) != 20 {
+		return nil, fmt.Errorf("invalid address payload length %d", len(payload))
+	}
+	return payload, nil
+}
+
+// TronAddressToHex base58地址转换为节点API使用的 41 开头的十六进制地址
+func TronAddressToHex(address string) (string, error) {
+	payload, err := DecodeTronAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(append([]byte{TronAddressPrefix}, payload...)), nil
+}
+
+// TronAddressFromHex 十六进制地址转换为base58地址
+// 支持 41 开头的21字节地址、20字节地址以及事件中左补零的32字节地址
+func TronAddressFromHex(hexAddress string) (string, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(hexAddress, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid hex address: %v", err)
+	}
+
+	switch len(raw) {
+	case 21:
+		if raw[0] != TronAddressPrefix {
+			return "", fmt.Errorf("address version 0x%02x is not a TRON address", raw[0])
+		}
+		raw = raw[1:]
+	case 20:
+	case 32:
+		raw = raw[12:]
+	default:
+		return "", fmt.Errorf("invalid hex address length %d", len(raw))
+	}
+	return EncodeBase58Check(TronAddressPrefix, raw), nil
+}
@@ -0,0 +1,41 @@
+package blockchain
+
+import "testing"
+
+func TestTronAddressHexConversion(t *testing.T) {
+	// TRON主网USDT合约
+	address := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	hexAddress := "41a614f803b6fd780986a42c78ec9c7f77e6ded13c"
+
+	got, err := TronAddressToHex(address)
+	if err != nil || got != hexAddress {
+		t.Fatalf("TronAddressToHex: expected %s, got %s (%v)", hexAddress, got, err)
+	}
+
+	for _, input := range []string{
+		hexAddress,
+		hexAddress[2:],
+		"000000000000000000000000" + hexAddress[2:],
+	} {
+		if got, err := TronAddressFromHex(input); err != nil || got != address {
+			t.Errorf("TronAddressFromHex(%s): expected %s, got %s (%v)", input, address, got, err)
+		}
+	}
+}
+
+func TestValidateTronAddress(t *testing.T) {
+	if err := ValidateTronAddress("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"); err != nil {
+		t.Errorf("valid address rejected: %v", err)
+	}
+
+	invalid := []string{
+		"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", // 校验和错误
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", // 比特币地址
+		"0xa614f803b6fd780986a42c78ec9c7f77e6ded13c",
+	}
+	for _, address := range invalid {
+		if err := ValidateTronAddress(address); err == nil {
+			t.Errorf("%s should be rejected", address)
+		}
+	}
+}
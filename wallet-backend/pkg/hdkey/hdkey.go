@@ -0,0 +1,365 @@
+package hdkey
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/mr-tron/base58/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// HardenedKeyStart 硬化派生的起始索引 (2^31)
+const HardenedKeyStart uint32 = 0x80000000
+
+var (
+	// ErrInvalidSeed 种子长度不合法
+	ErrInvalidSeed = errors.New("seed length must be between 16 and 64 bytes")
+	// ErrInvalidKey 派生出的密钥无效（概率极低，调用方应跳过该索引）
+	ErrInvalidKey = errors.New("derived key is invalid")
+	// ErrDeriveHardenedFromPublic 公钥无法进行硬化派生
+	ErrDeriveHardenedFromPublic = errors.New("cannot derive a hardened key from a public key")
+	// ErrNotPrivate 当前密钥不包含私钥
+	ErrNotPrivate = errors.New("extended key is not a private key")
+	// ErrInvalidPath 派生路径格式错误
+	ErrInvalidPath = errors.New("invalid derivation path")
+	// ErrInvalidExtendedKey 扩展密钥序列化格式错误
+	ErrInvalidExtendedKey = errors.New("invalid extended key")
+)
+
+var masterKey = []byte("Bitcoin seed")
+
+// Version 扩展密钥序列化版本号
+type Version struct {
+	Private [4]byte
+	Public  [4]byte
+}
+
+// MainNetVersion BIP32主网版本号 (xprv/xpub)
+var MainNetVersion = Version{
+	Private: [4]byte{0x04, 0x88, 0xad, 0xe4},
+	Public:  [4]byte{0x04, 0x88, 0xb2, 0x1e},
+}
+
+// ExtendedKey BIP32扩展密钥
+type ExtendedKey struct {
+	version      Version
+	key          []byte // 私钥32字节，或压缩公钥33字节
+	chainCode    []byte
+	depth        uint8
+	parentFP     []byte
+	childNum     uint32
+	isPrivate    bool
+	cachedPubKey []byte
+}
+
+// NewMaster 根据种子生成主密钥
+func NewMaster(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+
+	mac := hmac.New(sha512.New, masterKey)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	il, ir := sum[:32], sum[32:]
+	var k secp256k1.ModNScalar
+	if overflow := k.SetByteSlice(il); overflow || k.IsZero() {
+		return nil, ErrInvalidKey
+	}
+
+	return &ExtendedKey{
+		version:   MainNetVersion,
+		key:       il,
+		chainCode: ir,
+		parentFP:  []byte{0, 0, 0, 0},
+		isPrivate: true,
+	}, nil
+}
+
+// IsPrivate 是否为私钥
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.isPrivate
+}
+
+// Depth 派生深度
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
+
+// ChildIndex 子密钥索引
+func (k *ExtendedKey) ChildIndex() uint32 {
+	return k.childNum
+}
+
+// ChainCode 链码
+func (k *ExtendedKey) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode...)
+}
+
+// SetVersion 设置序列化版本号（如ypub/zpub或测试网版本）
+func (k *ExtendedKey) SetVersion(v Version) *ExtendedKey {
+	cp := *k
+	cp.version = v
+	return &cp
+}
+
+// PublicKeyBytes 返回33字节压缩公钥
+func (k *ExtendedKey) PublicKeyBytes() []byte {
+	if !k.isPrivate {
+		return k.key
+	}
+	if k.cachedPubKey == nil {
+		priv := secp256k1.PrivKeyFromBytes(k.key)
+		k.cachedPubKey = priv.PubKey().SerializeCompressed()
+	}
+	return k.cachedPubKey
+}
+
+// PrivateKeyBytes 返回32字节私钥
+func (k *ExtendedKey) PrivateKeyBytes() ([]byte, error) {
+	if !k.isPrivate {
+		return nil, ErrNotPrivate
+	}
+	return append([]byte(nil), k.key...), nil
+}
+
+// PublicKey 返回secp256k1公钥
+func (k *ExtendedKey) PublicKey() (*secp256k1.PublicKey, error) {
+	return secp256k1.ParsePubKey(k.PublicKeyBytes())
+}
+
+// Fingerprint 密钥指纹 HASH160(pubkey)[:4]
+func (k *ExtendedKey) Fingerprint() []byte {
+	return Hash160(k.PublicKeyBytes())[:4]
+}
+
+// Derive 派生第index个子密钥，index >= HardenedKeyStart 时为硬化派生
+func (k *ExtendedKey) Derive(index uint32) (*ExtendedKey, error) {
+	if k.depth == 255 {
+		return nil, fmt.Errorf("cannot derive beyond depth 255")
+	}
+
+	hardened := index >= HardenedKeyStart
+	if hardened && !k.isPrivate {
+		return nil, ErrDeriveHardenedFromPublic
+	}
+
+	data := make([]byte, 0, 37)
+	if hardened {
+		data = append(data, 0x00)
+		data = append(data, k.key...)
+	} else {
+		data = append(data, k.PublicKeyBytes()...)
+	}
+	var idx [4]byte
+	binary.BigEndian.PutUint32(idx[:], index)
+	data = append(data, idx[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	il, ir := sum[:32], sum[32:]
+
+	var ilNum secp256k1.ModNScalar
+	if overflow := ilNum.SetByteSlice(il); overflow {
+		return nil, ErrInvalidKey
+	}
+
+	var childKey []byte
+	if k.isPrivate {
+		// k_i = parse256(IL) + k_par (mod n)
+		var kpar secp256k1.ModNScalar
+		kpar.SetByteSlice(k.key)
+		ilNum.Add(&kpar)
+		if ilNum.IsZero() {
+			return nil, ErrInvalidKey
+		}
+		b := ilNum.Bytes()
+		childKey = b[:]
+	} else {
+		// K_i = point(parse256(IL)) + K_par
+		parent, err := secp256k1.ParsePubKey(k.key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse parent public key: %v", err)
+		}
+		var ilPoint, parentPoint, result secp256k1.JacobianPoint
+		secp256k1.ScalarBaseMultNonConst(&ilNum, &ilPoint)
+		parent.AsJacobian(&parentPoint)
+		secp256k1.AddNonConst(&ilPoint, &parentPoint, &result)
+		if (result.X.IsZero() && result.Y.IsZero()) || result.Z.IsZero() {
+			return nil, ErrInvalidKey
+		}
+		result.ToAffine()
+		childKey = secp256k1.NewPublicKey(&result.X, &result.Y).SerializeCompressed()
+	}
+
+	return &ExtendedKey{
+		version:   k.version,
+		key:       childKey,
+		chainCode: ir,
+		depth:     k.depth + 1,
+		parentFP:  k.Fingerprint(),
+		childNum:  index,
+		isPrivate: k.isPrivate,
+	}, nil
+}
+
+// DerivePath 按路径依次派生，例如 m/44'/60'/0'/0/0
+func (k *ExtendedKey) DerivePath(path string) (*ExtendedKey, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := k
+	for _, index := range indexes {
+		key, err = key.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Neuter 返回对应的扩展公钥
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	if !k.isPrivate {
+		return k
+	}
+	return &ExtendedKey{
+		version:   k.version,
+		key:       k.PublicKeyBytes(),
+		chainCode: k.chainCode,
+		depth:     k.depth,
+		parentFP:  k.parentFP,
+		childNum:  k.childNum,
+		isPrivate: false,
+	}
+}
+
+// String 序列化为base58check格式 (xprv/xpub等)
+func (k *ExtendedKey) String() string {
+	buf := make([]byte, 0, 82)
+	if k.isPrivate {
+		buf = append(buf, k.version.Private[:]...)
+	} else {
+		buf = append(buf, k.version.Public[:]...)
+	}
+	buf = append(buf, k.depth)
+	buf = append(buf, k.parentFP...)
+	var idx [4]byte
+	binary.BigEndian.PutUint32(idx[:], k.childNum)
+	buf = append(buf, idx[:]...)
+	buf = append(buf, k.chainCode...)
+	if k.isPrivate {
+		buf = append(buf, 0x00)
+	}
+	buf = append(buf, k.key...)
+
+	return base58.Encode(append(buf, checksum(buf)...))
+}
+
+// ParseExtendedKey 解析base58check格式的扩展密钥，返回密钥及其版本前缀
+func ParseExtendedKey(s string) (*ExtendedKey, [4]byte, error) {
+	var version [4]byte
+	raw, err := base58.Decode(s)
+	if err != nil || len(raw) != 82 {
+		return nil, version, ErrInvalidExtendedKey
+	}
+
+	payload, sum := raw[:78], raw[78:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return nil, version, fmt.Errorf("%w: checksum mismatch", ErrInvalidExtendedKey)
+	}
+
+	copy(version[:], payload[:4])
+	key := &ExtendedKey{
+		depth:     payload[4],
+		parentFP:  append([]byte(nil), payload[5:9]...),
+		childNum:  binary.BigEndian.Uint32(payload[9:13]),
+		chainCode: append([]byte(nil), payload[13:45]...),
+	}
+
+	keyData := payload[45:78]
+	if keyData[0] == 0x00 {
+		var k secp256k1.ModNScalar
+		if overflow := k.SetByteSlice(keyData[1:]); overflow || k.IsZero() {
+			return nil, version, ErrInvalidExtendedKey
+		}
+		key.isPrivate = true
+		key.key = append([]byte(nil), keyData[1:]...)
+		key.version = Version{Private: version}
+	} else {
+		if _, err := secp256k1.ParsePubKey(keyData); err != nil {
+			return nil, version, fmt.Errorf("%w: %v", ErrInvalidExtendedKey, err)
+		}
+		key.key = append([]byte(nil), keyData...)
+		key.version = Version{Public: version}
+	}
+
+	return key, version, nil
+}
+
+// ParsePath 解析派生路径，支持 ' h H 作为硬化标记，"m" 前缀可省略
+func ParsePath(path string) ([]uint32, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, ErrInvalidPath
+	}
+
+	parts := strings.Split(path, "/")
+	if parts[0] == "m" || parts[0] == "M" {
+		parts = parts[1:]
+	}
+
+	indexes := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+
+		hardened := false
+		switch part[len(part)-1] {
+		case '\'', 'h', 'H':
+			hardened = true
+			part = part[:len(part)-1]
+		}
+
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(n) >= HardenedKeyStart {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+
+		index := uint32(n)
+		if hardened {
+			index += HardenedKeyStart
+		}
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+// Hash160 RIPEMD160(SHA256(data))
+func Hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
+
+// checksum 双重SHA256前4字节
+func checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
@@ -0,0 +1,154 @@
+package hdkey
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// BIP32 官方测试向量
+// https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki#test-vectors
+func TestBIP32Vectors(t *testing.T) {
+	vectors := []struct {
+		seed string
+		path string
+		xpub string
+		xprv string
+	}{
+		// Test vector 1
+		{"000102030405060708090a0b0c0d0e0f", "m",
+			"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+			"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"},
+		{"000102030405060708090a0b0c0d0e0f", "m/0H",
+			"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+			"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7"},
+		{"000102030405060708090a0b0c0d0e0f", "m/0H/1",
+			"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+			"xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs"},
+		{"000102030405060708090a0b0c0d0e0f", "m/0H/1/2H",
+			"xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5",
+			"xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM"},
+		{"000102030405060708090a0b0c0d0e0f", "m/0H/1/2H/2",
+			"xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV",
+			"xprvA2JDeKCSNNZky6uBCviVfJSKyQ1mDYahRjijr5idH2WwLsEd4Hsb2Tyh8RfQMuPh7f7RtyzTtdrbdqqsunu5Mm3wDvUAKRHSC34sJ7in334"},
+		{"000102030405060708090a0b0c0d0e0f", "m/0H/1/2H/2/1000000000",
+			"xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy",
+			"xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76"},
+		// Test vector 3（私钥前导零）
+		{"4b381541583be4423346c643850da4b320e46a87ae3d2a4e6da11eba819cd4acba45d239319ac14f863b8d5ab5a0d0c64d2e8a1e7d1457df2e5a3c51c73235be", "m",
+			"xpub661MyMwAqRbcEZVB4dScxMAdx6d4nFc9nvyvH3v4gJL378CSRZiYmhRoP7mBy6gSPSCYk6SzXPTf3ND1cZAceL7SfJ1Z3GC8vBgp2epUt13",
+			"xprv9s21ZrQH143K25QhxbucbDDuQ4naNntJRi4KUfWT7xo4EKsHt2QJDu7KXp1A3u7Bi1j8ph3EGsZ9Xvz9dGuVrtHHs7pXeTzjuxBrCmmhgC6"},
+		{"4b381541583be4423346c643850da4b320e46a87ae3d2a4e6da11eba819cd4acba45d239319ac14f863b8d5ab5a0d0c64d2e8a1e7d1457df2e5a3c51c73235be", "m/0H",
+			"xpub68NZiKmJWnxxS6aaHmn81bvJeTESw724CRDs6HbuccFQN9Ku14VQrADWgqbhhTHBaohPX4CjNLf9fq9MYo6oDaPPLPxSb7gwQN3ih19Zm4Y",
+			"xprv9uPDJpEQgRQfDcW7BkF7eTya6RPxXeJCqCJGHuCJ4GiRVLzkTXBAJMu2qaMWPrS7AANYqdq6vcBcBUdJCVVFceUvJFjaPdGZ2y9WACViL4L"},
+	}
+
+	for _, v := range vectors {
+		seed, _ := hex.DecodeString(v.seed)
+		master, err := NewMaster(seed)
+		if err != nil {
+			t.Fatalf("NewMaster failed: %v", err)
+		}
+
+		var key *ExtendedKey
+		if v.path == "m" {
+			key = master
+		} else {
+			key, err = master.DerivePath(v.path)
+			if err != nil {
+				t.Fatalf("DerivePath(%s) failed: %v", v.path, err)
+			}
+		}
+
+		if got := key.String(); got != v.xprv {
+			t.Errorf("%s xprv mismatch:\n got  %s\n want %s", v.path, got, v.xprv)
+		}
+		if got := key.Neuter().String(); got != v.xpub {
+			t.Errorf("%s xpub mismatch:\n got  %s\n want %s", v.path, got, v.xpub)
+		}
+	}
+}
+
+func TestPublicDerivationMatchesPrivate(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, _ := NewMaster(seed)
+
+	account, err := master.DerivePath("m/44'/60'/0'")
+	if err != nil {
+		t.Fatalf("DerivePath failed: %v", err)
+	}
+
+	for i := uint32(0); i < 5; i++ {
+		priv, err := account.DerivePath(fmt.Sprintf("0/%d", i))
+		if err != nil {
+			t.Fatalf("private derive failed: %v", err)
+		}
+		pub, err := account.Neuter().DerivePath(fmt.Sprintf("0/%d", i))
+		if err != nil {
+			t.Fatalf("public derive failed: %v", err)
+		}
+		if priv.Neuter().String() != pub.String() {
+			t.Errorf("index %d: public derivation does not match private derivation", i)
+		}
+	}
+
+	if _, err := account.Neuter().Derive(HardenedKeyStart); err != ErrDeriveHardenedFromPublic {
+		t.Errorf("expected ErrDeriveHardenedFromPublic, got %v", err)
+	}
+}
+
+func TestParseExtendedKeyRoundTrip(t *testing.T) {
+	xprv := "xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7"
+	key, version, err := ParseExtendedKey(xprv)
+	if err != nil {
+		t.Fatalf("ParseExtendedKey failed: %v", err)
+	}
+	if version != MainNetVersion.Private || !key.IsPrivate() {
+		t.Errorf("expected mainnet private version, got %x", version)
+	}
+	if key.String() != xprv {
+		t.Errorf("round trip mismatch: %s", key.String())
+	}
+
+	if _, _, err := ParseExtendedKey(xprv[:len(xprv)-1] + "8"); err == nil {
+		t.Error("expected checksum error for tampered key")
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	cases := []struct {
+		path string
+		want []uint32
+		ok   bool
+	}{
+		{"m/44'/60'/0'/0/1", []uint32{HardenedKeyStart + 44, HardenedKeyStart + 60, HardenedKeyStart, 0, 1}, true},
+		{"m/0h/1H", []uint32{HardenedKeyStart, HardenedKeyStart + 1}, true},
+		{"0/1", []uint32{0, 1}, true},
+		{"m", []uint32{}, true},
+		{"m//1", nil, false},
+		{"m/x", nil, false},
+		{"m/2147483648", nil, false},
+		{"", nil, false},
+	}
+
+	for _, c := range cases {
+		got, err := ParsePath(c.path)
+		if (err == nil) != c.ok {
+			t.Errorf("ParsePath(%q) err = %v, want ok=%v", c.path, err, c.ok)
+			continue
+		}
+		if !c.ok {
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("ParsePath(%q) = %v, want %v", c.path, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("ParsePath(%q) = %v, want %v", c.path, got, c.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package hdkey
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrNonHardenedEd25519 ed25519 只支持硬化派生
+var ErrNonHardenedEd25519 = errors.New("ed25519 only supports hardened derivation")
+
+var ed25519SeedKey = []byte("ed25519 seed")
+
+// Ed25519Key SLIP-0010 ed25519 扩展密钥（Solana 等链使用）
+type Ed25519Key struct {
+	key       []byte // 32字节私钥种子
+	chainCode []byte
+	depth     uint8
+	childNum  uint32
+}
+
+// NewEd25519Master 根据种子生成 SLIP-0010 ed25519 主密钥
+func NewEd25519Master(seed []byte) (*Ed25519Key, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+
+	mac := hmac.New(sha512.New, ed25519SeedKey)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	return &Ed25519Key{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// Depth 派生深度
+func (k *Ed25519Key) Depth() uint8 {
+	return k.depth
+}
+
+// ChildIndex 子密钥索引
+func (k *Ed25519Key) ChildIndex() uint32 {
+	return k.childNum
+}
+
+// ChainCode 链码
+func (k *Ed25519Key) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode...)
+}
+
+// Seed 32字节私钥种子
+func (k *Ed25519Key) Seed() []byte {
+	return append([]byte(nil), k.key...)
+}
+
+// PrivateKey ed25519 私钥（种子 + 公钥，共64字节）
+func (k *Ed25519Key) PrivateKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(k.key)
+}
+
+// PublicKey ed25519 公钥
+func (k *Ed25519Key) PublicKey() ed25519.PublicKey {
+	return k.PrivateKey().Public().(ed25519.PublicKey)
+}
+
+// Derive 派生子密钥，索引必须为硬化索引
+func (k *Ed25519Key) Derive(index uint32) (*Ed25519Key, error) {
+	if index < HardenedKeyStart {
+		return nil, ErrNonHardenedEd25519
+	}
+
+	data := make([]byte, 37)
+	copy(data[1:33], k.key)
+	binary.BigEndian.PutUint32(data[33:], index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	return &Ed25519Key{
+		key:       sum[:32],
+		chainCode: sum[32:],
+		depth:     k.depth + 1,
+		childNum:  index,
+	}, nil
+}
+
+// DerivePath 按路径派生，如 m/44'/501'/0'/0'
+func (k *Ed25519Key) DerivePath(path string) (*Ed25519Key, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := k
+	for _, index := range indexes {
+		if key, err = key.Derive(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
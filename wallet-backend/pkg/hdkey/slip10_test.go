@@ -0,0 +1,60 @@
+package hdkey
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// SLIP-0010 ed25519 测试向量1
+// https://github.com/satoshilabs/slips/blob/master/slip-0010.md#test-vector-1-for-ed25519
+func TestSLIP10Ed25519Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewEd25519Master(seed)
+	if err != nil {
+		t.Fatalf("NewEd25519Master failed: %v", err)
+	}
+
+	vectors := []struct {
+		path      string
+		chainCode string
+		private   string
+		public    string
+	}{
+		{"m",
+			"90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb",
+			"2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+			"a4b2856bfec510abab89753fac1ac0e1112364e7d250545963f135f2a33188ed"},
+		{"m/0H",
+			"8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69",
+			"68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+			"8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c"},
+		{"m/0H/1H",
+			"a320425f77d1b5c2505a6b1b27382b37368ee640e3557c315416801243552f14",
+			"b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+			"1932a5270f335bed617d5b935c80aedb1a35bd9fc1e31acafd5372c30f5c1187"},
+		{"m/0H/1H/2H",
+			"2e69929e00b5ab250f49c3fb1c12f252de4fed2c1db88387094a0f8c4c9ccd6c",
+			"92a5b23c0b8a99e37d07df3fb9966917f5d06e02ddbd909c7e184371463e9fc9",
+			"ae98736566d30ed0e9d2f4486a64bc95740d89c7db33f52121f8ea8f76ff0fc1"},
+	}
+
+	for _, v := range vectors {
+		key, err := master.DerivePath(v.path)
+		if err != nil {
+			t.Fatalf("%s: derive failed: %v", v.path, err)
+		}
+		if got := hex.EncodeToString(key.ChainCode()); got != v.chainCode {
+			t.Errorf("%s: chain code = %s, want %s", v.path, got, v.chainCode)
+		}
+		if got := hex.EncodeToString(key.Seed()); got != v.private {
+			t.Errorf("%s: private key = %s, want %s", v.path, got, v.private)
+		}
+		if got := hex.EncodeToString(key.PublicKey()); got != v.public {
+			t.Errorf("%s: public key = %s, want %s", v.path, got, v.public)
+		}
+	}
+
+	if _, err := master.Derive(0); err != ErrNonHardenedEd25519 {
+		t.Errorf("expected ErrNonHardenedEd25519, got %v", err)
+	}
+}
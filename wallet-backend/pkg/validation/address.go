@@ -0,0 +1,167 @@
+package validation
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"wallet-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gagliardetto/solana-go"
+)
+
+// 地址校验错误码，接口返回给调用方用于区分失败原因
+const (
+	CodeInvalidFormat    = "INVALID_ADDRESS"          // 编码或长度不正确
+	CodeInvalidChecksum  = "INVALID_ADDRESS_CHECKSUM" // 校验和不匹配（EIP-55 / base58check / bech32）
+	CodeNetworkMismatch  = "ADDRESS_NETWORK_MISMATCH" // 地址属于其他网络（如主网配置下的测试网地址）
+	CodeUnsupportedChain = "UNSUPPORTED_CHAIN"        // 不支持的链类型
+)
+
+// AddressError 地址校验错误
+type AddressError struct {
+	Code    string
+	Chain   string
+	Address string
+	Reason  string
+}
+
+// Error 实现 error 接口
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("invalid %s address %q: %s", e.Chain, e.Address, e.Reason)
+}
+
+// ErrorCode 返回地址校验错误的错误码，非地址校验错误返回空字符串
+func ErrorCode(err error) string {
+	var addrErr *AddressError
+	if errors.As(err, &addrErr) {
+		return addrErr.Code
+	}
+	return ""
+}
+
+// AddressValidator 按链类型校验地址，比特币地址额外校验所属网络
+type AddressValidator struct {
+	bitcoin *blockchain.BitcoinNetworkParams
+}
+
+// NewAddressValidator 创建地址校验器，bitcoinNetwork 为 mainnet / testnet / regtest，空字符串视为主网
+func NewAddressValidator(bitcoinNetwork string) (*AddressValidator, error) {
+	params, err := blockchain.BitcoinNetworkByName(bitcoinNetwork)
+	if err != nil {
+		return nil, err
+	}
+	return &AddressValidator{bitcoin: params}, nil
+}
+
+// Validate 校验地址，chainType 不区分大小写，BSC等EVM链按以太坊规则校验
+func (v *AddressValidator) Validate(chainType, address string) error {
+	switch strings.ToLower(chainType) {
+	case "ethereum", "eth", "bsc", "bnb":
+		return ValidateEthereum(address)
+	case "bitcoin", "btc":
+		return ValidateBitcoin(address, v.bitcoin)
+	case "tron", "trx":
+		return ValidateTron(address)
+	case "solana", "sol":
+		return ValidateSolana(address)
+	default:
+		return &AddressError{Code: CodeUnsupportedChain, Chain: chainType, Address: address, Reason: "unsupported chain type"}
+	}
+}
+
+// ValidateEthereum 校验EVM地址，大小写混合时必须符合 EIP-55 校验和
+// 全小写或全大写地址不携带校验信息，只校验格式
+func ValidateEthereum(address string) error {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return &AddressError{Code: CodeInvalidFormat, Chain: "Ethereum", Address: address, Reason: "must be 0x followed by 40 hex characters"}
+	}
+	body := address[2:]
+	if _, err := hex.DecodeString(body); err != nil {
+		return &AddressError{Code: CodeInvalidFormat, Chain: "Ethereum", Address: address, Reason: "contains non-hex characters"}
+	}
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return nil
+	}
+	if common.HexToAddress(address).Hex() != address {
+		return &AddressError{Code: CodeInvalidChecksum, Chain: "Ethereum", Address: address, Reason: "EIP-55 checksum mismatch"}
+	}
+	return nil
+}
+
+// bitcoinNetworks 用于识别地址所属网络，regtest 的 bcrt 需先于主网匹配
+var bitcoinNetworks = []*blockchain.BitcoinNetworkParams{
+	blockchain.BitcoinRegTest,
+	blockchain.BitcoinTestNet,
+	blockchain.BitcoinMainNet,
+}
+
+// ValidateBitcoin 校验比特币地址：base58check（P2PKH/P2SH）或 bech32/bech32m（隔离见证/Taproot）
+// 校验通过但属于其他网络时返回 CodeNetworkMismatch
+func ValidateBitcoin(address string, params *blockchain.BitcoinNetworkParams) error {
+	lower := strings.ToLower(address)
+	for _, network := range bitcoinNetworks {
+		if !strings.HasPrefix(lower, network.Bech32HRP+"1") {
+			continue
+		}
+		if _, _, err := blockchain.DecodeSegWitAddress(network.Bech32HRP, address); err != nil {
+			return bitcoinError(address, err)
+		}
+		if network.Bech32HRP != params.Bech32HRP {
+			return &AddressError{Code: CodeNetworkMismatch, Chain: "Bitcoin", Address: address, Reason: fmt.Sprintf("%s address on %s", network.Name, params.Name)}
+		}
+		return nil
+	}
+
+	version, payload, err := blockchain.DecodeBase58Check(address)
+	if err != nil {
+		return bitcoinError(address, err)
+	}
+	if len(payload) != 20 {
+		return &AddressError{Code: CodeInvalidFormat, Chain: "Bitcoin", Address: address, Reason: fmt.Sprintf("invalid payload length %d", len(payload))}
+	}
+	if version == params.PubKeyHashAddrID || version == params.ScriptHashAddrID {
+		return nil
+	}
+	for _, network := range bitcoinNetworks {
+		if version == network.PubKeyHashAddrID || version == network.ScriptHashAddrID {
+			return &AddressError{Code: CodeNetworkMismatch, Chain: "Bitcoin", Address: address, Reason: fmt.Sprintf("%s address on %s", network.Name, params.Name)}
+		}
+	}
+	return &AddressError{Code: CodeInvalidFormat, Chain: "Bitcoin", Address: address, Reason: fmt.Sprintf("unknown version byte 0x%02x", version)}
+}
+
+// ValidateTron 校验TRON地址的base58check校验和与 0x41 版本字节
+// TRON主网和测试网（Shasta/Nile）使用相同前缀，无法区分网络
+func ValidateTron(address string) error {
+	version, payload, err := blockchain.DecodeBase58Check(address)
+	if err != nil {
+		code := CodeInvalidFormat
+		if errors.Is(err, blockchain.ErrInvalidChecksum) {
+			code = CodeInvalidChecksum
+		}
+		return &AddressError{Code: code, Chain: "TRON", Address: address, Reason: err.Error()}
+	}
+	if version != blockchain.TronAddressPrefix || len(payload) != 20 {
+		return &AddressError{Code: CodeInvalidFormat, Chain: "TRON", Address: address, Reason: "not a TRON address"}
+	}
+	return nil
+}
+
+// ValidateSolana 校验Solana地址（base58编码的32字节公钥，不含校验和）
+func ValidateSolana(address string) error {
+	if _, err := solana.PublicKeyFromBase58(address); err != nil {
+		return &AddressError{Code: CodeInvalidFormat, Chain: "Solana", Address: address, Reason: err.Error()}
+	}
+	return nil
+}
+
+// bitcoinError 将解码错误转换为带错误码的地址错误
+func bitcoinError(address string, err error) error {
+	code := CodeInvalidFormat
+	if errors.Is(err, blockchain.ErrInvalidChecksum) {
+		code = CodeInvalidChecksum
+	}
+	return &AddressError{Code: code, Chain: "Bitcoin", Address: address, Reason: err.Error()}
+}
@@ -0,0 +1,61 @@
+package validation
+
+import "testing"
+
+func TestAddressValidator(t *testing.T) {
+	mainnet, err := NewAddressValidator("mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testnet, err := NewAddressValidator("testnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		validator *AddressValidator
+		chain     string
+		address   string
+		code      string
+	}{
+		// EIP-55
+		{mainnet, "Ethereum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ""},
+		{mainnet, "Ethereum", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", ""},
+		{mainnet, "BSC", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", ""},
+		{mainnet, "Ethereum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", CodeInvalidChecksum},
+		{mainnet, "Ethereum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAe", CodeInvalidFormat},
+		{mainnet, "Ethereum", "0xZZAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", CodeInvalidFormat},
+
+		// base58check
+		{mainnet, "Bitcoin", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", ""},
+		{mainnet, "Bitcoin", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", ""},
+		{mainnet, "Bitcoin", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", CodeInvalidChecksum},
+		{mainnet, "Bitcoin", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", CodeNetworkMismatch},
+		{testnet, "Bitcoin", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", ""},
+		{testnet, "Bitcoin", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", CodeNetworkMismatch},
+
+		// bech32 / bech32m (BIP-173 / BIP-350)
+		{mainnet, "Bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", ""},
+		{mainnet, "Bitcoin", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", ""},
+		{mainnet, "Bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", CodeInvalidChecksum},
+		{mainnet, "Bitcoin", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", CodeInvalidFormat}, // v1 使用了 bech32 校验
+		{mainnet, "Bitcoin", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", CodeNetworkMismatch},
+		{testnet, "Bitcoin", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", ""},
+
+		// TRON / Solana
+		{mainnet, "TRON", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", ""},
+		{mainnet, "TRON", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", CodeInvalidChecksum},
+		{mainnet, "TRON", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", CodeInvalidFormat},
+		{mainnet, "Solana", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", ""},
+		{mainnet, "Solana", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", CodeInvalidFormat},
+
+		{mainnet, "Dogecoin", "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L", CodeUnsupportedChain},
+	}
+
+	for _, tt := range tests {
+		err := tt.validator.Validate(tt.chain, tt.address)
+		if code := ErrorCode(err); code != tt.code {
+			t.Errorf("%s %s: expected code %q, got %q (%v)", tt.chain, tt.address, tt.code, code, err)
+		}
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"wallet-backend/internal/models"
+	"wallet-backend/pkg/validation"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -60,15 +60,9 @@ func (ew *EthereumWallet) GetBalance(address string) (float64, error) {
 	return 0, nil
 }
 
-// ValidateAddress 验证以太坊地址
+// ValidateAddress 验证以太坊地址（含 EIP-55 校验和）
 func (ew *EthereumWallet) ValidateAddress(address string) bool {
-	if len(address) != 42 || address[:2] != "0x" {
-		return false
-	}
-
-	// 检查地址格式
-	_, err := common.HexToAddress(address)
-	return err == nil
+	return validation.ValidateEthereum(address) == nil
 }
 
 // GetPrivateKey 获取私钥（仅用于测试）